@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArtifactsOptions configures the artifacts download command.
+type ArtifactsOptions struct {
+	ServerURL string
+	Token     string
+	JobID     string
+	Dir       string // Destination directory
+}
+
+// Artifact describes a stored job artifact.
+type Artifact struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// DownloadArtifacts fetches a job's artifacts into opts.Dir. Tarballs
+// (.tar.gz) are extracted; anything else is saved as-is.
+func DownloadArtifacts(opts ArtifactsOptions, out io.Writer) error {
+	artifacts, err := listArtifacts(opts)
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		return fmt.Errorf("job %s has no artifacts", opts.JobID)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Minute}
+	for _, a := range artifacts {
+		apiURL := fmt.Sprintf("%s/api/jobs/%s/artifacts/%s", opts.ServerURL, opts.JobID, url.PathEscape(a.Name))
+		req, err := http.NewRequest("GET", apiURL, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+		}
+
+		if strings.HasSuffix(a.Name, ".tar.gz") {
+			n, err := extractTarGz(resp.Body, opts.Dir)
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("extract %s: %w", a.Name, err)
+			}
+			fmt.Fprintf(out, "Extracted %d file(s) from %s\n", n, a.Name)
+			continue
+		}
+
+		err = writeFile(filepath.Join(opts.Dir, filepath.Base(a.Name)), resp.Body, 0644)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("save %s: %w", a.Name, err)
+		}
+		fmt.Fprintf(out, "Saved %s\n", a.Name)
+	}
+	return nil
+}
+
+// listArtifacts gets the job's artifact list via HTTP.
+func listArtifacts(opts ArtifactsOptions) ([]Artifact, error) {
+	apiURL := fmt.Sprintf("%s/api/jobs/%s/artifacts", opts.ServerURL, opts.JobID)
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+opts.Token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("job not found: %s", opts.JobID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Artifacts []Artifact `json:"artifacts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return result.Artifacts, nil
+}
+
+// extractTarGz extracts regular files and directories from a tar.gz into
+// dir, refusing entries that would land outside it. Returns the number of
+// files written.
+func extractTarGz(r io.Reader, dir string) (int, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	files := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return files, err
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return files, fmt.Errorf("unsafe path in archive: %s", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return files, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return files, err
+			}
+			if err := writeFile(target, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return files, err
+			}
+			files++
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
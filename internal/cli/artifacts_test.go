@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestDownloadArtifacts(t *testing.T) {
+	archive := tarGz(t, map[string]string{"dist/app": "binary", "coverage.out": "mode: set\n"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/jobs/j_1/artifacts":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"artifacts": []Artifact{{Name: "artifacts.tar.gz", SizeBytes: int64(len(archive))}},
+			})
+		case "/api/jobs/j_1/artifacts/artifacts.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	err := DownloadArtifacts(ArtifactsOptions{ServerURL: srv.URL, JobID: "j_1", Dir: dir}, io.Discard)
+	if err != nil {
+		t.Fatalf("DownloadArtifacts failed: %v", err)
+	}
+	for name, want := range map[string]string{"dist/app": "binary", "coverage.out": "mode: set\n"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("read %s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExtractTarGzRejectsTraversal(t *testing.T) {
+	for _, name := range []string{"../evil", "/etc/evil", "a/../../evil"} {
+		dir := t.TempDir()
+		archive := tarGz(t, map[string]string{name: "x"})
+		if _, err := extractTarGz(bytes.NewReader(archive), dir); err == nil {
+			t.Errorf("extracting %q should fail", name)
+		}
+	}
+}
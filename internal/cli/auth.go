@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -97,8 +99,15 @@ func SaveConfig(cfg *CLIConfig) error {
 	return nil
 }
 
+// DefaultServerURL is the server used when none is given and none is saved.
+const DefaultServerURL = "https://cinch.sh"
+
+// DefaultServerName is the saved server name that aliases the most recent login.
+const DefaultServerName = "default"
+
 // GetServerConfig returns the config for a server, or nil if not found.
 func (c *CLIConfig) GetServerConfig(serverURL string) *ServerConfig {
+	serverURL = strings.TrimSuffix(serverURL, "/")
 	for _, sc := range c.Servers {
 		if sc.URL == serverURL {
 			return &sc
@@ -107,11 +116,65 @@ func (c *CLIConfig) GetServerConfig(serverURL string) *ServerConfig {
 	return nil
 }
 
+// Credentials returns the saved login for serverURL, falling back to the
+// CINCH_TOKEN environment variable (for CI without a persisted login) when
+// CINCH_URL names the same server. The token is never sent to a server the
+// environment didn't name. Returns nil if neither applies.
+func (c *CLIConfig) Credentials(serverURL string) *ServerConfig {
+	serverURL = strings.TrimSuffix(serverURL, "/")
+	if sc := c.GetServerConfig(serverURL); sc != nil && sc.Token != "" {
+		return sc
+	}
+	token := os.Getenv("CINCH_TOKEN")
+	if token == "" {
+		return nil
+	}
+	if envURL := os.Getenv("CINCH_URL"); envURL == "" || strings.TrimSuffix(envURL, "/") != serverURL {
+		return nil
+	}
+	return &ServerConfig{URL: serverURL, Token: token}
+}
+
 // SetServerConfig sets or updates a server config.
 func (c *CLIConfig) SetServerConfig(name string, sc ServerConfig) {
 	c.Servers[name] = sc
 }
 
+// ResolveServer turns a --server value into a server URL. The value may be
+// a URL or a saved server name; empty means the most recent login, falling
+// back to CINCH_URL and then DefaultServerURL.
+func (c *CLIConfig) ResolveServer(server string) string {
+	if server == "" {
+		server = DefaultServerName
+	}
+	if sc, ok := c.Servers[server]; ok && sc.URL != "" {
+		return sc.URL
+	}
+	if server == DefaultServerName {
+		if envURL := os.Getenv("CINCH_URL"); envURL != "" {
+			return strings.TrimSuffix(envURL, "/")
+		}
+		return DefaultServerURL
+	}
+	return strings.TrimSuffix(server, "/")
+}
+
+// SaveLogin stores sc under a name derived from its URL's host (see
+// ServerName) and makes it the default server.
+func (c *CLIConfig) SaveLogin(sc ServerConfig) {
+	c.SetServerConfig(ServerName(sc.URL), sc)
+	c.SetServerConfig(DefaultServerName, sc)
+}
+
+// ServerName returns the name a login to serverURL is saved under: its host,
+// e.g. "cinch.sh" or "ci.example.com:8080".
+func ServerName(serverURL string) string {
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return serverURL
+}
+
 // DeviceAuthResponse is the response from POST /auth/device.
 type DeviceAuthResponse struct {
 	DeviceCode      string `json:"device_code"`
@@ -201,6 +264,43 @@ func PollForToken(serverURL, deviceCode string, interval int) (*DeviceTokenRespo
 	}
 }
 
+// Whoami checks token against the server's /api/whoami and returns the
+// email of the user it belongs to.
+func Whoami(serverURL, token string) (string, error) {
+	req, err := http.NewRequest("GET", serverURL+"/api/whoami", nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("token rejected by %s (expired, revoked, or not issued by this server)", serverURL)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Email    string `json:"email"`
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if result.Email == "" {
+		return result.Username, nil
+	}
+	return result.Email, nil
+}
+
 // readerString is a simple io.Reader for a string.
 type readerString struct {
 	s   string
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWhoami(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/whoami" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"email":"ci@example.com","username":"ci"}`))
+	}))
+	defer srv.Close()
+
+	email, err := Whoami(srv.URL, "good")
+	if err != nil {
+		t.Fatalf("Whoami failed: %v", err)
+	}
+	if email != "ci@example.com" {
+		t.Errorf("email = %q, want ci@example.com", email)
+	}
+
+	if _, err := Whoami(srv.URL, "bad"); err == nil || !strings.Contains(err.Error(), "token rejected") {
+		t.Errorf("expected token rejected error, got %v", err)
+	}
+}
+
+func TestResolveServer(t *testing.T) {
+	t.Setenv("CINCH_URL", "")
+	cfg := &CLIConfig{Servers: make(map[string]ServerConfig)}
+	if got := cfg.ResolveServer(""); got != DefaultServerURL {
+		t.Errorf("no logins: ResolveServer(\"\") = %q, want %q", got, DefaultServerURL)
+	}
+
+	cfg.SaveLogin(ServerConfig{URL: "https://cinch.sh", Token: "a"})
+	cfg.SaveLogin(ServerConfig{URL: "http://ci.example.com:8080", Token: "b"})
+
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{"", "http://ci.example.com:8080"}, // most recent login
+		{"default", "http://ci.example.com:8080"},
+		{"cinch.sh", "https://cinch.sh"},
+		{"ci.example.com:8080", "http://ci.example.com:8080"},
+		{"https://other.example.com/", "https://other.example.com"},
+	}
+	for _, tt := range tests {
+		if got := cfg.ResolveServer(tt.server); got != tt.want {
+			t.Errorf("ResolveServer(%q) = %q, want %q", tt.server, got, tt.want)
+		}
+	}
+
+	if sc := cfg.GetServerConfig(cfg.ResolveServer("cinch.sh")); sc == nil || sc.Token != "a" {
+		t.Errorf("GetServerConfig for cinch.sh = %+v, want token a", sc)
+	}
+}
+
+func TestCredentialsEnvFallback(t *testing.T) {
+	t.Setenv("CINCH_URL", "https://ci.example.com/")
+	t.Setenv("CINCH_TOKEN", "env-token")
+
+	cfg := &CLIConfig{Servers: make(map[string]ServerConfig)}
+	serverURL := cfg.ResolveServer("")
+	if serverURL != "https://ci.example.com" {
+		t.Fatalf("ResolveServer(\"\") = %q, want CINCH_URL", serverURL)
+	}
+	if sc := cfg.Credentials(serverURL); sc == nil || sc.Token != "env-token" {
+		t.Errorf("Credentials without a login = %+v, want env token", sc)
+	}
+	if sc := cfg.Credentials("https://other.example.com"); sc != nil {
+		t.Errorf("Credentials for another server = %+v, want nil", sc)
+	}
+
+	// A saved login for the server takes precedence over the env
+	cfg.SaveLogin(ServerConfig{URL: "https://ci.example.com", Token: "saved"})
+	if sc := cfg.Credentials(serverURL); sc == nil || sc.Token != "saved" {
+		t.Errorf("Credentials with a login = %+v, want saved token", sc)
+	}
+
+	// Without CINCH_URL the token isn't sent anywhere, not even the default
+	t.Setenv("CINCH_URL", "")
+	if sc := cfg.Credentials(DefaultServerURL); sc != nil {
+		t.Errorf("Credentials without CINCH_URL = %+v, want nil", sc)
+	}
+}
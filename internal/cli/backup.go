@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// JobRecord is one line of a job export (NDJSON).
+// Jobs are stored field-for-field so a backup round-trips without loss.
+type JobRecord struct {
+	Job  *storage.Job        `json:"job"`
+	Repo *RepoStub           `json:"repo,omitempty"`
+	Logs []logstore.LogEntry `json:"logs,omitempty"`
+}
+
+// RepoStub identifies a job's repo in an export. Secrets (webhook secret,
+// forge token) are never exported; a repo created from a stub must be
+// reconnected before it can receive webhooks.
+type RepoStub struct {
+	ID        string
+	ForgeType storage.ForgeType
+	Owner     string
+	Name      string
+	CloneURL  string
+	HTMLURL   string
+	Private   bool
+}
+
+// ImportResult summarizes a job import.
+type ImportResult struct {
+	Imported     int // Jobs inserted
+	Skipped      int // Jobs whose ID already existed
+	ReposCreated int // Missing repos created from stubs
+	LogLines     int // Log entries written for imported jobs
+}
+
+// exportPageSize is how many jobs are read per query during export.
+const exportPageSize = 500
+
+// ExportJobs writes every job (with its repo and logs) as NDJSON.
+// Returns the number of jobs written.
+func ExportJobs(ctx context.Context, store storage.Storage, logs logstore.LogStore, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	repos := make(map[string]*RepoStub)
+	count := 0
+
+	for offset := 0; ; offset += exportPageSize {
+		jobs, err := store.ListJobs(ctx, storage.JobFilter{Limit: exportPageSize, Offset: offset})
+		if err != nil {
+			return count, fmt.Errorf("list jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			stub, ok := repos[job.RepoID]
+			if !ok {
+				repo, err := store.GetRepo(ctx, job.RepoID)
+				if err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return count, fmt.Errorf("get repo %s: %w", job.RepoID, err)
+				}
+				if repo != nil {
+					stub = &RepoStub{
+						ID:        repo.ID,
+						ForgeType: repo.ForgeType,
+						Owner:     repo.Owner,
+						Name:      repo.Name,
+						CloneURL:  repo.CloneURL,
+						HTMLURL:   repo.HTMLURL,
+						Private:   repo.Private,
+					}
+				}
+				repos[job.RepoID] = stub
+			}
+
+			rec := JobRecord{Job: job, Repo: stub}
+			if logs != nil {
+				rec.Logs, err = readLogEntries(ctx, logs, job.ID)
+				if err != nil {
+					return count, fmt.Errorf("read logs for %s: %w", job.ID, err)
+				}
+			}
+
+			if err := enc.Encode(rec); err != nil {
+				return count, fmt.Errorf("write job %s: %w", job.ID, err)
+			}
+			count++
+		}
+
+		if len(jobs) < exportPageSize {
+			return count, nil
+		}
+	}
+}
+
+// readLogEntries reads a job's NDJSON logs from the log store.
+func readLogEntries(ctx context.Context, logs logstore.LogStore, jobID string) ([]logstore.LogEntry, error) {
+	reader, err := logs.GetLogs(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var entries []logstore.LogEntry
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry logstore.LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// ImportJobs reads an NDJSON export and inserts jobs that don't already exist.
+// Jobs are matched by ID, so importing the same file twice is safe. Repos
+// missing from the target are created from the record's stub; a job whose
+// repo is missing and has no stub is an error.
+func ImportJobs(ctx context.Context, store storage.Storage, logs logstore.LogStore, r io.Reader) (ImportResult, error) {
+	var result ImportResult
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var rec JobRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.Job == nil || rec.Job.ID == "" || rec.Job.RepoID == "" {
+			return result, fmt.Errorf("line %d: missing job ID or repo ID", line)
+		}
+		job := rec.Job
+
+		if _, err := store.GetJob(ctx, job.ID); err == nil {
+			result.Skipped++
+			continue
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return result, fmt.Errorf("line %d: get job %s: %w", line, job.ID, err)
+		}
+
+		created, err := ensureRepo(ctx, store, job.RepoID, rec.Repo)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		if created {
+			result.ReposCreated++
+		}
+
+		inserted, err := store.ImportJob(ctx, job)
+		if err != nil {
+			return result, fmt.Errorf("line %d: import job %s: %w", line, job.ID, err)
+		}
+		if !inserted {
+			result.Skipped++
+			continue
+		}
+		result.Imported++
+
+		if logs == nil || len(rec.Logs) == 0 {
+			continue
+		}
+		for _, entry := range rec.Logs {
+			if err := logs.AppendChunk(ctx, job.ID, entry.Stream, []byte(entry.Data)); err != nil {
+				return result, fmt.Errorf("line %d: write logs for %s: %w", line, job.ID, err)
+			}
+		}
+		size, err := logs.Finalize(ctx, job.ID)
+		if err != nil {
+			return result, fmt.Errorf("line %d: finalize logs for %s: %w", line, job.ID, err)
+		}
+		if err := store.UpdateJobLogSize(ctx, job.ID, size); err != nil {
+			return result, fmt.Errorf("line %d: update log size for %s: %w", line, job.ID, err)
+		}
+		result.LogLines += len(rec.Logs)
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("read input: %w", err)
+	}
+
+	return result, nil
+}
+
+// ensureRepo makes sure a job's repo exists, creating it from the stub if needed.
+func ensureRepo(ctx context.Context, store storage.Storage, repoID string, stub *RepoStub) (bool, error) {
+	_, err := store.GetRepo(ctx, repoID)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return false, fmt.Errorf("get repo %s: %w", repoID, err)
+	}
+	if stub == nil || stub.ID != repoID {
+		return false, fmt.Errorf("repo %s not found and the export has no repo details", repoID)
+	}
+
+	if err := store.CreateRepo(ctx, &storage.Repo{
+		ID:        stub.ID,
+		ForgeType: stub.ForgeType,
+		Owner:     stub.Owner,
+		Name:      stub.Name,
+		CloneURL:  stub.CloneURL,
+		HTMLURL:   stub.HTMLURL,
+		Private:   stub.Private,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return false, fmt.Errorf("create repo %s: %w", repoID, err)
+	}
+	return true, nil
+}
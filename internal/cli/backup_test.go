@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+func TestExportImportJobs(t *testing.T) {
+	ctx := context.Background()
+
+	src, _ := storage.NewSQLite(":memory:", "", "")
+	defer src.Close()
+	srcLogs := logstore.NewMemoryLogStore()
+
+	_ = src.CreateRepo(ctx, &storage.Repo{
+		ID:            "r_1",
+		ForgeType:     storage.ForgeTypeGitHub,
+		Owner:         "test",
+		Name:          "repo",
+		CloneURL:      "https://github.com/test/repo.git",
+		WebhookSecret: "do-not-export",
+		CreatedAt:     time.Now(),
+	})
+	for _, id := range []string{"j_1", "j_2"} {
+		_ = src.CreateJob(ctx, &storage.Job{
+			ID:        id,
+			RepoID:    "r_1",
+			Commit:    "abc123",
+			Branch:    "main",
+			Status:    storage.JobStatusPending,
+			CreatedAt: time.Now(),
+		})
+	}
+	exitCode := 1
+	_ = src.UpdateJobStatus(ctx, "j_1", storage.JobStatusFailed, &exitCode)
+	_ = srcLogs.AppendChunk(ctx, "j_1", "stdout", []byte("FAIL\n"))
+
+	var backup bytes.Buffer
+	n, err := ExportJobs(ctx, src, srcLogs, &backup)
+	if err != nil {
+		t.Fatalf("ExportJobs failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("exported %d jobs, want 2", n)
+	}
+	if strings.Contains(backup.String(), "do-not-export") {
+		t.Error("export contains repo webhook secret")
+	}
+
+	// Restore into an empty server: repo is created from the stub
+	dst, _ := storage.NewSQLite(":memory:", "", "")
+	defer dst.Close()
+	dstLogs := logstore.NewMemoryLogStore()
+
+	result, err := ImportJobs(ctx, dst, dstLogs, bytes.NewReader(backup.Bytes()))
+	if err != nil {
+		t.Fatalf("ImportJobs failed: %v", err)
+	}
+	want := ImportResult{Imported: 2, ReposCreated: 1, LogLines: 1}
+	if result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+
+	job, err := dst.GetJob(ctx, "j_1")
+	if err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if job.Status != storage.JobStatusFailed || job.ExitCode == nil || *job.ExitCode != 1 {
+		t.Errorf("imported job = %s exit %v, want failed exit 1", job.Status, job.ExitCode)
+	}
+	entries, _ := readLogEntries(ctx, dstLogs, "j_1")
+	if len(entries) != 1 || entries[0].Data != "FAIL\n" {
+		t.Errorf("imported logs = %+v, want one FAIL line", entries)
+	}
+
+	// Importing again is a no-op
+	result, err = ImportJobs(ctx, dst, dstLogs, bytes.NewReader(backup.Bytes()))
+	if err != nil {
+		t.Fatalf("second ImportJobs failed: %v", err)
+	}
+	if result.Imported != 0 || result.Skipped != 2 {
+		t.Errorf("second import = %+v, want 0 imported, 2 skipped", result)
+	}
+}
+
+func TestImportJobsMissingRepo(t *testing.T) {
+	ctx := context.Background()
+	dst, _ := storage.NewSQLite(":memory:", "", "")
+	defer dst.Close()
+
+	input := `{"job":{"ID":"j_1","RepoID":"r_missing","Status":"success"}}` + "\n"
+	if _, err := ImportJobs(ctx, dst, nil, strings.NewReader(input)); err == nil {
+		t.Error("expected error for job whose repo is missing with no stub")
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CancelAllOptions configures cancelling every active job for a repo.
+type CancelAllOptions struct {
+	ServerURL string
+	Token     string
+	RepoID    string
+	Statuses  []string // Job statuses to cancel, e.g. "pending", "queued", "running"
+}
+
+// CancelResult summarizes a CancelAll run.
+type CancelResult struct {
+	Cancelled []string         // IDs of cancelled jobs
+	Failed    map[string]error // Job ID -> why it couldn't be cancelled
+}
+
+// ListRepoJobs returns all of a repo's jobs in the given statuses.
+func ListRepoJobs(opts CancelAllOptions) ([]JobStatus, error) {
+	const pageSize = 100
+
+	var jobs []JobStatus
+	for _, status := range opts.Statuses {
+		for offset := 0; ; offset += pageSize {
+			q := url.Values{}
+			q.Set("repo_id", opts.RepoID)
+			q.Set("status", status)
+			q.Set("limit", fmt.Sprint(pageSize))
+			q.Set("offset", fmt.Sprint(offset))
+
+			page, err := getJobs(opts.ServerURL, opts.Token, q)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, page...)
+			if len(page) < pageSize {
+				break
+			}
+		}
+	}
+	return jobs, nil
+}
+
+func getJobs(serverURL, token string, q url.Values) ([]JobStatus, error) {
+	req, err := http.NewRequest("GET", serverURL+"/api/jobs?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Jobs []JobStatus `json:"jobs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return result.Jobs, nil
+}
+
+// CancelJob cancels a pending or running job.
+func CancelJob(serverURL, token, jobID string) error {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/jobs/%s/cancel", serverURL, url.PathEscape(jobID)), nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &result) == nil && result.Error != "" {
+			return fmt.Errorf("cancel failed: %s", result.Error)
+		}
+		return fmt.Errorf("cancel failed: %s", string(body))
+	}
+	return nil
+}
+
+// CancelJobs cancels each of jobs, carrying on past failures.
+func CancelJobs(serverURL, token string, jobs []JobStatus) *CancelResult {
+	result := &CancelResult{Failed: make(map[string]error)}
+	for _, j := range jobs {
+		if err := CancelJob(serverURL, token, j.ID); err != nil {
+			result.Failed[j.ID] = err
+			continue
+		}
+		result.Cancelled = append(result.Cancelled, j.ID)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCancelAll(t *testing.T) {
+	// 150 running jobs (two pages) and one queued job that can't be cancelled
+	jobs := map[string][]JobStatus{}
+	for i := range 150 {
+		jobs["running"] = append(jobs["running"], JobStatus{ID: fmt.Sprintf("j_run%d", i), Status: "running"})
+	}
+	jobs["queued"] = []JobStatus{{ID: "j_stuck", Status: "queued"}}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/jobs":
+			q := r.URL.Query()
+			if q.Get("repo_id") != "r_1" {
+				t.Errorf("repo_id = %q, want r_1", q.Get("repo_id"))
+			}
+			all := jobs[q.Get("status")]
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			page := all[min(offset, len(all)):min(offset+limit, len(all))]
+			_ = json.NewEncoder(w).Encode(map[string]any{"jobs": page})
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/cancel"):
+			if strings.Contains(r.URL.Path, "j_stuck") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"job already completed"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"cancelled"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	opts := CancelAllOptions{ServerURL: srv.URL, Token: "tok", RepoID: "r_1", Statuses: []string{"pending", "queued", "running"}}
+	found, err := ListRepoJobs(opts)
+	if err != nil {
+		t.Fatalf("ListRepoJobs failed: %v", err)
+	}
+	if len(found) != 151 {
+		t.Fatalf("found %d jobs, want 151", len(found))
+	}
+
+	result := CancelJobs(srv.URL, "tok", found)
+	if len(result.Cancelled) != 150 {
+		t.Errorf("cancelled %d jobs, want 150", len(result.Cancelled))
+	}
+	var failed []string
+	for id := range result.Failed {
+		failed = append(failed, id)
+	}
+	sort.Strings(failed)
+	if len(failed) != 1 || failed[0] != "j_stuck" {
+		t.Errorf("failed = %v, want [j_stuck]", failed)
+	}
+	if err := result.Failed["j_stuck"]; err == nil || !strings.Contains(err.Error(), "job already completed") {
+		t.Errorf("failure reason = %v", err)
+	}
+}
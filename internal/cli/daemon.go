@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -21,21 +22,41 @@ import (
 
 // DaemonConfig holds configuration for daemon commands.
 type DaemonConfig struct {
-	Concurrency int
-	SocketPath  string
-	LogFile     string
-	Verbose     bool
-	Shared      bool   // Shared mode: run collaborator code
-	OwnerName   string // Username of worker owner
+	Concurrency     int
+	SocketPath      string
+	LogFile         string
+	Verbose         bool
+	Shared          bool          // Shared mode: run collaborator code
+	OwnerName       string        // Username of worker owner
+	Once            bool          // Run a single job, then exit with its exit code
+	IdleTimeout     time.Duration // Stop after this long without a job (0 = never)
+	PreJobHook      string        // Script run before each job (CINCH_PRE_JOB_HOOK)
+	PostJobHook     string        // Script run after each job (CINCH_POST_JOB_HOOK)
+	GitProxy        string        // http.proxy for git clone/fetch (GIT_PROXY)
+	CloneURLRewrite string        // Clone URL rewrite rules (CINCH_CLONE_URL_REWRITE)
+}
+
+// JobExitError carries a non-zero exit code out of a command: a failed job
+// from RunDaemon in Once mode, or a commit status gate.
+type JobExitError struct {
+	Code int
+}
+
+func (e *JobExitError) Error() string {
+	return fmt.Sprintf("job exited with code %d", e.Code)
 }
 
 // DefaultDaemonConfig returns the default daemon configuration.
 func DefaultDaemonConfig() DaemonConfig {
 	home, _ := os.UserHomeDir()
 	return DaemonConfig{
-		Concurrency: 1,
-		SocketPath:  filepath.Join(home, ".cinch", "daemon.sock"),
-		LogFile:     filepath.Join(home, ".cinch", "daemon.log"),
+		Concurrency:     1,
+		SocketPath:      filepath.Join(home, ".cinch", "daemon.sock"),
+		LogFile:         filepath.Join(home, ".cinch", "daemon.log"),
+		PreJobHook:      os.Getenv("CINCH_PRE_JOB_HOOK"),
+		PostJobHook:     os.Getenv("CINCH_POST_JOB_HOOK"),
+		GitProxy:        os.Getenv("GIT_PROXY"),
+		CloneURLRewrite: os.Getenv("CINCH_CLONE_URL_REWRITE"),
 	}
 }
 
@@ -47,6 +68,11 @@ func RunDaemon(cfg DaemonConfig, serverURL, token string, labels []string) error
 		return fmt.Errorf("create config directory: %w", err)
 	}
 
+	rewrites, err := worker.ParseCloneURLRewrites(cfg.CloneURLRewrite)
+	if err != nil {
+		return err
+	}
+
 	// Set up logging
 	var logWriter io.Writer = os.Stderr
 	if cfg.LogFile != "" {
@@ -75,6 +101,14 @@ func RunDaemon(cfg DaemonConfig, serverURL, token string, labels []string) error
 		SocketPath:  cfg.SocketPath,
 		Shared:      cfg.Shared,
 		OwnerName:   cfg.OwnerName,
+		PreJobHook:  cfg.PreJobHook,
+		PostJobHook: cfg.PostJobHook,
+
+		GitProxy:         cfg.GitProxy,
+		CloneURLRewrites: rewrites,
+	}
+	if cfg.Once {
+		workerCfg.MaxJobs = 1
 	}
 	w := worker.NewWorker(workerCfg, log)
 
@@ -105,13 +139,54 @@ func RunDaemon(cfg DaemonConfig, serverURL, token string, labels []string) error
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	<-ctx.Done()
-	log.Info("shutting down daemon")
-	w.Stop()
+	select {
+	case <-ctx.Done():
+		log.Info("shutting down daemon")
+		w.Stop()
+	case <-idleTimeout(w, cfg.IdleTimeout):
+		log.Info("idle timeout reached, shutting down daemon", "idle_timeout", cfg.IdleTimeout)
+		srv.BroadcastShutdown("idle timeout reached")
+		w.Stop()
+	case <-srv.Drained():
+		log.Info("drained, shutting down daemon")
+		w.Stop()
+	case <-w.Done():
+		// Once mode: the single job has finished
+		log.Info("job finished, shutting down daemon", "exit_code", w.LastExitCode())
+		w.Stop()
+		if code := w.LastExitCode(); code != 0 {
+			return &JobExitError{Code: code}
+		}
+	}
 
 	return nil
 }
 
+// idleTimeout returns a channel that is closed once w has gone timeout
+// without a running job. It never closes if timeout is 0.
+func idleTimeout(w *worker.Worker, timeout time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	if timeout <= 0 {
+		return ch
+	}
+
+	interval := timeout / 10
+	if interval > 10*time.Second {
+		interval = 10 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if w.IdleFor() >= timeout {
+				close(ch)
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 // StartDaemon starts the daemon in the background.
 func StartDaemon(cfg DaemonConfig, serverURL, token string, labels []string) error {
 	// Check if daemon is already running
@@ -192,21 +267,10 @@ func StopDaemon(socketPath string) error {
 		return fmt.Errorf("get status: %w", err)
 	}
 
-	// Read pid file
 	pidFile := socketPath + ".pid"
-	data, err := os.ReadFile(pidFile)
+	process, err := daemonProcess(socketPath)
 	if err != nil {
-		return fmt.Errorf("read pid file: %w", err)
-	}
-
-	pid, err := strconv.Atoi(string(data))
-	if err != nil {
-		return fmt.Errorf("invalid pid file: %w", err)
-	}
-
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return fmt.Errorf("find process: %w", err)
+		return err
 	}
 
 	// Show running jobs
@@ -257,16 +321,121 @@ func StopDaemon(socketPath string) error {
 
 	// Timeout - force kill
 	fmt.Println("Timeout waiting for jobs, forcing shutdown...")
+	killDaemon(socketPath, process)
+	return nil
+}
+
+// daemonProcess returns the daemon process named in its pid file.
+func daemonProcess(socketPath string) (*os.Process, error) {
+	data, err := os.ReadFile(socketPath + ".pid")
+	if err != nil {
+		return nil, fmt.Errorf("read pid file: %w", err)
+	}
+	pid, err := strconv.Atoi(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pid file: %w", err)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("find process: %w", err)
+	}
+	return process, nil
+}
+
+// killDaemon force-kills the daemon and removes its pid file and socket.
+func killDaemon(socketPath string, process *os.Process) {
 	_ = process.Kill()
-	os.Remove(pidFile)
+	os.Remove(socketPath + ".pid")
 	os.Remove(socketPath)
 	fmt.Println("Daemon killed")
+}
+
+// DrainDaemon tells the running daemon to stop accepting new jobs, then
+// waits for it to finish its running jobs and exit.
+func DrainDaemon(socketPath string, timeout time.Duration) error {
+	running, err := startDrain(socketPath, timeout)
+	if err != nil {
+		return err
+	}
+
+	// Past timeout the daemon shuts down anyway, and its shutdown waits up
+	// to another 5 minutes for stragglers
+	if waitForDrain(socketPath, timeout+6*time.Minute, running) {
+		return nil
+	}
+	return fmt.Errorf("daemon still running after %s (use 'cinch daemon stop' to force)", timeout)
+}
+
+// GracefulStopDaemon drains the daemon like DrainDaemon, but kills it if its
+// running jobs haven't finished within timeout.
+func GracefulStopDaemon(socketPath string, timeout time.Duration) error {
+	running, err := startDrain(socketPath, timeout)
+	if err != nil {
+		return err
+	}
+	if waitForDrain(socketPath, timeout, running) {
+		return nil
+	}
 
+	fmt.Printf("Jobs still running after %s, forcing shutdown...\n", timeout)
+	process, err := daemonProcess(socketPath)
+	if err != nil {
+		return err
+	}
+	killDaemon(socketPath, process)
 	return nil
 }
 
-// DaemonStatus returns the daemon's status.
-func DaemonStatus(socketPath string) error {
+// startDrain asks the daemon to drain and returns how many jobs it is
+// waiting on.
+func startDrain(socketPath string, timeout time.Duration) (int, error) {
+	client, err := daemon.Connect(socketPath)
+	if err != nil {
+		return 0, fmt.Errorf("daemon not running: %w", err)
+	}
+	resp, err := client.Drain(timeout)
+	client.Close()
+	if err != nil {
+		return 0, fmt.Errorf("drain: %w", err)
+	}
+
+	if resp.RunningJobs > 0 {
+		fmt.Printf("Draining: no new jobs accepted, waiting for %d running job(s)...\n", resp.RunningJobs)
+	} else {
+		fmt.Println("Draining: no jobs running")
+	}
+	return resp.RunningJobs, nil
+}
+
+// waitForDrain waits up to wait for a draining daemon to exit, reporting
+// progress as its jobs finish. It returns false if the daemon is still up.
+func waitForDrain(socketPath string, wait time.Duration, running int) bool {
+	deadline := time.Now().Add(wait)
+	lastCount := running
+	for time.Now().Before(deadline) {
+		if !daemon.IsDaemonRunning(socketPath) {
+			os.Remove(socketPath + ".pid")
+			fmt.Println("Daemon drained and stopped")
+			return true
+		}
+
+		if client, err := daemon.Connect(socketPath); err == nil {
+			if status, err := client.Status(); err == nil && status.SlotsBusy != lastCount {
+				if status.SlotsBusy > 0 {
+					fmt.Printf("Waiting for %d job(s)...\n", status.SlotsBusy)
+				}
+				lastCount = status.SlotsBusy
+			}
+			client.Close()
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+	return false
+}
+
+// DaemonStatus prints the daemon's status, as JSON with jsonOutput.
+func DaemonStatus(socketPath string, jsonOutput bool) error {
 	client, err := daemon.Connect(socketPath)
 	if err != nil {
 		return fmt.Errorf("daemon not running: %w", err)
@@ -278,7 +447,16 @@ func DaemonStatus(socketPath string) error {
 		return fmt.Errorf("get status: %w", err)
 	}
 
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
 	fmt.Printf("Slots: %d/%d\n", status.SlotsBusy, status.SlotsTotal)
+	if status.Version != "" {
+		fmt.Printf("Version: %s, up %s\n", status.Version, (time.Duration(status.UptimeSeconds) * time.Second).String())
+	}
 
 	if len(status.RunningJobs) == 0 {
 		fmt.Println("No jobs running")
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseDotenv parses a dotenv-style file into key/value pairs.
+//
+// Supported syntax:
+//   - Blank lines and lines starting with # are ignored
+//   - An optional "export " prefix is stripped
+//   - Unquoted values are trimmed; " #" starts an inline comment
+//   - Double-quoted values may span lines and support \n, \t, \", \\ escapes
+//   - Single-quoted values may span lines and are taken literally
+//
+// Later assignments of the same key win.
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	vars := make(map[string]string)
+	line := 1
+	for len(src) > 0 {
+		// Take the next logical line
+		var raw string
+		if idx := strings.IndexByte(src, '\n'); idx >= 0 {
+			raw, src = src[:idx], src[idx+1:]
+		} else {
+			raw, src = src, ""
+		}
+		start := line
+		line++
+
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		eq := strings.IndexByte(text, '=')
+		if eq == -1 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", start)
+		}
+		key := strings.TrimSpace(text[:eq])
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid key %q", start, key)
+		}
+		value := strings.TrimLeft(text[eq+1:], " \t")
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = value[:idx]
+			}
+			vars[key] = strings.TrimSpace(value)
+			continue
+		}
+
+		// Quoted value: keep consuming lines until the closing quote
+		quote := value[0]
+		body := value[1:]
+		for {
+			if end := closingQuote(body, quote); end >= 0 {
+				rest := strings.TrimSpace(body[end+1:])
+				if rest != "" && !strings.HasPrefix(rest, "#") {
+					return nil, fmt.Errorf("line %d: unexpected text after closing quote", start)
+				}
+				body = body[:end]
+				break
+			}
+			if src == "" {
+				return nil, fmt.Errorf("line %d: unterminated quoted value for %s", start, key)
+			}
+			var next string
+			if idx := strings.IndexByte(src, '\n'); idx >= 0 {
+				next, src = src[:idx], src[idx+1:]
+			} else {
+				next, src = src, ""
+			}
+			line++
+			body += "\n" + next
+		}
+
+		if quote == '"' {
+			body = unescapeDoubleQuoted(body)
+		}
+		vars[key] = body
+	}
+	return vars, nil
+}
+
+// closingQuote returns the index of the unescaped closing quote in s, or -1.
+// Backslash escapes only apply inside double quotes.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDoubleQuoted expands the escapes allowed in double-quoted values.
+// Unknown escapes are kept as-is.
+func unescapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	input := `# deploy secrets
+NPM_TOKEN=npm_abc
+
+export AWS_REGION = us-east-1  # inline comment
+EMPTY=
+DOUBLE="line one\nline two \"quoted\""
+SINGLE='literal \n # not a comment'
+KEY="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+NPM_TOKEN=npm_override
+`
+	got, err := ParseDotenv(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDotenv: %v", err)
+	}
+
+	want := map[string]string{
+		"NPM_TOKEN":  "npm_override",
+		"AWS_REGION": "us-east-1",
+		"EMPTY":      "",
+		"DOUBLE":     "line one\nline two \"quoted\"",
+		"SINGLE":     `literal \n # not a comment`,
+		"KEY":        "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d keys, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []string{
+		"NOEQUALS",
+		"=value",
+		"BAD KEY=value",
+		`OPEN="never closed`,
+		`TRAILING="value" junk`,
+	}
+	for _, in := range tests {
+		if _, err := ParseDotenv(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseDotenv(%q) expected error", in)
+		}
+	}
+}
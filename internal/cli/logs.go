@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -18,6 +20,8 @@ type LogsOptions struct {
 	Token     string
 	JobID     string
 	Follow    bool
+	Tail      int    // Only print the last N lines (0 = all)
+	Grep      string // Only print lines matching this RE2 pattern
 }
 
 // LogEntry represents a log line from the API.
@@ -33,36 +37,109 @@ type LogEntry struct {
 func Logs(ctx context.Context, opts LogsOptions, out io.Writer) error {
 	// If not following, just fetch existing logs
 	if !opts.Follow {
-		return fetchLogs(opts, out)
+		var grep *regexp.Regexp
+		if opts.Grep != "" {
+			re, err := regexp.Compile(opts.Grep)
+			if err != nil {
+				return fmt.Errorf("invalid --grep pattern: %w", err)
+			}
+			grep = re
+		}
+		entries, err := fetchLogs(opts)
+		if err != nil {
+			return err
+		}
+		if grep != nil {
+			entries = []string{grepLines(strings.Join(entries, ""), grep)}
+		}
+		printLogs(entries, opts.Tail, out)
+		return nil
 	}
 
 	// For follow mode, use WebSocket
-	return streamLogs(ctx, opts, out)
+	if opts.Tail <= 0 {
+		return streamLogs(ctx, opts, out, 0)
+	}
+
+	// Tail then follow: print the end of what exists now, then skip those
+	// entries when the stream replays them (logs are append-only)
+	entries, err := fetchLogs(opts)
+	if err != nil {
+		return err
+	}
+	printLogs(entries, opts.Tail, out)
+	return streamLogs(ctx, opts, out, len(entries))
+}
+
+// printLogs writes log data, keeping only the last tail lines if tail > 0.
+func printLogs(entries []string, tail int, out io.Writer) {
+	if tail > 0 {
+		fmt.Fprint(out, tailLines(strings.Join(entries, ""), tail))
+		return
+	}
+	for _, data := range entries {
+		fmt.Fprint(out, data)
+	}
+}
+
+// tailLines returns the last n lines of s. A trailing newline ends the last
+// line rather than starting an empty one.
+func tailLines(s string, n int) string {
+	end := len(s)
+	if strings.HasSuffix(s, "\n") {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if s[i] == '\n' {
+			n--
+			if n == 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return s
+}
+
+// grepLines returns the lines of s that match re.
+func grepLines(s string, re *regexp.Regexp) string {
+	var b strings.Builder
+	for line := range strings.Lines(s) {
+		if re.MatchString(strings.TrimSuffix(line, "\n")) {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
 }
 
-// fetchLogs gets existing logs via HTTP.
-func fetchLogs(opts LogsOptions, out io.Writer) error {
+// fetchLogs gets existing log data via HTTP, one string per entry. With
+// opts.Grep the server only returns entries containing a matching line;
+// entries hold many lines, so callers still filter them.
+func fetchLogs(opts LogsOptions) ([]string, error) {
 	apiURL := fmt.Sprintf("%s/api/jobs/%s/logs", opts.ServerURL, opts.JobID)
+	if opts.Grep != "" {
+		// Multi-line mode so ^ and $ match at line boundaries within an entry
+		apiURL += "?grep=" + url.QueryEscape("(?m)"+opts.Grep)
+	}
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+opts.Token)
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("job not found: %s", opts.JobID)
+		return nil, fmt.Errorf("job not found: %s", opts.JobID)
 	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
 	}
 
 	var logs []struct {
@@ -70,18 +147,57 @@ func fetchLogs(opts LogsOptions, out io.Writer) error {
 		Data   string `json:"data"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&logs); err != nil {
-		return fmt.Errorf("decode response: %w", err)
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	entries := make([]string, len(logs))
+	for i, log := range logs {
+		entries[i] = log.Data
+	}
+	return entries, nil
+}
+
+// ShareLogs creates a signed link to a job's logs that works without
+// logging in until it expires. A zero ttl uses the server's default.
+func ShareLogs(serverURL, token, jobID string, ttl time.Duration) (string, time.Time, error) {
+	apiURL := fmt.Sprintf("%s/api/jobs/%s/logs/share", serverURL, url.PathEscape(jobID))
+	if ttl > 0 {
+		apiURL += "?ttl=" + url.QueryEscape(ttl.String())
 	}
 
-	for _, log := range logs {
-		fmt.Fprint(out, log.Data)
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("create request: %w", err)
 	}
+	req.Header.Set("Authorization", "Bearer "+token)
 
-	return nil
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", time.Time{}, fmt.Errorf("job not found: %s", jobID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", time.Time{}, fmt.Errorf("server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		URL       string    `json:"url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", time.Time{}, fmt.Errorf("decode response: %w", err)
+	}
+	return result.URL, result.ExpiresAt, nil
 }
 
-// streamLogs streams logs via WebSocket.
-func streamLogs(ctx context.Context, opts LogsOptions, out io.Writer) error {
+// streamLogs streams logs via WebSocket, skipping the first skip log entries.
+func streamLogs(ctx context.Context, opts LogsOptions, out io.Writer, skip int) error {
 	// Convert HTTP URL to WebSocket URL
 	wsURL := strings.Replace(opts.ServerURL, "https://", "wss://", 1)
 	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
@@ -127,6 +243,10 @@ func streamLogs(ctx context.Context, opts LogsOptions, out io.Writer) error {
 
 		switch entry.Type {
 		case "log":
+			if skip > 0 {
+				skip--
+				continue
+			}
 			fmt.Fprint(out, entry.Data)
 		case "status":
 			if entry.Status == "success" || entry.Status == "failed" || entry.Status == "error" || entry.Status == "cancelled" {
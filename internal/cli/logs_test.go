@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTailLines(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n"},
+		{"a\nb\nc", 2, "b\nc"},
+		{"a\nb\n", 5, "a\nb\n"},
+		{"a\nb\nc\n", 1, "c\n"},
+		{"", 3, ""},
+	}
+	for _, tt := range tests {
+		if got := tailLines(tt.in, tt.n); got != tt.want {
+			t.Errorf("tailLines(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLogsTail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chunks don't align with lines
+		_ = json.NewEncoder(w).Encode([]map[string]string{
+			{"stream": "stdout", "data": "one\ntwo\nth"},
+			{"stream": "stdout", "data": "ree\nfour\n"},
+		})
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := Logs(t.Context(), LogsOptions{ServerURL: srv.URL, JobID: "j_1", Tail: 2}, &out)
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	if got := out.String(); got != "three\nfour\n" {
+		t.Errorf("output = %q, want last two lines", got)
+	}
+}
+
+func TestLogsGrep(t *testing.T) {
+	var gotGrep string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotGrep = r.URL.Query().Get("grep")
+		// The server filters whole entries; lines around the match come along
+		_ = json.NewEncoder(w).Encode([]map[string]string{
+			{"stream": "stdout", "data": "ok  pkg/a\n--- FAIL: TestB\n"},
+			{"stream": "stdout", "data": "FAIL pkg/b\nok  pkg/c\n"},
+		})
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	err := Logs(t.Context(), LogsOptions{ServerURL: srv.URL, JobID: "j_1", Grep: "^(---|FAIL) "}, &out)
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	if gotGrep != "(?m)^(---|FAIL) " {
+		t.Errorf("grep param = %q", gotGrep)
+	}
+	if got := out.String(); got != "--- FAIL: TestB\nFAIL pkg/b\n" {
+		t.Errorf("output = %q, want matching lines only", got)
+	}
+
+	if err := Logs(t.Context(), LogsOptions{ServerURL: srv.URL, JobID: "j_1", Grep: "(unclosed"}, &out); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestShareLogs(t *testing.T) {
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/jobs/j_1/logs/share" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("ttl"); got != "1h0m0s" {
+			http.Error(w, "bad ttl "+got, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"url":        "https://cinch.example.com/api/jobs/j_1/logs?format=text&token=t",
+			"expires_at": expires,
+		})
+	}))
+	defer srv.Close()
+
+	link, exp, err := ShareLogs(srv.URL, "tok", "j_1", time.Hour)
+	if err != nil {
+		t.Fatalf("ShareLogs: %v", err)
+	}
+	if link != "https://cinch.example.com/api/jobs/j_1/logs?format=text&token=t" || !exp.Equal(expires) {
+		t.Errorf("ShareLogs = %q, %v", link, exp)
+	}
+
+	if _, _, err := ShareLogs(srv.URL, "tok", "j_2", time.Hour); err == nil {
+		t.Error("expected error for unknown job")
+	}
+}
@@ -2,14 +2,19 @@ package cli
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -22,6 +27,10 @@ type ReleaseOptions struct {
 	Files      []string // Files to upload
 	Draft      bool
 	Prerelease bool
+	DryRun     bool // Print the planned release without creating it
+
+	Checksums    bool   // Generate and upload a checksums file
+	ChecksumAlgo string // sha256 (default) or sha512
 }
 
 // Release creates a release on the detected forge and uploads assets.
@@ -74,21 +83,35 @@ func Release(opts ReleaseOptions) error {
 		return fmt.Errorf("cannot detect repository: set CINCH_REPO or use --repo flag")
 	}
 
-	// Expand file globs
-	var files []string
-	for _, pattern := range opts.Files {
-		matches, err := filepath.Glob(pattern)
+	files, err := expandReleaseFiles(opts.Files)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no files to upload")
+	}
+
+	if opts.Checksums {
+		dir, err := os.MkdirTemp("", "cinch-release-")
 		if err != nil {
-			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+			return fmt.Errorf("create temp dir: %w", err)
 		}
-		if len(matches) == 0 {
-			return fmt.Errorf("no files match pattern %q", pattern)
+		defer os.RemoveAll(dir)
+
+		sums, err := writeChecksums(dir, files, opts.ChecksumAlgo)
+		if err != nil {
+			return err
 		}
-		files = append(files, matches...)
+		// A stale checksums file matched by a glob would be uploaded twice
+		files = slices.DeleteFunc(files, func(f string) bool {
+			return filepath.Base(f) == filepath.Base(sums)
+		})
+		files = append(files, sums)
 	}
 
-	if len(files) == 0 {
-		return fmt.Errorf("no files to upload")
+	if opts.DryRun {
+		return printReleasePlan(os.Stdout, forge, tag, repo, files, opts.Draft, opts.Prerelease)
 	}
 
 	fmt.Printf("Creating %s release %s for %s\n", forge, tag, repo)
@@ -106,6 +129,108 @@ func Release(opts ReleaseOptions) error {
 	}
 }
 
+// expandReleaseFiles resolves release arguments to files. Globs are expanded
+// here rather than relying on the shell, which doesn't happen on Windows or
+// when the argument was quoted. An argument naming an existing file is taken
+// literally even if it contains glob characters. Duplicates are dropped and
+// argument order is kept.
+func expandReleaseFiles(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil {
+			add(arg)
+			continue
+		}
+		if !strings.ContainsAny(arg, "*?[") {
+			return nil, fmt.Errorf("file not found: %s", arg)
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match pattern %q", arg)
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return files, nil
+}
+
+// writeChecksums writes a SHA256SUMS (or SHA512SUMS) file for files into dir
+// in the format sha256sum -c expects, and returns its path. Any existing
+// checksums file in files is left out of the listing.
+func writeChecksums(dir string, files []string, algo string) (string, error) {
+	var newHash func() hash.Hash
+	switch algo {
+	case "", "sha256":
+		algo, newHash = "sha256", sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("unknown checksum algorithm %q (use sha256 or sha512)", algo)
+	}
+	name := strings.ToUpper(algo) + "SUMS"
+
+	var buf bytes.Buffer
+	for _, file := range files {
+		if filepath.Base(file) == name {
+			continue
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return "", fmt.Errorf("open %s: %w", file, err)
+		}
+		h := newHash()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", file, err)
+		}
+		fmt.Fprintf(&buf, "%s  %s\n", hex.EncodeToString(h.Sum(nil)), filepath.Base(file))
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("write %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// printReleasePlan writes what Release would create and upload, without
+// touching the forge.
+func printReleasePlan(w io.Writer, forge, tag, repo string, files []string, draft, prerelease bool) error {
+	switch forge {
+	case "github", "gitlab", "gitea", "forgejo":
+	default:
+		return fmt.Errorf("unknown forge: %s", forge)
+	}
+
+	fmt.Fprintf(w, "Dry run: would create %s release %s for %s\n", forge, tag, repo)
+	fmt.Fprintf(w, "  draft: %v, prerelease: %v\n", draft, prerelease)
+	fmt.Fprintf(w, "  %d assets:\n", len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", file, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", file)
+		}
+		fmt.Fprintf(w, "    %s (%s)\n", filepath.Base(file), FormatBytes(info.Size()))
+	}
+	return nil
+}
+
 // parseRepoFromURL extracts owner/repo from a clone URL.
 func parseRepoFromURL(cloneURL string) string {
 	// Handle https://github.com/owner/repo.git
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintReleasePlan(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "myapp-linux-amd64")
+	if err := os.WriteFile(bin, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := printReleasePlan(&buf, "github", "v1.0.0", "owner/repo", []string{bin}, true, false); err != nil {
+		t.Fatalf("printReleasePlan failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"github release v1.0.0 for owner/repo", "draft: true, prerelease: false", "myapp-linux-amd64 (2.0 KB)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := printReleasePlan(&buf, "github", "v1.0.0", "owner/repo", []string{dir}, false, false); err == nil {
+		t.Error("expected error for a directory asset")
+	}
+	if err := printReleasePlan(&buf, "svn", "v1.0.0", "owner/repo", []string{bin}, false, false); err == nil {
+		t.Error("expected error for an unknown forge")
+	}
+}
+
+func TestExpandReleaseFiles(t *testing.T) {
+	dir := t.TempDir()
+	dist := filepath.Join(dir, "dist")
+	if err := os.Mkdir(dist, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"app-linux", "app-darwin", "app.exe"} {
+		if err := os.WriteFile(filepath.Join(dist, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exe := filepath.Join(dist, "app.exe")
+
+	got, err := expandReleaseFiles([]string{exe, filepath.Join(dist, "*")})
+	if err != nil {
+		t.Fatalf("expandReleaseFiles failed: %v", err)
+	}
+	want := []string{exe, filepath.Join(dist, "app-darwin"), filepath.Join(dist, "app-linux")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expandReleaseFiles = %v, want %v", got, want)
+	}
+
+	if _, err := expandReleaseFiles([]string{filepath.Join(dist, "*.tar.gz")}); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("unmatched glob error = %v", err)
+	}
+	if _, err := expandReleaseFiles([]string{filepath.Join(dist, "missing")}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing file error = %v", err)
+	}
+}
+
+func TestWriteChecksums(t *testing.T) {
+	dist := t.TempDir()
+	app := filepath.Join(dist, "app")
+	if err := os.WriteFile(app, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(dist, "SHA256SUMS")
+	if err := os.WriteFile(stale, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := writeChecksums(t.TempDir(), []string{app, stale}, "")
+	if err != nil {
+		t.Fatalf("writeChecksums failed: %v", err)
+	}
+	if filepath.Base(path) != "SHA256SUMS" {
+		t.Errorf("checksums file = %s, want SHA256SUMS", filepath.Base(path))
+	}
+	data, _ := os.ReadFile(path)
+	want := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  app\n"
+	if string(data) != want {
+		t.Errorf("SHA256SUMS = %q, want %q", data, want)
+	}
+
+	path, err = writeChecksums(t.TempDir(), []string{app}, "sha512")
+	if err != nil {
+		t.Fatalf("writeChecksums(sha512) failed: %v", err)
+	}
+	if filepath.Base(path) != "SHA512SUMS" {
+		t.Errorf("checksums file = %s, want SHA512SUMS", filepath.Base(path))
+	}
+
+	if _, err := writeChecksums(t.TempDir(), []string{app}, "md5"); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
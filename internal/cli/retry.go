@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryJob re-runs a finished job and returns the new job's ID.
+func RetryJob(serverURL, token, jobID string) (string, error) {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/jobs/%s/run", serverURL, url.PathEscape(jobID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		JobID string `json:"job_id"`
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &result)
+
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return "", fmt.Errorf("retry failed: %s", result.Error)
+		}
+		return "", fmt.Errorf("retry failed: %s", string(body))
+	}
+	return result.JobID, nil
+}
+
+// LatestCommit returns the commit of the repo's most recent job.
+func LatestCommit(serverURL, token, repoID string) (string, error) {
+	q := url.Values{}
+	q.Set("repo_id", repoID)
+	q.Set("limit", "1")
+	jobs, err := getJobs(serverURL, token, q)
+	if err != nil {
+		return "", err
+	}
+	if len(jobs) == 0 {
+		return "", fmt.Errorf("no jobs for this repo")
+	}
+	return jobs[0].Commit, nil
+}
+
+// FailedJobsOptions selects the jobs FailedJobs considers.
+type FailedJobsOptions struct {
+	ServerURL string
+	Token     string
+	RepoID    string
+	Commit    string // Full SHA or a prefix of one
+}
+
+// FailedJobs returns the repo's failed and errored jobs for a commit. Only
+// the latest job for each branch, tag or PR counts: a failure that has
+// since been re-run isn't returned.
+func FailedJobs(opts FailedJobsOptions) ([]JobStatus, error) {
+	const pageSize = 100
+
+	seen := make(map[string]bool)
+	var failed []JobStatus
+	for offset := 0; ; offset += pageSize {
+		q := url.Values{}
+		q.Set("repo_id", opts.RepoID)
+		q.Set("commit", opts.Commit)
+		q.Set("limit", fmt.Sprint(pageSize))
+		q.Set("offset", fmt.Sprint(offset))
+
+		page, err := getJobs(opts.ServerURL, opts.Token, q)
+		if err != nil {
+			return nil, err
+		}
+		// Jobs come newest first. Older servers ignore the commit filter.
+		for _, j := range page {
+			if !strings.HasPrefix(j.Commit, opts.Commit) {
+				continue
+			}
+			key := j.Branch + "\x00" + j.Tag
+			if j.PRNumber != nil {
+				key += "\x00" + strconv.Itoa(*j.PRNumber)
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if j.Status == "failed" || j.Status == "error" {
+				failed = append(failed, j)
+			}
+		}
+		if len(page) < pageSize {
+			return failed, nil
+		}
+	}
+}
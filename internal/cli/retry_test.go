@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRetryFailed(t *testing.T) {
+	pr := 3
+	// Newest first, as the server returns them
+	jobs := []JobStatus{
+		{ID: "j_new", Status: "running", Commit: "bbbb2222", Branch: "main"},
+		{ID: "j_f1", Status: "failed", Commit: "bbbb2222", Branch: "feature", PRNumber: &pr},
+		{ID: "j_f0", Status: "failed", Commit: "bbbb2222", Branch: "main"}, // re-run as j_new
+		{ID: "j_e1", Status: "error", Commit: "bbbb2222", Branch: "feature"},
+		{ID: "j_old", Status: "failed", Commit: "aaaa1111", Branch: "main"},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/jobs":
+			var matched []JobStatus
+			for _, j := range jobs {
+				if strings.HasPrefix(j.Commit, r.URL.Query().Get("commit")) {
+					matched = append(matched, j)
+				}
+			}
+			if r.URL.Query().Get("limit") == "1" {
+				matched = matched[:1]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"jobs": matched})
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/run"):
+			if strings.Contains(r.URL.Path, "j_e1") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"repo not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"job_id":"j_retry"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	commit, err := LatestCommit(srv.URL, "tok", "r_1")
+	if err != nil {
+		t.Fatalf("LatestCommit failed: %v", err)
+	}
+	if commit != "bbbb2222" {
+		t.Errorf("latest commit = %q, want bbbb2222", commit)
+	}
+
+	failed, err := FailedJobs(FailedJobsOptions{ServerURL: srv.URL, Token: "tok", RepoID: "r_1", Commit: "bbbb"})
+	if err != nil {
+		t.Fatalf("FailedJobs failed: %v", err)
+	}
+	if len(failed) != 2 || failed[0].ID != "j_f1" || failed[1].ID != "j_e1" {
+		t.Fatalf("failed jobs = %+v, want j_f1 and j_e1", failed)
+	}
+
+	if id, err := RetryJob(srv.URL, "tok", "j_f1"); err != nil || id != "j_retry" {
+		t.Errorf("RetryJob(j_f1) = %q, %v", id, err)
+	}
+	if _, err := RetryJob(srv.URL, "tok", "j_e1"); err == nil || !strings.Contains(err.Error(), "repo not found") {
+		t.Errorf("RetryJob(j_e1) error = %v", err)
+	}
+}
@@ -5,10 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/ehrlich-b/cinch/internal/config"
+	"github.com/ehrlich-b/cinch/internal/protocol"
 	"github.com/ehrlich-b/cinch/internal/worker"
 	"github.com/ehrlich-b/cinch/internal/worker/container"
 )
@@ -18,7 +22,19 @@ type RunOptions struct {
 	Command   string
 	WorkDir   string
 	BareMetal bool
+	NoCache   bool // Rebuild the container image even if a cached one matches
 	Env       map[string]string
+
+	// DebugOnFailure keeps the container running after a failed command,
+	// until interrupted, so it can be inspected with docker exec.
+	DebugOnFailure bool
+
+	// Overrides for the CINCH_* env vars normally detected from git.
+	// Useful in detached HEAD, or to exercise release scripts with a specific tag.
+	Commit string
+	Branch string
+	Tag    string
+	Event  string // "push", "pr" or "tag" (default: "tag" if a tag is set)
 }
 
 // Run executes a command locally, simulating what CI would do.
@@ -46,7 +62,17 @@ func Run(opts RunOptions) int {
 		}
 	}
 
-	command := opts.Command
+	switch opts.Event {
+	case "", protocol.EventPush, protocol.EventPR, protocol.EventTag:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown event %q (want push, pr or tag)\n", opts.Event)
+		return 1
+	}
+
+	var steps []config.Step
+	if opts.Command != "" {
+		steps = []config.Step{{Name: "run", Run: opts.Command}}
+	}
 	bareMetal := opts.BareMetal
 	var cfg *config.Config
 
@@ -56,9 +82,10 @@ func Run(opts RunOptions) int {
 		cfg = loadedCfg
 		fmt.Printf("Loaded config from %s\n", configFile)
 
-		// Use build command from config if not provided
-		if command == "" {
-			command = cfg.Build
+		// Run the config's build steps if no command was given, in order,
+		// as a worker does for a branch push
+		if steps == nil {
+			steps = cfg.StepsForEvent(false)
 		}
 
 		// Check if config specifies bare metal
@@ -71,24 +98,166 @@ func Run(opts RunOptions) int {
 	}
 
 	// Still no command?
-	if command == "" {
+	if len(steps) == 0 {
 		fmt.Fprintln(os.Stderr, "Error: no command provided and no config file found")
 		fmt.Fprintln(os.Stderr, "Usage: cinch run \"make test\"")
 		fmt.Fprintln(os.Stderr, "   or: create .cinch.yaml with 'build: make test'")
 		return 1
 	}
 
+	env := ciEnv(workDir, opts)
+	// Plain env from the config sits under --env, as it sits under secrets on a worker
+	if cfg != nil {
+		for k, v := range cfg.Env {
+			if _, set := env[k]; !set {
+				env[k] = v
+			}
+		}
+	}
+
 	// Bare metal mode - just run the command
 	if bareMetal {
-		return runBareMetal(ctx, command, workDir, opts.Env)
+		if opts.DebugOnFailure {
+			fmt.Fprintln(os.Stderr, "Warning: --debug-on-failure only applies to container runs")
+		}
+		dir := workDir
+		if cfg != nil && cfg.Workdir != "" {
+			dir = filepath.Join(workDir, cfg.Workdir)
+		}
+		return runBareMetal(ctx, steps, dir, env)
 	}
 
 	// Container mode (with optional services)
-	return runContainer(ctx, command, workDir, opts.Env, cfg)
+	return runContainer(ctx, steps, workDir, env, cfg, opts.NoCache, opts.DebugOnFailure)
+}
+
+// runSteps runs steps in order with run, stopping at the first that fails.
+// Multiple steps each get a header, in the same format as worker logs.
+func runSteps(steps []config.Step, run func(command string) (int, error)) (int, error) {
+	for i, step := range steps {
+		if len(steps) > 1 {
+			fmt.Printf("==> [%d/%d] %s\n", i+1, len(steps), step.Name)
+		}
+		exitCode, err := run(step.Run)
+		if err != nil || exitCode != 0 {
+			if len(steps) > 1 && err == nil {
+				fmt.Printf("==> step %q failed with exit code %d\n", step.Name, exitCode)
+			}
+			return exitCode, err
+		}
+	}
+	return 0, nil
+}
+
+// stepsSummary describes steps for the "Running:" line: the command for a
+// single step, or the step names in order.
+func stepsSummary(steps []config.Step) string {
+	if len(steps) == 1 {
+		return steps[0].Run
+	}
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+	}
+	return strings.Join(names, " → ")
+}
+
+// LoadRunEnv builds the extra environment for a local run from a dotenv
+// file (if envFile is set) and KEY=VALUE pairs, with pairs overriding the file.
+func LoadRunEnv(envFile string, pairs []string) (map[string]string, error) {
+	env := make(map[string]string)
+	if envFile != "" {
+		f, err := os.Open(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("open env file: %w", err)
+		}
+		fileEnv, err := ParseDotenv(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", envFile, err)
+		}
+		for k, v := range fileEnv {
+			env[k] = v
+		}
+	}
+
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --env %q: expected KEY=VALUE", pair)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid --env %q: key cannot be empty", pair)
+		}
+		env[key] = value
+	}
+	return env, nil
 }
 
-func runBareMetal(ctx context.Context, command, workDir string, env map[string]string) int {
-	fmt.Printf("Running (bare metal): %s\n", command)
+// ciEnv builds the job environment: opts.Env plus the CINCH_* variables a
+// worker would set, detected from git and overridden by opts.
+func ciEnv(workDir string, opts RunOptions) map[string]string {
+	env := make(map[string]string)
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+
+	commit := gitOutput(workDir, "rev-parse", "HEAD")
+	branch := gitOutput(workDir, "symbolic-ref", "--short", "-q", "HEAD")
+	tag := gitOutput(workDir, "describe", "--tags", "--exact-match", "HEAD")
+
+	if opts.Commit != "" {
+		commit = opts.Commit
+	}
+	// Tag builds have no branch and vice versa, like jobs from the server
+	switch {
+	case opts.Tag != "" && opts.Branch != "":
+		tag, branch = opts.Tag, opts.Branch
+	case opts.Tag != "":
+		tag, branch = opts.Tag, ""
+	case opts.Branch != "":
+		tag, branch = "", opts.Branch
+	case branch != "":
+		tag = "" // on a branch that happens to be tagged: a push build
+	}
+
+	ref := ""
+	if tag != "" {
+		ref = "refs/tags/" + tag
+	} else if branch != "" {
+		ref = "refs/heads/" + branch
+	}
+
+	event := opts.Event
+	if event == "" {
+		event = protocol.EventPush
+		if tag != "" {
+			event = protocol.EventTag
+		}
+	}
+
+	env["CINCH_JOB_ID"] = "local"
+	env["CINCH_REF"] = ref
+	env["CINCH_BRANCH"] = branch
+	env["CINCH_TAG"] = tag
+	env["CINCH_COMMIT"] = commit
+	env["CINCH_EVENT"] = event
+	return env
+}
+
+// gitOutput runs a git command in dir and returns trimmed stdout, or "" on error.
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func runBareMetal(ctx context.Context, steps []config.Step, workDir string, env map[string]string) int {
+	fmt.Printf("Running (bare metal): %s\n", stepsSummary(steps))
 	fmt.Printf("Working directory: %s\n\n", workDir)
 
 	exec := &worker.Executor{
@@ -98,7 +267,9 @@ func runBareMetal(ctx context.Context, command, workDir string, env map[string]s
 		Stderr:  os.Stderr,
 	}
 
-	exitCode, err := exec.Run(ctx, command)
+	exitCode, err := runSteps(steps, func(command string) (int, error) {
+		return exec.Run(ctx, command)
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
@@ -108,7 +279,7 @@ func runBareMetal(ctx context.Context, command, workDir string, env map[string]s
 	return exitCode
 }
 
-func runContainer(ctx context.Context, command, workDir string, env map[string]string, cfg *config.Config) int {
+func runContainer(ctx context.Context, steps []config.Step, workDir string, env map[string]string, cfg *config.Config, noCache, debugOnFailure bool) int {
 	// Check docker is available
 	if err := container.CheckAvailable(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -131,7 +302,7 @@ func runContainer(ctx context.Context, command, workDir string, env map[string]s
 
 	// Handle bare-metal case (shouldn't happen if runContainer was called, but be safe)
 	if source.Type == "bare-metal" {
-		return runBareMetal(ctx, command, workDir, env)
+		return runBareMetal(ctx, steps, workDir, env)
 	}
 
 	switch source.Type {
@@ -147,9 +318,13 @@ func runContainer(ctx context.Context, command, workDir string, env map[string]s
 		}
 	}
 
-	// Prepare image (pull or build)
+	// Prepare image (pull, build, or reuse an image built from the same inputs)
 	jobID := "local"
-	image, err := container.PrepareImage(ctx, source, jobID, os.Stdout, os.Stderr)
+	var cache *container.ImageCache
+	if path := container.DefaultImageCachePath(); path != "" && !noCache {
+		cache = container.NewImageCache(path)
+	}
+	image, err := container.PrepareCachedImage(ctx, source, jobID, cache, os.Stdout, os.Stderr)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error preparing image: %v\n", err)
 		return 1
@@ -168,15 +343,21 @@ func runContainer(ctx context.Context, command, workDir string, env map[string]s
 			return 1
 		}
 		network = svcManager.Network
+		for k, v := range container.ServiceEnv(cfg.Services) {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+			}
+		}
 		fmt.Println()
 	}
 
 	// Run in container
-	fmt.Printf("Running: %s\n", command)
+	fmt.Printf("Running: %s\n", stepsSummary(steps))
 	fmt.Printf("Working directory: /workspace (mounted from %s)\n\n", workDir)
 
 	docker := &container.Docker{
 		WorkDir:      workDir,
+		Dir:          effectiveCfg.Workdir,
 		Image:        image,
 		Env:          env,
 		Network:      network,
@@ -184,13 +365,28 @@ func runContainer(ctx context.Context, command, workDir string, env map[string]s
 		Stdout:       os.Stdout,
 		Stderr:       os.Stderr,
 	}
+	if debugOnFailure {
+		docker.Name = fmt.Sprintf("cinch-debug-%d", os.Getpid())
+		docker.KeepOnFailure = true
+	}
 
-	exitCode, err := docker.Run(ctx, command)
+	exitCode, err := runSteps(steps, func(command string) (int, error) {
+		return docker.Run(ctx, command)
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
 	}
 
 	fmt.Printf("\nExit code: %d\n", exitCode)
+	if debugOnFailure && exitCode != 0 && ctx.Err() == nil {
+		fmt.Printf("\nContainer kept for debugging. In another terminal, run:\n")
+		fmt.Printf("  %s\n", container.AttachCommand(docker.Name))
+		fmt.Printf("Press Ctrl-C to remove it.\n")
+		<-ctx.Done()
+		if err := container.RemoveContainer(context.Background(), docker.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
 	return exitCode
 }
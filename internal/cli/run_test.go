@@ -46,6 +46,35 @@ container: none
 	}
 }
 
+func TestRunConfigSteps(t *testing.T) {
+	dir := t.TempDir()
+
+	// Steps run in order and stop at the first failure
+	configContent := `container: none
+steps:
+  - name: first
+    run: echo 1 >> order.txt
+  - name: second
+    run: echo 2 >> order.txt && exit 3
+  - name: third
+    run: echo 3 >> order.txt
+`
+	if err := os.WriteFile(filepath.Join(dir, ".cinch.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if exitCode := Run(RunOptions{WorkDir: dir}); exitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", exitCode)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "order.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "1\n2\n" {
+		t.Errorf("steps ran %q, want first and second only", got)
+	}
+}
+
 func TestRunBareMetalFailure(t *testing.T) {
 	exitCode := Run(RunOptions{
 		Command:   "exit 42",
@@ -88,6 +117,103 @@ fi
 	}
 }
 
+func TestLoadRunEnv(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env.ci")
+	if err := os.WriteFile(envFile, []byte("API_URL=https://ci.example\nDEBUG=0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := LoadRunEnv(envFile, []string{"DEBUG=1", "TOKEN=a=b"})
+	if err != nil {
+		t.Fatalf("LoadRunEnv failed: %v", err)
+	}
+	want := map[string]string{
+		"API_URL": "https://ci.example",
+		"DEBUG":   "1", // --env overrides the file
+		"TOKEN":   "a=b",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+
+	for _, bad := range []string{"NOEQUALS", "=value"} {
+		if _, err := LoadRunEnv("", []string{bad}); err == nil {
+			t.Errorf("LoadRunEnv(%q) should fail", bad)
+		}
+	}
+	if _, err := LoadRunEnv(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("LoadRunEnv with missing file should fail")
+	}
+}
+
+func TestCIEnvOverrides(t *testing.T) {
+	// Temp dir is not a git repo, so nothing is detected
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		opts RunOptions
+		want map[string]string
+	}{
+		{
+			name: "tag",
+			opts: RunOptions{Tag: "v1.2.3", Commit: "abc123"},
+			want: map[string]string{
+				"CINCH_TAG":    "v1.2.3",
+				"CINCH_BRANCH": "",
+				"CINCH_REF":    "refs/tags/v1.2.3",
+				"CINCH_COMMIT": "abc123",
+				"CINCH_EVENT":  "tag",
+			},
+		},
+		{
+			name: "branch",
+			opts: RunOptions{Branch: "main"},
+			want: map[string]string{
+				"CINCH_TAG":    "",
+				"CINCH_BRANCH": "main",
+				"CINCH_REF":    "refs/heads/main",
+				"CINCH_EVENT":  "push",
+			},
+		},
+		{
+			name: "explicit event",
+			opts: RunOptions{Branch: "main", Event: "tag"},
+			want: map[string]string{"CINCH_EVENT": "tag"},
+		},
+		{
+			name: "env is preserved",
+			opts: RunOptions{Env: map[string]string{"MY_VAR": "x"}},
+			want: map[string]string{"MY_VAR": "x", "CINCH_JOB_ID": "local"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := ciEnv(dir, tt.opts)
+			for k, v := range tt.want {
+				if env[k] != v {
+					t.Errorf("%s = %q, want %q", k, env[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRunUnknownEvent(t *testing.T) {
+	exitCode := Run(RunOptions{
+		Command:   "true",
+		WorkDir:   t.TempDir(),
+		BareMetal: true,
+		Event:     "release",
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for an unknown event, got %d", exitCode)
+	}
+}
+
 func TestRunNoCommand(t *testing.T) {
 	dir := t.TempDir()
 
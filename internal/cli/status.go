@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,25 +18,88 @@ import (
 type StatusOptions struct {
 	ServerURL string
 	Token     string
+	Commit    string // Only jobs for this commit (or SHA prefix)
 	Limit     int
 }
 
 // JobStatus represents a job's status from the API.
 type JobStatus struct {
-	ID           string    `json:"id"`
-	Status       string    `json:"status"`
-	Repo         string    `json:"repo"`
-	Branch       string    `json:"branch"`
-	Tag          string    `json:"tag,omitempty"`
-	Commit       string    `json:"commit"`
-	PRNumber     *int      `json:"pr_number,omitempty"`
-	PRBaseBranch string    `json:"pr_base_branch,omitempty"`
-	ExitCode     *int      `json:"exit_code,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	StartedAt    *string   `json:"started_at,omitempty"`
-	FinishedAt   *string   `json:"finished_at,omitempty"`
-	Forge        string    `json:"-"` // Set locally, not from API
-	Owner        string    `json:"-"` // Parsed from Repo field
+	ID            string    `json:"id"`
+	Status        string    `json:"status"`
+	Repo          string    `json:"repo"`
+	Branch        string    `json:"branch"`
+	Tag           string    `json:"tag,omitempty"`
+	Commit        string    `json:"commit"`
+	PRNumber      *int      `json:"pr_number,omitempty"`
+	PRBaseBranch  string    `json:"pr_base_branch,omitempty"`
+	ExitCode      *int      `json:"exit_code,omitempty"`
+	Duration      *int64    `json:"duration,omitempty"` // milliseconds
+	CreatedAt     time.Time `json:"created_at"`
+	StartedAt     *string   `json:"started_at,omitempty"`
+	FinishedAt    *string   `json:"finished_at,omitempty"`
+	PendingReason string    `json:"pending_reason,omitempty"`
+	QueuePosition *int      `json:"queue_position,omitempty"`
+	Forge         string    `json:"-"` // Set locally, not from API
+	Owner         string    `json:"-"` // Parsed from Repo field
+}
+
+// Exit codes for gating on a commit's build (cinch status --exit-status).
+const (
+	StatusExitSuccess = 0
+	StatusExitFailed  = 1
+	StatusExitPending = 2
+)
+
+// ResolveCommit expands a revision (e.g. "HEAD" or a short SHA) to a full SHA
+// using the local repo. Revisions git doesn't know are returned unchanged, so
+// a SHA that only exists on the forge can still be matched by prefix.
+func ResolveCommit(rev string) string {
+	if sha := gitOutput("", "rev-parse", "--verify", "--quiet", rev+"^{commit}"); sha != "" {
+		return sha
+	}
+	return strings.ToLower(rev)
+}
+
+// CommitJobs returns the latest job per forge and ref for a commit.
+// Jobs must be sorted newest first, so a retry supersedes the run it replaced.
+func CommitJobs(jobs []JobStatus, commit string) []JobStatus {
+	seen := make(map[string]bool)
+	var out []JobStatus
+	for _, job := range jobs {
+		if commit == "" || !strings.HasPrefix(job.Commit, commit) {
+			continue
+		}
+		key := job.Forge + "|" + job.Repo + "|" + job.Branch + "|" + job.Tag
+		if job.PRNumber != nil {
+			key += fmt.Sprintf("|pr%d", *job.PRNumber)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, job)
+	}
+	return out
+}
+
+// CommitExitCode summarizes a commit's latest jobs (see CommitJobs) as an exit code:
+// success only if every job succeeded, failed if any job failed, errored, or was
+// cancelled, and pending otherwise (including when no jobs exist yet).
+func CommitExitCode(jobs []JobStatus) int {
+	if len(jobs) == 0 {
+		return StatusExitPending
+	}
+	code := StatusExitSuccess
+	for _, job := range jobs {
+		switch job.Status {
+		case "success":
+		case "failed", "error", "cancelled":
+			return StatusExitFailed
+		default:
+			code = StatusExitPending
+		}
+	}
+	return code
 }
 
 // RepoInfo parsed from git remote URL.
@@ -85,6 +149,9 @@ func Status(opts StatusOptions) ([]JobStatus, error) {
 func fetchJobsForRepo(client *http.Client, opts StatusOptions, info *RepoInfo) ([]JobStatus, error) {
 	apiURL := fmt.Sprintf("%s/api/repos/%s/%s/%s/jobs?limit=%d",
 		opts.ServerURL, info.Forge, info.Owner, info.Name, opts.Limit)
+	if opts.Commit != "" {
+		apiURL += "&commit=" + url.QueryEscape(opts.Commit)
+	}
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
@@ -230,6 +297,36 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
 }
 
+// FormatBytes formats a byte count with a binary unit (e.g. "12.3 MB").
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// ProgressBar renders used/total as a fixed-width bar, e.g. "[#####-----] 50%".
+func ProgressBar(used, total int64, width int) string {
+	if total <= 0 {
+		return ""
+	}
+	pct := float64(used) / float64(total)
+	filled := int(pct * float64(width))
+	if filled > width {
+		filled = width
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return fmt.Sprintf("[%s%s] %d%%", strings.Repeat("#", filled), strings.Repeat("-", width-filled), int(pct*100))
+}
+
 // RelativeTime formats a time as relative to now.
 func RelativeTime(t time.Time) string {
 	d := time.Since(t)
@@ -257,13 +354,45 @@ func RelativeTime(t time.Time) string {
 	return fmt.Sprintf("%d days ago", days)
 }
 
+// ParseTimeBound parses a --since/--until value: a duration before now
+// ("24h", "30m", "7d"), an RFC3339 timestamp, or a date ("2024-01-01",
+// midnight local time).
+func ParseTimeBound(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 24h or 7d, a date like 2024-01-01, or RFC3339)", s)
+}
+
+// StatusLabel returns the job's status for display, with its place in the
+// queue when the server reports one, e.g. "pending (#3 in queue)".
+func StatusLabel(job JobStatus) string {
+	if job.QueuePosition != nil {
+		return fmt.Sprintf("pending (#%d in queue)", *job.QueuePosition)
+	}
+	return job.Status
+}
+
 // StatusSymbol returns a terminal-friendly status symbol.
 func StatusSymbol(status string) string {
 	switch status {
 	case "success":
 		return "\033[32m✓\033[0m" // green check
-	case "failed", "error":
+	case "failed":
 		return "\033[31m✗\033[0m" // red X
+	case "error":
+		return "\033[38;5;208m!\033[0m" // orange bang (infra error, not the build)
 	case "running":
 		return "\033[33m●\033[0m" // yellow dot
 	case "pending", "queued":
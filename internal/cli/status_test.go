@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCommitExitCode(t *testing.T) {
+	pr := 7
+	jobs := []JobStatus{
+		// Newest first: a retry that succeeded supersedes the earlier failure
+		{Commit: "abc1234def", Forge: "github.com", Branch: "main", Status: "success"},
+		{Commit: "abc1234def", Forge: "github.com", Branch: "main", Status: "failed"},
+		{Commit: "abc1234def", Forge: "github.com", PRNumber: &pr, Status: "running"},
+		{Commit: "0000000fff", Forge: "github.com", Branch: "main", Status: "failed"},
+	}
+
+	got := CommitJobs(jobs, "abc1234")
+	if len(got) != 2 {
+		t.Fatalf("CommitJobs returned %d jobs, want 2", len(got))
+	}
+	if code := CommitExitCode(got); code != StatusExitPending {
+		t.Errorf("with running PR job: code = %d, want %d", code, StatusExitPending)
+	}
+
+	got[1].Status = "success"
+	if code := CommitExitCode(got); code != StatusExitSuccess {
+		t.Errorf("all success: code = %d, want %d", code, StatusExitSuccess)
+	}
+
+	got[1].Status = "cancelled"
+	if code := CommitExitCode(got); code != StatusExitFailed {
+		t.Errorf("cancelled: code = %d, want %d", code, StatusExitFailed)
+	}
+
+	if code := CommitExitCode(CommitJobs(jobs, "ffffff")); code != StatusExitPending {
+		t.Errorf("no jobs: code = %d, want %d", code, StatusExitPending)
+	}
+}
+
+func TestStatusSymbolDistinguishesError(t *testing.T) {
+	if StatusSymbol("failed") == StatusSymbol("error") {
+		t.Error("failed and error should render differently")
+	}
+}
+
+func TestStatusLabel(t *testing.T) {
+	pos := 3
+	if got := StatusLabel(JobStatus{Status: "queued", QueuePosition: &pos}); got != "pending (#3 in queue)" {
+		t.Errorf("StatusLabel(queued #3) = %q", got)
+	}
+	if got := StatusLabel(JobStatus{Status: "running"}); got != "running" {
+		t.Errorf("StatusLabel(running) = %q", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:               "512 B",
+		1536:              "1.5 KB",
+		100 * 1024 * 1024: "100.0 MB",
+		10 << 30:          "10.0 GB",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	if got := ProgressBar(50, 100, 10); got != "[#####-----] 50%" {
+		t.Errorf("ProgressBar(50, 100) = %q", got)
+	}
+	if got := ProgressBar(200, 100, 10); got != "[##########] 200%" {
+		t.Errorf("ProgressBar over quota = %q", got)
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"24h", now.Add(-24 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2024-01-01T08:00:00Z", time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeBound(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseTimeBound(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTimeBound(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTimeBound("yesterday", now); err == nil {
+		t.Error("expected error for unparseable time")
+	}
+}
+
+func TestFetchJobsForRepoFiltersByCommit(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"jobs":[{"id":"j_1","repo":"owner/repo","commit":"abc1234"}]}`))
+	}))
+	defer server.Close()
+
+	opts := StatusOptions{ServerURL: server.URL, Token: "t", Commit: "abc1234", Limit: 100}
+	jobs, err := fetchJobsForRepo(server.Client(), opts, &RepoInfo{Forge: "github.com", Owner: "owner", Name: "repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("commit") != "abc1234" {
+		t.Errorf("commit = %q, want the server to filter by commit", query.Get("commit"))
+	}
+	if len(jobs) != 1 || jobs[0].Owner != "owner" {
+		t.Errorf("jobs = %+v", jobs)
+	}
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TokenRefreshWindow is how close to expiry a login token gets refreshed
+// (or, if refreshing fails, warned about).
+const TokenRefreshWindow = 7 * 24 * time.Hour
+
+// TokenExpiry returns the expiry of a login token (a JWT) from its exp
+// claim. The signature is not checked. ok is false for tokens that aren't
+// JWTs or carry no expiry.
+func TokenExpiry(token string) (exp time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// RefreshToken exchanges a still-valid login token for a new one.
+func RefreshToken(serverURL, token string) (string, error) {
+	req, err := http.NewRequest("POST", serverURL+"/auth/token/refresh", nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result DeviceTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("server returned no token")
+	}
+	return result.AccessToken, nil
+}
+
+// CheckTokenExpiry keeps the login token for serverURL from expiring
+// unnoticed. A token within TokenRefreshWindow of expiry is refreshed and
+// saved; if that fails, or the token has already expired, a warning
+// suggesting 'cinch login' is written to w.
+func CheckTokenExpiry(cfg *CLIConfig, serverURL string, w io.Writer) {
+	for _, sc := range cfg.Servers {
+		if sc.URL != serverURL {
+			continue
+		}
+		exp, ok := TokenExpiry(sc.Token)
+		if !ok {
+			return
+		}
+		remaining := time.Until(exp)
+		if remaining > TokenRefreshWindow {
+			return
+		}
+		if remaining <= 0 {
+			fmt.Fprintf(w, "Warning: login token for %s expired on %s (run 'cinch login')\n", serverURL, exp.Format("2006-01-02"))
+			return
+		}
+
+		token, err := RefreshToken(serverURL, sc.Token)
+		if err == nil {
+			// Also update the server's other names (e.g. the "default" alias)
+			for other, osc := range cfg.Servers {
+				if osc.URL == sc.URL && osc.Token == sc.Token {
+					osc.Token = token
+					cfg.SetServerConfig(other, osc)
+				}
+			}
+			err = SaveConfig(cfg)
+		}
+		if err != nil {
+			days := int(math.Ceil(remaining.Hours() / 24))
+			fmt.Fprintf(w, "Warning: login token for %s expires in %d day(s) on %s (run 'cinch login' to renew): %v\n",
+				serverURL, days, exp.Format("2006-01-02"), err)
+		}
+		return
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeJWT builds an unsigned token with the given expiry.
+func fakeJWT(exp time.Time) string {
+	payload, _ := json.Marshal(map[string]any{"sub": "a@example.com", "exp": exp.Unix()})
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestTokenExpiry(t *testing.T) {
+	exp := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	got, ok := TokenExpiry(fakeJWT(exp))
+	if !ok || !got.Equal(exp) {
+		t.Errorf("TokenExpiry = %v, %v; want %v, true", got, ok, exp)
+	}
+
+	for _, token := range []string{"", "cinch_abc123", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + ".c"} {
+		if _, ok := TokenExpiry(token); ok {
+			t.Errorf("TokenExpiry(%q) should not be ok", token)
+		}
+	}
+}
+
+func TestCheckTokenExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	fresh := fakeJWT(time.Now().Add(90 * 24 * time.Hour))
+	refreshFails := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/token/refresh" || refreshFails {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer"}`, fresh)
+	}))
+	defer srv.Close()
+
+	newConfig := func(token string) *CLIConfig {
+		return &CLIConfig{Servers: map[string]ServerConfig{"default": {URL: srv.URL, Token: token}}}
+	}
+
+	// Far from expiry: nothing happens
+	var out bytes.Buffer
+	cfg := newConfig(fresh)
+	CheckTokenExpiry(cfg, srv.URL, &out)
+	if out.Len() != 0 {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	// Near expiry: refreshed and saved
+	cfg = newConfig(fakeJWT(time.Now().Add(3 * 24 * time.Hour)))
+	CheckTokenExpiry(cfg, srv.URL, &out)
+	if out.Len() != 0 {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+	saved, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.GetServerConfig(srv.URL).Token != fresh {
+		t.Error("refreshed token was not saved")
+	}
+
+	// Near expiry but refresh fails: warn
+	refreshFails = true
+	CheckTokenExpiry(newConfig(fakeJWT(time.Now().Add(3*24*time.Hour))), srv.URL, &out)
+	if !strings.Contains(out.String(), "expires in 3 day(s)") || !strings.Contains(out.String(), "cinch login") {
+		t.Errorf("warning = %q", out.String())
+	}
+
+	// Expired: warn without trying to refresh
+	out.Reset()
+	CheckTokenExpiry(newConfig(fakeJWT(time.Now().Add(-time.Hour))), srv.URL, &out)
+	if !strings.Contains(out.String(), "expired on") {
+		t.Errorf("warning = %q", out.String())
+	}
+}
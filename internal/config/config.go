@@ -18,9 +18,14 @@ var ErrNoConfig = errors.New("no cinch config file found")
 
 // Config is the parsed cinch configuration.
 type Config struct {
-	// Build is the command to run on branch pushes and PRs (required).
+	// Build is the command to run on branch pushes and PRs.
+	// Either Build or Steps is required; Build is a single-step shortcut.
 	Build string `yaml:"build" toml:"build" json:"build"`
 
+	// Steps are named commands run in order on branch pushes and PRs.
+	// The job fails at the first step that exits non-zero.
+	Steps []Step `yaml:"steps" toml:"steps" json:"steps"`
+
 	// Release is the command to run on tag pushes (optional).
 	// If not set, tags just run the build command.
 	Release string `yaml:"release" toml:"release" json:"release"`
@@ -32,9 +37,26 @@ type Config struct {
 	// Timeout for the job. Default: 30m.
 	Timeout Duration `yaml:"timeout" toml:"timeout" json:"timeout"`
 
+	// DebugOnFailure keeps a failed build's container (and its services)
+	// running on the worker this long, so it can be inspected with
+	// docker exec. Container builds only; at most MaxDebugOnFailure.
+	DebugOnFailure Duration `yaml:"debug_on_failure" toml:"debug_on_failure" json:"debug_on_failure"`
+
 	// Services are containers started before the build.
 	Services map[string]Service `yaml:"services" toml:"services" json:"services"`
 
+	// Env are plain (non-secret) environment variables for the build, e.g.
+	// CGO_ENABLED: "0". Precedence is process env < config env < secrets.
+	Env map[string]string `yaml:"env" toml:"env" json:"env"`
+
+	// Workdir is the directory, relative to the repo root, that the build
+	// runs in - e.g. "services/api" in a monorepo. Default: the repo root.
+	Workdir string `yaml:"workdir" toml:"workdir" json:"workdir"`
+
+	// Artifacts are glob patterns (relative to the repo root) for files to
+	// keep after the build, e.g. "dist/*" or "coverage.out".
+	Artifacts []string `yaml:"artifacts" toml:"artifacts" json:"artifacts"`
+
 	// Container resolution options (first match wins):
 
 	// Image is a pre-built image to use directly (e.g., "node:20").
@@ -51,6 +73,12 @@ type Config struct {
 	Container string `yaml:"container" toml:"container" json:"container"`
 }
 
+// Step is a named command in a multi-step build.
+type Step struct {
+	Name string `yaml:"name" toml:"name" json:"name"`
+	Run  string `yaml:"run" toml:"run" json:"run"`
+}
+
 // Service is a container that runs alongside the build.
 type Service struct {
 	Image       string            `yaml:"image" toml:"image" json:"image"`
@@ -59,9 +87,12 @@ type Service struct {
 	Healthcheck *Healthcheck      `yaml:"healthcheck" toml:"healthcheck" json:"healthcheck"`
 }
 
-// Healthcheck configures how to check if a service is ready.
+// Healthcheck configures how to check if a service is ready: a command run
+// inside the service container (ready once it exits 0), a TCP port the
+// service must be listening on, or both.
 type Healthcheck struct {
 	Cmd     string   `yaml:"cmd" toml:"cmd" json:"cmd"`
+	Port    int      `yaml:"port" toml:"port" json:"port"`
 	Timeout Duration `yaml:"timeout" toml:"timeout" json:"timeout"`
 }
 
@@ -183,23 +214,24 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// configFiles are the config file names Load looks for, in priority order.
+var configFiles = []struct {
+	name   string
+	parser func([]byte, *Config) error
+}{
+	{".cinch.yaml", parseYAML},
+	{".cinch.yml", parseYAML},
+	{".cinch.toml", parseTOML},
+	{".cinch.json", parseJSON},
+	{"cinch.yaml", parseYAML},
+	{"cinch.yml", parseYAML},
+	{"cinch.toml", parseTOML},
+	{"cinch.json", parseJSON},
+}
+
 // Load finds and parses a cinch config file from the given directory.
 func Load(dir string) (*Config, string, error) {
-	candidates := []struct {
-		name   string
-		parser func([]byte, *Config) error
-	}{
-		{".cinch.yaml", parseYAML},
-		{".cinch.yml", parseYAML},
-		{".cinch.toml", parseTOML},
-		{".cinch.json", parseJSON},
-		{"cinch.yaml", parseYAML},
-		{"cinch.yml", parseYAML},
-		{"cinch.toml", parseTOML},
-		{"cinch.json", parseJSON},
-	}
-
-	for _, c := range candidates {
+	for _, c := range configFiles {
 		path := filepath.Join(dir, c.name)
 		data, err := os.ReadFile(path)
 		if err != nil {
@@ -230,20 +262,41 @@ func parseYAML(data []byte, cfg *Config) error {
 	return decoder.Decode(cfg)
 }
 
+// parseTOML and parseJSON are as strict as parseYAML about unknown fields,
+// so a typo fails the same way whichever format the repo uses.
 func parseTOML(data []byte, cfg *Config) error {
-	_, err := toml.Decode(string(data), cfg)
-	return err
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		return err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown field %q", undecoded[0].String())
+	}
+	return nil
 }
 
 func parseJSON(data []byte, cfg *Config) error {
-	return json.Unmarshal(data, cfg)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
 }
 
 // Validate checks the config for errors.
 func (c *Config) Validate() error {
-	if c.Build == "" {
+	if c.Build == "" && len(c.Steps) == 0 {
 		return errors.New("build is required")
 	}
+	if c.Build != "" && len(c.Steps) > 0 {
+		return errors.New("build and steps are mutually exclusive - use one or the other")
+	}
+	for i, step := range c.Steps {
+		if step.Run == "" {
+			return fmt.Errorf("step %d: run is required", i+1)
+		}
+		if step.Run == "true" || step.Run == "false" {
+			return fmt.Errorf("step %d: run looks like a boolean - did YAML mangle it? Quote your command", i+1)
+		}
+	}
 
 	// Check for YAML footguns
 	if c.Build == "true" || c.Build == "false" {
@@ -253,21 +306,81 @@ func (c *Config) Validate() error {
 		return errors.New("release looks like a boolean - did YAML mangle it? Quote your command")
 	}
 
+	if c.DebugOnFailure < 0 || c.DebugOnFailure.Duration() > MaxDebugOnFailure {
+		return fmt.Errorf("debug_on_failure must be between 0 and %s", MaxDebugOnFailure)
+	}
+
+	for key := range c.Env {
+		if !validEnvKey(key) {
+			return fmt.Errorf("env: invalid variable name %q", key)
+		}
+	}
+
 	// Validate services
 	for name, svc := range c.Services {
 		if svc.Image == "" {
 			return fmt.Errorf("service %q: image is required", name)
 		}
+		if hc := svc.Healthcheck; hc != nil {
+			if hc.Cmd == "" && hc.Port == 0 {
+				return fmt.Errorf("service %q: healthcheck needs cmd or port", name)
+			}
+			if hc.Port < 0 || hc.Port > 65535 {
+				return fmt.Errorf("service %q: healthcheck port %d out of range", name, hc.Port)
+			}
+		}
+	}
+
+	if c.Workdir != "" && (filepath.IsAbs(c.Workdir) || !filepath.IsLocal(filepath.Clean(c.Workdir))) {
+		return fmt.Errorf("workdir: %q must be a path inside the repo", c.Workdir)
+	}
+
+	// Artifact globs must stay inside the repo
+	for _, pattern := range c.Artifacts {
+		if pattern == "" {
+			return errors.New("artifacts: empty pattern")
+		}
+		if filepath.IsAbs(pattern) || !filepath.IsLocal(filepath.Clean(pattern)) {
+			return fmt.Errorf("artifacts: %q must be a path inside the repo", pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("artifacts: invalid pattern %q: %w", pattern, err)
+		}
 	}
 
 	return nil
 }
 
+// MaxDebugOnFailure caps how long a worker keeps a failed build's container.
+const MaxDebugOnFailure = time.Hour
+
+// validEnvKey reports whether key is a portable shell variable name.
+func validEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Config) applyDefaults() {
 	if c.Timeout == 0 {
 		c.Timeout = Duration(30 * time.Minute)
 	}
 
+	for i := range c.Steps {
+		if c.Steps[i].Name == "" {
+			c.Steps[i].Name = fmt.Sprintf("step %d", i+1)
+		}
+	}
+
 	for name, svc := range c.Services {
 		if svc.Healthcheck != nil && svc.Healthcheck.Timeout == 0 {
 			svc.Healthcheck.Timeout = Duration(60 * time.Second)
@@ -283,9 +396,27 @@ func (c *Config) IsBareMetalContainer() bool {
 
 // CommandForEvent returns the appropriate command based on whether this is
 // a tag push (release) or a branch push/PR (build).
+// With steps configured, this is the first step's command; use StepsForEvent
+// to get them all.
 func (c *Config) CommandForEvent(isTag bool) string {
+	steps := c.StepsForEvent(isTag)
+	if len(steps) == 0 {
+		return ""
+	}
+	return steps[0].Run
+}
+
+// StepsForEvent returns the steps to run for a tag push (release) or a branch
+// push/PR (build). A single build or release command is returned as one step.
+func (c *Config) StepsForEvent(isTag bool) []Step {
 	if isTag && c.Release != "" {
-		return c.Release
+		return []Step{{Name: "release", Run: c.Release}}
+	}
+	if len(c.Steps) > 0 {
+		return c.Steps
+	}
+	if c.Build == "" {
+		return nil
 	}
-	return c.Build
+	return []Step{{Name: "build", Run: c.Build}}
 }
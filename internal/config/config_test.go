@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -80,6 +82,124 @@ func TestLoadJSON(t *testing.T) {
 	}
 }
 
+func TestLoadFormatParity(t *testing.T) {
+	files := map[string]string{
+		".cinch.yaml": `build: make test
+release: make release
+workers:
+  - linux
+  - arm64
+timeout: 10m
+workdir: services/api
+image: golang:1.23
+devcontainer: false
+env:
+  CGO_ENABLED: "0"
+artifacts:
+  - dist/*
+services:
+  postgres:
+    image: postgres:16
+    env:
+      POSTGRES_PASSWORD: postgres
+    command: postgres -c fsync=off
+    healthcheck:
+      cmd: pg_isready -U postgres
+      port: 5432
+      timeout: 30s
+`,
+		".cinch.toml": `build = "make test"
+release = "make release"
+workers = ["linux", "arm64"]
+timeout = "10m"
+workdir = "services/api"
+image = "golang:1.23"
+devcontainer = false
+artifacts = ["dist/*"]
+
+[env]
+CGO_ENABLED = "0"
+
+[services.postgres]
+image = "postgres:16"
+command = "postgres -c fsync=off"
+
+[services.postgres.env]
+POSTGRES_PASSWORD = "postgres"
+
+[services.postgres.healthcheck]
+cmd = "pg_isready -U postgres"
+port = 5432
+timeout = "30s"
+`,
+		".cinch.json": `{
+  "build": "make test",
+  "release": "make release",
+  "workers": ["linux", "arm64"],
+  "timeout": "10m",
+  "workdir": "services/api",
+  "image": "golang:1.23",
+  "devcontainer": false,
+  "env": {"CGO_ENABLED": "0"},
+  "artifacts": ["dist/*"],
+  "services": {
+    "postgres": {
+      "image": "postgres:16",
+      "env": {"POSTGRES_PASSWORD": "postgres"},
+      "command": "postgres -c fsync=off",
+      "healthcheck": {"cmd": "pg_isready -U postgres", "port": 5432, "timeout": "30s"}
+    }
+  }
+}`,
+	}
+
+	loaded := map[string]*Config{}
+	for name, content := range files {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, filename, err := Load(dir)
+		if err != nil {
+			t.Fatalf("Load %s failed: %v", name, err)
+		}
+		if filename != name {
+			t.Errorf("expected %s, got %s", name, filename)
+		}
+		loaded[name] = cfg
+	}
+
+	want := loaded[".cinch.yaml"]
+	if want.Timeout.Duration() != 10*time.Minute || want.Release != "make release" || want.Workdir != "services/api" ||
+		len(want.Workers) != 2 || !want.Devcontainer.Disabled || want.Env["CGO_ENABLED"] != "0" ||
+		want.Services["postgres"].Healthcheck == nil || want.Services["postgres"].Healthcheck.Port != 5432 {
+		t.Fatalf("yaml config not fully parsed: %+v", want)
+	}
+	for _, name := range []string{".cinch.toml", ".cinch.json"} {
+		if !reflect.DeepEqual(loaded[name], want) {
+			t.Errorf("%s parsed differently from .cinch.yaml:\n got %+v\nwant %+v", name, loaded[name], want)
+		}
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	files := map[string]string{
+		".cinch.yaml": "build: make test\nbuidl: typo\n",
+		".cinch.toml": "build = \"make test\"\nbuidl = \"typo\"\n",
+		".cinch.json": `{"build": "make test", "buidl": "typo"}`,
+	}
+	for name, content := range files {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := Load(dir)
+		if err == nil || !strings.Contains(err.Error(), "buidl") {
+			t.Errorf("%s: expected unknown field error naming buidl, got %v", name, err)
+		}
+	}
+}
+
 func TestLoadPriority(t *testing.T) {
 	// .cinch.yaml should take priority over cinch.yaml
 	dir := t.TempDir()
@@ -187,6 +307,47 @@ func TestValidateServiceNoImage(t *testing.T) {
 	}
 }
 
+func TestValidateServiceHealthcheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		hc      *Healthcheck
+		wantErr bool
+	}{
+		{"cmd", &Healthcheck{Cmd: "pg_isready"}, false},
+		{"port", &Healthcheck{Port: 5432}, false},
+		{"both", &Healthcheck{Cmd: "pg_isready", Port: 5432}, false},
+		{"empty", &Healthcheck{}, true},
+		{"port out of range", &Healthcheck{Port: 70000}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Build:    "test",
+				Services: map[string]Service{"db": {Image: "postgres:16", Healthcheck: tt.hc}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	for _, key := range []string{"CGO_ENABLED", "_private", "go111module"} {
+		cfg := &Config{Build: "test", Env: map[string]string{key: "1"}}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("env key %q: unexpected error %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "1ST", "MY-VAR", "A=B"} {
+		cfg := &Config{Build: "test", Env: map[string]string{key: "1"}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("env key %q: expected error", key)
+		}
+	}
+}
+
 func TestDefaults(t *testing.T) {
 	dir := t.TempDir()
 	content := `build: test`
@@ -278,6 +439,133 @@ func TestCommandForEvent(t *testing.T) {
 	}
 }
 
+func TestLoadWithSteps(t *testing.T) {
+	dir := t.TempDir()
+	content := `steps:
+  - name: lint
+    run: make lint
+  - run: make test
+release: make release
+`
+	if err := os.WriteFile(filepath.Join(dir, ".cinch.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := cfg.StepsForEvent(false)
+	want := []Step{{Name: "lint", Run: "make lint"}, {Name: "step 2", Run: "make test"}}
+	if len(steps) != len(want) {
+		t.Fatalf("expected %d steps, got %d: %+v", len(want), len(steps), steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, steps[i], want[i])
+		}
+	}
+
+	// Tags with a release command run it as a single step
+	tagSteps := cfg.StepsForEvent(true)
+	if len(tagSteps) != 1 || tagSteps[0].Run != "make release" {
+		t.Errorf("StepsForEvent(true) = %+v, want single release step", tagSteps)
+	}
+}
+
+func TestLoadWithStepsTOML(t *testing.T) {
+	dir := t.TempDir()
+	content := `[[steps]]
+name = "lint"
+run = "make lint"
+
+[[steps]]
+name = "test"
+run = "make test"
+`
+	if err := os.WriteFile(filepath.Join(dir, ".cinch.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Steps) != 2 || cfg.Steps[1].Name != "test" {
+		t.Errorf("unexpected steps: %+v", cfg.Steps)
+	}
+}
+
+func TestBuildIsSingleStep(t *testing.T) {
+	cfg := &Config{Build: "make check"}
+	steps := cfg.StepsForEvent(false)
+	if len(steps) != 1 || steps[0].Name != "build" || steps[0].Run != "make check" {
+		t.Errorf("StepsForEvent(false) = %+v, want single build step", steps)
+	}
+}
+
+func TestValidateSteps(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"build and steps", Config{Build: "make", Steps: []Step{{Run: "make test"}}}},
+		{"step without run", Config{Steps: []Step{{Name: "lint"}}}},
+		{"boolean step", Config{Steps: []Step{{Run: "true"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestValidateDebugOnFailure(t *testing.T) {
+	ok := Config{Build: "make", DebugOnFailure: Duration(15 * time.Minute)}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("debug_on_failure 15m: %v", err)
+	}
+	for _, d := range []time.Duration{-time.Minute, 2 * time.Hour} {
+		cfg := Config{Build: "make", DebugOnFailure: Duration(d)}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("debug_on_failure %s: expected validation error", d)
+		}
+	}
+}
+
+func TestValidateArtifacts(t *testing.T) {
+	valid := Config{Build: "make", Artifacts: []string{"dist/*", "coverage.out", "build/*.tar.gz"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid artifacts: %v", err)
+	}
+
+	for _, pattern := range []string{"", "/etc/passwd", "../secrets", "dist/../../x", "[bad"} {
+		cfg := Config{Build: "make", Artifacts: []string{pattern}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("artifacts %q: expected validation error", pattern)
+		}
+	}
+}
+
+func TestValidateWorkdir(t *testing.T) {
+	for _, dir := range []string{"services/api", "web", "./tools/"} {
+		cfg := Config{Build: "make", Workdir: dir}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("workdir %q: %v", dir, err)
+		}
+	}
+
+	for _, dir := range []string{"/srv/app", "..", "../other", "services/../../x"} {
+		cfg := Config{Build: "make", Workdir: dir}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("workdir %q: expected validation error", dir)
+		}
+	}
+}
+
 func TestLoadWithRelease(t *testing.T) {
 	dir := t.TempDir()
 	content := `build: make check
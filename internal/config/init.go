@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Project is a toolchain detected from a repo's manifest file.
+type Project struct {
+	Name     string // e.g. "Go"
+	Manifest string // file it was detected from, e.g. "go.mod"
+	Build    string // starter build command
+	Image    string // container image with the toolchain
+}
+
+// FindFile returns the name of the config file in dir, if any.
+func FindFile(dir string) (string, bool) {
+	for _, c := range configFiles {
+		if _, err := os.Stat(filepath.Join(dir, c.name)); err == nil {
+			return c.name, true
+		}
+	}
+	return "", false
+}
+
+// DetectProject guesses the project type in dir from its manifest files.
+// ok is false when none is recognised.
+func DetectProject(dir string) (p Project, ok bool) {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("go.mod"):
+		return Project{Name: "Go", Manifest: "go.mod", Build: "go vet ./... && go test ./...", Image: "golang:1"}, true
+	case exists("package.json"):
+		p := Project{Name: "Node", Manifest: "package.json", Image: "node:lts"}
+		switch {
+		case exists("pnpm-lock.yaml"):
+			p.Build = "corepack enable && pnpm install --frozen-lockfile && pnpm test"
+		case exists("yarn.lock"):
+			p.Build = "corepack enable && yarn install --frozen-lockfile && yarn test"
+		case exists("package-lock.json"):
+			p.Build = "npm ci && npm test"
+		default:
+			p.Build = "npm install && npm test"
+		}
+		return p, true
+	case exists("Cargo.toml"):
+		return Project{Name: "Rust", Manifest: "Cargo.toml", Build: "cargo test", Image: "rust:1"}, true
+	case exists("pyproject.toml"):
+		return Project{Name: "Python", Manifest: "pyproject.toml", Build: "pip install -e . pytest && python -m pytest", Image: "python:3"}, true
+	}
+	return Project{}, false
+}
+
+// Scaffold renders a starter .cinch.yaml for the project in dir, with the
+// optional settings commented out. Unrecognised projects get a "make check"
+// placeholder build.
+func Scaffold(dir string) []byte {
+	p, ok := DetectProject(dir)
+
+	var b strings.Builder
+	b.WriteString("# Cinch CI config\n")
+	if ok {
+		fmt.Fprintf(&b, "# Detected: %s (%s)\n", p.Name, p.Manifest)
+	} else {
+		b.WriteString("# Project type not detected: set build to your build and test command\n")
+		p.Build = "make check"
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "# Runs on branch pushes and pull requests\nbuild: %s\n\n", p.Build)
+
+	// A devcontainer is used automatically when no image is set
+	if p.Image != "" {
+		b.WriteString("# Container image for the build\n")
+		if _, err := os.Stat(filepath.Join(dir, ".devcontainer", "devcontainer.json")); err == nil {
+			fmt.Fprintf(&b, "# (.devcontainer/devcontainer.json is used while this is unset)\n# image: %s\n\n", p.Image)
+		} else {
+			fmt.Fprintf(&b, "image: %s\n\n", p.Image)
+		}
+	}
+
+	b.WriteString(`# Runs on tag pushes instead of build
+# release: make release
+
+# Job timeout (default: 30m)
+# timeout: 30m
+
+# Plain (non-secret) env vars for the build; secrets override these
+# env:
+#   CGO_ENABLED: "0"
+
+# Containers started before the build
+# services:
+#   postgres:
+#     image: postgres:16
+#     env:
+#       POSTGRES_PASSWORD: postgres
+#     healthcheck:
+#       port: 5432
+`)
+	return []byte(b.String())
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffold(t *testing.T) {
+	tests := []struct {
+		files     []string
+		wantBuild string
+		wantImage string
+	}{
+		{[]string{"go.mod"}, "go vet ./... && go test ./...", "golang:1"},
+		{[]string{"package.json"}, "npm install && npm test", "node:lts"},
+		{[]string{"package.json", "package-lock.json"}, "npm ci && npm test", "node:lts"},
+		{[]string{"package.json", "yarn.lock"}, "corepack enable && yarn install --frozen-lockfile && yarn test", "node:lts"},
+		{[]string{"Cargo.toml"}, "cargo test", "rust:1"},
+		{[]string{"pyproject.toml"}, "pip install -e . pytest && python -m pytest", "python:3"},
+		{[]string{"go.mod", "package.json"}, "go vet ./... && go test ./...", "golang:1"},
+		{nil, "make check", ""},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for _, f := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The scaffold must load as a valid config
+		if err := os.WriteFile(filepath.Join(dir, ".cinch.yaml"), Scaffold(dir), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, _, err := Load(dir)
+		if err != nil {
+			t.Fatalf("%v: Load failed: %v", tt.files, err)
+		}
+		if cfg.Build != tt.wantBuild {
+			t.Errorf("%v: build = %q, want %q", tt.files, cfg.Build, tt.wantBuild)
+		}
+		if cfg.Image != tt.wantImage {
+			t.Errorf("%v: image = %q, want %q", tt.files, cfg.Image, tt.wantImage)
+		}
+	}
+}
+
+func TestScaffoldWithDevcontainer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".devcontainer", "devcontainer.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(Scaffold(dir))
+	if !strings.Contains(out, "# image: golang:1") {
+		t.Errorf("image should be commented out when a devcontainer exists:\n%s", out)
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	dir := t.TempDir()
+	if name, ok := FindFile(dir); ok {
+		t.Errorf("FindFile = %q in empty dir", name)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "cinch.toml"), []byte(`build = "make"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := FindFile(dir); !ok || name != "cinch.toml" {
+		t.Errorf("FindFile = %q, %v; want cinch.toml, true", name, ok)
+	}
+}
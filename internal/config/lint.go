@@ -0,0 +1,216 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is how serious a lint diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the config won't work
+	SeverityWarning Severity = "warning" // likely a mistake, but it loads
+)
+
+// Diagnostic is a single lint finding.
+type Diagnostic struct {
+	Severity Severity
+	Field    string // Top-level key or path, e.g. "services.postgres"; empty for the whole file
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Field == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Field, d.Message)
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// minTimeout is the shortest job timeout Lint doesn't warn about.
+const minTimeout = time.Second
+
+// Lint checks a parsed config for errors and likely mistakes. It's stricter
+// than Validate: anything Validate rejects is an error, and suspicious but
+// loadable settings are warnings.
+func Lint(cfg *Config) []Diagnostic {
+	var diags []Diagnostic
+
+	if cfg.Build == "" && len(cfg.Steps) == 0 {
+		diags = append(diags, Diagnostic{SeverityError, "build", "build is empty and there are no steps; nothing would run"})
+	} else if err := cfg.Validate(); err != nil {
+		diags = append(diags, Diagnostic{SeverityError, "", err.Error()})
+	}
+
+	if t := cfg.Timeout.Duration(); t > 0 && t < minTimeout {
+		diags = append(diags, Diagnostic{SeverityWarning, "timeout", fmt.Sprintf("%s is under %s; every build will time out (durations need a unit, e.g. 10m)", t, minTimeout)})
+	}
+
+	names := make([]string, 0, len(cfg.Services))
+	for name := range cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if image := cfg.Services[name].Image; image != "" && !imageHasTag(image) {
+			diags = append(diags, Diagnostic{SeverityWarning, "services." + name, fmt.Sprintf("image %q has no tag, so it runs whatever \"latest\" is; pin a version", image)})
+		}
+	}
+
+	return diags
+}
+
+// imageHasTag reports whether an image reference names a tag or digest.
+func imageHasTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return true
+	}
+	// A colon before the last slash is a registry port, not a tag
+	return strings.Contains(image[strings.LastIndex(image, "/")+1:], ":")
+}
+
+// LintDir finds the cinch config in dir like Load does and lints it. Unknown
+// top-level keys are reported as warnings (with a suggestion when one is
+// close to a real key), and whatever Load's strict parse rejects, such as an
+// unknown nested key, is an error. The returned error is for files that
+// can't be read or parsed at all.
+func LintDir(dir string) (string, []Diagnostic, error) {
+	for _, c := range configFiles {
+		data, err := os.ReadFile(filepath.Join(dir, c.name))
+		if err != nil {
+			continue
+		}
+
+		keys, err := topLevelKeys(c.name, data)
+		if err != nil {
+			return c.name, nil, fmt.Errorf("parse %s: %w", c.name, err)
+		}
+		var cfg Config
+		if err := parseLenient(c.name, data, &cfg); err != nil {
+			return c.name, nil, fmt.Errorf("parse %s: %w", c.name, err)
+		}
+
+		var diags []Diagnostic
+		known := knownKeys()
+		for _, key := range keys {
+			if known[key] {
+				continue
+			}
+			msg := "unknown key; it will be rejected when the config is loaded"
+			if s := suggestKey(key, known); s != "" {
+				msg = fmt.Sprintf("unknown key (did you mean %q?)", s)
+			}
+			diags = append(diags, Diagnostic{SeverityWarning, key, msg})
+		}
+		// Unknown top-level keys are reported above; anything else the
+		// strict parse rejects (e.g. a typo inside services) is an error
+		if len(diags) == 0 {
+			if err := c.parser(data, &Config{}); err != nil {
+				diags = append(diags, Diagnostic{SeverityError, "", "rejected when loaded: " + err.Error()})
+			}
+		}
+		return c.name, append(diags, Lint(&cfg)...), nil
+	}
+	return "", nil, ErrNoConfig
+}
+
+// parseLenient decodes a config file, ignoring unknown keys.
+func parseLenient(name string, data []byte, cfg *Config) error {
+	switch filepath.Ext(name) {
+	case ".toml":
+		_, err := toml.Decode(string(data), cfg)
+		return err
+	case ".json":
+		return json.Unmarshal(data, cfg)
+	default:
+		return yaml.Unmarshal(data, cfg)
+	}
+}
+
+// topLevelKeys returns the file's top-level keys in sorted order.
+func topLevelKeys(name string, data []byte) ([]string, error) {
+	var raw map[string]any
+	var err error
+	switch filepath.Ext(name) {
+	case ".toml":
+		_, err = toml.Decode(string(data), &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// knownKeys returns the top-level keys Config accepts. YAML, TOML and JSON
+// tags are kept identical, so the YAML tags cover every format.
+func knownKeys() map[string]bool {
+	known := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); tag != "" && tag != "-" {
+			known[tag] = true
+		}
+	}
+	return known
+}
+
+// suggestKey returns the known key closest to key, if it's a likely typo.
+func suggestKey(key string, known map[string]bool) string {
+	best, bestDist := "", 3 // only suggest within two edits
+	for k := range known {
+		if d := editDistance(key, k); d < bestDist || d == bestDist && k < best {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
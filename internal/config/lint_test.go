@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLint(t *testing.T) {
+	clean := &Config{
+		Build:    "make test",
+		Timeout:  Duration(10 * time.Minute),
+		Services: map[string]Service{"db": {Image: "postgres:16"}, "cache": {Image: "registry.local:5000/redis@sha256:abc"}},
+	}
+	if diags := Lint(clean); len(diags) != 0 {
+		t.Errorf("clean config: unexpected diagnostics %v", diags)
+	}
+
+	cfg := &Config{
+		Timeout: Duration(500 * time.Millisecond),
+		Services: map[string]Service{
+			"db":    {Image: "postgres"},
+			"cache": {Image: "registry.local:5000/redis"},
+		},
+	}
+	var got []string
+	for _, d := range Lint(cfg) {
+		got = append(got, string(d.Severity)+" "+d.Field)
+	}
+	want := "error build|warning timeout|warning services.cache|warning services.db"
+	if strings.Join(got, "|") != want {
+		t.Errorf("diagnostics = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if !HasErrors(Lint(cfg)) {
+		t.Error("HasErrors = false for a config with no build")
+	}
+
+	// Whatever Validate rejects is an error too
+	bad := &Config{Build: "make", Workdir: "../x"}
+	if diags := Lint(bad); !HasErrors(diags) {
+		t.Errorf("invalid workdir: diagnostics %v, want an error", diags)
+	}
+}
+
+func TestLintDir(t *testing.T) {
+	files := map[string]string{
+		".cinch.yaml": "builds: make test\ntimeout: 10m\nfrobnicate: true\n",
+		".cinch.toml": "builds = \"make test\"\ntimeout = \"10m\"\nfrobnicate = true\n",
+		".cinch.json": `{"builds": "make test", "timeout": "10m", "frobnicate": true}`,
+	}
+	for name, content := range files {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		file, diags, err := LintDir(dir)
+		if err != nil {
+			t.Fatalf("%s: LintDir failed: %v", name, err)
+		}
+		if file != name {
+			t.Errorf("file = %q, want %q", file, name)
+		}
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		want := []string{
+			`warning: builds: unknown key (did you mean "build"?)`,
+			`warning: frobnicate: unknown key; it will be rejected when the config is loaded`,
+			`error: build: build is empty and there are no steps; nothing would run`,
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s diagnostics:\n%s\nwant:\n%s", name, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+
+	// Nested typos only the strict parse catches are errors
+	nested := map[string]string{
+		".cinch.yaml": "build: make test\nservices:\n  pg:\n    imgae: postgres:16\n",
+		".cinch.toml": "build = \"make test\"\n[services.pg]\nimgae = \"postgres:16\"\n",
+		".cinch.json": `{"build": "make test", "services": {"pg": {"imgae": "postgres:16"}}}`,
+	}
+	for name, content := range nested {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, diags, err := LintDir(dir)
+		if err != nil {
+			t.Fatalf("%s: LintDir failed: %v", name, err)
+		}
+		if !HasErrors(diags) || !strings.Contains(diags[0].Message, "imgae") {
+			t.Errorf("%s: diagnostics = %v, want an error naming imgae", name, diags)
+		}
+	}
+
+	if _, _, err := LintDir(t.TempDir()); err != ErrNoConfig {
+		t.Errorf("empty dir: err = %v, want ErrNoConfig", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // DefaultSocketPath returns the default daemon socket path.
@@ -73,6 +74,35 @@ func (c *Client) Status() (*StatusResponse, error) {
 	return &resp, nil
 }
 
+// Drain asks the daemon to stop accepting new jobs and exit once its running
+// jobs finish, waiting at most timeout for them (0 = daemon default).
+func (c *Client) Drain(timeout time.Duration) (*DrainResponse, error) {
+	if err := c.send(TypeDrainRequest, DrainRequest{Timeout: int(timeout.Seconds())}); err != nil {
+		return nil, err
+	}
+
+	msgType, payload, err := c.recv()
+	if err != nil {
+		return nil, err
+	}
+
+	if msgType == TypeError {
+		errMsg, _ := DecodePayload[Error](payload)
+		return nil, fmt.Errorf("daemon error: %s", errMsg.Message)
+	}
+
+	if msgType != TypeDrainResponse {
+		return nil, fmt.Errorf("unexpected response type: %s", msgType)
+	}
+
+	resp, err := DecodePayload[DrainResponse](payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 // StartStream starts streaming events from the daemon.
 func (c *Client) StartStream(jobID string, includeLogs bool) error {
 	req := StreamRequest{
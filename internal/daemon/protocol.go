@@ -12,8 +12,12 @@ const (
 	TypeStreamRequest  = "STREAM_REQUEST"
 	TypeStreamStop     = "STREAM_STOP"
 	TypeJobStarted     = "JOB_STARTED"
+	TypeStepStarted    = "STEP_STARTED"
 	TypeLogChunk       = "LOG_CHUNK"
 	TypeJobCompleted   = "JOB_COMPLETED"
+	TypeDrainRequest   = "DRAIN_REQUEST"
+	TypeDrainResponse  = "DRAIN_RESPONSE"
+	TypeShutdown       = "SHUTDOWN"
 	TypeError          = "ERROR"
 )
 
@@ -65,9 +69,12 @@ type StatusRequest struct{}
 
 // StatusResponse returns the daemon's current status.
 type StatusResponse struct {
-	SlotsTotal  int       `json:"slots_total"`
-	SlotsBusy   int       `json:"slots_busy"`
-	RunningJobs []JobInfo `json:"running_jobs"`
+	SlotsTotal    int       `json:"slots_total"`
+	SlotsBusy     int       `json:"slots_busy"`
+	RunningJobs   []JobInfo `json:"running_jobs"`
+	Version       string    `json:"version,omitempty"`
+	StartedAt     int64     `json:"started_at,omitempty"` // daemon start, unix seconds
+	UptimeSeconds int64     `json:"uptime_seconds"`
 }
 
 // JobInfo contains information about a running job.
@@ -78,6 +85,7 @@ type JobInfo struct {
 	Tag       string `json:"tag,omitempty"`
 	Commit    string `json:"commit"`
 	Command   string `json:"command,omitempty"`
+	Step      string `json:"step,omitempty"` // running step in a multi-step build
 	Mode      string `json:"mode,omitempty"`
 	Forge     string `json:"forge,omitempty"`
 	StartedAt int64  `json:"started_at"`
@@ -94,20 +102,30 @@ type StreamStop struct{}
 
 // JobStarted event when a job starts.
 type JobStarted struct {
-	JobID   string `json:"job_id"`
-	Repo    string `json:"repo"`
-	Branch  string `json:"branch,omitempty"`
-	Tag     string `json:"tag,omitempty"`
-	Commit  string `json:"commit"`
-	Command string `json:"command"`
-	Mode    string `json:"mode"`
-	Forge   string `json:"forge"`
+	JobID   string   `json:"job_id"`
+	Repo    string   `json:"repo"`
+	Branch  string   `json:"branch,omitempty"`
+	Tag     string   `json:"tag,omitempty"`
+	Commit  string   `json:"commit"`
+	Command string   `json:"command"`
+	Mode    string   `json:"mode"`
+	Forge   string   `json:"forge"`
+	Steps   []string `json:"steps,omitempty"` // step names, in order
+}
+
+// StepStarted event when a step of a multi-step build starts.
+type StepStarted struct {
+	JobID string `json:"job_id"`
+	Index int    `json:"index"` // 1-based
+	Total int    `json:"total"`
+	Name  string `json:"name"`
 }
 
 // LogChunk event for log output.
 type LogChunk struct {
 	JobID  string `json:"job_id"`
-	Stream string `json:"stream"` // "stdout" or "stderr"
+	Stream string `json:"stream"`         // "stdout" or "stderr"
+	Step   string `json:"step,omitempty"` // step that produced the output
 	Data   string `json:"data"`
 }
 
@@ -118,6 +136,22 @@ type JobCompleted struct {
 	DurationMs int64  `json:"duration_ms"`
 }
 
+// DrainRequest asks the daemon to stop accepting new jobs, finish its
+// running ones, then exit.
+type DrainRequest struct {
+	Timeout int `json:"timeout,omitempty"` // seconds to wait for running jobs (0 = default)
+}
+
+// DrainResponse acknowledges a drain request.
+type DrainResponse struct {
+	RunningJobs int `json:"running_jobs"` // jobs left to finish
+}
+
+// Shutdown is sent to every client just before the daemon stops on its own.
+type Shutdown struct {
+	Reason string `json:"reason"`
+}
+
 // Error is sent when an error occurs.
 type Error struct {
 	Message string `json:"message"`
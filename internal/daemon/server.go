@@ -9,7 +9,9 @@ import (
 	"net"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/ehrlich-b/cinch/internal/version"
 	"github.com/ehrlich-b/cinch/internal/worker"
 )
 
@@ -18,10 +20,16 @@ type Server struct {
 	socketPath string
 	worker     *worker.Worker
 	log        *slog.Logger
+	startedAt  time.Time
 
 	// Active client connections subscribed to job events
 	mu      sync.RWMutex
 	clients map[*clientConn]struct{}
+	steps   map[string]string // job ID -> running step, for tagging log chunks
+
+	// Closed once a drain requested over the socket has finished
+	drained   chan struct{}
+	drainOnce sync.Once
 
 	listener net.Listener
 	ctx      context.Context
@@ -44,7 +52,10 @@ func NewServer(socketPath string, w *worker.Worker, log *slog.Logger) *Server {
 		socketPath: socketPath,
 		worker:     w,
 		log:        log,
+		startedAt:  time.Now(),
 		clients:    make(map[*clientConn]struct{}),
+		steps:      make(map[string]string),
+		drained:    make(chan struct{}),
 		ctx:        ctx,
 		cancel:     cancel,
 	}
@@ -153,6 +164,8 @@ func (s *Server) handleClient(conn net.Conn) {
 			s.handleStreamRequest(client, payload)
 		case TypeStreamStop:
 			s.handleStreamStop(client)
+		case TypeDrainRequest:
+			s.handleDrainRequest(client, payload)
 		}
 	}
 
@@ -166,9 +179,12 @@ func (s *Server) handleStatusRequest(client *clientConn) {
 	jobs := s.worker.GetRunningJobs()
 
 	resp := StatusResponse{
-		SlotsTotal:  s.worker.Concurrency(),
-		SlotsBusy:   len(jobs),
-		RunningJobs: make([]JobInfo, 0, len(jobs)),
+		SlotsTotal:    s.worker.Concurrency(),
+		SlotsBusy:     len(jobs),
+		RunningJobs:   make([]JobInfo, 0, len(jobs)),
+		Version:       version.Version,
+		StartedAt:     s.startedAt.Unix(),
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
 	}
 
 	for _, j := range jobs {
@@ -179,6 +195,7 @@ func (s *Server) handleStatusRequest(client *clientConn) {
 			Tag:       j.Tag,
 			Commit:    j.Commit,
 			Command:   j.Command,
+			Step:      j.Step,
 			Mode:      j.Mode,
 			Forge:     j.Forge,
 			StartedAt: j.StartedAt.Unix(),
@@ -210,6 +227,51 @@ func (s *Server) handleStreamStop(client *clientConn) {
 	s.unsubscribe(client)
 }
 
+// handleDrainRequest stops the worker taking new jobs and, once its running
+// jobs finish (or the timeout passes), closes Drained.
+func (s *Server) handleDrainRequest(client *clientConn, payload json.RawMessage) {
+	var req DrainRequest
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			s.sendError(client, "invalid drain request")
+			return
+		}
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	running := s.worker.ActiveJobCount()
+	s.sendToClient(client, TypeDrainResponse, DrainResponse{RunningJobs: running})
+
+	s.drainOnce.Do(func() {
+		s.log.Info("drain requested", "jobs", running, "timeout", timeout)
+		go func() {
+			s.worker.Drain(timeout)
+			close(s.drained)
+		}()
+	})
+}
+
+// Drained returns a channel that is closed once a drain requested by a
+// client has finished. The daemon should exit then.
+func (s *Server) Drained() <-chan struct{} {
+	return s.drained
+}
+
+// BroadcastShutdown tells every connected client why the daemon is about to
+// stop. It writes directly so the message lands before Stop closes the
+// connections.
+func (s *Server) BroadcastShutdown(reason string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for client := range s.clients {
+		s.sendToClient(client, TypeShutdown, Shutdown{Reason: reason})
+	}
+}
+
 // subscribe adds a client to the subscribers list.
 func (s *Server) subscribe(client *clientConn) {
 	s.mu.Lock()
@@ -264,7 +326,7 @@ func (s *Server) sendError(client *clientConn, message string) {
 }
 
 // BroadcastJobStarted broadcasts a job start event to all subscribers.
-func (s *Server) BroadcastJobStarted(jobID, repo, branch, tag, commit, command, mode, forge string) {
+func (s *Server) BroadcastJobStarted(jobID, repo, branch, tag, commit, command, mode, forge string, steps []string) {
 	event := JobStarted{
 		JobID:   jobID,
 		Repo:    repo,
@@ -274,16 +336,39 @@ func (s *Server) BroadcastJobStarted(jobID, repo, branch, tag, commit, command,
 		Command: command,
 		Mode:    mode,
 		Forge:   forge,
+		Steps:   steps,
 	}
 
 	s.broadcast(jobID, TypeJobStarted, event)
 }
 
+// BroadcastStepStarted broadcasts a step start event to all subscribers.
+// Later log chunks for the job are tagged with the step name.
+func (s *Server) BroadcastStepStarted(jobID string, index, total int, name string) {
+	s.mu.Lock()
+	s.steps[jobID] = name
+	s.mu.Unlock()
+
+	event := StepStarted{
+		JobID: jobID,
+		Index: index,
+		Total: total,
+		Name:  name,
+	}
+
+	s.broadcast(jobID, TypeStepStarted, event)
+}
+
 // BroadcastLogChunk broadcasts a log chunk to all subscribers.
 func (s *Server) BroadcastLogChunk(jobID, stream string, data []byte) {
+	s.mu.RLock()
+	step := s.steps[jobID]
+	s.mu.RUnlock()
+
 	event := LogChunk{
 		JobID:  jobID,
 		Stream: stream,
+		Step:   step,
 		Data:   string(data),
 	}
 
@@ -292,6 +377,10 @@ func (s *Server) BroadcastLogChunk(jobID, stream string, data []byte) {
 
 // BroadcastJobCompleted broadcasts a job completion event to all subscribers.
 func (s *Server) BroadcastJobCompleted(jobID string, exitCode int, durationMs int64) {
+	s.mu.Lock()
+	delete(s.steps, jobID)
+	s.mu.Unlock()
+
 	event := JobCompleted{
 		JobID:      jobID,
 		ExitCode:   exitCode,
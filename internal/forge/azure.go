@@ -0,0 +1,346 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Azure implements the Forge interface for Azure DevOps Repos.
+//
+// Azure DevOps service hooks don't sign payloads. Instead the hook is
+// configured with basic authentication, and the password is the shared secret.
+type Azure struct {
+	// BaseURL is the organization URL (e.g., "https://dev.azure.com/myorg")
+	// or any URL within it. If empty, it is derived from the repo's HTML URL.
+	BaseURL string
+
+	// Token is a Personal Access Token with Code (status) scope.
+	Token string
+
+	// Client is the HTTP client to use. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Name returns "azure".
+func (a *Azure) Name() string {
+	return "azure"
+}
+
+// Identify returns true if the request comes from an Azure DevOps service hook.
+func (a *Azure) Identify(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("User-Agent"), "VSServices/")
+}
+
+// ParsePush parses an Azure DevOps git.push service hook.
+func (a *Azure) ParsePush(r *http.Request, secret string) (*PushEvent, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	// Verify shared secret
+	if secret != "" {
+		if err := a.verifySecret(r, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	var payload azurePushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parse payload: %w", err)
+	}
+
+	if payload.EventType != "git.push" {
+		return nil, fmt.Errorf("unexpected event type: %s", payload.EventType)
+	}
+	if len(payload.Resource.RefUpdates) == 0 {
+		return nil, errors.New("push has no ref updates")
+	}
+
+	// A push can update several refs; build the first one
+	update := payload.Resource.RefUpdates[0]
+	if strings.Trim(update.NewObjectID, "0") == "" {
+		return nil, errors.New("ref deletion event")
+	}
+
+	var branch, tag string
+	if strings.HasPrefix(update.Name, "refs/tags/") {
+		tag = strings.TrimPrefix(update.Name, "refs/tags/")
+	} else {
+		branch = strings.TrimPrefix(update.Name, "refs/heads/")
+	}
+
+	return &PushEvent{
+		Repo:   payload.Resource.Repository.toRepo(),
+		Commit: update.NewObjectID,
+		Ref:    update.Name,
+		Branch: branch,
+		Tag:    tag,
+		Sender: payload.Resource.PushedBy.UniqueName,
+	}, nil
+}
+
+// ParsePullRequest parses an Azure DevOps git.pullrequest.* service hook.
+func (a *Azure) ParsePullRequest(r *http.Request, secret string) (*PullRequestEvent, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	// Verify shared secret
+	if secret != "" {
+		if err := a.verifySecret(r, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	var payload azurePRPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parse payload: %w", err)
+	}
+
+	// Map Azure event types to our PR actions
+	var action string
+	switch payload.EventType {
+	case "git.pullrequest.created":
+		action = "opened"
+	case "git.pullrequest.updated":
+		action = "synchronize"
+	default:
+		return nil, fmt.Errorf("unexpected event type: %s", payload.EventType)
+	}
+
+	pr := payload.Resource
+	if pr.Status != "active" {
+		return nil, fmt.Errorf("ignoring PR status: %s", pr.Status)
+	}
+
+	return &PullRequestEvent{
+		Repo:       pr.Repository.toRepo(),
+		Number:     pr.PullRequestID,
+		Action:     action,
+		Commit:     pr.LastMergeSourceCommit.CommitID,
+		HeadBranch: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+		BaseBranch: strings.TrimPrefix(pr.TargetRefName, "refs/heads/"),
+		Title:      pr.Title,
+		Sender:     pr.CreatedBy.UniqueName,
+		IsFork:     pr.ForkSource != nil,
+	}, nil
+}
+
+// verifySecret checks the basic auth password against the shared secret.
+func (a *Azure) verifySecret(r *http.Request, secret string) error {
+	_, password, ok := r.BasicAuth()
+	if !ok {
+		return errors.New("missing basic auth credentials")
+	}
+	if subtle.ConstantTimeCompare([]byte(password), []byte(secret)) != 1 {
+		return errors.New("secret mismatch")
+	}
+	return nil
+}
+
+// PostStatus posts a commit status via the Azure DevOps Git status API.
+// Repo.Owner is the project name and Repo.Name is the repository name.
+func (a *Azure) PostStatus(ctx context.Context, repo *Repo, commit string, status *Status) error {
+	baseURL, err := a.orgURL(repo)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/commits/%s/statuses?api-version=7.1",
+		baseURL, url.PathEscape(repo.Owner), url.PathEscape(repo.Name), commit)
+
+	// Azure states: notSet, pending, succeeded, failed, error, notApplicable
+	state := string(status.State)
+	switch status.State {
+	case StatusRunning:
+		state = "pending"
+	case StatusSuccess:
+		state = "succeeded"
+	case StatusFailure:
+		state = "failed"
+	}
+
+	payload := azureStatusPayload{
+		State:       state,
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+	}
+	payload.Context.Name = status.Context
+	payload.Context.Genre = "continuous-integration"
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", a.authHeader())
+	req.Header.Set("Content-Type", "application/json")
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("azure devops api error: %s - %s", resp.Status, string(respBody))
+	}
+
+	return nil
+}
+
+// CloneToken returns the PAT for cloning private repos.
+func (a *Azure) CloneToken(ctx context.Context, repo *Repo) (string, time.Time, error) {
+	if !repo.Private {
+		return "", time.Time{}, nil
+	}
+	return a.Token, time.Now().Add(time.Hour), nil
+}
+
+// CreateWebhook is not supported for Azure DevOps.
+// Service hook subscriptions require the project GUID and an org-scoped token,
+// so they are configured manually in Project Settings > Service hooks.
+func (a *Azure) CreateWebhook(ctx context.Context, repo *Repo, webhookURL, secret string) (int64, error) {
+	return 0, errors.New("azure devops service hooks must be created manually")
+}
+
+// authHeader returns a basic auth header for the PAT (empty username).
+func (a *Azure) authHeader() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+a.Token))
+}
+
+// orgURL returns the organization URL, e.g. "https://dev.azure.com/myorg".
+// BaseURL may be the org URL or a full repo URL.
+func (a *Azure) orgURL(repo *Repo) (string, error) {
+	if a.BaseURL != "" {
+		return azureOrgURL(a.BaseURL)
+	}
+	return azureOrgURL(repo.HTMLURL)
+}
+
+// azureOrgURL derives the organization URL from a repo URL.
+// Handles both dev.azure.com/{org}/... and legacy {org}.visualstudio.com/... forms.
+func azureOrgURL(repoURL string) (string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" {
+		return "", errors.New("base URL not configured")
+	}
+	// Strip any user info (remoteUrl is often https://org@dev.azure.com/...)
+	u.User = nil
+
+	if strings.HasSuffix(u.Host, ".visualstudio.com") {
+		return u.Scheme + "://" + u.Host, nil
+	}
+
+	org, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if org == "" {
+		return "", errors.New("cannot determine azure devops organization")
+	}
+	return u.Scheme + "://" + u.Host + "/" + org, nil
+}
+
+// Azure DevOps service hook payload types
+
+type azureRepository struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	RemoteURL string `json:"remoteUrl"`
+	WebURL    string `json:"webUrl"`
+	Project   struct {
+		Name       string `json:"name"`
+		Visibility string `json:"visibility"` // "private" or "public"
+	} `json:"project"`
+}
+
+func (r *azureRepository) toRepo() *Repo {
+	htmlURL := r.WebURL
+	if htmlURL == "" {
+		htmlURL = r.RemoteURL
+	}
+
+	// Drop the "org@" user info Azure puts in remote URLs
+	cloneURL := r.RemoteURL
+	if u, err := url.Parse(cloneURL); err == nil {
+		u.User = nil
+		cloneURL = u.String()
+	}
+
+	return &Repo{
+		ForgeType: "azure",
+		Owner:     r.Project.Name,
+		Name:      r.Name,
+		CloneURL:  cloneURL,
+		HTMLURL:   htmlURL,
+		Private:   r.Project.Visibility != "public",
+	}
+}
+
+type azureIdentity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+type azurePushPayload struct {
+	EventType string `json:"eventType"`
+	Resource  struct {
+		RefUpdates []struct {
+			Name        string `json:"name"`
+			OldObjectID string `json:"oldObjectId"`
+			NewObjectID string `json:"newObjectId"`
+		} `json:"refUpdates"`
+		Repository azureRepository `json:"repository"`
+		PushedBy   azureIdentity   `json:"pushedBy"`
+	} `json:"resource"`
+}
+
+type azurePRPayload struct {
+	EventType string `json:"eventType"`
+	Resource  struct {
+		PullRequestID         int    `json:"pullRequestId"`
+		Status                string `json:"status"`
+		Title                 string `json:"title"`
+		SourceRefName         string `json:"sourceRefName"`
+		TargetRefName         string `json:"targetRefName"`
+		LastMergeSourceCommit struct {
+			CommitID string `json:"commitId"`
+		} `json:"lastMergeSourceCommit"`
+		Repository azureRepository `json:"repository"`
+		CreatedBy  azureIdentity   `json:"createdBy"`
+		ForkSource *struct {
+			Name string `json:"name"`
+		} `json:"forkSource"`
+	} `json:"resource"`
+}
+
+type azureStatusPayload struct {
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"targetUrl,omitempty"`
+	Context     struct {
+		Name  string `json:"name"`
+		Genre string `json:"genre"`
+	} `json:"context"`
+}
@@ -0,0 +1,218 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const azurePushJSON = `{
+	"eventType": "git.push",
+	"resource": {
+		"refUpdates": [{
+			"name": "refs/heads/main",
+			"oldObjectId": "1111111111111111111111111111111111111111",
+			"newObjectId": "abc123def456abc123def456abc123def456abcd"
+		}],
+		"repository": {
+			"id": "278d5cd2-584d-4b63-824a-2ba458937249",
+			"name": "myrepo",
+			"remoteUrl": "https://myorg@dev.azure.com/myorg/myproject/_git/myrepo",
+			"project": {"name": "myproject", "visibility": "private"}
+		},
+		"pushedBy": {"displayName": "Dev", "uniqueName": "dev@example.com"}
+	}
+}`
+
+func TestAzureIdentify(t *testing.T) {
+	az := &Azure{}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"service hook", map[string]string{"User-Agent": "VSServices/16.255.0"}, true},
+		{"no header", map[string]string{}, false},
+		{"github event", map[string]string{"X-GitHub-Event": "push"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/webhook", nil)
+			req.Header.Del("User-Agent")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := az.Identify(req); got != tt.want {
+				t.Errorf("Identify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureParsePush(t *testing.T) {
+	az := &Azure{}
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(azurePushJSON))
+	event, err := az.ParsePush(req, "")
+	if err != nil {
+		t.Fatalf("ParsePush failed: %v", err)
+	}
+
+	if event.Branch != "main" {
+		t.Errorf("Branch = %s, want main", event.Branch)
+	}
+	if event.Commit != "abc123def456abc123def456abc123def456abcd" {
+		t.Errorf("Commit = %s", event.Commit)
+	}
+	if event.Repo.Owner != "myproject" || event.Repo.Name != "myrepo" {
+		t.Errorf("Repo = %s, want myproject/myrepo", event.Repo.FullName())
+	}
+	if event.Repo.CloneURL != "https://dev.azure.com/myorg/myproject/_git/myrepo" {
+		t.Errorf("CloneURL = %s", event.Repo.CloneURL)
+	}
+	if !event.Repo.Private {
+		t.Error("Private = false, want true")
+	}
+	if event.Repo.ForgeType != "azure" {
+		t.Errorf("ForgeType = %s, want azure", event.Repo.ForgeType)
+	}
+	if event.Sender != "dev@example.com" {
+		t.Errorf("Sender = %s, want dev@example.com", event.Sender)
+	}
+}
+
+func TestAzureParsePushDeletion(t *testing.T) {
+	az := &Azure{}
+
+	payload := strings.Replace(azurePushJSON, "abc123def456abc123def456abc123def456abcd", "0000000000000000000000000000000000000000", 1)
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	_, err := az.ParsePush(req, "")
+	if err == nil || !strings.Contains(err.Error(), "deletion") {
+		t.Errorf("error = %v, want deletion error", err)
+	}
+}
+
+func TestAzureParsePullRequest(t *testing.T) {
+	az := &Azure{}
+
+	payload := `{
+		"eventType": "git.pullrequest.created",
+		"resource": {
+			"pullRequestId": 42,
+			"status": "active",
+			"title": "Add feature",
+			"sourceRefName": "refs/heads/feature",
+			"targetRefName": "refs/heads/main",
+			"lastMergeSourceCommit": {"commitId": "def456"},
+			"repository": {
+				"name": "myrepo",
+				"remoteUrl": "https://dev.azure.com/myorg/myproject/_git/myrepo",
+				"project": {"name": "myproject", "visibility": "public"}
+			},
+			"createdBy": {"uniqueName": "contributor@example.com"},
+			"forkSource": {"name": "refs/heads/feature"}
+		}
+	}`
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	event, err := az.ParsePullRequest(req, "")
+	if err != nil {
+		t.Fatalf("ParsePullRequest failed: %v", err)
+	}
+
+	if event.Number != 42 || event.Action != "opened" {
+		t.Errorf("Number/Action = %d/%s, want 42/opened", event.Number, event.Action)
+	}
+	if event.HeadBranch != "feature" || event.BaseBranch != "main" {
+		t.Errorf("branches = %s -> %s, want feature -> main", event.HeadBranch, event.BaseBranch)
+	}
+	if event.Commit != "def456" {
+		t.Errorf("Commit = %s, want def456", event.Commit)
+	}
+	if !event.IsFork {
+		t.Error("IsFork = false, want true")
+	}
+	if event.Repo.Private {
+		t.Error("Private = true, want false")
+	}
+
+	// Push payloads are not PRs
+	req = httptest.NewRequest("POST", "/webhook", strings.NewReader(azurePushJSON))
+	if _, err := az.ParsePullRequest(req, ""); err == nil {
+		t.Error("expected error parsing push as PR")
+	}
+}
+
+func TestAzureOrgURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://dev.azure.com/myorg", "https://dev.azure.com/myorg"},
+		{"https://dev.azure.com/myorg/myproject/_git/myrepo", "https://dev.azure.com/myorg"},
+		{"https://myorg@dev.azure.com/myorg/myproject/_git/myrepo", "https://dev.azure.com/myorg"},
+		{"https://myorg.visualstudio.com/myproject/_git/myrepo", "https://myorg.visualstudio.com"},
+	}
+
+	for _, tt := range tests {
+		got, err := azureOrgURL(tt.in)
+		if err != nil {
+			t.Errorf("azureOrgURL(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("azureOrgURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := azureOrgURL("https://dev.azure.com/"); err == nil {
+		t.Error("expected error for URL without organization")
+	}
+}
+
+func TestAzurePostStatus(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody azureStatusPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &gotBody)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	az := &Azure{
+		BaseURL: server.URL + "/myorg",
+		Token:   "pat",
+		Client:  server.Client(),
+	}
+
+	err := az.PostStatus(context.Background(), &Repo{Owner: "myproject", Name: "myrepo"}, "abc123", &Status{
+		State:   StatusSuccess,
+		Context: "cinch",
+	})
+	if err != nil {
+		t.Fatalf("PostStatus failed: %v", err)
+	}
+
+	if gotPath != "/myorg/myproject/_apis/git/repositories/myrepo/commits/abc123/statuses" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if gotAuth != "Basic OnBhdA==" {
+		t.Errorf("Authorization = %s, want Basic OnBhdA==", gotAuth)
+	}
+	if gotBody.State != "succeeded" {
+		t.Errorf("state = %s, want succeeded", gotBody.State)
+	}
+	if gotBody.Context.Name != "cinch" {
+		t.Errorf("context.name = %s, want cinch", gotBody.Context.Name)
+	}
+}
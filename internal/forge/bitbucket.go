@@ -0,0 +1,430 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// bitbucketAPIURL is the Bitbucket Cloud REST API base.
+const bitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// Bitbucket implements the Forge interface for Bitbucket Cloud.
+// Repo.Owner is the workspace slug and Repo.Name is the repository slug.
+type Bitbucket struct {
+	// Token is a repository or workspace access token with the
+	// repository:write and webhook scopes. "username:app_password" is
+	// also accepted and sent as basic auth.
+	Token string
+
+	// APIURL overrides the API base (for tests). Defaults to api.bitbucket.org.
+	APIURL string
+
+	// Client is the HTTP client to use. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Name returns "bitbucket".
+func (b *Bitbucket) Name() string {
+	return "bitbucket"
+}
+
+// Identify returns true if the request has Bitbucket Cloud webhook headers.
+func (b *Bitbucket) Identify(r *http.Request) bool {
+	return r.Header.Get("X-Event-Key") != "" && r.Header.Get("X-Hook-UUID") != ""
+}
+
+// ParsePush parses a Bitbucket repo:push webhook.
+func (b *Bitbucket) ParsePush(r *http.Request, secret string) (*PushEvent, error) {
+	if event := r.Header.Get("X-Event-Key"); event != "repo:push" {
+		return nil, fmt.Errorf("unexpected event type: %s", event)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	// Verify signature
+	if secret != "" {
+		if err := b.verifySignature(body, r.Header.Get("X-Hub-Signature"), secret); err != nil {
+			return nil, err
+		}
+	}
+
+	var payload bitbucketPushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parse payload: %w", err)
+	}
+	if len(payload.Push.Changes) == 0 {
+		return nil, errors.New("push has no changes")
+	}
+
+	// A push can update several refs; build the first one
+	change := payload.Push.Changes[0]
+	if change.New == nil {
+		return nil, errors.New("ref deletion event")
+	}
+
+	var ref, branch, tag string
+	if change.New.Type == "tag" {
+		tag = change.New.Name
+		ref = "refs/tags/" + tag
+	} else {
+		branch = change.New.Name
+		ref = "refs/heads/" + branch
+	}
+
+	return &PushEvent{
+		Repo:   payload.Repository.toRepo(),
+		Commit: change.New.Target.Hash,
+		Ref:    ref,
+		Branch: branch,
+		Tag:    tag,
+		Sender: payload.Actor.name(),
+	}, nil
+}
+
+// ParsePullRequest parses a Bitbucket pullrequest:created/updated webhook.
+func (b *Bitbucket) ParsePullRequest(r *http.Request, secret string) (*PullRequestEvent, error) {
+	// Map Bitbucket event keys to our PR actions
+	var action string
+	switch event := r.Header.Get("X-Event-Key"); event {
+	case "pullrequest:created":
+		action = "opened"
+	case "pullrequest:updated":
+		action = "synchronize"
+	default:
+		return nil, fmt.Errorf("unexpected event type: %s", event)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	// Verify signature
+	if secret != "" {
+		if err := b.verifySignature(body, r.Header.Get("X-Hub-Signature"), secret); err != nil {
+			return nil, err
+		}
+	}
+
+	var payload bitbucketPRPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parse payload: %w", err)
+	}
+
+	pr := payload.PullRequest
+	if pr.State != "OPEN" {
+		return nil, fmt.Errorf("ignoring PR state: %s", pr.State)
+	}
+
+	// Bitbucket sends an abbreviated hash; callers expand it with
+	// ResolvePullRequestCommit
+	return &PullRequestEvent{
+		Repo:       payload.Repository.toRepo(),
+		Number:     pr.ID,
+		Action:     action,
+		Commit:     pr.Source.Commit.Hash,
+		HeadBranch: pr.Source.Branch.Name,
+		BaseBranch: pr.Destination.Branch.Name,
+		Title:      pr.Title,
+		Sender:     payload.Actor.name(),
+		IsFork:     pr.Source.Repository.FullName != pr.Destination.Repository.FullName,
+	}, nil
+}
+
+// ResolvePullRequestCommit expands the abbreviated head hash from a pull
+// request webhook to the full hash, by finding it among the PR's commits.
+// That covers PRs from forks, whose commits the destination repo's commit
+// API doesn't serve.
+func (b *Bitbucket) ResolvePullRequestCommit(ctx context.Context, repo *Repo, number int, hash string) (string, error) {
+	apiURL := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/commits?fields=values.hash",
+		b.apiURL(), url.PathEscape(repo.Owner), url.PathEscape(repo.Name), number)
+
+	resp, err := b.do(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bitbucket api error: %s - %s", resp.Status, string(respBody))
+	}
+
+	// Commits are listed newest first, so the head is on the first page
+	var page struct {
+		Values []struct {
+			Hash string `json:"hash"`
+		} `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return "", fmt.Errorf("parse commits: %w", err)
+	}
+	for _, c := range page.Values {
+		if strings.HasPrefix(c.Hash, hash) {
+			return c.Hash, nil
+		}
+	}
+	return "", fmt.Errorf("commit %s not found in pull request #%d", hash, number)
+}
+
+// verifySignature checks an X-Hub-Signature header of the form "sha256=<hex>".
+func (b *Bitbucket) verifySignature(body []byte, signature, secret string) error {
+	if signature == "" {
+		return errors.New("missing signature header")
+	}
+
+	hexSig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return errors.New("unsupported signature algorithm")
+	}
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+// PostStatus posts a commit status via the Bitbucket build status API.
+func (b *Bitbucket) PostStatus(ctx context.Context, repo *Repo, commit string, status *Status) error {
+	apiURL := fmt.Sprintf("%s/repositories/%s/%s/commit/%s/statuses/build",
+		b.apiURL(), url.PathEscape(repo.Owner), url.PathEscape(repo.Name), commit)
+
+	// Bitbucket states: INPROGRESS, SUCCESSFUL, FAILED, STOPPED
+	var state string
+	switch status.State {
+	case StatusPending, StatusRunning:
+		state = "INPROGRESS"
+	case StatusSuccess:
+		state = "SUCCESSFUL"
+	default:
+		state = "FAILED"
+	}
+
+	// The build status API requires a URL
+	targetURL := status.TargetURL
+	if targetURL == "" {
+		targetURL = repo.HTMLURL
+	}
+
+	payload := bitbucketStatusPayload{
+		Key:         status.Context,
+		State:       state,
+		Name:        status.Context,
+		URL:         targetURL,
+		Description: status.Description,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do(ctx, "POST", apiURL, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bitbucket api error: %s - %s", resp.Status, string(respBody))
+	}
+
+	return nil
+}
+
+// CloneToken returns the access token for cloning private repos.
+func (b *Bitbucket) CloneToken(ctx context.Context, repo *Repo) (string, time.Time, error) {
+	if !repo.Private {
+		return "", time.Time{}, nil
+	}
+	return b.Token, time.Now().Add(24 * time.Hour), nil
+}
+
+// CreateWebhook creates a webhook for the repository.
+// Bitbucket identifies webhooks by UUID, so the returned ID is always 0.
+func (b *Bitbucket) CreateWebhook(ctx context.Context, repo *Repo, webhookURL, secret string) (int64, error) {
+	apiURL := fmt.Sprintf("%s/repositories/%s/%s/hooks",
+		b.apiURL(), url.PathEscape(repo.Owner), url.PathEscape(repo.Name))
+
+	payload := bitbucketWebhookPayload{
+		Description: "cinch",
+		URL:         webhookURL,
+		Active:      true,
+		Secret:      secret,
+		Events:      []string{"repo:push", "pullrequest:created", "pullrequest:updated"},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := b.do(ctx, "POST", apiURL, body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("bitbucket api error: %s - %s", resp.Status, string(respBody))
+	}
+
+	return 0, nil
+}
+
+// do sends an authenticated JSON request to the Bitbucket API.
+func (b *Bitbucket) do(ctx context.Context, method, apiURL string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", b.authHeader())
+	req.Header.Set("Content-Type", "application/json")
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// authHeader returns a bearer header for access tokens, or basic auth for
+// "username:app_password" credentials.
+func (b *Bitbucket) authHeader() string {
+	if strings.Contains(b.Token, ":") {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(b.Token))
+	}
+	return "Bearer " + b.Token
+}
+
+func (b *Bitbucket) apiURL() string {
+	if b.APIURL != "" {
+		return strings.TrimSuffix(b.APIURL, "/")
+	}
+	return bitbucketAPIURL
+}
+
+// Bitbucket Cloud webhook payload types
+
+type bitbucketRepository struct {
+	Name      string `json:"name"`
+	FullName  string `json:"full_name"` // "workspace/repo-slug"
+	IsPrivate bool   `json:"is_private"`
+	Links     struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+func (r *bitbucketRepository) toRepo() *Repo {
+	// full_name holds the slugs; name is the display name and may differ
+	owner, name, _ := strings.Cut(r.FullName, "/")
+
+	htmlURL := r.Links.HTML.Href
+	if htmlURL == "" {
+		htmlURL = "https://bitbucket.org/" + r.FullName
+	}
+
+	return &Repo{
+		ForgeType: "bitbucket",
+		Owner:     owner,
+		Name:      name,
+		CloneURL:  htmlURL + ".git",
+		HTMLURL:   htmlURL,
+		Private:   r.IsPrivate,
+	}
+}
+
+type bitbucketActor struct {
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+}
+
+func (a *bitbucketActor) name() string {
+	if a.Nickname != "" {
+		return a.Nickname
+	}
+	return a.DisplayName
+}
+
+type bitbucketPushPayload struct {
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"` // "branch" or "tag"
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"` // null when the ref is deleted
+		} `json:"changes"`
+	} `json:"push"`
+	Repository bitbucketRepository `json:"repository"`
+	Actor      bitbucketActor      `json:"actor"`
+}
+
+type bitbucketPREndpoint struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+type bitbucketPRPayload struct {
+	PullRequest struct {
+		ID          int                 `json:"id"`
+		Title       string              `json:"title"`
+		State       string              `json:"state"` // OPEN, MERGED, DECLINED, SUPERSEDED
+		Source      bitbucketPREndpoint `json:"source"`
+		Destination bitbucketPREndpoint `json:"destination"`
+	} `json:"pullrequest"`
+	Repository bitbucketRepository `json:"repository"`
+	Actor      bitbucketActor      `json:"actor"`
+}
+
+type bitbucketStatusPayload struct {
+	Key         string `json:"key"`
+	State       string `json:"state"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type bitbucketWebhookPayload struct {
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret,omitempty"`
+	Events      []string `json:"events"`
+}
@@ -0,0 +1,271 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const bitbucketPushJSON = `{
+	"push": {
+		"changes": [{
+			"new": {
+				"type": "branch",
+				"name": "main",
+				"target": {"type": "commit", "hash": "abc123def456abc123def456abc123def456abcd"}
+			},
+			"old": {
+				"type": "branch",
+				"name": "main",
+				"target": {"type": "commit", "hash": "1111111111111111111111111111111111111111"}
+			},
+			"created": false,
+			"closed": false
+		}]
+	},
+	"repository": {
+		"type": "repository",
+		"name": "My Repo",
+		"full_name": "myworkspace/my-repo",
+		"is_private": true,
+		"links": {"html": {"href": "https://bitbucket.org/myworkspace/my-repo"}}
+	},
+	"actor": {"display_name": "Dev Person", "nickname": "dev"}
+}`
+
+const bitbucketPRJSON = `{
+	"pullrequest": {
+		"id": 7,
+		"title": "Add feature",
+		"state": "OPEN",
+		"source": {
+			"branch": {"name": "feature"},
+			"commit": {"hash": "def456abc123"},
+			"repository": {"full_name": "contributor/my-repo"}
+		},
+		"destination": {
+			"branch": {"name": "main"},
+			"commit": {"hash": "abc123def456"},
+			"repository": {"full_name": "myworkspace/my-repo"}
+		}
+	},
+	"repository": {
+		"name": "My Repo",
+		"full_name": "myworkspace/my-repo",
+		"is_private": false,
+		"links": {"html": {"href": "https://bitbucket.org/myworkspace/my-repo"}}
+	},
+	"actor": {"display_name": "Contributor", "nickname": "contrib"}
+}`
+
+func newBitbucketRequest(event, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	req.Header.Set("X-Event-Key", event)
+	req.Header.Set("X-Hook-UUID", "{0b6c4d5e-1234-4c1f-9a2b-000000000000}")
+	return req
+}
+
+func TestBitbucketIdentify(t *testing.T) {
+	bb := &Bitbucket{}
+
+	if !bb.Identify(newBitbucketRequest("repo:push", "")) {
+		t.Error("Identify() = false for Bitbucket Cloud headers")
+	}
+
+	// Bitbucket Server/Data Center sends X-Event-Key without X-Hook-UUID
+	req := httptest.NewRequest("POST", "/webhook", nil)
+	req.Header.Set("X-Event-Key", "repo:refs_changed")
+	if bb.Identify(req) {
+		t.Error("Identify() = true for Bitbucket Server request")
+	}
+
+	req = httptest.NewRequest("POST", "/webhook", nil)
+	req.Header.Set("X-GitHub-Event", "push")
+	if bb.Identify(req) {
+		t.Error("Identify() = true for GitHub request")
+	}
+}
+
+func TestBitbucketParsePush(t *testing.T) {
+	bb := &Bitbucket{}
+
+	event, err := bb.ParsePush(newBitbucketRequest("repo:push", bitbucketPushJSON), "")
+	if err != nil {
+		t.Fatalf("ParsePush failed: %v", err)
+	}
+
+	if event.Branch != "main" || event.Ref != "refs/heads/main" || event.Tag != "" {
+		t.Errorf("Branch/Ref/Tag = %s/%s/%s, want main/refs/heads/main/''", event.Branch, event.Ref, event.Tag)
+	}
+	if event.Commit != "abc123def456abc123def456abc123def456abcd" {
+		t.Errorf("Commit = %s", event.Commit)
+	}
+	// Slugs come from full_name, not the display name
+	if event.Repo.Owner != "myworkspace" || event.Repo.Name != "my-repo" {
+		t.Errorf("Repo = %s, want myworkspace/my-repo", event.Repo.FullName())
+	}
+	if event.Repo.CloneURL != "https://bitbucket.org/myworkspace/my-repo.git" {
+		t.Errorf("CloneURL = %s", event.Repo.CloneURL)
+	}
+	if !event.Repo.Private {
+		t.Error("Private = false, want true")
+	}
+	if event.Repo.ForgeType != "bitbucket" {
+		t.Errorf("ForgeType = %s, want bitbucket", event.Repo.ForgeType)
+	}
+	if event.Sender != "dev" {
+		t.Errorf("Sender = %s, want dev", event.Sender)
+	}
+}
+
+func TestBitbucketParsePushTag(t *testing.T) {
+	bb := &Bitbucket{}
+
+	payload := strings.Replace(bitbucketPushJSON, `"type": "branch",
+				"name": "main"`, `"type": "tag",
+				"name": "v1.0.0"`, 1)
+	event, err := bb.ParsePush(newBitbucketRequest("repo:push", payload), "")
+	if err != nil {
+		t.Fatalf("ParsePush failed: %v", err)
+	}
+	if event.Tag != "v1.0.0" || event.Branch != "" || event.Ref != "refs/tags/v1.0.0" {
+		t.Errorf("Tag/Branch/Ref = %s/%s/%s, want v1.0.0/''/refs/tags/v1.0.0", event.Tag, event.Branch, event.Ref)
+	}
+}
+
+func TestBitbucketParsePushDeletion(t *testing.T) {
+	bb := &Bitbucket{}
+
+	payload := `{"push":{"changes":[{"new":null,"old":{"type":"branch","name":"gone"},"closed":true}]},
+		"repository":{"full_name":"myworkspace/my-repo"}}`
+	_, err := bb.ParsePush(newBitbucketRequest("repo:push", payload), "")
+	if err == nil || !strings.Contains(err.Error(), "deletion") {
+		t.Errorf("error = %v, want deletion error", err)
+	}
+}
+
+func TestBitbucketParsePullRequest(t *testing.T) {
+	bb := &Bitbucket{}
+
+	event, err := bb.ParsePullRequest(newBitbucketRequest("pullrequest:created", bitbucketPRJSON), "")
+	if err != nil {
+		t.Fatalf("ParsePullRequest failed: %v", err)
+	}
+
+	if event.Number != 7 || event.Action != "opened" {
+		t.Errorf("Number/Action = %d/%s, want 7/opened", event.Number, event.Action)
+	}
+	if event.HeadBranch != "feature" || event.BaseBranch != "main" {
+		t.Errorf("branches = %s -> %s, want feature -> main", event.HeadBranch, event.BaseBranch)
+	}
+	if event.Commit != "def456abc123" {
+		t.Errorf("Commit = %s, want def456abc123", event.Commit)
+	}
+	if !event.IsFork {
+		t.Error("IsFork = false, want true")
+	}
+	if event.Sender != "contrib" {
+		t.Errorf("Sender = %s, want contrib", event.Sender)
+	}
+	if event.Repo.FullName() != "myworkspace/my-repo" {
+		t.Errorf("Repo = %s, want myworkspace/my-repo", event.Repo.FullName())
+	}
+
+	// Updates are synchronize
+	event, err = bb.ParsePullRequest(newBitbucketRequest("pullrequest:updated", bitbucketPRJSON), "")
+	if err != nil || event.Action != "synchronize" {
+		t.Errorf("updated: action = %v, err = %v, want synchronize", event, err)
+	}
+
+	// Merged PRs are ignored
+	merged := strings.Replace(bitbucketPRJSON, `"state": "OPEN"`, `"state": "MERGED"`, 1)
+	if _, err := bb.ParsePullRequest(newBitbucketRequest("pullrequest:updated", merged), ""); err == nil {
+		t.Error("expected error for merged PR")
+	}
+
+	// Push events are not PRs
+	if _, err := bb.ParsePullRequest(newBitbucketRequest("repo:push", bitbucketPushJSON), ""); err == nil {
+		t.Error("expected error parsing push as PR")
+	}
+}
+
+func TestBitbucketPostStatus(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody bitbucketStatusPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &gotBody)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	bb := &Bitbucket{
+		APIURL: server.URL + "/2.0",
+		Token:  "access-token",
+		Client: server.Client(),
+	}
+
+	err := bb.PostStatus(context.Background(), &Repo{Owner: "myworkspace", Name: "my-repo"}, "abc123", &Status{
+		State:     StatusRunning,
+		Context:   "cinch",
+		TargetURL: "https://cinch.sh/jobs/j_1",
+	})
+	if err != nil {
+		t.Fatalf("PostStatus failed: %v", err)
+	}
+
+	if gotPath != "/2.0/repositories/myworkspace/my-repo/commit/abc123/statuses/build" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if gotAuth != "Bearer access-token" {
+		t.Errorf("Authorization = %s, want Bearer access-token", gotAuth)
+	}
+	if gotBody.State != "INPROGRESS" || gotBody.Key != "cinch" || gotBody.URL != "https://cinch.sh/jobs/j_1" {
+		t.Errorf("body = %+v", gotBody)
+	}
+
+	// App passwords use basic auth
+	bb.Token = "user:app-pass"
+	_ = bb.PostStatus(context.Background(), &Repo{Owner: "myworkspace", Name: "my-repo"}, "abc123", &Status{State: StatusFailure, Context: "cinch"})
+	if gotAuth != "Basic dXNlcjphcHAtcGFzcw==" {
+		t.Errorf("Authorization = %s, want basic auth", gotAuth)
+	}
+	if gotBody.State != "FAILED" {
+		t.Errorf("state = %s, want FAILED", gotBody.State)
+	}
+}
+
+func TestBitbucketResolvePullRequestCommit(t *testing.T) {
+	const full = "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"values":[{"hash":"` + full + `"},{"hash":"ffffffffffff0000000000000000000000000000"}]}`))
+	}))
+	defer server.Close()
+
+	bb := &Bitbucket{APIURL: server.URL + "/2.0", Token: "access-token", Client: server.Client()}
+	repo := &Repo{Owner: "myworkspace", Name: "my-repo"}
+
+	got, err := bb.ResolvePullRequestCommit(context.Background(), repo, 7, full[:12])
+	if err != nil {
+		t.Fatalf("ResolvePullRequestCommit failed: %v", err)
+	}
+	if got != full {
+		t.Errorf("hash = %s, want %s", got, full)
+	}
+	if gotPath != "/2.0/repositories/myworkspace/my-repo/pullrequests/7/commits" {
+		t.Errorf("path = %s", gotPath)
+	}
+
+	if _, err := bb.ResolvePullRequestCommit(context.Background(), repo, 7, "0123456789ab"); err == nil {
+		t.Error("expected error for a commit not in the PR")
+	}
+}
@@ -36,6 +36,13 @@ type Forge interface {
 	CreateWebhook(ctx context.Context, repo *Repo, webhookURL, secret string) (int64, error)
 }
 
+// PullRequestCommitResolver is implemented by forges whose pull request
+// webhooks carry an abbreviated head commit hash (Bitbucket). It returns the
+// full hash so jobs, statuses and duplicate checks use the same commit.
+type PullRequestCommitResolver interface {
+	ResolvePullRequestCommit(ctx context.Context, repo *Repo, number int, hash string) (string, error)
+}
+
 // PushEvent represents a push webhook event.
 type PushEvent struct {
 	Repo   *Repo
@@ -44,6 +51,43 @@ type PushEvent struct {
 	Branch string // Branch name (empty for tag pushes)
 	Tag    string // Tag name (empty for branch pushes)
 	Sender string // Username who pushed
+
+	// ChangedFiles are the paths added, modified or removed by the pushed
+	// commits. Nil when the payload doesn't list them all (or the forge
+	// doesn't send file lists), in which case path filters can't apply.
+	ChangedFiles []string
+}
+
+// PushCommit is a commit's file lists in a push payload. GitHub, GitLab and
+// Forgejo/Gitea all use these field names.
+type PushCommit struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// ChangedFiles returns the files touched by a push's commits, in first-seen
+// order. total is the number of commits pushed, if the forge reports it
+// (0 otherwise). It returns nil when the list may be incomplete: no commits
+// (e.g. a new branch at an existing commit) or fewer commits than total,
+// since forges cap how many they include.
+func ChangedFiles(commits []PushCommit, total int) []string {
+	if len(commits) == 0 || len(commits) < total {
+		return nil
+	}
+	files := []string{}
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		for _, list := range [][]string{c.Added, c.Removed, c.Modified} {
+			for _, f := range list {
+				if !seen[f] {
+					seen[f] = true
+					files = append(files, f)
+				}
+			}
+		}
+	}
+	return files
 }
 
 // PullRequestEvent represents a pull request webhook event.
@@ -61,7 +105,7 @@ type PullRequestEvent struct {
 
 // Repo represents a git repository.
 type Repo struct {
-	ForgeType string // "github", "forgejo", "gitea"
+	ForgeType string // "github", "gitlab", "forgejo", "gitea", "azure", "bitbucket"
 	Owner     string
 	Name      string
 	CloneURL  string
@@ -95,17 +139,21 @@ const (
 
 // Forge type constants - match storage.ForgeType values
 const (
-	TypeGitHub  = "github"
-	TypeGitLab  = "gitlab"
-	TypeForgejo = "forgejo"
-	TypeGitea   = "gitea"
+	TypeGitHub    = "github"
+	TypeGitLab    = "gitlab"
+	TypeForgejo   = "forgejo"
+	TypeGitea     = "gitea"
+	TypeAzure     = "azure"
+	TypeBitbucket = "bitbucket"
 )
 
 // ForgeConfig holds configuration for creating a forge instance.
 type ForgeConfig struct {
-	Type    string // TypeGitHub, TypeForgejo, etc.
-	Token   string // API token for authentication
-	BaseURL string // Base URL for self-hosted instances (Forgejo, GitLab)
+	Type    string       // TypeGitHub, TypeForgejo, etc.
+	Token   string       // API token for authentication
+	BaseURL string       // Base URL for self-hosted instances (Forgejo, GitLab, GitHub Enterprise) or Azure DevOps org
+	APIURL  string       // GitHub API URL override (GitHub Enterprise Server)
+	Client  *http.Client // HTTP client for API calls (nil uses http.DefaultClient)
 }
 
 // New creates a Forge instance based on the config.
@@ -113,13 +161,18 @@ type ForgeConfig struct {
 func New(cfg ForgeConfig) Forge {
 	switch cfg.Type {
 	case TypeGitHub:
-		return &GitHub{Token: cfg.Token}
+		return &GitHub{Token: cfg.Token, BaseURL: cfg.BaseURL, APIURL: cfg.APIURL, Client: cfg.Client}
 	case TypeGitLab:
-		return &GitLab{Token: cfg.Token, BaseURL: cfg.BaseURL}
+		return &GitLab{Token: cfg.Token, BaseURL: cfg.BaseURL, Client: cfg.Client}
 	case TypeForgejo:
-		return &Forgejo{Token: cfg.Token, BaseURL: cfg.BaseURL}
+		return &Forgejo{Token: cfg.Token, BaseURL: cfg.BaseURL, Client: cfg.Client}
 	case TypeGitea:
-		return &Forgejo{Token: cfg.Token, BaseURL: cfg.BaseURL, IsGitea: true}
+		return &Forgejo{Token: cfg.Token, BaseURL: cfg.BaseURL, IsGitea: true, Client: cfg.Client}
+	case TypeAzure:
+		return &Azure{Token: cfg.Token, BaseURL: cfg.BaseURL, Client: cfg.Client}
+	case TypeBitbucket:
+		// Bitbucket Cloud only; the API host is fixed
+		return &Bitbucket{Token: cfg.Token, Client: cfg.Client}
 	default:
 		return nil
 	}
@@ -0,0 +1,161 @@
+package forge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWebhookVerificationMatrix checks every registered forge's webhook
+// authentication scheme: a correct secret passes, a wrong or missing one fails.
+func TestWebhookVerificationMatrix(t *testing.T) {
+	const secret = "matrix-secret"
+
+	hmacHex := func(body, key string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	type signer func(r *http.Request, body, key string)
+
+	tests := []struct {
+		name    string
+		forge   Forge
+		headers map[string]string
+		body    string
+		sign    signer
+	}{
+		{
+			name:    "github hmac-sha256",
+			forge:   &GitHub{},
+			headers: map[string]string{"X-GitHub-Event": "push"},
+			body:    `{"ref":"refs/heads/main","after":"abc123","repository":{"name":"r","owner":{"login":"o"}}}`,
+			sign: func(r *http.Request, body, key string) {
+				r.Header.Set("X-Hub-Signature-256", "sha256="+hmacHex(body, key))
+			},
+		},
+		{
+			name:    "forgejo hmac-sha256 hex",
+			forge:   &Forgejo{},
+			headers: map[string]string{"X-Forgejo-Event": "push"},
+			body:    `{"ref":"refs/heads/main","after":"abc123","repository":{"name":"r","owner":{"username":"o"}}}`,
+			sign: func(r *http.Request, body, key string) {
+				r.Header.Set("X-Forgejo-Signature", hmacHex(body, key))
+			},
+		},
+		{
+			name:    "gitea hmac-sha256 hex",
+			forge:   &Forgejo{IsGitea: true},
+			headers: map[string]string{"X-Gitea-Event": "push"},
+			body:    `{"ref":"refs/heads/main","after":"abc123","repository":{"name":"r","owner":{"username":"o"}}}`,
+			sign: func(r *http.Request, body, key string) {
+				r.Header.Set("X-Gitea-Signature", hmacHex(body, key))
+			},
+		},
+		{
+			name:    "gitlab shared token",
+			forge:   &GitLab{},
+			headers: map[string]string{"X-Gitlab-Event": "Push Hook"},
+			body:    `{"ref":"refs/heads/main","after":"abc123","project":{"name":"r","path_with_namespace":"o/r"}}`,
+			sign: func(r *http.Request, body, key string) {
+				r.Header.Set("X-Gitlab-Token", key)
+			},
+		},
+		{
+			name:    "bitbucket hmac-sha256",
+			forge:   &Bitbucket{},
+			headers: map[string]string{"X-Event-Key": "repo:push", "X-Hook-UUID": "{hook}"},
+			body:    bitbucketPushJSON,
+			sign: func(r *http.Request, body, key string) {
+				r.Header.Set("X-Hub-Signature", "sha256="+hmacHex(body, key))
+			},
+		},
+		{
+			name:    "azure basic auth",
+			forge:   &Azure{},
+			headers: map[string]string{"User-Agent": "VSServices/16.0"},
+			body:    azurePushJSON,
+			sign: func(r *http.Request, body, key string) {
+				r.SetBasicAuth("cinch", key)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		newReq := func() *http.Request {
+			req := httptest.NewRequest("POST", "/webhook", strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			return req
+		}
+
+		t.Run(tt.name+"/valid", func(t *testing.T) {
+			req := newReq()
+			tt.sign(req, tt.body, secret)
+			if !tt.forge.Identify(req) {
+				t.Fatal("Identify() = false")
+			}
+			if _, err := tt.forge.ParsePush(req, secret); err != nil {
+				t.Errorf("ParsePush with valid secret failed: %v", err)
+			}
+		})
+
+		t.Run(tt.name+"/wrong secret", func(t *testing.T) {
+			req := newReq()
+			tt.sign(req, tt.body, "wrong-secret")
+			if _, err := tt.forge.ParsePush(req, secret); err == nil {
+				t.Error("expected error for wrong secret")
+			}
+		})
+
+		t.Run(tt.name+"/missing", func(t *testing.T) {
+			if _, err := tt.forge.ParsePush(newReq(), secret); err == nil {
+				t.Error("expected error for missing signature")
+			}
+		})
+
+		t.Run(tt.name+"/tampered body", func(t *testing.T) {
+			// Shared-token schemes don't cover the body, so only HMAC forges apply
+			if _, ok := tt.forge.(*GitLab); ok {
+				t.Skip("token scheme does not sign the body")
+			}
+			if _, ok := tt.forge.(*Azure); ok {
+				t.Skip("basic auth scheme does not sign the body")
+			}
+			req := newReq()
+			tt.sign(req, tt.body+" ", secret)
+			if _, err := tt.forge.ParsePush(req, secret); err == nil {
+				t.Error("expected error for signature over a different body")
+			}
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	commits := []PushCommit{
+		{Added: []string{"api/new.go"}, Modified: []string{"README.md"}},
+		{Removed: []string{"old.go"}, Modified: []string{"README.md", "api/main.go"}},
+	}
+
+	got := ChangedFiles(commits, 2)
+	want := "api/new.go,README.md,old.go,api/main.go"
+	if strings.Join(got, ",") != want {
+		t.Errorf("ChangedFiles = %v, want %s", got, want)
+	}
+
+	if got := ChangedFiles(commits, 25); got != nil {
+		t.Errorf("truncated commit list: got %v, want nil", got)
+	}
+	if got := ChangedFiles(nil, 0); got != nil {
+		t.Errorf("no commits: got %v, want nil", got)
+	}
+	if got := ChangedFiles([]PushCommit{{}}, 0); got == nil || len(got) != 0 {
+		t.Errorf("commit with no files: got %#v, want empty list", got)
+	}
+}
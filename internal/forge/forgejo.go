@@ -79,6 +79,11 @@ func (f *Forgejo) ParsePush(r *http.Request, secret string) (*PushEvent, error)
 		return nil, fmt.Errorf("parse payload: %w", err)
 	}
 
+	// Skip deletions (when after is all zeros)
+	if payload.After == "0000000000000000000000000000000000000000" {
+		return nil, errors.New("ref deletion event")
+	}
+
 	// Parse ref to determine if branch or tag
 	var branch, tag string
 	if strings.HasPrefix(payload.Ref, "refs/tags/") {
@@ -102,11 +107,12 @@ func (f *Forgejo) ParsePush(r *http.Request, secret string) (*PushEvent, error)
 			HTMLURL:   payload.Repository.HTMLURL,
 			Private:   payload.Repository.Private,
 		},
-		Commit: payload.After,
-		Ref:    payload.Ref,
-		Branch: branch,
-		Tag:    tag,
-		Sender: payload.Sender.Username,
+		Commit:       payload.After,
+		Ref:          payload.Ref,
+		Branch:       branch,
+		Tag:          tag,
+		Sender:       payload.Sender.Username,
+		ChangedFiles: ChangedFiles(payload.Commits, payload.TotalCommits),
 	}, nil
 }
 
@@ -361,6 +367,10 @@ type forgejoPushPayload struct {
 	Sender struct {
 		Username string `json:"username"`
 	} `json:"sender"`
+
+	// Forgejo/Gitea cap the commits listed; total_commits is the real count
+	Commits      []PushCommit `json:"commits"`
+	TotalCommits int          `json:"total_commits"`
 }
 
 type forgejoStatusPayload struct {
@@ -133,6 +133,44 @@ func TestForgejoParsePushGitea(t *testing.T) {
 	}
 }
 
+func TestForgejoParsePushGiteaTag(t *testing.T) {
+	fg := &Forgejo{IsGitea: true}
+
+	payload := `{
+		"ref": "refs/tags/v1.2.0",
+		"before": "0000000000000000000000000000000000000000",
+		"after": "def456",
+		"repository": {
+			"name": "repo",
+			"clone_url": "https://gitea.example.com/user/repo.git",
+			"owner": {"username": "user"}
+		},
+		"sender": {"username": "user"}
+	}`
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	req.Header.Set("X-Gitea-Event", "push")
+
+	event, err := fg.ParsePush(req, "")
+	if err != nil {
+		t.Fatalf("ParsePush failed: %v", err)
+	}
+	if event.Tag != "v1.2.0" || event.Branch != "" {
+		t.Errorf("Tag/Branch = %q/%q, want v1.2.0/empty", event.Tag, event.Branch)
+	}
+	if event.Commit != "def456" {
+		t.Errorf("Commit = %s, want def456", event.Commit)
+	}
+
+	// Deleting the tag must not start a release
+	deleted := strings.Replace(payload, `"after": "def456"`, `"after": "0000000000000000000000000000000000000000"`, 1)
+	req = httptest.NewRequest("POST", "/webhook", strings.NewReader(deleted))
+	req.Header.Set("X-Gitea-Event", "push")
+	if _, err := fg.ParsePush(req, ""); err == nil || !strings.Contains(err.Error(), "deletion") {
+		t.Errorf("ParsePush(tag deletion) error = %v, want deletion error", err)
+	}
+}
+
 func TestForgejoParsePushWithSignature(t *testing.T) {
 	fg := &Forgejo{}
 	secret := "webhook-secret"
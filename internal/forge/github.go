@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -21,10 +22,30 @@ type GitHub struct {
 	// Needs repo:status scope for status posting.
 	Token string
 
+	// BaseURL is the GitHub web URL, or any URL on its host (such as a repo's
+	// HTML URL). Empty means github.com.
+	BaseURL string
+
+	// APIURL overrides the API base URL. Defaults to https://api.github.com,
+	// or BaseURL's host + "/api/v3" for GitHub Enterprise Server.
+	APIURL string
+
 	// Client is the HTTP client to use. If nil, http.DefaultClient is used.
 	Client *http.Client
 }
 
+// apiURL returns the REST API base URL, without a trailing slash.
+func (g *GitHub) apiURL() string {
+	if g.APIURL != "" {
+		return strings.TrimSuffix(g.APIURL, "/")
+	}
+	u, err := url.Parse(g.BaseURL)
+	if err != nil || u.Host == "" || u.Host == "github.com" || u.Host == "www.github.com" {
+		return "https://api.github.com"
+	}
+	return u.Scheme + "://" + u.Host + "/api/v3"
+}
+
 // Name returns "github".
 func (g *GitHub) Name() string {
 	return "github"
@@ -85,14 +106,18 @@ func (g *GitHub) ParsePush(r *http.Request, secret string) (*PushEvent, error) {
 			HTMLURL:   payload.Repository.HTMLURL,
 			Private:   payload.Repository.Private,
 		},
-		Commit: payload.After,
-		Ref:    payload.Ref,
-		Branch: branch,
-		Tag:    tag,
-		Sender: payload.Sender.Login,
+		Commit:       payload.After,
+		Ref:          payload.Ref,
+		Branch:       branch,
+		Tag:          tag,
+		Sender:       payload.Sender.Login,
+		ChangedFiles: ChangedFiles(payload.Commits, 0),
 	}, nil
 }
 
+// verifySignature checks an X-Hub-Signature-256 (HMAC-SHA256) header value.
+// The legacy SHA-1 X-Hub-Signature header is never consulted, so a request
+// signed only with SHA-1 fails as unsigned.
 func (g *GitHub) verifySignature(body []byte, signature, secret string) error {
 	if signature == "" {
 		return errors.New("missing signature header")
@@ -121,8 +146,8 @@ func (g *GitHub) verifySignature(body []byte, signature, secret string) error {
 
 // PostStatus posts a commit status to GitHub.
 func (g *GitHub) PostStatus(ctx context.Context, repo *Repo, commit string, status *Status) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/statuses/%s",
-		repo.Owner, repo.Name, commit)
+	url := fmt.Sprintf("%s/repos/%s/%s/statuses/%s",
+		g.apiURL(), repo.Owner, repo.Name, commit)
 
 	// Map our status state to GitHub's
 	state := string(status.State)
@@ -184,8 +209,8 @@ func (g *GitHub) CloneToken(ctx context.Context, repo *Repo) (string, time.Time,
 
 // CreateWebhook creates a webhook for the repository.
 func (g *GitHub) CreateWebhook(ctx context.Context, repo *Repo, webhookURL, secret string) (int64, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/hooks",
-		repo.Owner, repo.Name)
+	url := fmt.Sprintf("%s/repos/%s/%s/hooks",
+		g.apiURL(), repo.Owner, repo.Name)
 
 	payload := githubWebhookPayload{
 		Name:   "web",
@@ -302,10 +327,11 @@ func (g *GitHub) ParsePullRequest(r *http.Request, secret string) (*PullRequestE
 // GitHub webhook payload types
 
 type githubPushPayload struct {
-	Ref        string `json:"ref"`
-	Before     string `json:"before"`
-	After      string `json:"after"`
-	Deleted    bool   `json:"deleted"`
+	Ref        string       `json:"ref"`
+	Before     string       `json:"before"`
+	After      string       `json:"after"`
+	Deleted    bool         `json:"deleted"`
+	Commits    []PushCommit `json:"commits"`
 	Repository struct {
 		Name     string `json:"name"`
 		FullName string `json:"full_name"`
@@ -3,6 +3,7 @@ package forge
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
@@ -151,6 +152,50 @@ func TestGitHubParsePushInvalidSignature(t *testing.T) {
 	}
 }
 
+func TestGitHubVerifySignature(t *testing.T) {
+	gh := &GitHub{}
+	// Test vector from GitHub's "Validating webhook deliveries" docs
+	secret := "It's a Secret to Everybody"
+	body := []byte("Hello, World!")
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   bool
+	}{
+		{"valid sha256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", false},
+		{"wrong digest", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e18", true},
+		{"sha1 digest", "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59", true},
+		{"sha1 digest with sha256 prefix", "sha256=01dc10d0c83e72ed246219cdd91669667fe2ca59", true},
+		{"missing", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gh.verifySignature(body, tt.signature, secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitHubParsePushRejectsSHA1Only(t *testing.T) {
+	gh := &GitHub{}
+	secret := "test-secret"
+	payload := `{"ref": "refs/heads/main", "after": "abc123", "repository": {"name": "repo", "owner": {"login": "user"}}}`
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+
+	if _, err := gh.ParsePush(req, secret); err == nil {
+		t.Fatal("expected error for a delivery signed only with SHA-1")
+	}
+}
+
 func TestGitHubParsePushBranchDeletion(t *testing.T) {
 	gh := &GitHub{}
 
@@ -176,24 +221,22 @@ func TestGitHubParsePushBranchDeletion(t *testing.T) {
 }
 
 func TestGitHubPostStatus(t *testing.T) {
-	var receivedAuth string
+	var receivedAuth, receivedPath string
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		receivedAuth = r.Header.Get("Authorization")
+		receivedPath = r.URL.Path
 		_, _ = io.ReadAll(r.Body)
 		w.WriteHeader(http.StatusCreated)
 	}))
 	defer server.Close()
 
-	// We can't easily test against the real GitHub API,
-	// but we can verify the request is properly formed
 	gh := &GitHub{
 		Token:  "test-token",
+		APIURL: server.URL,
 		Client: server.Client(),
 	}
 
-	// This will fail because we're not hitting the real API
-	// but we can at least verify the code paths
 	ctx := context.Background()
 	err := gh.PostStatus(ctx, &Repo{
 		Owner: "testuser",
@@ -204,12 +247,30 @@ func TestGitHubPostStatus(t *testing.T) {
 		Description: "Build passed",
 		TargetURL:   "https://example.com/jobs/1",
 	})
+	if err != nil {
+		t.Fatalf("PostStatus: %v", err)
+	}
+	if receivedAuth != "Bearer test-token" {
+		t.Errorf("Authorization = %s, want Bearer test-token", receivedAuth)
+	}
+	if receivedPath != "/repos/testuser/testrepo/statuses/abc123" {
+		t.Errorf("path = %s", receivedPath)
+	}
+}
 
-	// Will fail since we're hitting localhost not api.github.com
-	if err == nil {
-		// If somehow it succeeded (shouldn't happen), check the request
-		if receivedAuth != "Bearer test-token" {
-			t.Errorf("Authorization = %s, want Bearer test-token", receivedAuth)
+func TestGitHubAPIURL(t *testing.T) {
+	tests := []struct {
+		baseURL, apiURL, want string
+	}{
+		{"", "", "https://api.github.com"},
+		{"https://github.com/owner/repo", "", "https://api.github.com"},
+		{"https://ghe.example.com/owner/repo", "", "https://ghe.example.com/api/v3"},
+		{"https://ghe.example.com/owner/repo", "https://api.ghe.example.com/", "https://api.ghe.example.com"},
+	}
+	for _, tt := range tests {
+		gh := &GitHub{BaseURL: tt.baseURL, APIURL: tt.apiURL}
+		if got := gh.apiURL(); got != tt.want {
+			t.Errorf("apiURL(%q, %q) = %q, want %q", tt.baseURL, tt.apiURL, got, tt.want)
 		}
 	}
 }
@@ -104,11 +104,12 @@ func (g *GitLab) ParsePush(r *http.Request, secret string) (*PushEvent, error) {
 			HTMLURL:   payload.Project.WebURL,
 			Private:   payload.Project.VisibilityLevel != 20, // 20 = public
 		},
-		Commit: payload.After,
-		Ref:    payload.Ref,
-		Branch: branch,
-		Tag:    tag,
-		Sender: payload.UserUsername,
+		Commit:       payload.After,
+		Ref:          payload.Ref,
+		Branch:       branch,
+		Tag:          tag,
+		Sender:       payload.UserUsername,
+		ChangedFiles: ChangedFiles(payload.Commits, payload.TotalCommitsCount),
 	}, nil
 }
 
@@ -330,6 +331,12 @@ func (g *GitLab) ParsePullRequest(r *http.Request, secret string) (*PullRequestE
 		return nil, fmt.Errorf("ignoring MR action: %s", payload.ObjectAttributes.Action)
 	}
 
+	// Build (and report status on) the MR's source head commit. The payload
+	// also carries merge_commit_sha, but that only exists once merged.
+	if payload.ObjectAttributes.LastCommit.ID == "" {
+		return nil, errors.New("merge request has no last_commit")
+	}
+
 	// Extract owner from path_with_namespace
 	owner := payload.Project.Namespace
 	if payload.Project.PathWithNamespace != "" {
@@ -378,6 +385,10 @@ type gitlabPushPayload struct {
 		GitHTTPURL        string `json:"git_http_url"`
 		VisibilityLevel   int    `json:"visibility_level"` // 0=private, 10=internal, 20=public
 	} `json:"project"`
+
+	// GitLab lists at most 20 commits; total_commits_count is the real count
+	Commits           []PushCommit `json:"commits"`
+	TotalCommitsCount int          `json:"total_commits_count"`
 }
 
 type gitlabStatusPayload struct {
@@ -406,7 +417,7 @@ type gitlabMRPayload struct {
 		SourceProjectID int    `json:"source_project_id"`
 		TargetProjectID int    `json:"target_project_id"`
 		LastCommit      struct {
-			ID string `json:"id"` // Commit SHA
+			ID string `json:"id"` // Head SHA of the source branch
 		} `json:"last_commit"`
 	} `json:"object_attributes"`
 	Project struct {
@@ -2,6 +2,8 @@ package forge
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -218,6 +220,168 @@ func TestGitLabParsePushDeletion(t *testing.T) {
 	}
 }
 
+// gitlabMRHookBody is a Merge Request Hook as delivered by gitlab.com
+// (trimmed of fields cinch doesn't read). merge_commit_sha and the
+// target branch's last commit deliberately differ from last_commit.
+const gitlabMRHookBody = `{
+  "object_kind": "merge_request",
+  "event_type": "merge_request",
+  "user": {
+    "id": 1,
+    "name": "Administrator",
+    "username": "root",
+    "avatar_url": "http://www.gravatar.com/avatar/e64c7d89f26bd1972efa854d13d7dd61?s=40&d=identicon",
+    "email": "admin@example.com"
+  },
+  "project": {
+    "id": 1,
+    "name": "Gitlab Test",
+    "description": "Aut reprehenderit ut est.",
+    "web_url": "http://example.com/gitlabhq/gitlab-test",
+    "avatar_url": null,
+    "git_ssh_url": "git@example.com:gitlabhq/gitlab-test.git",
+    "git_http_url": "http://example.com/gitlabhq/gitlab-test.git",
+    "namespace": "GitlabHQ",
+    "visibility_level": 20,
+    "path_with_namespace": "gitlabhq/gitlab-test",
+    "default_branch": "master"
+  },
+  "object_attributes": {
+    "id": 99,
+    "iid": 1,
+    "target_branch": "master",
+    "source_branch": "ms-viewport",
+    "source_project_id": 14,
+    "author_id": 51,
+    "assignee_ids": [6],
+    "title": "MS-Viewport",
+    "created_at": "2013-12-03T17:23:34Z",
+    "updated_at": "2013-12-03T17:23:34Z",
+    "target_project_id": 14,
+    "description": "",
+    "state": "opened",
+    "merge_status": "unchecked",
+    "merge_commit_sha": "f0e1d2c3b4a5968778695a4b3c2d1e0f9a8b7c6d",
+    "url": "http://example.com/diaspora/merge_requests/1",
+    "source": {
+      "name": "Awesome Project",
+      "git_http_url": "http://example.com/awesome_space/awesome_project.git",
+      "path_with_namespace": "awesome_space/awesome_project",
+      "visibility_level": 20
+    },
+    "target": {
+      "name": "Awesome Project",
+      "git_http_url": "http://example.com/awesome_space/awesome_project.git",
+      "path_with_namespace": "awesome_space/awesome_project",
+      "visibility_level": 20
+    },
+    "last_commit": {
+      "id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+      "message": "fixed readme",
+      "timestamp": "2012-01-03T23:36:29+02:00",
+      "url": "http://example.com/awesome_space/awesome_project/commits/da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+      "author": {
+        "name": "GitLab dev user",
+        "email": "gitlabdev@dv6700.(none)"
+      }
+    },
+    "work_in_progress": false,
+    "action": "open"
+  },
+  "labels": [],
+  "changes": {},
+  "repository": {
+    "name": "Gitlab Test",
+    "url": "http://example.com/gitlabhq/gitlab-test.git",
+    "description": "Aut reprehenderit ut est.",
+    "homepage": "http://example.com/gitlabhq/gitlab-test"
+  }
+}`
+
+func TestGitLabParseMergeRequest(t *testing.T) {
+	gl := &GitLab{}
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(gitlabMRHookBody))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", "secret")
+
+	if !gl.Identify(req) {
+		t.Fatal("Identify() = false for a Merge Request Hook")
+	}
+	if _, err := gl.ParsePush(req, "secret"); err == nil {
+		t.Error("ParsePush should reject a Merge Request Hook")
+	}
+
+	req = httptest.NewRequest("POST", "/webhook", strings.NewReader(gitlabMRHookBody))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", "secret")
+	event, err := gl.ParsePullRequest(req, "secret")
+	if err != nil {
+		t.Fatalf("ParsePullRequest failed: %v", err)
+	}
+
+	if event.Number != 1 {
+		t.Errorf("Number = %d, want 1 (the MR iid, not its global id)", event.Number)
+	}
+	if event.BaseBranch != "master" {
+		t.Errorf("BaseBranch = %q, want master", event.BaseBranch)
+	}
+	if event.HeadBranch != "ms-viewport" {
+		t.Errorf("HeadBranch = %q, want ms-viewport", event.HeadBranch)
+	}
+	if event.Commit != "da1560886d4f094c3e6c9ef40349f7d38b5d27d7" {
+		t.Errorf("Commit = %q, want the source head (last_commit.id), not merge_commit_sha", event.Commit)
+	}
+	if event.Action != "open" || event.Sender != "root" || event.IsFork {
+		t.Errorf("Action/Sender/IsFork = %q/%q/%v, want open/root/false", event.Action, event.Sender, event.IsFork)
+	}
+	if event.Repo.Owner != "gitlabhq" || event.Repo.CloneURL != "http://example.com/gitlabhq/gitlab-test.git" {
+		t.Errorf("Repo = %+v", event.Repo)
+	}
+}
+
+func TestGitLabParseMergeRequestWithoutLastCommit(t *testing.T) {
+	gl := &GitLab{}
+	payload := `{"object_attributes": {"iid": 3, "action": "open", "target_branch": "main"}}`
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	if _, err := gl.ParsePullRequest(req, ""); err == nil {
+		t.Error("expected error for merge request without last_commit")
+	}
+}
+
+func TestGitLabPostStatusToMRHead(t *testing.T) {
+	var gotPath string
+	var got gitlabStatusPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-test" {
+			t.Errorf("PRIVATE-TOKEN = %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	gl := &GitLab{BaseURL: srv.URL, Token: "glpat-test"}
+	repo := &Repo{ForgeType: "gitlab", Owner: "gitlabhq", Name: "gitlab-test"}
+	err := gl.PostStatus(context.Background(), repo, "da1560886d4f094c3e6c9ef40349f7d38b5d27d7", &Status{
+		State:   StatusSuccess,
+		Context: "cinch",
+	})
+	if err != nil {
+		t.Fatalf("PostStatus failed: %v", err)
+	}
+
+	if want := "/api/v4/projects/gitlabhq%2Fgitlab-test/statuses/da1560886d4f094c3e6c9ef40349f7d38b5d27d7"; gotPath != want {
+		t.Errorf("path = %s, want %s", gotPath, want)
+	}
+	if got.Context != "cinch" || got.State != "success" {
+		t.Errorf("payload = %+v, want name cinch and state success", got)
+	}
+}
+
 func TestGitLabCloneToken(t *testing.T) {
 	gl := &GitLab{Token: "glpat-xxxyyyzzz"}
 
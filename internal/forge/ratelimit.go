@@ -0,0 +1,247 @@
+package forge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter tracks forge API rate-limit budgets from response headers and
+// throttles requests once a budget runs low.
+//
+// Budgets are tracked per host and credential (each PAT or OAuth token has
+// its own limit). GitHub App installation tokens rotate hourly but share the
+// installation's limit, so callers register them with ShareBudget to keep
+// one budget per installation. While a budget is healthy,
+// requests go straight through. Once it drops to MinRemaining, requests for
+// that budget are serialized and held until the reset time (capped at MaxWait)
+// so one busy repo can't get the whole server throttled.
+type RateLimiter struct {
+	// MinRemaining is the remaining-request threshold below which requests
+	// are held until the budget resets.
+	MinRemaining int
+
+	// MaxWait caps how long a single request waits for a reset.
+	MaxWait time.Duration
+
+	// MaxBuckets caps how many budgets are tracked. When full, idle budgets
+	// are dropped first, then the least recently used.
+	MaxBuckets int
+
+	// IdleTTL is how long an unused budget is kept.
+	IdleTTL time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+	shared  map[string]sharedBudget // credential hash -> budget name
+}
+
+type sharedBudget struct {
+	name    string
+	expires time.Time
+}
+
+type rateBucket struct {
+	serial sync.Mutex // held by in-flight requests while the budget is low
+
+	mu        sync.Mutex
+	known     bool      // remaining/reset have been seen in a response
+	remaining int       // requests left in the current window
+	reset     time.Time // when the window resets
+	retryAt   time.Time // set by Retry-After on 429/403
+
+	lastUsed time.Time // guarded by RateLimiter.mu
+}
+
+// NewRateLimiter creates a rate limiter with default thresholds.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		MinRemaining: 10,
+		MaxWait:      time.Minute,
+		MaxBuckets:   1024,
+		IdleTTL:      2 * time.Hour,
+		buckets:      make(map[string]*rateBucket),
+		shared:       make(map[string]sharedBudget),
+	}
+}
+
+// Client returns an HTTP client whose requests are tracked by the limiter.
+func (l *RateLimiter) Client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &rateLimitTransport{limiter: l, base: http.DefaultTransport},
+	}
+}
+
+// ShareBudget makes requests authenticated with token count against the
+// named budget instead of a budget of their own, until expires. Used for
+// GitHub App installation tokens, which all draw on the installation's limit.
+func (l *RateLimiter) ShareBudget(token, name string, expires time.Time) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, s := range l.shared {
+		if !now.Before(s.expires) {
+			delete(l.shared, k)
+		}
+	}
+	l.shared[credentialHash(token)] = sharedBudget{name: name, expires: expires}
+}
+
+// bucket returns the budget for a request's host and credential.
+func (l *RateLimiter) bucket(req *http.Request) *rateBucket {
+	now := time.Now()
+	key := req.URL.Host
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cred := requestCredential(req); cred != "" {
+		hash := credentialHash(cred)
+		if s, ok := l.shared[hash]; ok && now.Before(s.expires) {
+			key += "|" + s.name
+		} else {
+			key += "|" + hash
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		l.evict(now)
+		b = &rateBucket{}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+	return b
+}
+
+// evict makes room for a new bucket once MaxBuckets is reached. Caller
+// holds l.mu.
+func (l *RateLimiter) evict(now time.Time) {
+	if l.MaxBuckets <= 0 || len(l.buckets) < l.MaxBuckets {
+		return
+	}
+	var oldestKey string
+	var oldest time.Time
+	for k, b := range l.buckets {
+		if now.Sub(b.lastUsed) > l.IdleTTL {
+			delete(l.buckets, k)
+			continue
+		}
+		if oldestKey == "" || b.lastUsed.Before(oldest) {
+			oldestKey, oldest = k, b.lastUsed
+		}
+	}
+	if len(l.buckets) >= l.MaxBuckets {
+		delete(l.buckets, oldestKey)
+	}
+}
+
+// requestCredential returns the token a request is authenticated with,
+// without its auth scheme.
+func requestCredential(req *http.Request) string {
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		if _, token, ok := strings.Cut(auth, " "); ok {
+			return token
+		}
+		return auth
+	}
+	return req.Header.Get("PRIVATE-TOKEN")
+}
+
+func credentialHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// wait returns how long to hold a request, and whether the budget is low.
+func (b *rateBucket) wait(now time.Time, minRemaining int) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.retryAt) {
+		return b.retryAt.Sub(now), true
+	}
+	if !b.known || b.remaining > minRemaining {
+		return 0, false
+	}
+	if !now.Before(b.reset) {
+		// Window has reset; budget is unknown until the next response
+		b.known = false
+		return 0, false
+	}
+	if b.remaining > 0 {
+		// Low but not exhausted: serialize without waiting
+		return 0, true
+	}
+	return b.reset.Sub(now), true
+}
+
+// update records the budget reported by a response.
+func (b *rateBucket) update(resp *http.Response, now time.Time) {
+	h := resp.Header
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// GitHub/Gitea use X-RateLimit-*, GitLab uses RateLimit-*
+	remaining := h.Get("X-RateLimit-Remaining")
+	reset := h.Get("X-RateLimit-Reset")
+	if remaining == "" {
+		remaining = h.Get("RateLimit-Remaining")
+		reset = h.Get("RateLimit-Reset")
+	}
+	if n, err := strconv.Atoi(remaining); err == nil {
+		b.known = true
+		b.remaining = n
+		if ts, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			b.reset = time.Unix(ts, 0)
+		} else {
+			b.reset = now.Add(time.Minute)
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden {
+		if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+			b.retryAt = now.Add(time.Duration(secs) * time.Second)
+		}
+	}
+}
+
+type rateLimitTransport struct {
+	limiter *RateLimiter
+	base    http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := t.limiter.bucket(req)
+
+	d, low := b.wait(time.Now(), t.limiter.MinRemaining)
+	if low {
+		b.serial.Lock()
+		defer b.serial.Unlock()
+		// Re-check: another request may have refreshed the budget while we queued
+		d, _ = b.wait(time.Now(), t.limiter.MinRemaining)
+	}
+	if d > t.limiter.MaxWait {
+		d = t.limiter.MaxWait
+	}
+	if d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	b.update(resp, time.Now())
+	return resp, nil
+}
@@ -0,0 +1,148 @@
+package forge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWaitsForReset(t *testing.T) {
+	reset := time.Now().Add(1500 * time.Millisecond)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 1 {
+			// First response exhausts the budget
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix()+1, 10))
+		} else {
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter()
+	limiter.MaxWait = 200 * time.Millisecond
+	client := limiter.Client(5 * time.Second)
+
+	get := func() {
+		t.Helper()
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		req.Header.Set("Authorization", "Bearer token-a")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	get()
+
+	// Exhausted budget: the next request is held (capped at MaxWait)
+	start := time.Now()
+	get()
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Errorf("request was not held after budget exhausted (waited %v)", waited)
+	}
+
+	// Budget restored: no wait
+	start = time.Now()
+	get()
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Errorf("request was held with a healthy budget (waited %v)", waited)
+	}
+
+	// A different credential has its own budget
+	limiter.MaxWait = time.Minute
+	if d, _ := limiter.bucket(mustRequest(t, server.URL, "Bearer token-b")).wait(time.Now(), limiter.MinRemaining); d != 0 {
+		t.Errorf("unrelated token was throttled for %v", d)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	b := &rateBucket{}
+	now := time.Now()
+	b.update(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}, now)
+
+	d, low := b.wait(now, 10)
+	if !low || d != 30*time.Second {
+		t.Errorf("wait = %v, %v; want 30s, true", d, low)
+	}
+}
+
+func TestRateLimiterGitLabHeaders(t *testing.T) {
+	b := &rateBucket{}
+	now := time.Now()
+	b.update(&http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Ratelimit-Remaining": []string{"5"},
+			"Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(time.Minute).Unix(), 10)},
+		},
+	}, now)
+
+	// Low but not exhausted: serialized without waiting
+	d, low := b.wait(now, 10)
+	if !low || d != 0 {
+		t.Errorf("wait = %v, %v; want 0, true", d, low)
+	}
+}
+
+func TestRateLimiterSharedBudget(t *testing.T) {
+	limiter := NewRateLimiter()
+	expires := time.Now().Add(time.Hour)
+	limiter.ShareBudget("ghs_old", "installation:42", expires)
+	limiter.ShareBudget("ghs_new", "installation:42", expires)
+
+	// Rotated installation tokens draw on the same budget
+	old := limiter.bucket(mustRequest(t, "https://api.github.com/repos", "token ghs_old"))
+	if limiter.bucket(mustRequest(t, "https://api.github.com/repos", "Bearer ghs_new")) != old {
+		t.Error("tokens for the same installation got separate budgets")
+	}
+	if limiter.bucket(mustRequest(t, "https://api.github.com/repos", "token ghp_pat")) == old {
+		t.Error("unrelated token shares the installation budget")
+	}
+}
+
+func TestRateLimiterEvictsBuckets(t *testing.T) {
+	limiter := NewRateLimiter()
+	limiter.MaxBuckets = 2
+
+	a := limiter.bucket(mustRequest(t, "https://api.github.com", "Bearer a"))
+	limiter.bucket(mustRequest(t, "https://api.github.com", "Bearer b"))
+	limiter.bucket(mustRequest(t, "https://api.github.com", "Bearer a")) // b is now least recently used
+	limiter.bucket(mustRequest(t, "https://api.github.com", "Bearer c"))
+
+	if len(limiter.buckets) != 2 {
+		t.Fatalf("tracking %d buckets, want 2", len(limiter.buckets))
+	}
+	if limiter.bucket(mustRequest(t, "https://api.github.com", "Bearer a")) != a {
+		t.Error("recently used bucket was evicted")
+	}
+
+	// Idle buckets go first
+	for _, b := range limiter.buckets {
+		b.lastUsed = time.Now().Add(-3 * time.Hour)
+	}
+	limiter.bucket(mustRequest(t, "https://api.github.com", "Bearer d"))
+	if len(limiter.buckets) != 1 {
+		t.Errorf("tracking %d buckets after idle eviction, want 1", len(limiter.buckets))
+	}
+}
+
+func mustRequest(t *testing.T, url, auth string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", auth)
+	return req
+}
@@ -0,0 +1,220 @@
+package logstore
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	bufferFlushSize     = 32 * 1024              // 32KB - flush a job's buffer when exceeded
+	bufferFlushInterval = 200 * time.Millisecond // flush all buffers this often
+)
+
+// BufferedLogStore batches log chunks in memory before handing them to
+// another LogStore. Consecutive chunks on the same stream are merged, so a
+// chatty build turns into a few large writes (one INSERT or file write each)
+// instead of one per chunk. Buffers are flushed every 200ms, when a job's
+// buffer passes 32KB, before Finalize and GetLogs, and on Close.
+//
+// Merged chunks are timestamped by the underlying store when they're
+// flushed, so entry times are accurate to the flush interval.
+type BufferedLogStore struct {
+	LogStore // artifacts and anything else not buffered go straight through
+
+	log     *slog.Logger
+	mu      sync.Mutex // guards buffers, closed and every buffer's chunks/size
+	buffers map[string]*logBuffer
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// logBuffer holds a job's chunks that haven't been written yet.
+type logBuffer struct {
+	chunks []bufferedChunk
+	size   int
+
+	// writeMu is held while taking chunks and writing them, so concurrent
+	// flushes of the same job reach the underlying store in order.
+	writeMu sync.Mutex
+}
+
+type bufferedChunk struct {
+	stream string
+	data   []byte
+}
+
+// NewBufferedLogStore wraps store with write buffering.
+func NewBufferedLogStore(store LogStore, log *slog.Logger) *BufferedLogStore {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	s := &BufferedLogStore{
+		LogStore: store,
+		log:      log,
+		buffers:  make(map[string]*logBuffer),
+		done:     make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+
+	return s
+}
+
+// flushLoop periodically writes out every buffer.
+func (s *BufferedLogStore) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(bufferFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				s.log.Warn("failed to flush log buffers", "error", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// AppendChunk buffers data, writing the job's buffer once it's full.
+func (s *BufferedLogStore) AppendChunk(ctx context.Context, jobID, stream string, data []byte) error {
+	s.mu.Lock()
+	buf := s.buffers[jobID]
+	if buf == nil {
+		buf = &logBuffer{}
+		s.buffers[jobID] = buf
+	}
+	if n := len(buf.chunks); n > 0 && buf.chunks[n-1].stream == stream {
+		buf.chunks[n-1].data = append(buf.chunks[n-1].data, data...)
+	} else {
+		buf.chunks = append(buf.chunks, bufferedChunk{stream: stream, data: append([]byte(nil), data...)})
+	}
+	buf.size += len(data)
+	// Workers can still send chunks while the server shuts down; write
+	// those straight through (behind anything already buffered)
+	full := buf.size >= bufferFlushSize || s.closed
+	s.mu.Unlock()
+
+	if full {
+		return s.flushBuffer(ctx, jobID, buf)
+	}
+	return nil
+}
+
+// Flush writes every job's buffered chunks to the underlying store.
+func (s *BufferedLogStore) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	buffers := make(map[string]*logBuffer, len(s.buffers))
+	for jobID, buf := range s.buffers {
+		buffers[jobID] = buf
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for jobID, buf := range buffers {
+		if err := s.flushBuffer(ctx, jobID, buf); err != nil {
+			s.log.Warn("failed to flush job logs", "job_id", jobID, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// flushBuffer writes buf's chunks in order. Chunks that fail to write are
+// put back at the front of the buffer for the next attempt.
+func (s *BufferedLogStore) flushBuffer(ctx context.Context, jobID string, buf *logBuffer) error {
+	buf.writeMu.Lock()
+	defer buf.writeMu.Unlock()
+
+	s.mu.Lock()
+	chunks := buf.chunks
+	buf.chunks, buf.size = nil, 0
+	s.mu.Unlock()
+
+	for i, c := range chunks {
+		if err := s.LogStore.AppendChunk(ctx, jobID, c.stream, c.data); err != nil {
+			s.mu.Lock()
+			buf.chunks = append(append([]bufferedChunk(nil), chunks[i:]...), buf.chunks...)
+			for _, r := range chunks[i:] {
+				buf.size += len(r.data)
+			}
+			s.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// takeBuffer removes jobID's buffer so no more chunks are added to it.
+func (s *BufferedLogStore) takeBuffer(jobID string) *logBuffer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := s.buffers[jobID]
+	delete(s.buffers, jobID)
+	return buf
+}
+
+// Finalize writes the job's remaining chunks, then finalizes it in the
+// underlying store.
+func (s *BufferedLogStore) Finalize(ctx context.Context, jobID string) (int64, error) {
+	if buf := s.takeBuffer(jobID); buf != nil {
+		if err := s.flushBuffer(ctx, jobID, buf); err != nil {
+			return 0, err
+		}
+	}
+	return s.LogStore.Finalize(ctx, jobID)
+}
+
+// GetLogs writes the job's buffered chunks first so readers see everything
+// received so far.
+func (s *BufferedLogStore) GetLogs(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	buf := s.buffers[jobID]
+	s.mu.Unlock()
+
+	if buf != nil {
+		if err := s.flushBuffer(ctx, jobID, buf); err != nil {
+			return nil, err
+		}
+	}
+	return s.LogStore.GetLogs(ctx, jobID)
+}
+
+// Delete drops the job's buffered chunks and deletes its stored logs.
+func (s *BufferedLogStore) Delete(ctx context.Context, jobID string) error {
+	s.takeBuffer(jobID)
+	return s.LogStore.Delete(ctx, jobID)
+}
+
+// Close stops the flush loop, writes all buffered chunks and closes the
+// underlying store. Chunks appended after Close are written immediately.
+func (s *BufferedLogStore) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+
+	flushErr := s.Flush(context.Background())
+	if err := s.LogStore.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
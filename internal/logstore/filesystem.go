@@ -10,15 +10,18 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
 // FilesystemLogStore stores logs as files on disk.
-// Each job gets one file: {logDir}/{jobID}.log in NDJSON format.
+// Each job gets one file: {logDir}/{jobID}.log in NDJSON format, gzipped to
+// {jobID}.log.gz when the job finishes (unless CINCH_LOG_COMPRESSION=off).
 type FilesystemLogStore struct {
-	logDir string
-	log    *slog.Logger
+	logDir   string
+	log      *slog.Logger
+	compress bool
 
 	// File handles for active jobs
 	mu    sync.Mutex
@@ -26,20 +29,31 @@ type FilesystemLogStore struct {
 }
 
 // NewFilesystemLogStore creates a new filesystem-based log store.
+// Finished logs are gzipped unless CINCH_LOG_COMPRESSION is "off".
 func NewFilesystemLogStore(logDir string, log *slog.Logger) (*FilesystemLogStore, error) {
 	if log == nil {
 		log = slog.Default()
 	}
 
+	compress := true
+	switch v := os.Getenv("CINCH_LOG_COMPRESSION"); v {
+	case "", "on", "true", "1":
+	case "off", "false", "0":
+		compress = false
+	default:
+		return nil, fmt.Errorf("invalid CINCH_LOG_COMPRESSION %q (expected on or off)", v)
+	}
+
 	// Create log directory if it doesn't exist
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("create log directory: %w", err)
 	}
 
 	return &FilesystemLogStore{
-		logDir: logDir,
-		log:    log,
-		files:  make(map[string]*os.File),
+		logDir:   logDir,
+		log:      log,
+		compress: compress,
+		files:    make(map[string]*os.File),
 	}, nil
 }
 
@@ -106,7 +120,7 @@ func (s *FilesystemLogStore) getOrCreateFile(jobID string) (*os.File, error) {
 }
 
 // Finalize closes the file handle and compresses the log file.
-// Returns the final compressed size in bytes for storage tracking.
+// Returns the final on-disk size in bytes for storage tracking.
 func (s *FilesystemLogStore) Finalize(ctx context.Context, jobID string) (int64, error) {
 	s.mu.Lock()
 	if f, ok := s.files[jobID]; ok {
@@ -120,10 +134,22 @@ func (s *FilesystemLogStore) Finalize(ctx context.Context, jobID string) (int64,
 	}
 	s.mu.Unlock()
 
-	// Compress the log file (text logs compress ~10:1)
 	srcPath := filepath.Join(s.logDir, jobID+".log")
 	dstPath := filepath.Join(s.logDir, jobID+".log.gz")
 
+	if !s.compress {
+		info, err := os.Stat(srcPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return 0, nil // No logs written
+			}
+			return 0, fmt.Errorf("stat log file: %w", err)
+		}
+		return info.Size(), nil
+	}
+
+	// Compress the log file (text logs compress ~10:1)
+
 	// Read uncompressed content
 	raw, err := os.ReadFile(srcPath)
 	if err != nil {
@@ -162,7 +188,8 @@ func (s *FilesystemLogStore) Finalize(ctx context.Context, jobID string) (int64,
 }
 
 // GetLogs returns the log file as a streaming reader.
-// Tries compressed (.log.gz) first, then uncompressed (.log) for in-progress jobs.
+// Tries compressed (.log.gz) first, then uncompressed (.log) for in-progress
+// jobs and logs finalized with compression off.
 func (s *FilesystemLogStore) GetLogs(ctx context.Context, jobID string) (io.ReadCloser, error) {
 	// Try compressed file first (finalized jobs)
 	gzPath := filepath.Join(s.logDir, jobID+".log.gz")
@@ -220,9 +247,87 @@ func (s *FilesystemLogStore) Delete(ctx context.Context, jobID string) error {
 			return fmt.Errorf("remove log file: %w", err)
 		}
 	}
+	if err := os.RemoveAll(s.artifactDir(jobID)); err != nil {
+		return fmt.Errorf("remove artifacts: %w", err)
+	}
 	return nil
 }
 
+// artifactDir is where a job's artifacts live: {logDir}/artifacts/{jobID}.
+func (s *FilesystemLogStore) artifactDir(jobID string) string {
+	return filepath.Join(s.logDir, "artifacts", jobID)
+}
+
+// PutArtifact writes an artifact to {logDir}/artifacts/{jobID}/{name}.
+func (s *FilesystemLogStore) PutArtifact(ctx context.Context, jobID, name string, r io.Reader) (int64, error) {
+	if !ValidArtifactName(name) {
+		return 0, fmt.Errorf("invalid artifact name %q", name)
+	}
+	dir := s.artifactDir(jobID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("create artifact directory: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial artifact
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp*")
+	if err != nil {
+		return 0, fmt.Errorf("create artifact file: %w", err)
+	}
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, fmt.Errorf("write artifact: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return 0, fmt.Errorf("rename artifact: %w", err)
+	}
+	return n, nil
+}
+
+// GetArtifact opens a stored artifact.
+func (s *FilesystemLogStore) GetArtifact(ctx context.Context, jobID, name string) (io.ReadCloser, error) {
+	if !ValidArtifactName(name) {
+		return nil, ErrArtifactNotFound
+	}
+	f, err := os.Open(filepath.Join(s.artifactDir(jobID), name))
+	if os.IsNotExist(err) {
+		return nil, ErrArtifactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open artifact: %w", err)
+	}
+	return f, nil
+}
+
+// ListArtifacts lists the artifacts stored for a job.
+func (s *FilesystemLogStore) ListArtifacts(ctx context.Context, jobID string) ([]Artifact, error) {
+	entries, err := os.ReadDir(s.artifactDir(jobID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read artifact directory: %w", err)
+	}
+
+	var artifacts []Artifact
+	for _, e := range entries {
+		// Skip in-progress temp files
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{Name: e.Name(), SizeBytes: info.Size()})
+	}
+	return artifacts, nil
+}
+
 // Close closes all open file handles.
 func (s *FilesystemLogStore) Close() error {
 	s.mu.Lock()
@@ -4,10 +4,19 @@ package logstore
 
 import (
 	"context"
+	"errors"
 	"io"
+	"strings"
 	"time"
 )
 
+// ErrArtifactNotFound is returned by GetArtifact for a missing artifact.
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// ErrNoSignedURL is returned by GetSignedURL when a job's logs can't be read
+// directly from storage, e.g. because the job is still writing them.
+var ErrNoSignedURL = errors.New("no signed URL for job logs")
+
 // LogEntry represents a log line with metadata.
 type LogEntry struct {
 	Time   time.Time `json:"t"`
@@ -29,9 +38,42 @@ type LogStore interface {
 	// Returns newline-delimited JSON log entries.
 	GetLogs(ctx context.Context, jobID string) (io.ReadCloser, error)
 
-	// Delete removes all logs for a job (for retention cleanup).
+	// Delete removes all logs and artifacts for a job (for retention cleanup).
 	Delete(ctx context.Context, jobID string) error
 
+	// PutArtifact stores a named build artifact, replacing any existing one.
+	// Returns the stored size in bytes for storage tracking.
+	PutArtifact(ctx context.Context, jobID, name string, r io.Reader) (sizeBytes int64, err error)
+
+	// GetArtifact returns an artifact's contents, or ErrArtifactNotFound.
+	GetArtifact(ctx context.Context, jobID, name string) (io.ReadCloser, error)
+
+	// ListArtifacts returns a job's artifacts sorted by name.
+	ListArtifacts(ctx context.Context, jobID string) ([]Artifact, error)
+
 	// Close shuts down the log store (stops flush loop, etc).
 	Close() error
 }
+
+// URLSigner is implemented by log stores that can hand out short-lived URLs
+// for reading a finished job's logs straight from object storage, so large
+// logs don't have to be proxied through the server.
+type URLSigner interface {
+	// GetSignedURL returns a URL serving the job's logs as NDJSON, or
+	// ErrNoSignedURL if they aren't finalized yet.
+	GetSignedURL(ctx context.Context, jobID string) (string, error)
+}
+
+// Artifact describes a stored build artifact.
+type Artifact struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// ValidArtifactName reports whether name can be used as an artifact name:
+// a single, non-hidden path element, so it can't escape the job's artifact
+// directory or collide with a store's temp files.
+func ValidArtifactName(name string) bool {
+	return name != "" && len(name) <= 255 && !strings.HasPrefix(name, ".") &&
+		!strings.ContainsAny(name, "/\\\x00")
+}
@@ -199,6 +199,105 @@ func TestFilesystemLogStore_Compression(t *testing.T) {
 	}
 }
 
+func TestFilesystemLogStore_CompressionOff(t *testing.T) {
+	t.Setenv("CINCH_LOG_COMPRESSION", "off")
+	tmpDir := t.TempDir()
+
+	ls, err := logstore.NewFilesystemLogStore(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewFilesystemLogStore failed: %v", err)
+	}
+	defer ls.Close()
+
+	ctx := context.Background()
+	if err := ls.AppendChunk(ctx, "job-plain", "stdout", []byte("hello\n")); err != nil {
+		t.Fatalf("AppendChunk failed: %v", err)
+	}
+	size, err := ls.Finalize(ctx, "job-plain")
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(tmpDir, "job-plain.log"))
+	if err != nil {
+		t.Fatalf("uncompressed file should remain after finalize: %v", err)
+	}
+	if size != info.Size() {
+		t.Errorf("returned size %d doesn't match file size %d", size, info.Size())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "job-plain.log.gz")); !os.IsNotExist(err) {
+		t.Errorf("compressed file should not exist with compression off")
+	}
+
+	reader, err := ls.GetLogs(ctx, "job-plain")
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	defer reader.Close()
+	data, _ := io.ReadAll(reader)
+	if !strings.Contains(string(data), `"d":"hello\n"`) {
+		t.Errorf("unexpected log content: %s", data)
+	}
+
+	t.Setenv("CINCH_LOG_COMPRESSION", "maybe")
+	if _, err := logstore.NewFilesystemLogStore(tmpDir, nil); err == nil {
+		t.Error("expected error for invalid CINCH_LOG_COMPRESSION")
+	}
+}
+
+func TestFilesystemLogStore_Artifacts(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ls, err := logstore.NewFilesystemLogStore(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("NewFilesystemLogStore failed: %v", err)
+	}
+	defer ls.Close()
+
+	ctx := context.Background()
+	size, err := ls.PutArtifact(ctx, "job-art", "artifacts.tar.gz", strings.NewReader("tarball"))
+	if err != nil {
+		t.Fatalf("PutArtifact failed: %v", err)
+	}
+	if size != 7 {
+		t.Errorf("size = %d, want 7", size)
+	}
+	for _, name := range []string{"../escape", ".hidden", ""} {
+		if _, err := ls.PutArtifact(ctx, "job-art", name, strings.NewReader("x")); err == nil {
+			t.Errorf("PutArtifact(%q) should fail", name)
+		}
+	}
+
+	artifacts, err := ls.ListArtifacts(ctx, "job-art")
+	if err != nil {
+		t.Fatalf("ListArtifacts failed: %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Name != "artifacts.tar.gz" || artifacts[0].SizeBytes != 7 {
+		t.Errorf("artifacts = %+v, want artifacts.tar.gz (7 bytes)", artifacts)
+	}
+
+	reader, err := ls.GetArtifact(ctx, "job-art", "artifacts.tar.gz")
+	if err != nil {
+		t.Fatalf("GetArtifact failed: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "tarball" {
+		t.Errorf("artifact content = %q, want %q", data, "tarball")
+	}
+	if _, err := ls.GetArtifact(ctx, "job-art", "missing"); err != logstore.ErrArtifactNotFound {
+		t.Errorf("GetArtifact(missing) error = %v, want ErrArtifactNotFound", err)
+	}
+
+	// Delete removes artifacts along with logs
+	if err := ls.Delete(ctx, "job-art"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if artifacts, _ := ls.ListArtifacts(ctx, "job-art"); len(artifacts) != 0 {
+		t.Errorf("artifacts after Delete = %+v, want none", artifacts)
+	}
+}
+
 func TestLogEntry_JSON(t *testing.T) {
 	entry := logstore.LogEntry{
 		Time:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
@@ -234,3 +333,218 @@ func TestLogEntry_JSON(t *testing.T) {
 		t.Errorf("got %q, want %q", decoded.Data, "test output\n")
 	}
 }
+
+func TestMemoryLogStore(t *testing.T) {
+	ctx := context.Background()
+	ls := logstore.NewMemoryLogStore()
+	defer ls.Close()
+
+	// No logs yet: empty reader, not an error
+	reader, err := ls.GetLogs(ctx, "j_1")
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if len(data) != 0 {
+		t.Errorf("expected no logs, got %q", data)
+	}
+
+	_ = ls.AppendChunk(ctx, "j_1", "stdout", []byte("building\n"))
+	_ = ls.AppendChunk(ctx, "j_1", "stderr", []byte("warning\n"))
+
+	size, err := ls.Finalize(ctx, "j_1")
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if size == 0 {
+		t.Error("expected non-zero size")
+	}
+	if !ls.IsFinalized("j_1") {
+		t.Error("expected job to be finalized")
+	}
+
+	reader, err = ls.GetLogs(ctx, "j_1")
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	defer reader.Close()
+	data, _ = io.ReadAll(reader)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d entries, want 2", len(lines))
+	}
+	var entry logstore.LogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if entry.Stream != "stderr" || entry.Data != "warning\n" {
+		t.Errorf("entry = %+v, want stderr/warning", entry)
+	}
+
+	if err := ls.Delete(ctx, "j_1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	reader, _ = ls.GetLogs(ctx, "j_1")
+	data, _ = io.ReadAll(reader)
+	if len(data) != 0 {
+		t.Errorf("expected logs deleted, got %q", data)
+	}
+}
+
+// newLogJob opens a SQLite database at path with a repo and a running job
+// to attach logs to.
+func newLogJob(tb testing.TB, path string) (storage.Storage, string) {
+	tb.Helper()
+
+	store, err := storage.NewSQLite(path, "", "")
+	if err != nil {
+		tb.Fatalf("NewSQLite failed: %v", err)
+	}
+	tb.Cleanup(func() { store.Close() })
+
+	ctx := context.Background()
+	repo := &storage.Repo{
+		ID:            "r_1",
+		ForgeType:     storage.ForgeTypeGitHub,
+		Owner:         "test",
+		Name:          "test",
+		CloneURL:      "https://github.com/test/test.git",
+		WebhookSecret: "secret",
+		Build:         "make",
+		CreatedAt:     time.Now(),
+	}
+	if err := store.CreateRepo(ctx, repo); err != nil {
+		tb.Fatalf("CreateRepo failed: %v", err)
+	}
+	job := &storage.Job{
+		ID:        "j_1",
+		RepoID:    repo.ID,
+		Commit:    "abc123",
+		Branch:    "main",
+		Status:    storage.JobStatusRunning,
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateJob(ctx, job); err != nil {
+		tb.Fatalf("CreateJob failed: %v", err)
+	}
+	return store, job.ID
+}
+
+func TestBufferedLogStore(t *testing.T) {
+	store, jobID := newLogJob(t, ":memory:")
+	ctx := context.Background()
+
+	ls := logstore.NewBufferedLogStore(logstore.NewSQLiteLogStore(store), nil)
+
+	for _, c := range []struct{ stream, data string }{
+		{"stdout", "one\n"},
+		{"stdout", "two\n"},
+		{"stderr", "oops\n"},
+		{"stdout", "three\n"},
+	} {
+		if err := ls.AppendChunk(ctx, jobID, c.stream, []byte(c.data)); err != nil {
+			t.Fatalf("AppendChunk failed: %v", err)
+		}
+	}
+
+	// Reads see buffered chunks, with same-stream neighbours merged into
+	// a single row
+	r, err := ls.GetLogs(ctx, jobID)
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e logstore.LogEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		got = append(got, e.Stream+":"+e.Data)
+	}
+	want := []string{"stdout:one\ntwo\n", "stderr:oops\n", "stdout:three\n"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("logs = %q, want %q", got, want)
+	}
+
+	// A full buffer is written without waiting for the flush loop
+	big := strings.Repeat("x", 40*1024)
+	if err := ls.AppendChunk(ctx, jobID, "stdout", []byte(big)); err != nil {
+		t.Fatalf("AppendChunk failed: %v", err)
+	}
+	logs, _ := store.GetLogs(ctx, jobID)
+	if len(logs) != 4 || logs[3].Data != big {
+		t.Errorf("expected full buffer to be flushed, got %d rows", len(logs))
+	}
+
+	// Close writes whatever is still buffered
+	if err := ls.AppendChunk(ctx, jobID, "stdout", []byte("last\n")); err != nil {
+		t.Fatalf("AppendChunk failed: %v", err)
+	}
+	if err := ls.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	logs, _ = store.GetLogs(ctx, jobID)
+	if len(logs) != 5 || logs[4].Data != "last\n" {
+		t.Errorf("expected Close to flush the last chunk, got %d rows", len(logs))
+	}
+}
+
+func TestBufferedLogStore_FlushLoop(t *testing.T) {
+	store, jobID := newLogJob(t, ":memory:")
+	ctx := context.Background()
+
+	ls := logstore.NewBufferedLogStore(logstore.NewSQLiteLogStore(store), nil)
+	defer ls.Close()
+
+	if err := ls.AppendChunk(ctx, jobID, "stdout", []byte("hello\n")); err != nil {
+		t.Fatalf("AppendChunk failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		logs, _ := store.GetLogs(ctx, jobID)
+		if len(logs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffered chunk was never flushed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// BenchmarkSQLiteAppendChunk compares writing each chunk with its own
+// INSERT against batching them through BufferedLogStore.
+func BenchmarkSQLiteAppendChunk(b *testing.B) {
+	chunk := []byte("building package github.com/ehrlich-b/cinch/internal/server ...\n")
+
+	run := func(b *testing.B, buffered bool) {
+		store, jobID := newLogJob(b, filepath.Join(b.TempDir(), "cinch.db"))
+		ctx := context.Background()
+
+		var ls logstore.LogStore = logstore.NewSQLiteLogStore(store)
+		if buffered {
+			ls = logstore.NewBufferedLogStore(ls, nil)
+		}
+
+		b.SetBytes(int64(len(chunk)))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := ls.AppendChunk(ctx, jobID, "stdout", chunk); err != nil {
+				b.Fatalf("AppendChunk failed: %v", err)
+			}
+		}
+		if _, err := ls.Finalize(ctx, jobID); err != nil {
+			b.Fatalf("Finalize failed: %v", err)
+		}
+		b.StopTimer()
+		ls.Close()
+	}
+
+	b.Run("per-chunk", func(b *testing.B) { run(b, false) })
+	b.Run("buffered", func(b *testing.B) { run(b, true) })
+}
@@ -0,0 +1,147 @@
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryLogStore keeps logs in memory. Intended for tests: nothing touches
+// disk or R2, and logs are gone when the process exits.
+type MemoryLogStore struct {
+	mu        sync.Mutex
+	logs      map[string]*bytes.Buffer // jobID -> NDJSON entries
+	finalized map[string]bool
+	artifacts map[string]map[string][]byte // jobID -> name -> contents
+}
+
+// NewMemoryLogStore creates an empty in-memory log store.
+func NewMemoryLogStore() *MemoryLogStore {
+	return &MemoryLogStore{
+		logs:      make(map[string]*bytes.Buffer),
+		finalized: make(map[string]bool),
+		artifacts: make(map[string]map[string][]byte),
+	}
+}
+
+// AppendChunk appends a log entry for the job.
+func (s *MemoryLogStore) AppendChunk(ctx context.Context, jobID, stream string, data []byte) error {
+	line, err := json.Marshal(LogEntry{
+		Time:   time.Now(),
+		Stream: stream,
+		Data:   string(data),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal log entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, ok := s.logs[jobID]
+	if !ok {
+		buf = &bytes.Buffer{}
+		s.logs[jobID] = buf
+	}
+	buf.Write(line)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// Finalize marks the job's logs complete and returns their (uncompressed) size.
+func (s *MemoryLogStore) Finalize(ctx context.Context, jobID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.finalized[jobID] = true
+	if buf, ok := s.logs[jobID]; ok {
+		return int64(buf.Len()), nil
+	}
+	return 0, nil
+}
+
+// IsFinalized reports whether Finalize has been called for the job.
+func (s *MemoryLogStore) IsFinalized(jobID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finalized[jobID]
+}
+
+// GetLogs returns a snapshot of the job's logs as NDJSON.
+// Returns an empty reader if the job has no logs.
+func (s *MemoryLogStore) GetLogs(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data []byte
+	if buf, ok := s.logs[jobID]; ok {
+		data = bytes.Clone(buf.Bytes())
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete removes all logs for a job.
+func (s *MemoryLogStore) Delete(ctx context.Context, jobID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.logs, jobID)
+	delete(s.finalized, jobID)
+	delete(s.artifacts, jobID)
+	return nil
+}
+
+// PutArtifact stores an artifact for the job.
+func (s *MemoryLogStore) PutArtifact(ctx context.Context, jobID, name string, r io.Reader) (int64, error) {
+	if !ValidArtifactName(name) {
+		return 0, fmt.Errorf("invalid artifact name %q", name)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("read artifact: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.artifacts[jobID] == nil {
+		s.artifacts[jobID] = make(map[string][]byte)
+	}
+	s.artifacts[jobID][name] = data
+	return int64(len(data)), nil
+}
+
+// GetArtifact returns a copy of a stored artifact.
+func (s *MemoryLogStore) GetArtifact(ctx context.Context, jobID, name string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.artifacts[jobID][name]
+	if !ok {
+		return nil, ErrArtifactNotFound
+	}
+	return io.NopCloser(bytes.NewReader(bytes.Clone(data))), nil
+}
+
+// ListArtifacts lists the artifacts stored for a job.
+func (s *MemoryLogStore) ListArtifacts(ctx context.Context, jobID string) ([]Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var artifacts []Artifact
+	for name, data := range s.artifacts[jobID] {
+		artifacts = append(artifacts, Artifact{Name: name, SizeBytes: int64(len(data))})
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Name < artifacts[j].Name })
+	return artifacts, nil
+}
+
+// Close is a no-op.
+func (s *MemoryLogStore) Close() error {
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -17,12 +18,15 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 const (
 	flushSize     = 256 * 1024       // 256KB - flush buffer when exceeded
 	flushInterval = 30 * time.Second // flush stale buffers every 30s
 	flushLoopTick = 5 * time.Second  // check for stale buffers every 5s
+
+	signedURLExpiry = 15 * time.Minute // lifetime of GetSignedURL links
 )
 
 // R2Config contains configuration for R2 storage.
@@ -391,6 +395,33 @@ func (s *R2LogStore) GetLogs(ctx context.Context, jobID string) (io.ReadCloser,
 	return io.NopCloser(&content), nil
 }
 
+// GetSignedURL returns a pre-signed URL for a finished job's final.log. It's
+// stored gzip-encoded, which browsers decode transparently. Fetching it from
+// a browser on another origin needs a CORS rule on the bucket.
+func (s *R2LogStore) GetSignedURL(ctx context.Context, jobID string) (string, error) {
+	finalKey := fmt.Sprintf("logs/%s/final.log", jobID)
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(finalKey),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return "", ErrNoSignedURL
+		}
+		return "", fmt.Errorf("head final.log: %w", err)
+	}
+
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(finalKey),
+	}, s3.WithPresignExpires(signedURLExpiry))
+	if err != nil {
+		return "", fmt.Errorf("presign final.log: %w", err)
+	}
+	return req.URL, nil
+}
+
 // Delete removes all logs for a job.
 func (s *R2LogStore) Delete(ctx context.Context, jobID string) error {
 	// Remove from buffers
@@ -422,6 +453,90 @@ func (s *R2LogStore) Delete(ctx context.Context, jobID string) error {
 	return nil
 }
 
+// artifactKey returns the object key for an artifact. Artifacts live under
+// the job's logs/ prefix so Delete removes them with the logs.
+func artifactKey(jobID, name string) string {
+	return fmt.Sprintf("logs/%s/artifacts/%s", jobID, name)
+}
+
+// PutArtifact uploads an artifact to logs/{jobID}/artifacts/{name}.
+func (s *R2LogStore) PutArtifact(ctx context.Context, jobID, name string, r io.Reader) (int64, error) {
+	if !ValidArtifactName(name) {
+		return 0, fmt.Errorf("invalid artifact name %q", name)
+	}
+
+	// The S3 client needs a seekable body to sign the upload
+	body, ok := r.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return 0, fmt.Errorf("read artifact: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	size, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("seek artifact: %w", err)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek artifact: %w", err)
+	}
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(artifactKey(jobID, name)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String("application/octet-stream"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("upload artifact: %w", err)
+	}
+	return size, nil
+}
+
+// GetArtifact downloads an artifact.
+func (s *R2LogStore) GetArtifact(ctx context.Context, jobID, name string) (io.ReadCloser, error) {
+	if !ValidArtifactName(name) {
+		return nil, ErrArtifactNotFound
+	}
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(artifactKey(jobID, name)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrArtifactNotFound
+		}
+		return nil, fmt.Errorf("get artifact: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// ListArtifacts lists the artifacts stored for a job.
+func (s *R2LogStore) ListArtifacts(ctx context.Context, jobID string) ([]Artifact, error) {
+	prefix := fmt.Sprintf("logs/%s/artifacts/", jobID)
+	listResp, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list artifacts: %w", err)
+	}
+
+	var artifacts []Artifact
+	for _, obj := range listResp.Contents {
+		a := Artifact{Name: strings.TrimPrefix(aws.ToString(obj.Key), prefix)}
+		if obj.Size != nil {
+			a.SizeBytes = *obj.Size
+		}
+		artifacts = append(artifacts, a)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Name < artifacts[j].Name })
+	return artifacts, nil
+}
+
 // Close shuts down the log store.
 func (s *R2LogStore) Close() error {
 	close(s.done)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 
 	"github.com/ehrlich-b/cinch/internal/storage"
@@ -61,6 +62,22 @@ func (s *SQLiteLogStore) Delete(ctx context.Context, jobID string) error {
 	return nil
 }
 
+// PutArtifact is not supported: the SQLite store keeps logs in the database
+// and has nowhere to put build outputs. Use the filesystem or R2 store.
+func (s *SQLiteLogStore) PutArtifact(ctx context.Context, jobID, name string, r io.Reader) (int64, error) {
+	return 0, errors.New("artifacts are not supported by the SQLite log store")
+}
+
+// GetArtifact always returns ErrArtifactNotFound.
+func (s *SQLiteLogStore) GetArtifact(ctx context.Context, jobID, name string) (io.ReadCloser, error) {
+	return nil, ErrArtifactNotFound
+}
+
+// ListArtifacts always returns no artifacts.
+func (s *SQLiteLogStore) ListArtifacts(ctx context.Context, jobID string) ([]Artifact, error) {
+	return nil, nil
+}
+
 // Close is a no-op for SQLite.
 func (s *SQLiteLogStore) Close() error {
 	return nil
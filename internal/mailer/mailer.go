@@ -0,0 +1,125 @@
+// Package mailer sends plain-text email over SMTP.
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/notify"
+)
+
+// Config is an SMTP relay to send through.
+type Config struct {
+	Host     string
+	Port     int    // Default 587
+	Username string // Optional; enables PLAIN auth
+	Password string
+	From     string // Sender address, e.g. "Cinch <ci@example.com>"
+}
+
+// Configured reports whether enough is set to send mail.
+func (c Config) Configured() bool {
+	return c.Host != "" && c.From != ""
+}
+
+// Mailer sends messages through an SMTP relay.
+type Mailer struct {
+	cfg      Config
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// New returns a Mailer for cfg.
+func New(cfg Config) *Mailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &Mailer{cfg: cfg, sendMail: smtp.SendMail}
+}
+
+// Send mails a plain-text message to a single recipient. The connection is
+// upgraded with STARTTLS when the server offers it.
+func (m *Mailer) Send(to, subject, body string) error {
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	msg := Message(from, rcpt, subject, body, time.Now())
+	if err := m.sendMail(addr, auth, from.Address, []string{rcpt.Address}, msg); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// Message renders an RFC 5322 plain-text message with CRLF line endings.
+func Message(from, to *mail.Address, subject, body string, date time.Time) []byte {
+	// Newlines in a header would let the subject inject headers
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
+
+// JobFailed returns the subject and body of the email sent to a commit's
+// author when its job fails.
+func JobFailed(ev notify.Event) (subject, body string) {
+	ref := ev.Branch
+	if ev.Tag != "" {
+		ref = ev.Tag
+	}
+	commit := ev.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+
+	subject = fmt.Sprintf("[cinch] %s: build %s on %s (%s)", ev.Repo, ev.Status, ref, commit)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The build of %s on %s %s.\n\n", ev.Repo, ref, verb(ev.Status))
+	fmt.Fprintf(&b, "Commit: %s\n", ev.Commit)
+	if ev.DurationMs > 0 {
+		fmt.Fprintf(&b, "Duration: %s\n", (time.Duration(ev.DurationMs) * time.Millisecond).Round(time.Second))
+	}
+	if ev.JobURL != "" {
+		fmt.Fprintf(&b, "\nLogs: %s\n", ev.JobURL)
+	}
+	b.WriteString("\nYou're receiving this because you authored the commit and the repo has failure emails turned on.\n")
+	return subject, b.String()
+}
+
+func verb(status string) string {
+	if status == "error" {
+		return "could not run"
+	}
+	return "failed"
+}
@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/notify"
+)
+
+func TestMessage(t *testing.T) {
+	from := &mail.Address{Name: "Cinch", Address: "ci@example.com"}
+	to := &mail.Address{Address: "dev@example.com"}
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	msg := string(Message(from, to, "build failed\r\nBcc: evil@example.com", "line one\nline two\n", date))
+
+	for _, want := range []string{
+		"From: \"Cinch\" <ci@example.com>\r\n",
+		"To: <dev@example.com>\r\n",
+		"Subject: build failed Bcc: evil@example.com\r\n",
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Error("subject newline injected a header")
+	}
+
+	// Non-ASCII subjects are Q-encoded
+	msg = string(Message(from, to, "✗ failed", "", date))
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("non-ASCII subject not encoded:\n%s", msg)
+	}
+}
+
+func TestJobFailed(t *testing.T) {
+	subject, body := JobFailed(notify.Event{
+		JobID:      "j_1",
+		Repo:       "owner/repo",
+		Commit:     "abc1234def5678",
+		Branch:     "main",
+		Status:     "failed",
+		DurationMs: 90_000,
+		JobURL:     "https://cinch.example.com/jobs/j_1",
+	})
+
+	if subject != "[cinch] owner/repo: build failed on main (abc1234)" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{
+		"The build of owner/repo on main failed.",
+		"Commit: abc1234def5678",
+		"Duration: 1m30s",
+		"Logs: https://cinch.example.com/jobs/j_1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+
+	subject, body = JobFailed(notify.Event{Repo: "owner/repo", Commit: "abc", Tag: "v1.0.0", Status: "error"})
+	if subject != "[cinch] owner/repo: build error on v1.0.0 (abc)" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "on v1.0.0 could not run.") || strings.Contains(body, "Logs:") {
+		t.Errorf("error body:\n%s", body)
+	}
+}
+
+func TestSend(t *testing.T) {
+	m := New(Config{Host: "smtp.example.com", Username: "user", Password: "pass", From: "Cinch <ci@example.com>"})
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	m.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo = addr, a, from, to
+		return nil
+	}
+
+	if err := m.Send("Dev <dev@example.com>", "subject", "body"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" {
+		t.Errorf("addr = %q, want default port 587", gotAddr)
+	}
+	if gotAuth == nil {
+		t.Error("expected PLAIN auth with a username set")
+	}
+	if gotFrom != "ci@example.com" || len(gotTo) != 1 || gotTo[0] != "dev@example.com" {
+		t.Errorf("envelope = %q -> %v", gotFrom, gotTo)
+	}
+
+	if err := m.Send("not an address", "subject", "body"); err == nil {
+		t.Error("expected error for invalid recipient")
+	}
+}
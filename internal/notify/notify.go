@@ -0,0 +1,223 @@
+// Package notify posts job results to chat and webhook endpoints.
+//
+// The payload format is picked from the URL: Slack incoming webhooks and
+// Discord webhooks get a message formatted for that service, anything else
+// gets the Event as JSON.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for notification targets inside the
+// server's network: loopback, private, link-local and similar addresses.
+var ErrBlockedAddress = errors.New("notification target is an internal address")
+
+// Event describes a finished job.
+type Event struct {
+	JobID      string `json:"job_id"`
+	Repo       string `json:"repo"` // owner/name
+	Commit     string `json:"commit"`
+	Branch     string `json:"branch,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	JobURL     string `json:"job_url,omitempty"`
+}
+
+// Notifier delivers events, retrying failed deliveries.
+type Notifier struct {
+	Client     *http.Client
+	Attempts   int           // Deliveries tried per URL (default 3)
+	RetryDelay time.Duration // Wait before the first retry, doubled after each (default 2s)
+}
+
+// New returns a Notifier with default settings. Its client refuses to
+// connect to internal addresses, checked after DNS resolution so a name
+// can't be re-pointed at one after the URL was accepted.
+func New() *Notifier {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: dialControl}
+	return &Notifier{
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+		},
+		Attempts:   3,
+		RetryDelay: 2 * time.Second,
+	}
+}
+
+// CheckHost rejects a URL host that names an internal address: an IP
+// literal that isn't public, localhost, or a .internal name. Other names
+// are checked when a notification is sent.
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return ErrBlockedAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !publicAddr(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// dialControl refuses connections to internal addresses.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil || !publicAddr(ap.Addr()) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// nonPublicPrefixes are internal ranges netip.Addr's predicates don't
+// cover: "this network" (Linux routes it to the local host) and
+// carrier-grade NAT space.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// publicAddr reports whether ip is routable outside the server's network.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Send posts ev to target. Network errors, 429s and 5xx responses are
+// retried; other failures are returned immediately.
+func (n *Notifier) Send(ctx context.Context, target string, ev Event) error {
+	body, err := payload(target, ev)
+	if err != nil {
+		return err
+	}
+
+	attempts := max(n.Attempts, 1)
+	delay := n.RetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, target, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (n *Notifier) post(ctx context.Context, target string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cinch-notify")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return !errors.Is(err, ErrBlockedAddress), fmt.Errorf("post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("notification endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// payload renders ev in the format target's service expects.
+func payload(target string, ev Event) ([]byte, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification URL: %w", err)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "hooks.slack.com":
+		return json.Marshal(map[string]string{"text": Summary(ev)})
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return json.Marshal(map[string]string{"content": Summary(ev)})
+	default:
+		return json.Marshal(ev)
+	}
+}
+
+// Summary renders ev as a one-line chat message, e.g.
+// "✗ owner/repo main (abc1234) failed in 1m30s - https://...".
+func Summary(ev Event) string {
+	symbol := "•"
+	switch ev.Status {
+	case "success":
+		symbol = "✓"
+	case "failed":
+		symbol = "✗"
+	case "error":
+		symbol = "!"
+	}
+
+	ref := ev.Branch
+	if ev.Tag != "" {
+		ref = ev.Tag
+	}
+	commit := ev.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", symbol, ev.Repo)
+	if ref != "" {
+		fmt.Fprintf(&b, " %s", ref)
+	}
+	if commit != "" {
+		fmt.Fprintf(&b, " (%s)", commit)
+	}
+	fmt.Fprintf(&b, " %s", ev.Status)
+	if ev.DurationMs > 0 {
+		d := time.Duration(ev.DurationMs) * time.Millisecond
+		fmt.Fprintf(&b, " in %s", d.Round(time.Second))
+	}
+	if ev.JobURL != "" {
+		fmt.Fprintf(&b, " - %s", ev.JobURL)
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testEvent = Event{
+	JobID:      "j_1",
+	Repo:       "owner/repo",
+	Commit:     "abc1234def5678",
+	Branch:     "main",
+	Status:     "failed",
+	DurationMs: 90_000,
+	JobURL:     "https://cinch.example.com/jobs/j_1",
+}
+
+func TestSendRetriesThenDelivers(t *testing.T) {
+	var calls atomic.Int32
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "try again", http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("generic payload is not an Event: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	n := &Notifier{Client: srv.Client(), Attempts: 3, RetryDelay: time.Millisecond}
+	if err := n.Send(context.Background(), srv.URL, testEvent); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	if got != testEvent {
+		t.Errorf("delivered %+v, want %+v", got, testEvent)
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	n := &Notifier{Client: srv.Client(), Attempts: 3, RetryDelay: time.Millisecond}
+	if err := n.Send(context.Background(), srv.URL, testEvent); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Send error = %v, want 404", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1 (4xx isn't retried)", calls.Load())
+	}
+}
+
+func TestPayloadFormats(t *testing.T) {
+	summary := "✗ owner/repo main (abc1234) failed in 1m30s - https://cinch.example.com/jobs/j_1"
+	tests := []struct {
+		url  string
+		key  string
+		want string
+	}{
+		{"https://hooks.slack.com/services/T0/B0/xyz", "text", summary},
+		{"https://discord.com/api/webhooks/1/abc", "content", summary},
+		{"https://example.com/hook", "status", "failed"},
+	}
+	for _, tt := range tests {
+		body, err := payload(tt.url, testEvent)
+		if err != nil {
+			t.Fatalf("payload(%s) failed: %v", tt.url, err)
+		}
+		var m map[string]any
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatalf("payload(%s) is not JSON: %v", tt.url, err)
+		}
+		if m[tt.key] != tt.want {
+			t.Errorf("payload(%s)[%s] = %v, want %q", tt.url, tt.key, m[tt.key], tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		blocked bool
+	}{
+		{"hooks.slack.com", false},
+		{"203.0.113.7", false},
+		{"2606:4700::1111", false},
+		{"localhost", true},
+		{"api.localhost", true},
+		{"metadata.google.internal", true},
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"192.168.0.1", true},
+		{"169.254.169.254", true},
+		{"100.100.100.200", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+	}
+	for _, tt := range tests {
+		if err := CheckHost(tt.host); (err != nil) != tt.blocked {
+			t.Errorf("CheckHost(%q) = %v, want blocked=%v", tt.host, err, tt.blocked)
+		}
+	}
+}
+
+func TestSendRefusesInternalAddress(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	// A name that passed CheckHost can still resolve to loopback; the dialer
+	// catches it, and it isn't retried
+	n := New()
+	n.RetryDelay = time.Millisecond
+	target := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	if err := n.Send(context.Background(), target, testEvent); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Send error = %v, want ErrBlockedAddress", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("internal server received %d requests", calls.Load())
+	}
+}
@@ -21,15 +21,16 @@ const (
 
 // Message types for worker → server communication
 const (
-	TypeRegister     = "REGISTER"
-	TypeJobAck       = "JOB_ACK"
-	TypeJobReject    = "JOB_REJECT"
-	TypeLogChunk     = "LOG_CHUNK"
-	TypeJobStarted   = "JOB_STARTED"
-	TypeJobComplete  = "JOB_COMPLETE"
-	TypeJobError     = "JOB_ERROR"
-	TypePing         = "PING"
-	TypeStatusUpdate = "STATUS_UPDATE"
+	TypeRegister      = "REGISTER"
+	TypeJobAck        = "JOB_ACK"
+	TypeJobReject     = "JOB_REJECT"
+	TypeLogChunk      = "LOG_CHUNK"
+	TypeJobStarted    = "JOB_STARTED"
+	TypeJobComplete   = "JOB_COMPLETE"
+	TypeJobError      = "JOB_ERROR"
+	TypePing          = "PING"
+	TypeStatusUpdate  = "STATUS_UPDATE"
+	TypeArtifactChunk = "ARTIFACT_CHUNK"
 )
 
 // Message types for relay communication (self-hosted servers ↔ cinch.sh)
@@ -121,6 +122,25 @@ type JobRepo struct {
 	PRNumber   int    `json:"pr_number,omitempty"`
 }
 
+// Job events, as exposed to builds in CINCH_EVENT.
+const (
+	EventPush = "push"
+	EventPR   = "pr"
+	EventTag  = "tag"
+)
+
+// Event returns the job's event: EventTag, EventPR or EventPush.
+func (r JobRepo) Event() string {
+	switch {
+	case r.Tag != "":
+		return EventTag
+	case r.IsPR:
+		return EventPR
+	default:
+		return EventPush
+	}
+}
+
 // JobConfig contains the command and execution config.
 type JobConfig struct {
 	Command string            `json:"command"`
@@ -210,6 +230,19 @@ type LogChunk struct {
 	Data      string `json:"data"`
 }
 
+// MaxArtifactSize is the largest artifact the server accepts.
+const MaxArtifactSize = 500 << 20 // 500MB
+
+// ArtifactChunk carries part of a build artifact. Artifacts are split into
+// chunks that fit under the WebSocket message limit; the server stores the
+// artifact once it receives the chunk with Final set.
+type ArtifactChunk struct {
+	JobID string `json:"job_id"`
+	Name  string `json:"name"`
+	Data  []byte `json:"data"` // base64 in JSON
+	Final bool   `json:"final,omitempty"`
+}
+
 // NewLogChunk creates a LogChunk with current timestamp.
 func NewLogChunk(jobID, stream, data string) LogChunk {
 	return LogChunk{
@@ -240,6 +273,7 @@ type JobComplete struct {
 	ExitCode   int    `json:"exit_code"`
 	DurationMs int64  `json:"duration_ms"`
 	Timestamp  int64  `json:"timestamp"`
+	TimedOut   bool   `json:"timed_out,omitempty"` // Killed for exceeding the job timeout
 }
 
 // NewJobComplete creates a JobComplete with current timestamp.
@@ -257,12 +291,28 @@ type JobError struct {
 	JobID string `json:"job_id"`
 	Error string `json:"error"`
 	Phase string `json:"phase"` // "clone", "setup", "execute", "cleanup"
+
+	// Retryable marks transient infrastructure failures (clone, image pull)
+	// that may succeed on another attempt. Errors in the repo's own setup
+	// (bad workdir, Dockerfile build) aren't retryable.
+	Retryable bool `json:"retryable,omitempty"`
 }
 
 // Ping is a heartbeat from worker.
 type Ping struct {
-	Timestamp  int64    `json:"timestamp"`
-	ActiveJobs []string `json:"active_jobs,omitempty"`
+	Timestamp  int64       `json:"timestamp"`
+	ActiveJobs []string    `json:"active_jobs,omitempty"`
+	Load       *WorkerLoad `json:"load,omitempty"` // Absent from older workers
+}
+
+// WorkerLoad is a snapshot of a worker's host resources and job slots.
+// Metrics the worker's platform can't report are zero.
+type WorkerLoad struct {
+	CPUs         int     `json:"cpus,omitempty"`
+	LoadAvg1     float64 `json:"load_avg_1,omitempty"` // 1-minute load average
+	MemFreeBytes uint64  `json:"mem_free_bytes,omitempty"`
+	SlotsUsed    int     `json:"slots_used"`
+	SlotsTotal   int     `json:"slots_total"`
 }
 
 // NewPing creates a Ping with current timestamp.
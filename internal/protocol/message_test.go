@@ -240,6 +240,26 @@ func TestNewPing(t *testing.T) {
 	}
 }
 
+func TestPingLoadOptional(t *testing.T) {
+	// Older workers send no load; it must decode as nil, not a zero snapshot
+	ping, err := DecodePayload[Ping]([]byte(`{"timestamp":1,"active_jobs":["j_1"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ping.Load != nil {
+		t.Errorf("Load = %+v, want nil", ping.Load)
+	}
+
+	data, _ := json.Marshal(Ping{Timestamp: 1, Load: &WorkerLoad{CPUs: 8, LoadAvg1: 1.5, SlotsTotal: 2}})
+	ping, err = DecodePayload[Ping](data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ping.Load == nil || ping.Load.CPUs != 8 || ping.Load.LoadAvg1 != 1.5 || ping.Load.SlotsTotal != 2 {
+		t.Errorf("Load = %+v", ping.Load)
+	}
+}
+
 func TestMessageFormat(t *testing.T) {
 	// Verify the wire format matches the spec
 	data, _ := Encode(TypeAuthOK, AuthOK{
@@ -267,3 +287,19 @@ func TestMessageFormat(t *testing.T) {
 		t.Errorf("server_version = %v, want %q", payload["server_version"], "0.1.0")
 	}
 }
+
+func TestJobRepoEvent(t *testing.T) {
+	tests := []struct {
+		repo JobRepo
+		want string
+	}{
+		{JobRepo{Branch: "main"}, EventPush},
+		{JobRepo{Branch: "feature", IsPR: true, PRNumber: 3}, EventPR},
+		{JobRepo{Tag: "v1.0.0"}, EventTag},
+	}
+	for _, tt := range tests {
+		if got := tt.repo.Event(); got != tt.want {
+			t.Errorf("Event(%+v) = %q, want %q", tt.repo, got, tt.want)
+		}
+	}
+}
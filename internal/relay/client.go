@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
@@ -27,8 +28,14 @@ const (
 	// pingPeriod is how often to send pings.
 	pingPeriod = 30 * time.Second
 
-	// reconnectDelay is how long to wait before reconnecting.
-	reconnectDelay = 5 * time.Second
+	// Reconnect backoff: doubles from minReconnectDelay up to MaxBackoff,
+	// with ±20% jitter.
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 60 * time.Second
+
+	// stableConnection is how long a connection must stay up before the
+	// backoff resets.
+	stableConnection = 30 * time.Second
 )
 
 // Client connects a self-hosted server to cinch.sh for webhook relay.
@@ -39,6 +46,11 @@ type Client struct {
 	log        *slog.Logger
 	httpClient *http.Client
 
+	// MinBackoff and MaxBackoff bound the reconnect delay. NewClient sets
+	// them to 1s and 60s; tests can shrink them before calling Run.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
 	mu              sync.Mutex
 	conn            *websocket.Conn
 	relayID         string
@@ -57,6 +69,8 @@ func NewClient(relayURL, token, localAddr string, log *slog.Logger) *Client {
 		localAddr:  localAddr,
 		log:        log,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
+		MinBackoff: minReconnectDelay,
+		MaxBackoff: maxReconnectDelay,
 		done:       make(chan struct{}),
 	}
 }
@@ -68,35 +82,87 @@ func (c *Client) RelayURL() string {
 	return c.relayWebhookURL
 }
 
-// Run starts the relay client and reconnects on failure.
+// Run starts the relay client and reconnects on failure with exponential
+// backoff. The backoff resets once a connection has stayed up for 30s.
 func (c *Client) Run(ctx context.Context) error {
+	delay := c.MinBackoff
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-c.done:
+			return nil
 		default:
 		}
 
 		if err := c.connect(ctx); err != nil {
-			c.log.Warn("relay connection failed, reconnecting...", "error", err)
-			select {
-			case <-ctx.Done():
+			c.closeConn()
+			wait := jitter(delay)
+			c.log.Warn("relay connection failed, reconnecting...", "error", err, "delay", wait)
+			if !c.sleep(ctx, wait) {
 				return ctx.Err()
-			case <-time.After(reconnectDelay):
 			}
+			delay = c.nextBackoff(delay)
 			continue
 		}
 
+		connected := time.Now()
 		c.readPump(ctx)
+		c.closeConn()
 
-		// Clean up
-		c.mu.Lock()
-		if c.conn != nil {
-			c.conn.Close()
-			c.conn = nil
+		if time.Since(connected) >= stableConnection {
+			delay = c.MinBackoff
 		}
-		c.mu.Unlock()
+		wait := jitter(delay)
+		c.log.Info("relay disconnected, reconnecting...", "delay", wait)
+		if !c.sleep(ctx, wait) {
+			return ctx.Err()
+		}
+		delay = c.nextBackoff(delay)
+	}
+}
+
+// nextBackoff doubles delay, capped at MaxBackoff.
+func (c *Client) nextBackoff(delay time.Duration) time.Duration {
+	delay *= 2
+	if delay > c.MaxBackoff {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
+
+// sleep waits for d, returning false if ctx is cancelled or the client stops.
+func (c *Client) sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-c.done:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// closeConn closes and clears the current connection, if any.
+func (c *Client) closeConn() {
+	c.mu.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.mu.Unlock()
+}
+
+// jitter spreads d by ±20% so relays don't reconnect in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	spread := int64(d) * 2 / 5
+	if spread == 0 {
+		return d
 	}
+	return d - time.Duration(spread/2) + time.Duration(rand.Int63n(spread+1))
 }
 
 // Stop gracefully stops the client.
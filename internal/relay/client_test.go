@@ -0,0 +1,68 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJitterBounds(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		d := jitter(10 * time.Second)
+		if d < 8*time.Second || d > 12*time.Second {
+			t.Fatalf("jitter(10s) = %v, want within ±20%%", d)
+		}
+	}
+}
+
+func TestNextBackoffCapped(t *testing.T) {
+	c := NewClient("ws://example.invalid", "token", "http://localhost:8080", nil)
+
+	delay := c.MinBackoff
+	var got []time.Duration
+	for i := 0; i < 8; i++ {
+		delay = c.nextBackoff(delay)
+		got = append(got, delay)
+	}
+
+	want := []time.Duration{2, 4, 8, 16, 32, 60, 60, 60}
+	for i, w := range want {
+		if got[i] != w*time.Second {
+			t.Errorf("step %d: got %v, want %v", i, got[i], w*time.Second)
+		}
+	}
+}
+
+func TestRunRetriesWithBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	c := NewClient(wsURL, "token", "http://localhost:8080", nil)
+	c.MinBackoff = time.Millisecond
+	c.MaxBackoff = 5 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := c.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Run returned %v, want deadline exceeded", err)
+	}
+
+	// With a 5ms cap there is room for many attempts, but never a tight loop
+	n := attempts.Load()
+	if n < 5 {
+		t.Errorf("expected at least 5 reconnect attempts, got %d", n)
+	}
+	if n > 200 {
+		t.Errorf("expected backoff between attempts, got %d attempts in 200ms", n)
+	}
+}
@@ -2,19 +2,29 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ehrlich-b/cinch/internal/forge"
 	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/notify"
+	"github.com/ehrlich-b/cinch/internal/protocol"
 	"github.com/ehrlich-b/cinch/internal/storage"
 	"golang.org/x/crypto/sha3"
 )
@@ -26,6 +36,7 @@ type OrgTokens struct {
 	GitHub  string // CINCH_GITHUB_TOKEN
 	GitLab  string // CINCH_GITLAB_TOKEN
 	Forgejo string // CINCH_FORGEJO_TOKEN
+	Azure   string // CINCH_AZURE_TOKEN
 	BaseURL string // Server base URL for webhook callbacks
 }
 
@@ -39,7 +50,11 @@ type APIHandler struct {
 	githubApp  *GitHubAppHandler
 	wsHandler  *WSHandler
 	orgTokens  *OrgTokens
+	webhooks   *WebhookHandler
 	log        *slog.Logger
+
+	statsMu    sync.Mutex
+	statsCache map[string]cachedStats // viewer user ID ("" = anonymous) -> stats
 }
 
 // NewAPIHandler creates a new API handler.
@@ -80,6 +95,11 @@ func (h *APIHandler) SetOrgTokens(tokens *OrgTokens) {
 	h.orgTokens = tokens
 }
 
+// SetWebhookHandler sets the webhook handler for replaying stored deliveries.
+func (h *APIHandler) SetWebhookHandler(wh *WebhookHandler) {
+	h.webhooks = wh
+}
+
 // ServeHTTP routes API requests.
 func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api")
@@ -90,6 +110,28 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Jobs
 	case path == "/jobs" && r.Method == http.MethodGet:
 		h.listJobs(w, r)
+	// Artifact routes come first: an artifact name could end in /logs or /run
+	case strings.HasPrefix(path, "/jobs/") && strings.HasSuffix(path, "/artifacts"):
+		jobID := strings.TrimSuffix(strings.TrimPrefix(path, "/jobs/"), "/artifacts")
+		if r.Method == http.MethodGet {
+			h.listJobArtifacts(w, r, jobID)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case strings.HasPrefix(path, "/jobs/") && strings.Contains(path, "/artifacts/"):
+		jobID, name, _ := strings.Cut(strings.TrimPrefix(path, "/jobs/"), "/artifacts/")
+		if r.Method == http.MethodGet {
+			h.downloadJobArtifact(w, r, jobID, name)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case strings.HasPrefix(path, "/jobs/") && strings.HasSuffix(path, "/logs/share"):
+		jobID := strings.TrimSuffix(strings.TrimPrefix(path, "/jobs/"), "/logs/share")
+		if r.Method == http.MethodGet {
+			h.shareJobLogs(w, r, jobID)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
 	case strings.HasPrefix(path, "/jobs/") && strings.HasSuffix(path, "/logs"):
 		jobID := strings.TrimSuffix(strings.TrimPrefix(path, "/jobs/"), "/logs")
 		if r.Method == http.MethodGet {
@@ -122,6 +164,12 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Workers
 	case path == "/workers" && r.Method == http.MethodGet:
 		h.listWorkers(w, r)
+	case path == "/workers/drain-all":
+		if r.Method == http.MethodPost {
+			h.drainAllWorkers(w, r)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
 	case strings.HasPrefix(path, "/workers/") && strings.HasSuffix(path, "/jobs"):
 		workerID := strings.TrimSuffix(strings.TrimPrefix(path, "/workers/"), "/jobs")
 		if r.Method == http.MethodGet {
@@ -200,6 +248,8 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				switch r.Method {
 				case http.MethodGet:
 					h.getRepo(w, r, repoID)
+				case http.MethodPatch:
+					h.updateRepoSettings(w, r, repoID)
 				case http.MethodDelete:
 					h.deleteRepo(w, r, repoID)
 				default:
@@ -225,6 +275,10 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case path == "/give-me-pro" && r.Method == http.MethodPost:
 		h.giveMePro(w, r)
 
+	// Dashboard summary
+	case path == "/stats" && r.Method == http.MethodGet:
+		h.getStats(w, r)
+
 	// User/Account
 	case path == "/whoami" && r.Method == http.MethodGet:
 		h.whoami(w, r)
@@ -232,6 +286,8 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.getUser(w, r)
 	case path == "/user" && r.Method == http.MethodDelete:
 		h.deleteUser(w, r)
+	case path == "/user/usage" && r.Method == http.MethodGet:
+		h.getUserUsage(w, r)
 	case strings.HasPrefix(path, "/user/forges/"):
 		forgeType := strings.TrimPrefix(path, "/user/forges/")
 		if r.Method == http.MethodDelete {
@@ -244,6 +300,17 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case path == "/forge/connect" && r.Method == http.MethodPost:
 		h.connectForge(w, r)
 
+	// Webhook deliveries
+	case path == "/webhooks/recent" && r.Method == http.MethodGet:
+		h.listRecentDeliveries(w, r)
+	case strings.HasPrefix(path, "/deliveries/") && strings.HasSuffix(path, "/replay"):
+		deliveryID := strings.TrimSuffix(strings.TrimPrefix(path, "/deliveries/"), "/replay")
+		if r.Method == http.MethodPost {
+			h.replayDelivery(w, r, deliveryID)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+
 	default:
 		http.Error(w, "not found", http.StatusNotFound)
 	}
@@ -267,6 +334,10 @@ type jobResponse struct {
 	StartedAt    *time.Time `json:"started_at,omitempty"`
 	FinishedAt   *time.Time `json:"finished_at,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
+	// PendingReason explains why a queued job hasn't started (e.g. concurrency limit)
+	PendingReason string `json:"pending_reason,omitempty"`
+	// QueuePosition is a queued job's 1-based place in the dispatch queue
+	QueuePosition *int `json:"queue_position,omitempty"`
 }
 
 // jobDetailResponse extends jobResponse with sibling attempts
@@ -305,28 +376,37 @@ func jobToResponse(j *storage.Job) jobResponse {
 	return resp
 }
 
+// pendingReason returns the dispatcher's reason a queued job is waiting.
+func (h *APIHandler) pendingReason(j *storage.Job) string {
+	if h.dispatcher == nil || j.Status != storage.JobStatusQueued {
+		return ""
+	}
+	return h.dispatcher.PendingReason(j.ID)
+}
+
+// queuePosition returns a queued job's place in the dispatch queue, or nil
+// for jobs that aren't waiting in it.
+func (h *APIHandler) queuePosition(j *storage.Job) *int {
+	if h.dispatcher == nil || j.Status != storage.JobStatusQueued {
+		return nil
+	}
+	pos := h.dispatcher.QueuePosition(j.ID)
+	if pos == 0 {
+		return nil
+	}
+	return &pos
+}
+
 func (h *APIHandler) listJobs(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	ctx := r.Context()
 	user := h.getCurrentUser(ctx, r)
 
-	filter := storage.JobFilter{
-		RepoID: q.Get("repo_id"),
-		Status: storage.JobStatus(q.Get("status")),
-		Branch: q.Get("branch"),
-		Limit:  50, // default
-	}
-
-	if limit := q.Get("limit"); limit != "" {
-		if n, err := strconv.Atoi(limit); err == nil && n > 0 && n <= 100 {
-			filter.Limit = n
-		}
-	}
-	if offset := q.Get("offset"); offset != "" {
-		if n, err := strconv.Atoi(offset); err == nil && n >= 0 {
-			filter.Offset = n
-		}
+	filter, ok := parseJobFilter(w, q)
+	if !ok {
+		return
 	}
+	filter.RepoID = q.Get("repo_id")
 
 	jobs, err := h.storage.ListJobs(ctx, filter)
 	if err != nil {
@@ -334,6 +414,21 @@ func (h *APIHandler) listJobs(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	// A total is only reported for a single repo the caller can see: across
+	// repos, CountJobs would include jobs in repos they can't access
+	var total *int
+	if filter.RepoID != "" {
+		repo, err := h.storage.GetRepo(ctx, filter.RepoID)
+		if err == nil && h.canAccessRepo(ctx, user, repo) {
+			n, err := h.storage.CountJobs(ctx, filter)
+			if err != nil {
+				h.log.Error("failed to count jobs", "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			total = &n
+		}
+	}
 
 	// Build response with repo names, filtering by access
 	var resp []jobResponse
@@ -350,12 +445,69 @@ func (h *APIHandler) listJobs(w http.ResponseWriter, r *http.Request) {
 
 		jr := jobToResponse(j)
 		jr.Repo = repo.Owner + "/" + repo.Name
+		jr.PendingReason = h.pendingReason(j)
+		jr.QueuePosition = h.queuePosition(j)
 		resp = append(resp, jr)
 	}
 
-	h.writeJSON(w, map[string]any{"jobs": resp})
+	// limit and offset are over all jobs matching the filter; jobs in repos
+	// the caller can't access are then left out of the page
+	out := map[string]any{
+		"jobs":   resp,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	}
+	if total != nil {
+		out["total"] = *total
+	}
+	h.writeJSON(w, out)
+}
+
+// parseJobFilter reads the status, branch, commit, limit, offset and
+// created_after/created_before query parameters shared by the job list
+// endpoints. On a bad commit or timestamp it writes a 400 and returns false.
+func parseJobFilter(w http.ResponseWriter, q url.Values) (storage.JobFilter, bool) {
+	filter := storage.JobFilter{
+		Status: storage.JobStatus(q.Get("status")),
+		Branch: q.Get("branch"),
+		Commit: strings.ToLower(q.Get("commit")),
+		Limit:  50, // default
+	}
+	if filter.Commit != "" && !commitPrefixRe.MatchString(filter.Commit) {
+		http.Error(w, "commit must be a hex SHA or prefix of one", http.StatusBadRequest)
+		return filter, false
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		if n, err := strconv.Atoi(limit); err == nil && n > 0 && n <= 100 {
+			filter.Limit = n
+		}
+	}
+	if offset := q.Get("offset"); offset != "" {
+		if n, err := strconv.Atoi(offset); err == nil && n >= 0 {
+			filter.Offset = n
+		}
+	}
+	for param, dst := range map[string]*time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, param+" must be an RFC3339 timestamp", http.StatusBadRequest)
+				return filter, false
+			}
+			*dst = t
+		}
+	}
+	return filter, true
 }
 
+// commitPrefixRe matches a lowercase commit SHA (SHA-1 or SHA-256) or a
+// prefix of one.
+var commitPrefixRe = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
 func (h *APIHandler) getJob(w http.ResponseWriter, r *http.Request, jobID string) {
 	ctx := r.Context()
 	job, err := h.storage.GetJob(ctx, jobID)
@@ -391,6 +543,8 @@ func (h *APIHandler) getJob(w http.ResponseWriter, r *http.Request, jobID string
 	resp := jobDetailResponse{
 		jobResponse: jobToResponse(job),
 	}
+	resp.PendingReason = h.pendingReason(job)
+	resp.QueuePosition = h.queuePosition(job)
 
 	siblings, err := h.storage.GetJobSiblings(ctx, job.RepoID, job.Commit, job.ID)
 	if err == nil && len(siblings) > 0 {
@@ -407,6 +561,148 @@ func (h *APIHandler) getJob(w http.ResponseWriter, r *http.Request, jobID string
 	h.writeJSON(w, resp)
 }
 
+// maxLogLineSize bounds one NDJSON log entry; a chunk of output without
+// newlines is stored as a single entry, so lines can far exceed
+// bufio.Scanner's 64KB default.
+const maxLogLineSize = 64 * 1024 * 1024
+
+// newLogScanner returns a scanner over NDJSON log entries.
+func newLogScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	return scanner
+}
+
+// writeLogNDJSON serves a job's log entries as NDJSON. When the log store
+// can sign URLs, finished logs redirect to object storage so large logs don't
+// pass through the server; otherwise they're proxied. Callers must have
+// checked access to the job first.
+func (h *APIHandler) writeLogNDJSON(w http.ResponseWriter, r *http.Request, jobID string, grep *regexp.Regexp) {
+	ctx := r.Context()
+
+	// Filtered logs have to pass through here, so no redirect
+	if signer, ok := h.logStore.(logstore.URLSigner); ok && grep == nil {
+		signedURL, err := signer.GetSignedURL(ctx, jobID)
+		switch {
+		case err == nil:
+			w.Header().Set("Cache-Control", "no-store")
+			http.Redirect(w, r, signedURL, http.StatusFound)
+			return
+		case !errors.Is(err, logstore.ErrNoSignedURL):
+			h.log.Warn("failed to sign log URL, proxying instead", "job_id", jobID, "error", err)
+		}
+	}
+
+	if h.logStore != nil {
+		reader, err := h.logStore.GetLogs(ctx, jobID)
+		if err != nil {
+			h.log.Error("failed to get logs", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		defer reader.Close()
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		if grep == nil {
+			_, _ = io.Copy(w, reader)
+			return
+		}
+		scanner := newLogScanner(reader)
+		for scanner.Scan() {
+			var entry logstore.LogEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || !grep.MatchString(entry.Data) {
+				continue
+			}
+			if _, err := w.Write(append(scanner.Bytes(), '\n')); err != nil {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			h.log.Error("failed to read logs", "error", err)
+		}
+		return
+	}
+
+	logs, err := h.storage.GetLogs(ctx, jobID)
+	if err != nil {
+		h.log.Error("failed to get logs", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, l := range logs {
+		if !logMatches(grep, l.Data) {
+			continue
+		}
+		if err := enc.Encode(logstore.LogEntry{Time: l.CreatedAt, Stream: l.Stream, Data: l.Data}); err != nil {
+			return
+		}
+	}
+}
+
+// writeLogText sets the headers for a plain-text log download.
+func (h *APIHandler) writeLogText(w http.ResponseWriter, jobID string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", jobID+".log"))
+}
+
+// shareJobLogs returns a signed, time-limited link to a job's logs that
+// works without logging in. ?ttl= sets the lifetime (default 24h, max 7 days).
+func (h *APIHandler) shareJobLogs(w http.ResponseWriter, r *http.Request, jobID string) {
+	ctx := r.Context()
+
+	user := h.getCurrentUser(ctx, r)
+	if user == nil || h.auth == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ttl := defaultLogShareTTL
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxLogShareTTL {
+			http.Error(w, fmt.Sprintf("invalid ttl %q (expected a duration up to %s)", v, maxLogShareTTL), http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	job, err := h.storage.GetJob(ctx, jobID)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to get job for log share", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	repo, err := h.storage.GetRepo(ctx, job.RepoID)
+	if err != nil {
+		h.log.Error("failed to get repo for log share", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !h.canAccessRepo(ctx, user, repo) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	token, expires, err := h.auth.CreateLogShareToken(jobID, ttl)
+	if err != nil {
+		h.log.Error("failed to sign log share token", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Info("log share link created", "job_id", jobID, "expires_at", expires, "by_user", user.ID)
+	h.writeJSON(w, map[string]any{
+		"url":        fmt.Sprintf("%s/api/jobs/%s/logs?format=text&token=%s", h.auth.config.BaseURL, url.PathEscape(jobID), url.QueryEscape(token)),
+		"token":      token,
+		"expires_at": expires,
+	})
+}
+
 func (h *APIHandler) getJobLogs(w http.ResponseWriter, r *http.Request, jobID string) {
 	type logResponse struct {
 		Stream    string    `json:"stream"`
@@ -416,6 +712,25 @@ func (h *APIHandler) getJobLogs(w http.ResponseWriter, r *http.Request, jobID st
 
 	ctx := r.Context()
 
+	// format=text returns the raw log output for download; format=ndjson
+	// returns the stored entries, straight from object storage if possible
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" && format != "ndjson" {
+		http.Error(w, "invalid format (expected json, text or ndjson)", http.StatusBadRequest)
+		return
+	}
+
+	// grep keeps only entries whose data matches an RE2 pattern
+	var grep *regexp.Regexp
+	if pattern := r.URL.Query().Get("grep"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			http.Error(w, "invalid grep pattern: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		grep = re
+	}
+
 	// Authorization: check access to the job's repo
 	job, err := h.storage.GetJob(ctx, jobID)
 	if err != nil {
@@ -435,8 +750,10 @@ func (h *APIHandler) getJobLogs(w http.ResponseWriter, r *http.Request, jobID st
 		return
 	}
 
+	// A share link's token stands in for a session
 	user := h.getCurrentUser(ctx, r)
-	if !h.canAccessRepo(ctx, user, repo) {
+	shared := h.auth != nil && h.auth.ValidLogShareToken(r.URL.Query().Get("token"), jobID)
+	if !shared && !h.canAccessRepo(ctx, user, repo) {
 		if user == nil {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 		} else {
@@ -445,6 +762,11 @@ func (h *APIHandler) getJobLogs(w http.ResponseWriter, r *http.Request, jobID st
 		return
 	}
 
+	if format == "ndjson" {
+		h.writeLogNDJSON(w, r, jobID, grep)
+		return
+	}
+
 	// Use logStore if available
 	if h.logStore != nil {
 		reader, err := h.logStore.GetLogs(r.Context(), jobID)
@@ -455,12 +777,30 @@ func (h *APIHandler) getJobLogs(w http.ResponseWriter, r *http.Request, jobID st
 		}
 		defer reader.Close()
 
+		if format == "text" {
+			h.writeLogText(w, jobID)
+			scanner := newLogScanner(reader)
+			for scanner.Scan() {
+				var entry logstore.LogEntry
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || !logMatches(grep, entry.Data) {
+					continue
+				}
+				if _, err := io.WriteString(w, entry.Data); err != nil {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				h.log.Error("failed to read logs", "error", err)
+			}
+			return
+		}
+
 		// Read NDJSON and convert to response format
 		var resp []logResponse
-		scanner := bufio.NewScanner(reader)
+		scanner := newLogScanner(reader)
 		for scanner.Scan() {
 			var entry logstore.LogEntry
-			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || !logMatches(grep, entry.Data) {
 				continue
 			}
 			resp = append(resp, logResponse{
@@ -487,18 +827,115 @@ func (h *APIHandler) getJobLogs(w http.ResponseWriter, r *http.Request, jobID st
 		return
 	}
 
-	resp := make([]logResponse, len(logs))
-	for i, l := range logs {
-		resp[i] = logResponse{
+	if format == "text" {
+		h.writeLogText(w, jobID)
+		for _, l := range logs {
+			if !logMatches(grep, l.Data) {
+				continue
+			}
+			if _, err := io.WriteString(w, l.Data); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	resp := make([]logResponse, 0, len(logs))
+	for _, l := range logs {
+		if !logMatches(grep, l.Data) {
+			continue
+		}
+		resp = append(resp, logResponse{
 			Stream:    l.Stream,
 			Data:      l.Data,
 			CreatedAt: l.CreatedAt,
-		}
+		})
 	}
 
 	h.writeJSON(w, resp)
 }
 
+// logMatches reports whether a log entry passes the ?grep= filter.
+func logMatches(grep *regexp.Regexp, data string) bool {
+	return grep == nil || grep.MatchString(data)
+}
+
+// artifactJob loads a job for an artifact request and checks the caller can
+// see its repo. Writes the error response and returns nil on failure.
+func (h *APIHandler) artifactJob(w http.ResponseWriter, r *http.Request, jobID string) *storage.Job {
+	ctx := r.Context()
+	job, err := h.storage.GetJob(ctx, jobID)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return nil
+		}
+		h.log.Error("failed to get job for artifact auth", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil
+	}
+
+	repo, err := h.storage.GetRepo(ctx, job.RepoID)
+	if err != nil {
+		h.log.Error("failed to get repo for artifact auth", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil
+	}
+	if !h.checkRepoAccess(w, r, repo) {
+		return nil
+	}
+	return job
+}
+
+func (h *APIHandler) listJobArtifacts(w http.ResponseWriter, r *http.Request, jobID string) {
+	if h.artifactJob(w, r, jobID) == nil {
+		return
+	}
+
+	artifacts := []logstore.Artifact{}
+	if h.logStore != nil {
+		list, err := h.logStore.ListArtifacts(r.Context(), jobID)
+		if err != nil {
+			h.log.Error("failed to list artifacts", "job_id", jobID, "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if list != nil {
+			artifacts = list
+		}
+	}
+
+	h.writeJSON(w, map[string]any{"artifacts": artifacts})
+}
+
+func (h *APIHandler) downloadJobArtifact(w http.ResponseWriter, r *http.Request, jobID, name string) {
+	if h.artifactJob(w, r, jobID) == nil {
+		return
+	}
+	if h.logStore == nil || !logstore.ValidArtifactName(name) {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+
+	reader, err := h.logStore.GetArtifact(r.Context(), jobID, name)
+	if err != nil {
+		if errors.Is(err, logstore.ErrArtifactNotFound) {
+			http.Error(w, "artifact not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to get artifact", "job_id", jobID, "name", name, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if _, err := io.Copy(w, reader); err != nil {
+		h.log.Warn("artifact download interrupted", "job_id", jobID, "name", name, "error", err)
+	}
+}
+
 // runJob handles POST /api/jobs/{id}/run
 // For failed/success/error/cancelled jobs: creates a new job with same params (retry)
 // For pending_contributor jobs: approves and queues the existing job
@@ -543,21 +980,77 @@ func (h *APIHandler) runJob(w http.ResponseWriter, r *http.Request, jobID string
 		return
 	}
 
+	switch job.Status {
+	case storage.JobStatusPendingContributor,
+		storage.JobStatusFailed, storage.JobStatusSuccess, storage.JobStatusError, storage.JobStatusCancelled:
+	case storage.JobStatusPending, storage.JobStatusQueued, storage.JobStatusRunning:
+		http.Error(w, "job already in progress", http.StatusConflict)
+		return
+	default:
+		http.Error(w, "job cannot be run", http.StatusBadRequest)
+		return
+	}
+
+	// Resolve everything that can fail before approving or creating the job,
+	// so a failure can't leave a job behind that's never queued. A retry
+	// clones the same commit the same way.
+
+	// Build clone token
+	var cloneToken string
+	var installationID int64
+
+	if job.InstallationID != nil && h.githubApp != nil && h.githubApp.IsConfigured() {
+		// GitHub App job - get fresh token
+		installationID = *job.InstallationID
+		token, err := h.githubApp.GetInstallationToken(installationID)
+		if err != nil {
+			h.log.Error("failed to get installation token", "job_id", jobID, "error", err)
+			http.Error(w, "failed to get clone token", http.StatusInternalServerError)
+			return
+		}
+		cloneToken = token
+	} else {
+		// Non-GitHub App - use stored forge token
+		cloneToken = repo.ForgeToken
+	}
+
+	// Construct ref from branch or tag
+	var ref string
+	if job.PRNumber != nil {
+		ref = fmt.Sprintf("refs/pull/%d/head", *job.PRNumber)
+	} else if job.Tag != "" {
+		ref = "refs/tags/" + job.Tag
+	} else if job.Branch != "" {
+		ref = "refs/heads/" + job.Branch
+	} else {
+		h.log.Error("job has no branch or tag", "job_id", jobID)
+		http.Error(w, "job has no branch or tag", http.StatusBadRequest)
+		return
+	}
+
 	var newJobID string
 
 	switch job.Status {
 	case storage.JobStatusPendingContributor:
 		// Approve the job (user already authorized as repo owner above)
 
-		// Approve and queue the existing job
-		if err := h.storage.ApproveJob(ctx, jobID, user.Name); err != nil {
+		// Approve and reload the existing job atomically, then queue it
+		err := h.storage.WithTx(ctx, func(tx storage.Storage) error {
+			if err := tx.ApproveJob(ctx, jobID, user.Name); err != nil {
+				return err
+			}
+			approved, err := tx.GetJob(ctx, jobID)
+			if err != nil {
+				return err
+			}
+			job = approved
+			return nil
+		})
+		if err != nil {
 			h.log.Error("failed to approve job", "job_id", jobID, "error", err)
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
-
-		// Reload job with updated status
-		job, _ = h.storage.GetJob(ctx, jobID)
 		newJobID = jobID
 
 		h.log.Info("job approved", "job_id", jobID, "approved_by", user.Name)
@@ -588,13 +1081,14 @@ func (h *APIHandler) runJob(w http.ResponseWriter, r *http.Request, jobID string
 
 		// Create a new GitHub Check Run for the retry
 		if newJob.InstallationID != nil && h.githubApp != nil && h.githubApp.IsConfigured() {
-			checkRunID, err := h.githubApp.CreateCheckRun(repo, newJob.Commit, newJob.ID, *newJob.InstallationID)
+			checkRunID, err := h.githubApp.CreateCheckRun(repo, newJob, *newJob.InstallationID)
 			if err != nil {
 				h.log.Warn("failed to create check run for retry", "job_id", newJob.ID, "error", err)
 			} else {
 				if err := h.storage.UpdateJobCheckRunID(ctx, newJob.ID, checkRunID); err != nil {
 					h.log.Warn("failed to save check run ID", "job_id", newJob.ID, "error", err)
 				}
+				newJob.CheckRunID = &checkRunID
 			}
 		}
 
@@ -602,47 +1096,6 @@ func (h *APIHandler) runJob(w http.ResponseWriter, r *http.Request, jobID string
 		newJobID = newJob.ID
 
 		h.log.Info("job retry created", "job_id", newJobID, "original_job_id", jobID, "user", user.Name)
-
-	case storage.JobStatusPending, storage.JobStatusQueued, storage.JobStatusRunning:
-		http.Error(w, "job already in progress", http.StatusConflict)
-		return
-
-	default:
-		http.Error(w, "job cannot be run", http.StatusBadRequest)
-		return
-	}
-
-	// Build clone token
-	var cloneToken string
-	var installationID int64
-
-	if job.InstallationID != nil && h.githubApp != nil && h.githubApp.IsConfigured() {
-		// GitHub App job - get fresh token
-		installationID = *job.InstallationID
-		token, err := h.githubApp.GetInstallationToken(installationID)
-		if err != nil {
-			h.log.Error("failed to get installation token", "job_id", newJobID, "error", err)
-			http.Error(w, "failed to get clone token", http.StatusInternalServerError)
-			return
-		}
-		cloneToken = token
-	} else {
-		// Non-GitHub App - use stored forge token
-		cloneToken = repo.ForgeToken
-	}
-
-	// Construct ref from branch or tag
-	var ref string
-	if job.PRNumber != nil {
-		ref = fmt.Sprintf("refs/pull/%d/head", *job.PRNumber)
-	} else if job.Tag != "" {
-		ref = "refs/tags/" + job.Tag
-	} else if job.Branch != "" {
-		ref = "refs/heads/" + job.Branch
-	} else {
-		h.log.Error("job has no branch or tag", "job_id", newJobID)
-		http.Error(w, "job has no branch or tag", http.StatusBadRequest)
-		return
 	}
 
 	// Queue the job
@@ -708,15 +1161,18 @@ func (h *APIHandler) cancelJob(w http.ResponseWriter, r *http.Request, jobID str
 		return
 	}
 
-	// Update job status to cancelled
-	if err := h.storage.UpdateJobStatus(ctx, jobID, storage.JobStatusCancelled, nil); err != nil {
+	// Drop it from the queue, stop the worker if it's running, mark cancelled
+	if h.dispatcher != nil {
+		err = h.dispatcher.Cancel(ctx, job, "cancelled by "+user.Name)
+	} else {
+		err = h.storage.UpdateJobStatus(ctx, jobID, storage.JobStatusCancelled, nil)
+	}
+	if err != nil {
 		h.log.Error("failed to update job status", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	// TODO: If job is running on a worker, send cancel signal
-
 	h.log.Info("job cancelled", "job_id", jobID, "cancelled_by", user.Name)
 
 	w.WriteHeader(http.StatusOK)
@@ -737,9 +1193,10 @@ type workerResponse struct {
 	LastSeen  time.Time `json:"last_seen"`
 	CreatedAt time.Time `json:"created_at"`
 	// Live info from hub
-	Connected  bool     `json:"connected"`
-	ActiveJobs []string `json:"active_jobs,omitempty"`
-	CurrentJob *string  `json:"currentJob,omitempty"` // First active job for frontend
+	Connected  bool                 `json:"connected"`
+	ActiveJobs []string             `json:"active_jobs,omitempty"`
+	CurrentJob *string              `json:"currentJob,omitempty"` // First active job for frontend
+	Load       *protocol.WorkerLoad `json:"load,omitempty"`       // Latest heartbeat snapshot
 }
 
 func (h *APIHandler) listWorkers(w http.ResponseWriter, r *http.Request) {
@@ -834,6 +1291,7 @@ func (h *APIHandler) listWorkers(w http.ResponseWriter, r *http.Request) {
 			wr.ActiveJobs = conn.ActiveJobs
 			wr.Hostname = conn.Hostname
 			wr.Version = conn.Version
+			wr.Load = conn.Load
 			if len(conn.ActiveJobs) > 0 {
 				wr.CurrentJob = &conn.ActiveJobs[0]
 			}
@@ -884,6 +1342,7 @@ func (h *APIHandler) listWorkers(w http.ResponseWriter, r *http.Request) {
 				Version:    conn.Version,
 				Connected:  true,
 				ActiveJobs: conn.ActiveJobs,
+				Load:       conn.Load,
 			}
 			if len(conn.ActiveJobs) > 0 {
 				wr.CurrentJob = &conn.ActiveJobs[0]
@@ -997,6 +1456,46 @@ func (h *APIHandler) drainWorker(w http.ResponseWriter, r *http.Request, workerI
 	h.writeJSON(w, map[string]any{"ok": true, "message": "drain command sent"})
 }
 
+// drainAllWorkers sends a drain request to every connected shared worker
+// the current user owns, e.g. before upgrading a fleet.
+func (h *APIHandler) drainAllWorkers(w http.ResponseWriter, r *http.Request) {
+	user := h.getCurrentUser(r.Context(), r)
+	if user == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Timeout int    `json:"timeout"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Use defaults if no body
+		req.Timeout = 300 // 5 minutes default
+	}
+
+	if h.wsHandler == nil {
+		http.Error(w, "worker control not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	drained := []string{}
+	for _, worker := range h.hub.List() {
+		if worker.Mode != "shared" || worker.OwnerName != user.Name {
+			continue
+		}
+		if err := h.wsHandler.SendDrain(worker.ID, req.Timeout, req.Reason); err != nil {
+			// Disconnected since List; nothing left to drain
+			h.log.Warn("failed to send drain", "worker_id", worker.ID, "error", err)
+			continue
+		}
+		drained = append(drained, worker.ID)
+	}
+
+	h.log.Info("drain all workers requested", "by", user.Name, "workers", len(drained), "timeout", req.Timeout)
+	h.writeJSON(w, map[string]any{"ok": true, "drained": drained})
+}
+
 // disconnectWorker sends a kill request to a worker (force disconnect).
 func (h *APIHandler) disconnectWorker(w http.ResponseWriter, r *http.Request, workerID string) {
 	// Check auth - use getCurrentUser to properly resolve email->user
@@ -1049,17 +1548,23 @@ func (h *APIHandler) disconnectWorker(w http.ResponseWriter, r *http.Request, wo
 // --- Repos ---
 
 type repoResponse struct {
-	ID              string    `json:"id"`
-	ForgeType       string    `json:"forge_type"`
-	Owner           string    `json:"owner"`
-	Name            string    `json:"name"`
-	Private         bool      `json:"private,omitempty"`
-	CloneURL        string    `json:"clone_url"`
-	HTMLURL         string    `json:"html_url,omitempty"`
-	Build           string    `json:"build"`
-	Release         string    `json:"release,omitempty"`
-	CreatedAt       time.Time `json:"created_at"`
-	LatestJobStatus *string   `json:"latest_job_status,omitempty"` // For ?include_status=true
+	ID               string    `json:"id"`
+	ForgeType        string    `json:"forge_type"`
+	Owner            string    `json:"owner"`
+	Name             string    `json:"name"`
+	Private          bool      `json:"private,omitempty"`
+	CloneURL         string    `json:"clone_url"`
+	HTMLURL          string    `json:"html_url,omitempty"`
+	Build            string    `json:"build"`
+	Release          string    `json:"release,omitempty"`
+	CancelInProgress bool      `json:"cancel_in_progress"`
+	MaxParallel      int       `json:"max_parallel,omitempty"`
+	InfraRetries     int       `json:"infra_retries,omitempty"`
+	Paths            []string  `json:"paths,omitempty"`
+	StatusContext    string    `json:"status_context,omitempty"`
+	Timeout          string    `json:"timeout,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	LatestJobStatus  *string   `json:"latest_job_status,omitempty"` // For ?include_status=true
 }
 
 type createRepoRequest struct {
@@ -1073,6 +1578,19 @@ type createRepoRequest struct {
 	Release    string `json:"release"`
 }
 
+// repoDryRunResponse describes what createRepo would do, without doing it.
+type repoDryRunResponse struct {
+	DryRun            bool   `json:"dry_run"`
+	ForgeType         string `json:"forge_type"`
+	Owner             string `json:"owner"`
+	Name              string `json:"name"`
+	CloneURL          string `json:"clone_url"`
+	AlreadyExists     bool   `json:"already_exists"`
+	OrgToken          bool   `json:"org_token"`             // server has an org token for this forge
+	WebhookURL        string `json:"webhook_url,omitempty"` // where the webhook would point
+	WebhookAutoCreate bool   `json:"webhook_auto_create"`   // server would try to create the webhook
+}
+
 // createRepoResponse includes webhook secret - only used for initial creation
 // so user can configure webhook on their forge. Never returned by GET endpoints.
 type createRepoResponse struct {
@@ -1105,19 +1623,25 @@ func (h *APIHandler) listRepos(w http.ResponseWriter, r *http.Request) {
 
 		htmlURL := repo.HTMLURL
 		if htmlURL == "" {
-			htmlURL = computeHTMLURL(repo.ForgeType, repo.Owner, repo.Name)
+			htmlURL = h.computeHTMLURL(repo)
 		}
 		rr := repoResponse{
-			ID:        repo.ID,
-			ForgeType: string(repo.ForgeType),
-			Owner:     repo.Owner,
-			Name:      repo.Name,
-			Private:   repo.Private,
-			CloneURL:  repo.CloneURL,
-			HTMLURL:   htmlURL,
-			Build:     repo.Build,
-			Release:   repo.Release,
-			CreatedAt: repo.CreatedAt,
+			ID:               repo.ID,
+			ForgeType:        string(repo.ForgeType),
+			Owner:            repo.Owner,
+			Name:             repo.Name,
+			Private:          repo.Private,
+			CloneURL:         repo.CloneURL,
+			HTMLURL:          htmlURL,
+			Build:            repo.Build,
+			Release:          repo.Release,
+			CancelInProgress: repo.CancelInProgress,
+			MaxParallel:      repo.MaxParallel,
+			InfraRetries:     repo.InfraRetries,
+			Paths:            repo.Paths,
+			StatusContext:    repo.StatusContext,
+			Timeout:          repo.JobTimeout,
+			CreatedAt:        repo.CreatedAt,
 		}
 
 		// Include latest job status if requested
@@ -1170,9 +1694,15 @@ func (h *APIHandler) getRepo(w http.ResponseWriter, r *http.Request, repoID stri
 		CloneURL:  repo.CloneURL,
 		HTMLURL:   repo.HTMLURL,
 		// WebhookSecret intentionally omitted - never expose secrets in API
-		Build:     repo.Build,
-		Release:   repo.Release,
-		CreatedAt: repo.CreatedAt,
+		Build:            repo.Build,
+		Release:          repo.Release,
+		CancelInProgress: repo.CancelInProgress,
+		MaxParallel:      repo.MaxParallel,
+		InfraRetries:     repo.InfraRetries,
+		Paths:            repo.Paths,
+		StatusContext:    repo.StatusContext,
+		Timeout:          repo.JobTimeout,
+		CreatedAt:        repo.CreatedAt,
 	}
 
 	h.writeJSON(w, resp)
@@ -1197,6 +1727,11 @@ func (h *APIHandler) createRepo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("dry_run") == "true" {
+		h.createRepoDryRun(w, r, user, &req)
+		return
+	}
+
 	// Generate webhook secret
 	secret, err := generateSecret(32)
 	if err != nil {
@@ -1227,6 +1762,11 @@ func (h *APIHandler) createRepo(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:     time.Now(),
 	}
 
+	if repo.HTMLURL == "" && repo.ForgeType == storage.ForgeTypeAzure {
+		// Azure DevOps status posting derives the organization from it
+		repo.HTMLURL = h.computeHTMLURL(repo)
+	}
+
 	if err := h.storage.CreateRepo(r.Context(), repo); err != nil {
 		h.log.Error("failed to create repo", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
@@ -1256,15 +1796,21 @@ func (h *APIHandler) createRepo(w http.ResponseWriter, r *http.Request) {
 	// Return repo with webhook info
 	resp := createRepoResponse{
 		repoResponse: repoResponse{
-			ID:        repo.ID,
-			ForgeType: string(repo.ForgeType),
-			Owner:     repo.Owner,
-			Name:      repo.Name,
-			CloneURL:  repo.CloneURL,
-			HTMLURL:   repo.HTMLURL,
-			Build:     repo.Build,
-			Release:   repo.Release,
-			CreatedAt: repo.CreatedAt,
+			ID:               repo.ID,
+			ForgeType:        string(repo.ForgeType),
+			Owner:            repo.Owner,
+			Name:             repo.Name,
+			CloneURL:         repo.CloneURL,
+			HTMLURL:          repo.HTMLURL,
+			Build:            repo.Build,
+			Release:          repo.Release,
+			CancelInProgress: repo.CancelInProgress,
+			MaxParallel:      repo.MaxParallel,
+			InfraRetries:     repo.InfraRetries,
+			Paths:            repo.Paths,
+			StatusContext:    repo.StatusContext,
+			Timeout:          repo.JobTimeout,
+			CreatedAt:        repo.CreatedAt,
 		},
 		WebhookAutoCreated: webhookAutoCreated,
 		WebhookURL:         webhookURL,
@@ -1278,6 +1824,36 @@ func (h *APIHandler) createRepo(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, resp)
 }
 
+// createRepoDryRun reports what createRepo would do for req without
+// generating secrets, storing anything, or calling the forge. An existing
+// repo is only reported if user can see it, so the dry run can't be used to
+// probe other tenants' private repos.
+func (h *APIHandler) createRepoDryRun(w http.ResponseWriter, r *http.Request, user *storage.User, req *createRepoRequest) {
+	resp := repoDryRunResponse{
+		DryRun:    true,
+		ForgeType: req.ForgeType,
+		Owner:     req.Owner,
+		Name:      req.Name,
+		CloneURL:  req.CloneURL,
+		OrgToken:  h.getOrgToken(req.ForgeType) != "",
+	}
+
+	if existing, err := h.storage.GetRepoByCloneURL(r.Context(), req.CloneURL); err == nil {
+		resp.AlreadyExists = h.canAccessRepo(r.Context(), user, existing)
+	} else if err != storage.ErrNotFound {
+		h.log.Error("failed to look up repo", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if h.orgTokens != nil && h.orgTokens.BaseURL != "" {
+		resp.WebhookURL = strings.TrimSuffix(h.orgTokens.BaseURL, "/") + "/webhooks/" + req.ForgeType
+	}
+	resp.WebhookAutoCreate = resp.OrgToken && resp.WebhookURL != ""
+
+	h.writeJSON(w, resp)
+}
+
 // getOrgToken returns the org token for the given forge type, if configured.
 func (h *APIHandler) getOrgToken(forgeType string) string {
 	if h.orgTokens == nil {
@@ -1290,6 +1866,8 @@ func (h *APIHandler) getOrgToken(forgeType string) string {
 		return h.orgTokens.GitLab
 	case "forgejo", "gitea":
 		return h.orgTokens.Forgejo
+	case "azure":
+		return h.orgTokens.Azure
 	default:
 		return ""
 	}
@@ -1298,55 +1876,324 @@ func (h *APIHandler) getOrgToken(forgeType string) string {
 // createWebhookForRepo creates a webhook using the org token.
 func (h *APIHandler) createWebhookForRepo(ctx context.Context, repo *storage.Repo, webhookURL string) error {
 	// Create forge client with org token
+	_, githubAPI := h.githubApp.githubEndpoints()
 	f := forge.New(forge.ForgeConfig{
 		Type:    string(repo.ForgeType),
 		Token:   repo.ForgeToken,
 		BaseURL: repo.HTMLURL, // Use HTMLURL to derive base URL for self-hosted forges
+		APIURL:  githubAPI,
+		Client:  forgeAPIClient,
 	})
 	if f == nil {
 		return fmt.Errorf("unknown forge type: %s", repo.ForgeType)
 	}
 
-	forgeRepo := &forge.Repo{
-		ForgeType: string(repo.ForgeType),
-		Owner:     repo.Owner,
-		Name:      repo.Name,
-		CloneURL:  repo.CloneURL,
-		HTMLURL:   repo.HTMLURL,
-		Private:   repo.Private,
-	}
+	forgeRepo := &forge.Repo{
+		ForgeType: string(repo.ForgeType),
+		Owner:     repo.Owner,
+		Name:      repo.Name,
+		CloneURL:  repo.CloneURL,
+		HTMLURL:   repo.HTMLURL,
+		Private:   repo.Private,
+	}
+
+	_, err := f.CreateWebhook(ctx, forgeRepo, webhookURL, repo.WebhookSecret)
+	return err
+}
+
+func (h *APIHandler) deleteRepo(w http.ResponseWriter, r *http.Request, repoID string) {
+	// Get the repo first to check ownership
+	repo, err := h.storage.GetRepo(r.Context(), repoID)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			http.Error(w, "repo not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("failed to get repo for delete", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Authorization: require ownership to delete
+	user := h.requireRepoOwnership(w, r, repo)
+	if user == nil {
+		return // error already written
+	}
+
+	if err := h.storage.DeleteRepo(r.Context(), repoID); err != nil {
+		h.log.Error("failed to delete repo", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Info("repo deleted", "repo_id", repoID, "by_user", user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	_, err := f.CreateWebhook(ctx, forgeRepo, webhookURL, repo.WebhookSecret)
-	return err
+// repoSettingsRequest is a partial update of repo settings; nil fields are unchanged.
+type repoSettingsRequest struct {
+	Build            *string   `json:"build"`
+	Release          *string   `json:"release"` // "" clears it (tags run the build command)
+	CancelInProgress *bool     `json:"cancel_in_progress"`
+	MaxParallel      *int      `json:"max_parallel"`   // 0 = unlimited
+	InfraRetries     *int      `json:"infra_retries"`  // 0 = off
+	Paths            *[]string `json:"paths"`          // empty builds every push
+	StatusContext    *string   `json:"status_context"` // "" resets to "cinch"
+	Timeout          *string   `json:"timeout"`        // "" leaves it to .cinch.yaml
+	NotifyURLs       *[]string `json:"notify_urls"`    // empty disables notifications
+	NotifyOn         *[]string `json:"notify_on"`      // empty means failed and error
+	NotifyEmail      *bool     `json:"notify_email"`
 }
 
-func (h *APIHandler) deleteRepo(w http.ResponseWriter, r *http.Request, repoID string) {
-	// Get the repo first to check ownership
+// maxInfraRetries bounds automatic retries after infrastructure errors.
+const maxInfraRetries = 5
+
+// maxJobTimeout bounds the per-repo job timeout.
+const maxJobTimeout = 24 * time.Hour
+
+// maxRepoPaths bounds how many path filters a repo can have.
+const maxRepoPaths = 20
+
+// maxNotifyURLs bounds how many notification targets a repo can have.
+const maxNotifyURLs = 5
+
+func (h *APIHandler) updateRepoSettings(w http.ResponseWriter, r *http.Request, repoID string) {
 	repo, err := h.storage.GetRepo(r.Context(), repoID)
 	if err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, "repo not found", http.StatusNotFound)
 			return
 		}
-		h.log.Error("failed to get repo for delete", "error", err)
+		h.log.Error("failed to get repo for settings", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	// Authorization: require ownership to delete
+	// Authorization: require ownership to change settings
 	user := h.requireRepoOwnership(w, r, repo)
 	if user == nil {
 		return // error already written
 	}
 
-	if err := h.storage.DeleteRepo(r.Context(), repoID); err != nil {
-		h.log.Error("failed to delete repo", "error", err)
+	var req repoSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Build != nil || req.Release != nil {
+		build, release := repo.Build, repo.Release
+		if req.Build != nil {
+			build = strings.TrimSpace(*req.Build)
+			if build == "" {
+				http.Error(w, "build cannot be empty", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Release != nil {
+			release = strings.TrimSpace(*req.Release)
+		}
+		if err := h.storage.UpdateRepoBuild(r.Context(), repo.ID, build, release); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.Build, repo.Release = build, release
+	}
+	if req.CancelInProgress != nil {
+		if err := h.storage.UpdateRepoCancelInProgress(r.Context(), repo.ID, *req.CancelInProgress); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.CancelInProgress = *req.CancelInProgress
+	}
+	if req.MaxParallel != nil {
+		if *req.MaxParallel < 0 {
+			http.Error(w, "max_parallel must be 0 (unlimited) or more", http.StatusBadRequest)
+			return
+		}
+		if err := h.storage.UpdateRepoMaxParallel(r.Context(), repo.ID, *req.MaxParallel); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.MaxParallel = *req.MaxParallel
+		if h.dispatcher != nil {
+			h.dispatcher.SetRepoMaxParallel(repo.ID, repo.MaxParallel)
+		}
+	}
+	if req.InfraRetries != nil {
+		if *req.InfraRetries < 0 || *req.InfraRetries > maxInfraRetries {
+			http.Error(w, fmt.Sprintf("infra_retries must be between 0 (off) and %d", maxInfraRetries), http.StatusBadRequest)
+			return
+		}
+		if err := h.storage.UpdateRepoInfraRetries(r.Context(), repo.ID, *req.InfraRetries); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.InfraRetries = *req.InfraRetries
+	}
+	if req.Paths != nil {
+		var paths []string
+		for _, p := range *req.Paths {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			if err := validatePathPattern(p); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			paths = append(paths, p)
+		}
+		if len(paths) > maxRepoPaths {
+			http.Error(w, fmt.Sprintf("at most %d paths are allowed", maxRepoPaths), http.StatusBadRequest)
+			return
+		}
+		if err := h.storage.UpdateRepoPaths(r.Context(), repo.ID, paths); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.Paths = paths
+	}
+	if req.StatusContext != nil {
+		template := strings.TrimSpace(*req.StatusContext)
+		if max := maxStatusContextLen(repo.ForgeType); len(template) > max {
+			http.Error(w, fmt.Sprintf("status_context must be at most %d characters for %s", max, repo.ForgeType), http.StatusBadRequest)
+			return
+		}
+		if p := unknownStatusPlaceholder(template); p != "" {
+			http.Error(w, fmt.Sprintf("status_context: unknown placeholder %s (use {event}, {branch} or {step})", p), http.StatusBadRequest)
+			return
+		}
+		if err := h.storage.UpdateRepoStatusContext(r.Context(), repo.ID, template); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.StatusContext = template
+	}
+	if req.Timeout != nil {
+		timeout := strings.TrimSpace(*req.Timeout)
+		if timeout != "" {
+			d, err := time.ParseDuration(timeout)
+			if err != nil || d <= 0 || d > maxJobTimeout {
+				http.Error(w, fmt.Sprintf("timeout must be a duration between 1s and %s, e.g. 45m", maxJobTimeout), http.StatusBadRequest)
+				return
+			}
+			timeout = d.String()
+		}
+		if err := h.storage.UpdateRepoJobTimeout(r.Context(), repo.ID, timeout); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		repo.JobTimeout = timeout
+	}
+	notifications, err := h.storage.GetRepoNotifications(r.Context(), repo.ID)
+	if err != nil {
+		h.log.Error("failed to get repo notifications", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	if req.NotifyURLs != nil || req.NotifyOn != nil || req.NotifyEmail != nil {
+		if req.NotifyURLs != nil {
+			urls, err := parseNotifyURLs(*req.NotifyURLs)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			notifications.URLs = urls
+		}
+		if req.NotifyOn != nil {
+			on, err := parseNotifyOn(*req.NotifyOn)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			notifications.On = on
+		}
+		if req.NotifyEmail != nil {
+			notifications.Email = *req.NotifyEmail
+		}
+		if err := h.storage.UpdateRepoNotifications(r.Context(), repo.ID, notifications); err != nil {
+			h.log.Error("failed to update repo settings", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
 
-	h.log.Info("repo deleted", "repo_id", repoID, "by_user", user.ID)
-	w.WriteHeader(http.StatusNoContent)
+	h.log.Info("repo settings updated", "repo_id", repo.ID, "build", repo.Build, "release", repo.Release, "cancel_in_progress", repo.CancelInProgress, "max_parallel", repo.MaxParallel, "infra_retries", repo.InfraRetries, "paths", repo.Paths, "status_context", repo.StatusContext, "timeout", repo.JobTimeout, "by_user", user.ID)
+	h.writeJSON(w, map[string]any{
+		"id":                 repo.ID,
+		"build":              repo.Build,
+		"release":            repo.Release,
+		"cancel_in_progress": repo.CancelInProgress,
+		"max_parallel":       repo.MaxParallel,
+		"infra_retries":      repo.InfraRetries,
+		"paths":              repo.Paths,
+		"status_context":     repo.StatusContext,
+		"timeout":            repo.JobTimeout,
+		"notify_urls":        len(notifications.URLs),
+		"notify_on":          notifyOn(notifications),
+		"notify_email":       notifications.Email,
+	})
+}
+
+// notifyOn returns the statuses a repo is notified about, or nil when it has
+// no notification URLs. The URLs themselves are never returned since
+// webhook URLs embed their credentials.
+func notifyOn(n *storage.RepoNotifications) []storage.JobStatus {
+	if len(n.URLs) == 0 {
+		return nil
+	}
+	if len(n.On) == 0 {
+		return []storage.JobStatus{storage.JobStatusFailed, storage.JobStatusError}
+	}
+	return n.On
+}
+
+// parseNotifyURLs validates notification targets. Only http(s) URLs on
+// public hosts are accepted since the server posts to them.
+func parseNotifyURLs(urls []string) ([]string, error) {
+	if len(urls) > maxNotifyURLs {
+		return nil, fmt.Errorf("at most %d notification URLs are allowed", maxNotifyURLs)
+	}
+	var valid []string
+	for _, raw := range urls {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("invalid notification URL %q: must be an http(s) URL", raw)
+		}
+		if err := notify.CheckHost(u.Hostname()); err != nil {
+			return nil, fmt.Errorf("invalid notification URL %q: must not point at an internal address", raw)
+		}
+		valid = append(valid, raw)
+	}
+	return valid, nil
+}
+
+// parseNotifyOn validates the finished statuses a repo is notified about.
+func parseNotifyOn(on []string) ([]storage.JobStatus, error) {
+	var statuses []storage.JobStatus
+	for _, s := range on {
+		status := storage.JobStatus(strings.TrimSpace(s))
+		switch status {
+		case storage.JobStatusSuccess, storage.JobStatusFailed, storage.JobStatusError, storage.JobStatusCancelled:
+		default:
+			return nil, fmt.Errorf("invalid notification status %q: must be success, failed, error or cancelled", s)
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
 }
 
 // forgeDomainToType converts a domain like "github.com" to a forge type like "github"
@@ -1360,6 +2207,10 @@ func forgeDomainToType(domain string) string {
 		return "forgejo"
 	case "gitea.com":
 		return "gitea"
+	case "bitbucket.org":
+		return "bitbucket"
+	case "dev.azure.com":
+		return "azure"
 	default:
 		// For self-hosted instances, try to infer from domain
 		if strings.Contains(domain, "gitlab") {
@@ -1371,6 +2222,9 @@ func forgeDomainToType(domain string) string {
 		if strings.Contains(domain, "forgejo") || strings.Contains(domain, "codeberg") {
 			return "forgejo"
 		}
+		if strings.HasSuffix(domain, ".visualstudio.com") {
+			return "azure"
+		}
 		return domain
 	}
 }
@@ -1393,17 +2247,26 @@ func (h *APIHandler) checkRepoAccess(w http.ResponseWriter, r *http.Request, rep
 
 // Response type for per-repo endpoint with latest job
 type repoWithStatusResponse struct {
-	ID        string       `json:"id"`
-	ForgeType string       `json:"forge_type"`
-	Owner     string       `json:"owner"`
-	Name      string       `json:"name"`
-	Private   bool         `json:"private"`
-	CloneURL  string       `json:"clone_url"`
-	HTMLURL   string       `json:"html_url,omitempty"`
-	Build     string       `json:"build"`
-	Release   string       `json:"release,omitempty"`
-	CreatedAt time.Time    `json:"created_at"`
-	LatestJob *jobResponse `json:"latest_job,omitempty"`
+	ID               string              `json:"id"`
+	ForgeType        string              `json:"forge_type"`
+	Owner            string              `json:"owner"`
+	Name             string              `json:"name"`
+	Private          bool                `json:"private"`
+	CloneURL         string              `json:"clone_url"`
+	HTMLURL          string              `json:"html_url,omitempty"`
+	Build            string              `json:"build"`
+	Release          string              `json:"release,omitempty"`
+	CancelInProgress bool                `json:"cancel_in_progress"`
+	MaxParallel      int                 `json:"max_parallel"`
+	InfraRetries     int                 `json:"infra_retries"`
+	Paths            []string            `json:"paths,omitempty"`
+	StatusContext    string              `json:"status_context,omitempty"`
+	Timeout          string              `json:"timeout,omitempty"`
+	NotifyURLs       int                 `json:"notify_urls"`
+	NotifyOn         []storage.JobStatus `json:"notify_on,omitempty"`
+	NotifyEmail      bool                `json:"notify_email"`
+	CreatedAt        time.Time           `json:"created_at"`
+	LatestJob        *jobResponse        `json:"latest_job,omitempty"`
 }
 
 func (h *APIHandler) getRepoByPath(w http.ResponseWriter, r *http.Request, forge, owner, repoName string) {
@@ -1427,20 +2290,31 @@ func (h *APIHandler) getRepoByPath(w http.ResponseWriter, r *http.Request, forge
 
 	htmlURL := repo.HTMLURL
 	if htmlURL == "" {
-		htmlURL = computeHTMLURL(repo.ForgeType, repo.Owner, repo.Name)
+		htmlURL = h.computeHTMLURL(repo)
 	}
 
 	resp := repoWithStatusResponse{
-		ID:        repo.ID,
-		ForgeType: string(repo.ForgeType),
-		Owner:     repo.Owner,
-		Name:      repo.Name,
-		Private:   repo.Private,
-		CloneURL:  repo.CloneURL,
-		HTMLURL:   htmlURL,
-		Build:     repo.Build,
-		Release:   repo.Release,
-		CreatedAt: repo.CreatedAt,
+		ID:               repo.ID,
+		ForgeType:        string(repo.ForgeType),
+		Owner:            repo.Owner,
+		Name:             repo.Name,
+		Private:          repo.Private,
+		CloneURL:         repo.CloneURL,
+		HTMLURL:          htmlURL,
+		Build:            repo.Build,
+		Release:          repo.Release,
+		CancelInProgress: repo.CancelInProgress,
+		MaxParallel:      repo.MaxParallel,
+		InfraRetries:     repo.InfraRetries,
+		Paths:            repo.Paths,
+		StatusContext:    repo.StatusContext,
+		Timeout:          repo.JobTimeout,
+		CreatedAt:        repo.CreatedAt,
+	}
+	if n, err := h.storage.GetRepoNotifications(r.Context(), repo.ID); err == nil {
+		resp.NotifyURLs = len(n.URLs)
+		resp.NotifyOn = notifyOn(n)
+		resp.NotifyEmail = n.Email
 	}
 
 	// Get latest job
@@ -1476,24 +2350,11 @@ func (h *APIHandler) listRepoJobs(w http.ResponseWriter, r *http.Request, forge,
 		return
 	}
 
-	q := r.URL.Query()
-	filter := storage.JobFilter{
-		RepoID: repo.ID,
-		Status: storage.JobStatus(q.Get("status")),
-		Branch: q.Get("branch"),
-		Limit:  50,
-	}
-
-	if limit := q.Get("limit"); limit != "" {
-		if n, err := strconv.Atoi(limit); err == nil && n > 0 && n <= 100 {
-			filter.Limit = n
-		}
-	}
-	if offset := q.Get("offset"); offset != "" {
-		if n, err := strconv.Atoi(offset); err == nil && n >= 0 {
-			filter.Offset = n
-		}
+	filter, ok := parseJobFilter(w, r.URL.Query())
+	if !ok {
+		return
 	}
+	filter.RepoID = repo.ID
 
 	jobs, err := h.storage.ListJobs(r.Context(), filter)
 	if err != nil {
@@ -1501,14 +2362,27 @@ func (h *APIHandler) listRepoJobs(w http.ResponseWriter, r *http.Request, forge,
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	total, err := h.storage.CountJobs(r.Context(), filter)
+	if err != nil {
+		h.log.Error("failed to count jobs", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
 
 	resp := make([]jobResponse, len(jobs))
 	for i, j := range jobs {
 		resp[i] = jobToResponse(j)
 		resp[i].Repo = repo.Owner + "/" + repo.Name
+		resp[i].PendingReason = h.pendingReason(j)
+		resp[i].QueuePosition = h.queuePosition(j)
 	}
 
-	h.writeJSON(w, map[string]any{"jobs": resp})
+	h.writeJSON(w, map[string]any{
+		"jobs":   resp,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
 }
 
 // --- Secrets ---
@@ -1958,6 +2832,122 @@ func (h *APIHandler) getUser(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, resp)
 }
 
+// usageResponse reports a user's consumption against their tier.
+type usageResponse struct {
+	Tier              string    `json:"tier"`
+	StorageUsedBytes  int64     `json:"storage_used_bytes"`
+	StorageQuotaBytes int64     `json:"storage_quota_bytes"`
+	Repos             int       `json:"repos"`
+	PeriodStart       time.Time `json:"period_start"`  // Start of the current calendar month (UTC)
+	JobsThisPeriod    int       `json:"jobs"`          // Jobs created this period
+	BuildMinutes      float64   `json:"build_minutes"` // Job run time this period
+	RunningJobs       int       `json:"running_jobs"`
+	ConcurrencyLimit  int       `json:"concurrency_limit,omitempty"` // 0 = unlimited
+}
+
+func (h *APIHandler) getUserUsage(w http.ResponseWriter, r *http.Request) {
+	user := h.requireAuth(w, r)
+	if user == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	periodStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	usage, err := h.storage.GetUserUsage(r.Context(), user.ID, periodStart)
+	if err != nil {
+		h.log.Error("failed to get user usage", "user_id", user.ID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := usageResponse{
+		Tier:              string(user.Tier),
+		StorageUsedBytes:  user.StorageUsedBytes,
+		StorageQuotaBytes: user.StorageQuota(),
+		Repos:             usage.Repos,
+		PeriodStart:       periodStart,
+		JobsThisPeriod:    usage.Jobs,
+		BuildMinutes:      float64(usage.BuildSeconds) / 60,
+	}
+	if h.dispatcher != nil {
+		resp.RunningJobs, resp.ConcurrencyLimit = h.dispatcher.Concurrency(user)
+	}
+
+	h.writeJSON(w, resp)
+}
+
+// statsCacheTTL bounds how often dashboard auto-refresh hits the database.
+const statsCacheTTL = 5 * time.Second
+
+type statsResponse struct {
+	Repos            int            `json:"repos"`
+	Jobs24h          map[string]int `json:"jobs_24h"` // Jobs created in the last 24h, by status
+	Jobs7d           map[string]int `json:"jobs_7d"`  // Jobs created in the last 7d, by status
+	ConnectedWorkers int            `json:"connected_workers"`
+	AvgDurationMs    int64          `json:"avg_duration_ms"` // Mean run time of jobs finished in the last 7d
+}
+
+type cachedStats struct {
+	resp    statsResponse
+	expires time.Time
+}
+
+// getStats returns dashboard totals. Anyone may call it; anonymous callers
+// only see counts for public repos, signed-in users also their private ones.
+func (h *APIHandler) getStats(w http.ResponseWriter, r *http.Request) {
+	var viewerID string
+	if user := h.getCurrentUser(r.Context(), r); user != nil {
+		viewerID = user.ID
+	}
+
+	now := time.Now()
+	h.statsMu.Lock()
+	cached, ok := h.statsCache[viewerID]
+	h.statsMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		h.writeJSON(w, cached.resp)
+		return
+	}
+
+	stats, err := h.storage.JobStats(r.Context(), viewerID, now)
+	if err != nil {
+		h.log.Error("failed to get job stats", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := statsResponse{
+		Repos:         stats.Repos,
+		Jobs24h:       make(map[string]int, len(stats.Last24h)),
+		Jobs7d:        make(map[string]int, len(stats.Last7d)),
+		AvgDurationMs: stats.AvgDuration.Milliseconds(),
+	}
+	for status, n := range stats.Last24h {
+		resp.Jobs24h[string(status)] = n
+	}
+	for status, n := range stats.Last7d {
+		resp.Jobs7d[string(status)] = n
+	}
+	if h.hub != nil {
+		resp.ConnectedWorkers = h.hub.Count()
+	}
+
+	h.statsMu.Lock()
+	if h.statsCache == nil {
+		h.statsCache = make(map[string]cachedStats)
+	}
+	for id, c := range h.statsCache {
+		if now.After(c.expires) {
+			delete(h.statsCache, id)
+		}
+	}
+	h.statsCache[viewerID] = cachedStats{resp: resp, expires: now.Add(statsCacheTTL)}
+	h.statsMu.Unlock()
+
+	h.writeJSON(w, resp)
+}
+
 func (h *APIHandler) disconnectForge(w http.ResponseWriter, r *http.Request, forgeType string) {
 	user := h.getCurrentUser(r.Context(), r)
 	if user == nil {
@@ -2122,6 +3112,135 @@ func (h *APIHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// --- Webhook Deliveries ---
+
+type replayDeliveryResponse struct {
+	DeliveryID string `json:"delivery_id"`
+	Status     int    `json:"status"`
+	Body       string `json:"body"`
+}
+
+type deliveryResponse struct {
+	ID        string    `json:"id"`
+	RepoID    string    `json:"repo_id"`
+	Source    string    `json:"source"`
+	Status    int       `json:"status"`
+	Result    string    `json:"result"`
+	JobID     string    `json:"job_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// listRecentDeliveries lists recent webhook deliveries for the user's repos
+// with how each was handled.
+func (h *APIHandler) listRecentDeliveries(w http.ResponseWriter, r *http.Request) {
+	user := h.requireAuth(w, r)
+	if user == nil {
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.storage.ListWebhookDeliveriesByOwner(r.Context(), user.ID, limit)
+	if err != nil {
+		h.log.Error("failed to list webhook deliveries", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]deliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, deliveryResponse{
+			ID:        d.ID,
+			RepoID:    d.RepoID,
+			Source:    d.Source,
+			Status:    d.Status,
+			Result:    d.Result,
+			JobID:     d.JobID,
+			CreatedAt: d.CreatedAt,
+		})
+	}
+	h.writeJSON(w, map[string]any{"deliveries": resp})
+}
+
+// replayDelivery re-feeds a stored webhook delivery through the handler that received it.
+// Signature verification is skipped, so only the repo owner may replay.
+func (h *APIHandler) replayDelivery(w http.ResponseWriter, r *http.Request, deliveryID string) {
+	ctx := r.Context()
+
+	if h.requireAuth(w, r) == nil {
+		return
+	}
+
+	delivery, err := h.storage.GetWebhookDelivery(ctx, deliveryID)
+	if err == storage.ErrNotFound {
+		http.Error(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.log.Error("failed to get webhook delivery", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	repo, err := h.storage.GetRepo(ctx, delivery.RepoID)
+	if err == storage.ErrNotFound {
+		http.Error(w, "repo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.log.Error("failed to get repo", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if h.requireRepoOwnership(w, r, repo) == nil {
+		return
+	}
+
+	var handler http.Handler
+	switch delivery.Source {
+	case deliverySourceGitHubApp:
+		if h.githubApp != nil {
+			handler = h.githubApp
+		}
+	default:
+		if h.webhooks != nil {
+			handler = h.webhooks
+		}
+	}
+	if handler == nil {
+		http.Error(w, "webhook handler not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(withReplay(ctx), http.MethodPost, "/webhooks", bytes.NewReader(delivery.Payload))
+	if err != nil {
+		http.Error(w, "failed to build request", http.StatusInternalServerError)
+		return
+	}
+	for k, v := range delivery.Headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	saveDeliveryResult(ctx, h.storage, h.log, delivery.ID, rec.Code, rec.Body.Bytes())
+
+	h.log.Info("replayed webhook delivery", "delivery_id", delivery.ID, "repo_id", repo.ID, "status", rec.Code)
+	h.writeJSON(w, replayDeliveryResponse{
+		DeliveryID: delivery.ID,
+		Status:     rec.Code,
+		Body:       rec.Body.String(),
+	})
+}
+
 // --- Helpers ---
 
 // getCurrentUser returns the authenticated user, or nil if not authenticated.
@@ -2194,16 +3313,30 @@ func generateSecret(length int) (string, error) {
 }
 
 // computeHTMLURL constructs a web URL for a repo if not stored in DB
-func computeHTMLURL(forgeType storage.ForgeType, owner, name string) string {
-	switch forgeType {
+func (h *APIHandler) computeHTMLURL(repo *storage.Repo) string {
+	owner, name := repo.Owner, repo.Name
+	switch repo.ForgeType {
 	case storage.ForgeTypeGitHub:
-		return fmt.Sprintf("https://github.com/%s/%s", owner, name)
+		githubBase, _ := h.githubApp.githubEndpoints()
+		return fmt.Sprintf("%s/%s/%s", githubBase, owner, name)
 	case storage.ForgeTypeGitLab:
 		return fmt.Sprintf("https://gitlab.com/%s/%s", owner, name)
 	case storage.ForgeTypeGitea:
 		return fmt.Sprintf("https://gitea.com/%s/%s", owner, name)
 	case storage.ForgeTypeForgejo:
 		return fmt.Sprintf("https://codeberg.org/%s/%s", owner, name)
+	case storage.ForgeTypeBitbucket:
+		return fmt.Sprintf("https://bitbucket.org/%s/%s", owner, name)
+	case storage.ForgeTypeAzure:
+		// The organization is only in the clone URL, which doubles as the
+		// web URL: https://dev.azure.com/{org}/{project}/_git/{repo}
+		u, err := url.Parse(repo.CloneURL)
+		if err != nil || u.Host == "" {
+			return ""
+		}
+		u.User = nil
+		u.Path = strings.TrimSuffix(u.Path, ".git")
+		return u.String()
 	default:
 		return ""
 	}
@@ -6,10 +6,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/protocol"
 	"github.com/ehrlich-b/cinch/internal/storage"
 )
 
@@ -156,74 +159,752 @@ func TestAPIListJobsWithFilters(t *testing.T) {
 	}
 }
 
+func TestAPIListJobsPagination(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	for i := 0; i < 5; i++ {
+		_ = store.CreateJob(t.Context(), &storage.Job{
+			ID:        "j_" + string(rune('a'+i)),
+			RepoID:    "r_1",
+			Branch:    "main",
+			Status:    storage.JobStatusSuccess,
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	api := NewAPIHandler(store, nil, nil, nil)
+
+	req := httptest.NewRequest("GET", "/api/jobs?repo_id=r_1&limit=2&offset=2", nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Jobs   []jobResponse `json:"jobs"`
+		Total  int           `json:"total"`
+		Limit  int           `json:"limit"`
+		Offset int           `json:"offset"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(resp.Jobs) != 2 || resp.Total != 5 || resp.Limit != 2 || resp.Offset != 2 {
+		t.Errorf("got %d jobs, total=%d limit=%d offset=%d; want 2, 5, 2, 2",
+			len(resp.Jobs), resp.Total, resp.Limit, resp.Offset)
+	}
+	if len(resp.Jobs) == 2 && resp.Jobs[0].ID != "j_c" {
+		t.Errorf("first job = %s, want j_c", resp.Jobs[0].ID)
+	}
+
+	// Across repos the count would include jobs the caller can't see
+	req = httptest.NewRequest("GET", "/api/jobs?limit=2", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	var unscoped map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&unscoped); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if _, ok := unscoped["total"]; ok {
+		t.Errorf("unscoped list reported total %v", unscoped["total"])
+	}
+}
+
+func TestAPIListJobsCommitFilter(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	for i, commit := range []string{"abc1230", "abc4560", "def7890"} {
+		_ = store.CreateJob(t.Context(), &storage.Job{
+			ID:        "j_" + string(rune('a'+i)),
+			RepoID:    "r_1",
+			Commit:    commit,
+			Status:    storage.JobStatusFailed,
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	api := NewAPIHandler(store, nil, nil, nil)
+	list := func(query string) (*httptest.ResponseRecorder, []jobResponse) {
+		req := httptest.NewRequest("GET", "/api/jobs?repo_id=r_1&"+query, nil)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		var resp struct {
+			Jobs []jobResponse `json:"jobs"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp.Jobs
+	}
+
+	if _, jobs := list("commit=ABC"); len(jobs) != 2 || jobs[0].ID != "j_b" || jobs[1].ID != "j_a" {
+		t.Errorf("commit prefix matched %+v, want j_b and j_a", jobs)
+	}
+	// LIKE wildcards aren't accepted as a prefix
+	if w, _ := list("commit=%25"); w.Code != http.StatusBadRequest {
+		t.Errorf("wildcard commit: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAPIListRepoJobsFilters(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		Owner:     "test",
+		Name:      "repo",
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		status := storage.JobStatusSuccess
+		if i%2 == 0 {
+			status = storage.JobStatusFailed
+		}
+		_ = store.CreateJob(t.Context(), &storage.Job{
+			ID:        "j_" + string(rune('a'+i)),
+			RepoID:    "r_1",
+			Branch:    "main",
+			Status:    status,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	api := NewAPIHandler(store, nil, nil, nil)
+
+	// Failed jobs are j_a, j_c, j_e; created_after drops j_a
+	after := url.QueryEscape(base.Add(30 * time.Minute).Format(time.RFC3339))
+	req := httptest.NewRequest("GET", "/api/repos/github.com/test/repo/jobs?status=failed&limit=1&created_after="+after, nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Jobs  []jobResponse `json:"jobs"`
+		Total int           `json:"total"`
+		Limit int           `json:"limit"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(resp.Jobs) != 1 || resp.Total != 2 || resp.Limit != 1 {
+		t.Fatalf("got %d jobs, total=%d limit=%d; want 1, 2, 1", len(resp.Jobs), resp.Total, resp.Limit)
+	}
+	if resp.Jobs[0].ID != "j_e" || resp.Jobs[0].Repo != "test/repo" {
+		t.Errorf("job = %s (%s), want j_e (test/repo)", resp.Jobs[0].ID, resp.Jobs[0].Repo)
+	}
+
+	req = httptest.NewRequest("GET", "/api/repos/github.com/test/repo/jobs?created_before=yesterday", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad timestamp: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestAPIDrainAllWorkers(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+
+	hub := NewHub()
+	workers := []*WorkerConn{
+		{ID: "w_shared", Mode: protocol.ModeShared, OwnerName: user.Name, Send: make(chan []byte, 1)},
+		{ID: "w_personal", Mode: protocol.ModePersonal, OwnerName: user.Name, Send: make(chan []byte, 1)},
+		{ID: "w_other", Mode: protocol.ModeShared, OwnerName: "someone-else", Send: make(chan []byte, 1)},
+	}
+	for _, wk := range workers {
+		hub.Register(wk)
+	}
+
+	api := NewAPIHandler(store, hub, auth, nil)
+	api.SetWSHandler(NewWSHandler(hub, store, nil))
+
+	req := httptest.NewRequest("POST", "/api/workers/drain-all", strings.NewReader(`{"timeout": 60}`))
+	addAuthCookie(t, auth, req, user.Email)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp struct {
+		Drained []string `json:"drained"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(resp.Drained) != 1 || resp.Drained[0] != "w_shared" {
+		t.Errorf("drained = %v, want [w_shared]", resp.Drained)
+	}
+
+	select {
+	case msg := <-workers[0].Send:
+		msgType, payload, err := protocol.Decode(msg)
+		if err != nil || msgType != protocol.TypeWorkerDrain {
+			t.Fatalf("sent %q (err %v), want %q", msgType, err, protocol.TypeWorkerDrain)
+		}
+		drain, _ := protocol.DecodePayload[protocol.WorkerDrain](payload)
+		if drain.DrainTimeout != 60 {
+			t.Errorf("DrainTimeout = %d, want 60", drain.DrainTimeout)
+		}
+	default:
+		t.Error("owned shared worker was not sent a drain")
+	}
+	for _, wk := range workers[1:] {
+		if len(wk.Send) != 0 {
+			t.Errorf("%s was sent a drain", wk.ID)
+		}
+	}
+
+	// Requires login
+	req = httptest.NewRequest("POST", "/api/workers/drain-all", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAPIRepoSettingsStatusContext(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		Owner:       "test",
+		Name:        "repo",
+		CloneURL:    "https://github.com/test/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+
+	api := NewAPIHandler(store, nil, auth, nil)
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/repos/r_1", strings.NewReader(body))
+		addAuthCookie(t, auth, req, user.Email)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := patch(`{"status_context": " cinch/{event} "}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	repo, _ := store.GetRepo(t.Context(), "r_1")
+	if repo.StatusContext != "cinch/{event}" {
+		t.Errorf("StatusContext = %q, want %q", repo.StatusContext, "cinch/{event}")
+	}
+
+	// Other settings leave it alone
+	if w := patch(`{"cancel_in_progress": true}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	repo, _ = store.GetRepo(t.Context(), "r_1")
+	if repo.StatusContext != "cinch/{event}" || !repo.CancelInProgress {
+		t.Errorf("got status_context=%q cancel_in_progress=%t", repo.StatusContext, repo.CancelInProgress)
+	}
+
+	if w := patch(`{"status_context": "` + strings.Repeat("x", maxStatusContextLen(storage.ForgeTypeGitHub)+1) + `"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("too long: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := patch(`{"status_context": "cinch/{label}"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown placeholder: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestAPIRepoSettingsTimeout(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		Owner:       "test",
+		Name:        "repo",
+		CloneURL:    "https://github.com/test/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+
+	api := NewAPIHandler(store, nil, auth, nil)
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/repos/r_1", strings.NewReader(body))
+		addAuthCookie(t, auth, req, user.Email)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := patch(`{"timeout": "90m"}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	repo, _ := store.GetRepo(t.Context(), "r_1")
+	if repo.JobTimeout != "1h30m0s" {
+		t.Errorf("JobTimeout = %q, want %q", repo.JobTimeout, "1h30m0s")
+	}
+
+	for _, bad := range []string{"soon", "-5m", "0s", "25h"} {
+		if w := patch(`{"timeout": "` + bad + `"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("timeout %q: status = %d, want %d", bad, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	if w := patch(`{"timeout": ""}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	repo, _ = store.GetRepo(t.Context(), "r_1")
+	if repo.JobTimeout != "" {
+		t.Errorf("JobTimeout = %q after clearing", repo.JobTimeout)
+	}
+}
+
+func TestAPIRepoSettingsBuild(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		Owner:       "test",
+		Name:        "repo",
+		CloneURL:    "https://github.com/test/repo.git",
+		Build:       "make check",
+		Release:     "make release",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+
+	api := NewAPIHandler(store, nil, auth, nil)
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/repos/r_1", strings.NewReader(body))
+		addAuthCookie(t, auth, req, user.Email)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(`{"build": "make test"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp struct {
+		Build   string `json:"build"`
+		Release string `json:"release"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Build != "make test" || resp.Release != "make release" {
+		t.Errorf("response build=%q release=%q", resp.Build, resp.Release)
+	}
+
+	// Clearing the release leaves the build alone
+	if w := patch(`{"release": ""}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	repo, _ := store.GetRepo(t.Context(), "r_1")
+	if repo.Build != "make test" || repo.Release != "" {
+		t.Errorf("got build=%q release=%q", repo.Build, repo.Release)
+	}
+
+	if w := patch(`{"build": "  "}`); w.Code != http.StatusBadRequest {
+		t.Errorf("empty build: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
 func TestAPIGetJob(t *testing.T) {
 	store, _ := storage.NewSQLite(":memory:", "", "")
 	defer store.Close()
 
-	// Create repo
+	// Create repo
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+
+	exitCode := 0
+	job := &storage.Job{
+		ID:        "j_test",
+		RepoID:    "r_1",
+		Commit:    "abc123def",
+		Branch:    "feature",
+		Status:    storage.JobStatusSuccess,
+		ExitCode:  &exitCode,
+		CreatedAt: time.Now(),
+	}
+	_ = store.CreateJob(t.Context(), job)
+
+	api := NewAPIHandler(store, nil, nil, nil)
+
+	req := httptest.NewRequest("GET", "/api/jobs/j_test", nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var got jobResponse
+	_ = json.NewDecoder(w.Body).Decode(&got)
+
+	if got.ID != "j_test" {
+		t.Errorf("ID = %s, want j_test", got.ID)
+	}
+	if got.Commit != "abc123def" {
+		t.Errorf("Commit = %s, want abc123def", got.Commit)
+	}
+	if got.Branch != "feature" {
+		t.Errorf("Branch = %s, want feature", got.Branch)
+	}
+}
+
+func TestAPIGetJobNotFound(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	api := NewAPIHandler(store, nil, nil, nil)
+
+	req := httptest.NewRequest("GET", "/api/jobs/nonexistent", nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestAPIGetJobLogs(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	// Create repo and job
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	_ = store.CreateJob(t.Context(), &storage.Job{
+		ID:        "j_1",
+		RepoID:    "r_1",
+		Status:    storage.JobStatusRunning,
+		CreatedAt: time.Now(),
+	})
+
+	// Add logs
+	_ = store.AppendLog(t.Context(), "j_1", "stdout", "Hello world\n")
+	_ = store.AppendLog(t.Context(), "j_1", "stderr", "Warning\n")
+
+	api := NewAPIHandler(store, nil, nil, nil)
+
+	req := httptest.NewRequest("GET", "/api/jobs/j_1/logs", nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var logs []struct {
+		Stream string `json:"stream"`
+		Data   string `json:"data"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&logs)
+
+	if len(logs) != 2 {
+		t.Errorf("len(logs) = %d, want 2", len(logs))
+	}
+}
+
+func TestAPIGetJobLogsFromLogStore(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	_ = store.CreateJob(t.Context(), &storage.Job{
+		ID:        "j_1",
+		RepoID:    "r_1",
+		Status:    storage.JobStatusSuccess,
+		CreatedAt: time.Now(),
+	})
+
+	logs := logstore.NewMemoryLogStore()
+	_ = logs.AppendChunk(t.Context(), "j_1", "stdout", []byte("Hello world\n"))
+	_ = logs.AppendChunk(t.Context(), "j_1", "stderr", []byte("Warning\n"))
+	_, _ = logs.Finalize(t.Context(), "j_1")
+
+	api := NewAPIHandler(store, nil, nil, nil)
+	api.SetLogStore(logs)
+
+	req := httptest.NewRequest("GET", "/api/jobs/j_1/logs", nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp []struct {
+		Stream string `json:"stream"`
+		Data   string `json:"data"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+
+	if len(resp) != 2 {
+		t.Fatalf("len(logs) = %d, want 2", len(resp))
+	}
+	if resp[0].Stream != "stdout" || resp[0].Data != "Hello world\n" {
+		t.Errorf("logs[0] = %+v, want stdout 'Hello world'", resp[0])
+	}
+	if resp[1].Stream != "stderr" {
+		t.Errorf("logs[1].stream = %q, want stderr", resp[1].Stream)
+	}
+
+	// format=text streams the raw output as a download
+	req = httptest.NewRequest("GET", "/api/jobs/j_1/logs?format=text", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("text status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Body.String(); got != "Hello world\nWarning\n" {
+		t.Errorf("text body = %q, want concatenated output", got)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="j_1.log"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	req = httptest.NewRequest("GET", "/api/jobs/j_1/logs?format=xml", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// signingLogStore is a log store whose finalized logs have signed URLs.
+type signingLogStore struct {
+	*logstore.MemoryLogStore
+	finalized map[string]bool
+}
+
+func (s *signingLogStore) GetSignedURL(ctx context.Context, jobID string) (string, error) {
+	if !s.finalized[jobID] {
+		return "", logstore.ErrNoSignedURL
+	}
+	return "https://logs.example.com/" + jobID + "?sig=x", nil
+}
+
+func TestAPIJobLogsNDJSON(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/test/private.git",
+		Private:     true,
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+	for _, id := range []string{"j_done", "j_running"} {
+		_ = store.CreateJob(t.Context(), &storage.Job{ID: id, RepoID: "r_1", Status: storage.JobStatusSuccess, CreatedAt: time.Now()})
+	}
+
+	logs := &signingLogStore{MemoryLogStore: logstore.NewMemoryLogStore(), finalized: map[string]bool{"j_done": true}}
+	_ = logs.AppendChunk(t.Context(), "j_running", "stdout", []byte("still going\n"))
+
+	api := NewAPIHandler(store, nil, auth, nil)
+	api.SetLogStore(logs)
+	get := func(target string, cookie bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if cookie {
+			addAuthCookie(t, auth, req, user.Email)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	// Finished logs redirect to storage
+	w := get("/api/jobs/j_done/logs?format=ndjson", true)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://logs.example.com/j_done?sig=x" {
+		t.Errorf("finished: status = %d, location = %q", w.Code, w.Header().Get("Location"))
+	}
+
+	// No URL is handed out without access to the private repo
+	if w := get("/api/jobs/j_done/logs?format=ndjson", false); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Logs still being written are proxied
+	w = get("/api/jobs/j_running/logs?format=ndjson", true)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("running: status = %d, content type = %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var entry logstore.LogEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || entry.Data != "still going\n" {
+		t.Errorf("running: body = %q", w.Body.String())
+	}
+
+	// Stores that can't sign URLs always proxy
+	api.SetLogStore(logs.MemoryLogStore)
+	if w := get("/api/jobs/j_running/logs?format=ndjson", true); w.Code != http.StatusOK {
+		t.Errorf("unsigned store: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAPIGetJobLogsGrep(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
 	_ = store.CreateRepo(t.Context(), &storage.Repo{
 		ID:        "r_1",
 		ForgeType: storage.ForgeTypeGitHub,
 		CloneURL:  "https://github.com/test/repo.git",
 		CreatedAt: time.Now(),
 	})
+	_ = store.CreateJob(t.Context(), &storage.Job{ID: "j_1", RepoID: "r_1", Status: storage.JobStatusSuccess, CreatedAt: time.Now()})
 
-	exitCode := 0
-	job := &storage.Job{
-		ID:        "j_test",
-		RepoID:    "r_1",
-		Commit:    "abc123def",
-		Branch:    "feature",
-		Status:    storage.JobStatusSuccess,
-		ExitCode:  &exitCode,
-		CreatedAt: time.Now(),
-	}
-	_ = store.CreateJob(t.Context(), job)
+	logs := &signingLogStore{MemoryLogStore: logstore.NewMemoryLogStore(), finalized: map[string]bool{"j_1": true}}
+	_ = logs.AppendChunk(t.Context(), "j_1", "stdout", []byte("ok  pkg/a\n"))
+	_ = logs.AppendChunk(t.Context(), "j_1", "stderr", []byte("--- FAIL: TestB\n"))
+	long := "long " + strings.Repeat("x", 100*1024) + "\n" // over bufio.Scanner's default limit
+	_ = logs.AppendChunk(t.Context(), "j_1", "stdout", []byte(long))
+	_ = logs.AppendChunk(t.Context(), "j_1", "stdout", []byte("FAIL pkg/b\n"))
+	_, _ = logs.Finalize(t.Context(), "j_1")
 
 	api := NewAPIHandler(store, nil, nil, nil)
+	api.SetLogStore(logs)
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
 
-	req := httptest.NewRequest("GET", "/api/jobs/j_test", nil)
-	w := httptest.NewRecorder()
-	api.ServeHTTP(w, req)
+	w := get("/api/jobs/j_1/logs?grep=" + url.QueryEscape("^(---|FAIL) "))
+	var resp []struct {
+		Data string `json:"data"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || len(resp) != 2 || resp[0].Data != "--- FAIL: TestB\n" || resp[1].Data != "FAIL pkg/b\n" {
+		t.Errorf("json: status = %d, logs = %+v", w.Code, resp)
+	}
 
-	if w.Code != http.StatusOK {
-		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	if w := get("/api/jobs/j_1/logs?format=text&grep=pkg/"); w.Body.String() != "ok  pkg/a\nFAIL pkg/b\n" {
+		t.Errorf("text body = %q", w.Body.String())
 	}
 
-	var got jobResponse
-	_ = json.NewDecoder(w.Body).Decode(&got)
+	// Filtered NDJSON is proxied rather than redirected to the whole file
+	w = get("/api/jobs/j_1/logs?format=ndjson&grep=TestB")
+	if w.Code != http.StatusOK {
+		t.Fatalf("ndjson: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var entry logstore.LogEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || entry.Stream != "stderr" || entry.Data != "--- FAIL: TestB\n" {
+		t.Errorf("ndjson body = %q", w.Body.String())
+	}
 
-	if got.ID != "j_test" {
-		t.Errorf("ID = %s, want j_test", got.ID)
+	w = get("/api/jobs/j_1/logs?format=ndjson&grep=" + url.QueryEscape("^long "))
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || entry.Data != long {
+		t.Errorf("long line: got %d bytes, want %d", len(entry.Data), len(long))
 	}
-	if got.Commit != "abc123def" {
-		t.Errorf("Commit = %s, want abc123def", got.Commit)
+
+	if w := get("/api/jobs/j_1/logs?grep=" + url.QueryEscape("(unclosed")); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid pattern: status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
-	if got.Branch != "feature" {
-		t.Errorf("Branch = %s, want feature", got.Branch)
+
+	// Without a log store, entries come from the database
+	_ = store.AppendLog(t.Context(), "j_1", "stdout", "needle\n")
+	_ = store.AppendLog(t.Context(), "j_1", "stdout", "haystack\n")
+	api.SetLogStore(nil)
+	resp = nil
+	_ = json.NewDecoder(get("/api/jobs/j_1/logs?grep=needle").Body).Decode(&resp)
+	if len(resp) != 1 || resp[0].Data != "needle\n" {
+		t.Errorf("storage fallback: logs = %+v", resp)
 	}
 }
 
-func TestAPIGetJobNotFound(t *testing.T) {
+func TestAPIRunJobLeavesNoJobOnFailure(t *testing.T) {
 	store, _ := storage.NewSQLite(":memory:", "", "")
 	defer store.Close()
 
-	api := NewAPIHandler(store, nil, nil, nil)
+	auth, user := setupTestAuth(t, store)
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/test/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+	// No branch or tag, so there's nothing to clone
+	_ = store.CreateJob(t.Context(), &storage.Job{ID: "j_1", RepoID: "r_1", Commit: "abc", Status: storage.JobStatusPending, CreatedAt: time.Now()})
+	_ = store.UpdateJobStatus(t.Context(), "j_1", storage.JobStatusFailed, nil)
+	_ = store.CreateJob(t.Context(), &storage.Job{ID: "j_2", RepoID: "r_1", Commit: "abc", Status: storage.JobStatusPendingContributor, CreatedAt: time.Now()})
 
-	req := httptest.NewRequest("GET", "/api/jobs/nonexistent", nil)
-	w := httptest.NewRecorder()
-	api.ServeHTTP(w, req)
+	api := NewAPIHandler(store, nil, auth, nil)
+	api.SetDispatcher(NewDispatcher(NewHub(), store, nil, nil))
+	for _, id := range []string{"j_1", "j_2"} {
+		req := httptest.NewRequest("POST", "/api/jobs/"+id+"/run", nil)
+		addAuthCookie(t, auth, req, user.Email)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", id, w.Code, http.StatusBadRequest)
+		}
+	}
 
-	if w.Code != http.StatusNotFound {
-		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	// No retry job was created and the pending job wasn't approved
+	jobs, err := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Errorf("got %d jobs, want 2", len(jobs))
+	}
+	if job, _ := store.GetJob(t.Context(), "j_2"); job.Status != storage.JobStatusPendingContributor {
+		t.Errorf("j_2 status = %s, want still pending_contributor", job.Status)
 	}
 }
 
-func TestAPIGetJobLogs(t *testing.T) {
+func TestAPIJobArtifacts(t *testing.T) {
 	store, _ := storage.NewSQLite(":memory:", "", "")
 	defer store.Close()
 
-	// Create repo and job
 	_ = store.CreateRepo(t.Context(), &storage.Repo{
 		ID:        "r_1",
 		ForgeType: storage.ForgeTypeGitHub,
@@ -233,32 +914,45 @@ func TestAPIGetJobLogs(t *testing.T) {
 	_ = store.CreateJob(t.Context(), &storage.Job{
 		ID:        "j_1",
 		RepoID:    "r_1",
-		Status:    storage.JobStatusRunning,
+		Status:    storage.JobStatusSuccess,
 		CreatedAt: time.Now(),
 	})
 
-	// Add logs
-	_ = store.AppendLog(t.Context(), "j_1", "stdout", "Hello world\n")
-	_ = store.AppendLog(t.Context(), "j_1", "stderr", "Warning\n")
+	logs := logstore.NewMemoryLogStore()
+	_, _ = logs.PutArtifact(t.Context(), "j_1", "artifacts.tar.gz", strings.NewReader("tarball"))
 
 	api := NewAPIHandler(store, nil, nil, nil)
+	api.SetLogStore(logs)
 
-	req := httptest.NewRequest("GET", "/api/jobs/j_1/logs", nil)
+	req := httptest.NewRequest("GET", "/api/jobs/j_1/artifacts", nil)
 	w := httptest.NewRecorder()
 	api.ServeHTTP(w, req)
-
 	if w.Code != http.StatusOK {
-		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		t.Fatalf("list status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var list struct {
+		Artifacts []logstore.Artifact `json:"artifacts"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Artifacts) != 1 || list.Artifacts[0].Name != "artifacts.tar.gz" || list.Artifacts[0].SizeBytes != 7 {
+		t.Errorf("artifacts = %+v, want artifacts.tar.gz (7 bytes)", list.Artifacts)
 	}
 
-	var logs []struct {
-		Stream string `json:"stream"`
-		Data   string `json:"data"`
+	req = httptest.NewRequest("GET", "/api/jobs/j_1/artifacts/artifacts.tar.gz", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("download status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w.Body.String() != "tarball" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "tarball")
 	}
-	_ = json.NewDecoder(w.Body).Decode(&logs)
 
-	if len(logs) != 2 {
-		t.Errorf("len(logs) = %d, want 2", len(logs))
+	req = httptest.NewRequest("GET", "/api/jobs/j_1/artifacts/missing", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing artifact status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -356,6 +1050,120 @@ func TestAPICreateRepo(t *testing.T) {
 	}
 }
 
+func TestAPICreateRepoAzure(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, _ := setupTestAuth(t, store)
+	api := NewAPIHandler(store, nil, auth, nil)
+	api.SetOrgTokens(&OrgTokens{Azure: "azure-pat"})
+
+	body := `{
+		"forge_type": "azure",
+		"owner": "myproject",
+		"name": "myrepo",
+		"clone_url": "https://myorg@dev.azure.com/myorg/myproject/_git/myrepo"
+	}`
+	req := httptest.NewRequest("POST", "/api/repos", strings.NewReader(body))
+	addAuthCookie(t, auth, req, "test@example.com")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	repo, err := store.GetRepoByCloneURL(t.Context(), "https://myorg@dev.azure.com/myorg/myproject/_git/myrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.HTMLURL != "https://dev.azure.com/myorg/myproject/_git/myrepo" {
+		t.Errorf("HTMLURL = %q", repo.HTMLURL)
+	}
+	if repo.ForgeToken != "azure-pat" {
+		t.Errorf("ForgeToken = %q, want the org token", repo.ForgeToken)
+	}
+
+	for domain, want := range map[string]string{
+		"dev.azure.com":          "azure",
+		"myorg.visualstudio.com": "azure",
+		"gitlab.example.com":     "gitlab",
+		"git.example.com":        "git.example.com",
+	} {
+		if got := forgeDomainToType(domain); got != want {
+			t.Errorf("forgeDomainToType(%q) = %q, want %q", domain, got, want)
+		}
+	}
+}
+
+func TestAPICreateRepoDryRun(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, _ := setupTestAuth(t, store)
+	api := NewAPIHandler(store, nil, auth, nil)
+	api.SetOrgTokens(&OrgTokens{GitHub: "ghp_org", BaseURL: "https://ci.example.com"})
+
+	body := `{
+		"forge_type": "github",
+		"owner": "myorg",
+		"name": "myrepo",
+		"clone_url": "https://github.com/myorg/myrepo.git"
+	}`
+
+	req := httptest.NewRequest("POST", "/api/repos?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	addAuthCookie(t, auth, req, "test@example.com")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var plan repoDryRunResponse
+	_ = json.NewDecoder(w.Body).Decode(&plan)
+
+	if !plan.DryRun || !plan.OrgToken || !plan.WebhookAutoCreate {
+		t.Errorf("plan = %+v, want dry run with webhook auto-create", plan)
+	}
+	if plan.WebhookURL != "https://ci.example.com/webhooks/github" {
+		t.Errorf("WebhookURL = %s", plan.WebhookURL)
+	}
+
+	// Nothing was stored
+	if _, err := store.GetRepoByCloneURL(context.Background(), "https://github.com/myorg/myrepo.git"); err != storage.ErrNotFound {
+		t.Errorf("dry run created a repo (err = %v)", err)
+	}
+
+	// Another tenant's private repo isn't revealed; a public one is
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_other",
+		ForgeType:   storage.ForgeTypeGitHub,
+		Owner:       "myorg",
+		Name:        "myrepo",
+		CloneURL:    "https://github.com/myorg/myrepo.git",
+		Private:     true,
+		OwnerUserID: "u_someone_else",
+		CreatedAt:   time.Now(),
+	})
+	dryRun := func() repoDryRunResponse {
+		req := httptest.NewRequest("POST", "/api/repos?dry_run=true", strings.NewReader(body))
+		addAuthCookie(t, auth, req, "test@example.com")
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		var plan repoDryRunResponse
+		_ = json.NewDecoder(w.Body).Decode(&plan)
+		return plan
+	}
+	if plan := dryRun(); plan.AlreadyExists {
+		t.Error("already_exists reported for another user's private repo")
+	}
+	_ = store.UpdateRepoPrivate(t.Context(), "r_other", false)
+	if plan := dryRun(); !plan.AlreadyExists {
+		t.Error("already_exists not reported for a public repo")
+	}
+}
+
 func TestAPICreateRepoMissingFields(t *testing.T) {
 	store, _ := storage.NewSQLite(":memory:", "", "")
 	defer store.Close()
@@ -432,6 +1240,80 @@ func TestAPIListRepos(t *testing.T) {
 	}
 }
 
+func TestAPIStats(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_public",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/org/public.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_private",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/org/private.git",
+		OwnerUserID: user.ID,
+		Private:     true,
+		CreatedAt:   time.Now(),
+	})
+	for _, repoID := range []string{"r_public", "r_private"} {
+		_ = store.CreateJob(t.Context(), &storage.Job{
+			ID:        "j_" + repoID,
+			RepoID:    repoID,
+			Commit:    "abc123",
+			Status:    storage.JobStatusFailed,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	api := NewAPIHandler(store, nil, auth, nil)
+
+	get := func(authed bool) statsResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/stats", nil)
+		if authed {
+			addAuthCookie(t, auth, req, "test@example.com")
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var resp statsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		return resp
+	}
+
+	// Anonymous callers don't see the private repo or its job
+	anon := get(false)
+	if anon.Repos != 1 || anon.Jobs24h["failed"] != 1 || anon.Jobs7d["failed"] != 1 {
+		t.Errorf("anonymous stats = %+v, want 1 repo and 1 failed job", anon)
+	}
+	owner := get(true)
+	if owner.Repos != 2 || owner.Jobs24h["failed"] != 2 {
+		t.Errorf("owner stats = %+v, want 2 repos and 2 failed jobs", owner)
+	}
+
+	// Results are cached briefly
+	_ = store.CreateJob(t.Context(), &storage.Job{
+		ID:        "j_new",
+		RepoID:    "r_public",
+		Commit:    "def456",
+		Status:    storage.JobStatusPending,
+		CreatedAt: time.Now(),
+	})
+	if got := get(false); got.Jobs24h["pending"] != 0 {
+		t.Errorf("expected cached stats, got %+v", got)
+	}
+}
+
 func TestAPIGetRepo(t *testing.T) {
 	store, _ := storage.NewSQLite(":memory:", "", "")
 	defer store.Close()
@@ -692,3 +1574,102 @@ func TestAPIInvalidJSON(t *testing.T) {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
+
+func TestAPIShareJobLogs(t *testing.T) {
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	auth, user := setupTestAuth(t, store)
+	auth.config.BaseURL = "https://cinch.example.com"
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/test/private.git",
+		Private:     true,
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+	for _, id := range []string{"j_1", "j_2"} {
+		_ = store.CreateJob(t.Context(), &storage.Job{ID: id, RepoID: "r_1", Commit: "abc", Status: storage.JobStatusFailed, CreatedAt: time.Now()})
+	}
+	_ = store.AppendLog(t.Context(), "j_1", "stdout", "FAIL: TestThing\n")
+
+	api := NewAPIHandler(store, nil, auth, nil)
+	get := func(target string, cookie bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if cookie {
+			addAuthCookie(t, auth, req, user.Email)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	// Creating a link needs a session
+	if w := get("/api/jobs/j_1/logs/share", false); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous share: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := get("/api/jobs/j_1/logs/share?ttl=720h", true); w.Code != http.StatusBadRequest {
+		t.Errorf("ttl over max: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w := get("/api/jobs/j_1/logs/share?ttl=1h", true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("share: status = %d: %s", w.Code, w.Body.String())
+	}
+	var share struct {
+		URL       string    `json:"url"`
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &share)
+	if !strings.HasPrefix(share.URL, "https://cinch.example.com/api/jobs/j_1/logs?") {
+		t.Errorf("url = %q", share.URL)
+	}
+	if d := time.Until(share.ExpiresAt); d <= 0 || d > time.Hour {
+		t.Errorf("expires_at = %s, want within the hour", share.ExpiresAt)
+	}
+
+	// The link works without a session
+	w = get(strings.TrimPrefix(share.URL, "https://cinch.example.com"), false)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "FAIL: TestThing") {
+		t.Errorf("shared logs: status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	// ...but not for another job, tampered, or expired
+	expired, _, _ := auth.CreateLogShareToken("j_1", -time.Minute)
+	tampered := share.Token[:len(share.Token)-2] + "xx"
+	for name, target := range map[string]string{
+		"other job": "/api/jobs/j_2/logs?token=" + share.Token,
+		"tampered":  "/api/jobs/j_1/logs?token=" + tampered,
+		"expired":   "/api/jobs/j_1/logs?token=" + expired,
+	} {
+		if w := get(target, false); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// A session token isn't a share token
+	session, _ := auth.createUserToken(user.Email)
+	if w := get("/api/jobs/j_1/logs?token="+session, false); w.Code != http.StatusUnauthorized {
+		t.Errorf("session token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestParseNotifyURLsRejectsInternalHosts(t *testing.T) {
+	if got, err := parseNotifyURLs([]string{" https://hooks.slack.com/services/T0/B0/x ", ""}); err != nil || len(got) != 1 {
+		t.Errorf("parseNotifyURLs(slack) = %v, %v", got, err)
+	}
+	for _, raw := range []string{
+		"ftp://example.com/hook",
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"https://metadata.google.internal/",
+	} {
+		if _, err := parseNotifyURLs([]string{raw}); err == nil {
+			t.Errorf("parseNotifyURLs(%q) succeeded", raw)
+		}
+	}
+}
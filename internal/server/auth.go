@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -22,10 +23,8 @@ import (
 )
 
 const (
-	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
-	githubTokenURL     = "https://github.com/login/oauth/access_token"
-	githubUserURL      = "https://api.github.com/user"
-	githubEmailsURL    = "https://api.github.com/user/emails"
+	defaultGitHubBaseURL = "https://github.com"
+	defaultGitHubAPIURL  = "https://api.github.com"
 
 	authCookieName     = "cinch_auth"
 	authCookieLifetime = 7 * 24 * time.Hour
@@ -35,21 +34,17 @@ const (
 	deviceCodePollDelay = 5 // seconds
 )
 
-// deviceCode represents a pending device authorization request.
-type deviceCode struct {
-	UserCode   string    // Human-readable code (e.g., "CINCH-1234")
-	ExpiresAt  time.Time // When this code expires
-	Authorized bool      // Whether the user has authorized
-	Email      string    // User's email, set when authorized
-}
-
 // AuthConfig holds GitHub OAuth configuration.
 type AuthConfig struct {
 	GitHubClientID     string
 	GitHubClientSecret string
+	GitHubBaseURL      string // Defaults to https://github.com; set for GitHub Enterprise Server
+	GitHubAPIURL       string // Defaults to https://api.github.com, or GitHubBaseURL + "/api/v3"
 	JWTSecret          string
+	JWTSecondarySecret string // Rotation target: signs new tokens; JWTSecret still verifies old ones
 	BaseURL            string // e.g., "https://cinch.sh"
 	WsBaseURL          string // e.g., "wss://ws.cinch.sh" - defaults to BaseURL if not set
+	OIDCEnabled        bool   // Offer /auth/oidc (OIDCHandler) on the login page
 }
 
 // deviceVerifyAttempt tracks rate limiting for device code verification
@@ -65,10 +60,6 @@ type AuthHandler struct {
 	log     *slog.Logger
 	storage AuthStorage // For user lookups/creation
 
-	// Device auth state (in-memory, keyed by device_code)
-	deviceCodes   map[string]*deviceCode
-	deviceCodesMu sync.RWMutex
-
 	// Rate limiting for device code verification (keyed by IP)
 	deviceVerifyAttempts   map[string]*deviceVerifyAttempt
 	deviceVerifyAttemptsMu sync.Mutex
@@ -79,6 +70,14 @@ type AuthStorage interface {
 	GetUserByEmail(ctx context.Context, email string) (*storage.User, error)
 	GetOrCreateUserByEmail(ctx context.Context, email, name string) (*storage.User, error)
 	UpdateUserGitHubConnected(ctx context.Context, userID string) error
+
+	// Device codes are persisted so a restart doesn't break logins in progress
+	CreateDeviceCode(ctx context.Context, dc *storage.DeviceCode) error
+	GetDeviceCode(ctx context.Context, deviceCode string) (*storage.DeviceCode, error)
+	GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*storage.DeviceCode, error)
+	AuthorizeDeviceCode(ctx context.Context, deviceCode, email string) error
+	DeleteDeviceCode(ctx context.Context, deviceCode string) error
+	DeleteExpiredDeviceCodes(ctx context.Context, now time.Time) error
 }
 
 // NewAuthHandler creates a new auth handler.
@@ -86,11 +85,11 @@ func NewAuthHandler(cfg AuthConfig, store AuthStorage, log *slog.Logger) *AuthHa
 	if log == nil {
 		log = slog.Default()
 	}
+	cfg.GitHubBaseURL, cfg.GitHubAPIURL = githubEndpoints(cfg.GitHubBaseURL, cfg.GitHubAPIURL)
 	return &AuthHandler{
 		config:               cfg,
 		storage:              store,
 		log:                  log,
-		deviceCodes:          make(map[string]*deviceCode),
 		deviceVerifyAttempts: make(map[string]*deviceVerifyAttempt),
 	}
 }
@@ -118,11 +117,16 @@ func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleDeviceVerify(w, r)
 	case "/device/token", "/device/token/":
 		h.handleDeviceToken(w, r)
+	case "/token/refresh", "/token/refresh/":
+		h.handleTokenRefresh(w, r)
 	default:
 		http.NotFound(w, r)
 	}
 }
 
+// githubMarkPath is the GitHub logo's SVG path.
+const githubMarkPath = "M8 0C3.58 0 0 3.58 0 8c0 3.54 2.29 6.53 5.47 7.59.4.07.55-.17.55-.38 0-.19-.01-.82-.01-1.49-2.01.37-2.53-.49-2.69-.94-.09-.23-.48-.94-.82-1.13-.28-.15-.68-.52-.01-.53.63-.01 1.08.58 1.23.82.72 1.21 1.87.87 2.33.66.07-.52.28-.87.51-1.07-1.78-.2-3.64-.89-3.64-3.95 0-.87.31-1.59.82-2.15-.08-.2-.36-1.02.08-2.12 0 0 .67-.21 2.2.82.64-.18 1.32-.27 2-.27.68 0 1.36.09 2 .27 1.53-1.04 2.2-.82 2.2-.82.44 1.1.16 1.92.08 2.12.51.56.82 1.27.82 2.15 0 3.07-1.87 3.75-3.65 3.95.29.25.54.73.54 1.48 0 1.07-.01 1.93-.01 2.2 0 .21.15.46.55.38A8.013 8.013 0 0016 8c0-4.42-3.58-8-8-8z"
+
 // handleLogin shows a simple login page.
 func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	returnTo := sanitizeReturnTo(r.URL.Query().Get("return_to"), h.config.BaseURL)
@@ -134,9 +138,9 @@ func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if GitHub OAuth is configured
-	if h.config.GitHubClientID == "" {
-		http.Error(w, "GitHub OAuth not configured", http.StatusInternalServerError)
+	// Check if any login method is configured
+	if h.config.GitHubClientID == "" && !h.config.OIDCEnabled {
+		http.Error(w, "No login method configured", http.StatusInternalServerError)
 		return
 	}
 
@@ -144,7 +148,18 @@ func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Cache-Control", "no-store")
 
-	oauthURL := fmt.Sprintf("/auth/github?return_to=%s", url.QueryEscape(returnTo))
+	var buttons strings.Builder
+	if h.config.GitHubClientID != "" {
+		fmt.Fprintf(&buttons, `
+  <a href="/auth/github?return_to=%s" class="btn">
+    <svg viewBox="0 0 16 16" fill="currentColor"><path d="%s"/></svg>
+    Continue with GitHub
+  </a>`, url.QueryEscape(returnTo), githubMarkPath)
+	}
+	if h.config.OIDCEnabled {
+		fmt.Fprintf(&buttons, `
+  <a href="/auth/oidc?return_to=%s" class="btn">Continue with SSO</a>`, url.QueryEscape(returnTo))
+	}
 
 	fmt.Fprintf(w, `<!DOCTYPE html>
 <html>
@@ -190,19 +205,16 @@ p {
 }
 .btn:hover { background: #2ea043; }
 .btn svg { width: 20px; height: 20px; }
+.btn + .btn { margin-left: 0.5rem; }
 </style>
 </head>
 <body>
 <div class="container">
   <h1>Sign in to Cinch</h1>
-  <p>Authenticate to manage repos and workers.</p>
-  <a href="%s" class="btn">
-    <svg viewBox="0 0 16 16" fill="currentColor"><path d="M8 0C3.58 0 0 3.58 0 8c0 3.54 2.29 6.53 5.47 7.59.4.07.55-.17.55-.38 0-.19-.01-.82-.01-1.49-2.01.37-2.53-.49-2.69-.94-.09-.23-.48-.94-.82-1.13-.28-.15-.68-.52-.01-.53.63-.01 1.08.58 1.23.82.72 1.21 1.87.87 2.33.66.07-.52.28-.87.51-1.07-1.78-.2-3.64-.89-3.64-3.95 0-.87.31-1.59.82-2.15-.08-.2-.36-1.02.08-2.12 0 0 .67-.21 2.2.82.64-.18 1.32-.27 2-.27.68 0 1.36.09 2 .27 1.53-1.04 2.2-.82 2.2-.82.44 1.1.16 1.92.08 2.12.51.56.82 1.27.82 2.15 0 3.07-1.87 3.75-3.65 3.95.29.25.54.73.54 1.48 0 1.07-.01 1.93-.01 2.2 0 .21.15.46.55.38A8.013 8.013 0 0016 8c0-4.42-3.58-8-8-8z"/></svg>
-    Continue with GitHub
-  </a>
+  <p>Authenticate to manage repos and workers.</p>%s
 </div>
 </body>
-</html>`, oauthURL)
+</html>`, buttons.String())
 }
 
 // handleGitHubLogin initiates the GitHub OAuth flow.
@@ -226,7 +238,7 @@ func (h *AuthHandler) handleGitHubLogin(w http.ResponseWriter, r *http.Request)
 	// Build GitHub authorization URL
 	// user:email scope gives us access to the user's verified emails
 	authURL := fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&scope=%s&state=%s",
-		githubAuthorizeURL,
+		h.config.GitHubBaseURL+"/login/oauth/authorize",
 		url.QueryEscape(h.config.GitHubClientID),
 		url.QueryEscape(h.config.BaseURL+"/auth/callback"),
 		url.QueryEscape("read:user user:email"),
@@ -420,8 +432,7 @@ func (h *AuthHandler) SetAuthCookie(w http.ResponseWriter, username string) erro
 		"exp": time.Now().Add(authCookieLifetime).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(h.getJWTSigningKey())
+	tokenString, err := h.signJWT(claims)
 	if err != nil {
 		return fmt.Errorf("failed to sign JWT: %w", err)
 	}
@@ -454,12 +465,7 @@ func (h *AuthHandler) getAuthFromCookie(r *http.Request) (string, bool) {
 		return "", false
 	}
 
-	token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return h.getJWTSigningKey(), nil
-	})
+	token, err := h.parseJWT(cookie.Value)
 	if err != nil || !token.Valid {
 		return "", false
 	}
@@ -497,22 +503,85 @@ func (h *AuthHandler) clearAuthCookie(w http.ResponseWriter) {
 	})
 }
 
+// getJWTSigningKey returns the key new tokens are signed with. When a
+// secondary key is configured it is the rotation target, so it signs.
 func (h *AuthHandler) getJWTSigningKey() []byte {
-	if h.config.JWTSecret != "" {
-		return []byte(h.config.JWTSecret)
+	return h.getJWTKeys()[0]
+}
+
+// getJWTKeys returns the signing key followed by any other key still
+// accepted for verification during a rotation.
+func (h *AuthHandler) getJWTKeys() [][]byte {
+	primary := h.config.JWTSecret
+	secondary := h.config.JWTSecondarySecret
+	if primary == "" {
+		// Check environment (new name first, then deprecated name)
+		primary = os.Getenv("CINCH_SECRET_KEY")
+		if primary == "" {
+			primary = os.Getenv("CINCH_JWT_SECRET")
+		}
+		if secondary == "" {
+			secondary = os.Getenv("CINCH_SECRET_KEY_SECONDARY")
+		}
+	}
+
+	if primary == "" && secondary == "" {
+		// No secret configured - this is a fatal misconfiguration
+		// Panic to prevent the server from running with forgeable tokens
+		panic("FATAL: secret not configured. Set config.JWTSecret or CINCH_SECRET_KEY environment variable.")
 	}
 
-	// Check environment (new name first, then deprecated name)
-	if secret := os.Getenv("CINCH_SECRET_KEY"); secret != "" {
-		return []byte(secret)
+	if secondary == "" {
+		return [][]byte{[]byte(primary)}
 	}
-	if secret := os.Getenv("CINCH_JWT_SECRET"); secret != "" {
-		return []byte(secret)
+	if primary == "" || primary == secondary {
+		return [][]byte{[]byte(secondary)}
 	}
+	return [][]byte{[]byte(secondary), []byte(primary)}
+}
 
-	// No secret configured - this is a fatal misconfiguration
-	// Panic to prevent the server from running with forgeable tokens
-	panic("FATAL: secret not configured. Set config.JWTSecret or CINCH_SECRET_KEY environment variable.")
+// JWTKeyID returns the key ID ("kid" header) for an HMAC signing key.
+// It's a short hash, so it identifies the key without revealing it.
+func JWTKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// signJWT signs claims with the current key, recording its kid.
+func (h *AuthHandler) signJWT(claims jwt.MapClaims) (string, error) {
+	key := h.getJWTSigningKey()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = JWTKeyID(key)
+	return token.SignedString(key)
+}
+
+// parseJWT verifies a token against the key matching its kid. Tokens
+// without a kid (issued before key IDs) are tried against every key.
+func (h *AuthHandler) parseJWT(tokenString string) (*jwt.Token, error) {
+	keys := h.getJWTKeys()
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// Legacy token: find the key whose signature verifies
+			if parts := strings.Split(token.Raw, "."); len(parts) == 3 {
+				for _, key := range keys {
+					if token.Method.Verify(parts[0]+"."+parts[1], parts[2], key) == nil {
+						return key, nil
+					}
+				}
+			}
+			return keys[0], nil
+		}
+		for _, key := range keys {
+			if JWTKeyID(key) == kid {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	})
 }
 
 // --- OAuth State ---
@@ -530,17 +599,11 @@ func (h *AuthHandler) createOAuthState(returnTo string) (string, error) {
 		"exp":       time.Now().Add(10 * time.Minute).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(h.getJWTSigningKey())
+	return h.signJWT(claims)
 }
 
 func (h *AuthHandler) parseOAuthState(stateToken string) (string, error) {
-	token, err := jwt.Parse(stateToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return h.getJWTSigningKey(), nil
-	})
+	token, err := h.parseJWT(stateToken)
 	if err != nil || !token.Valid {
 		return "", fmt.Errorf("invalid state token: %w", err)
 	}
@@ -554,8 +617,70 @@ func (h *AuthHandler) parseOAuthState(stateToken string) (string, error) {
 	return returnTo, nil
 }
 
+// --- Log Share Links ---
+
+const (
+	// defaultLogShareTTL is how long a shared log link lasts by default.
+	defaultLogShareTTL = 24 * time.Hour
+	// maxLogShareTTL bounds how long a shared log link can last.
+	maxLogShareTTL = 7 * 24 * time.Hour
+)
+
+// CreateLogShareToken signs a token that grants read access to one job's
+// logs, without a session, until it expires.
+func (h *AuthHandler) CreateLogShareToken(jobID string, ttl time.Duration) (string, time.Time, error) {
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	claims := jwt.MapClaims{
+		"type": "log_share",
+		"job":  jobID,
+		"exp":  expires.Unix(),
+	}
+	token, err := h.signJWT(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+// ValidLogShareToken reports whether tokenString is an unexpired share
+// token for jobID's logs.
+func (h *AuthHandler) ValidLogShareToken(tokenString, jobID string) bool {
+	if tokenString == "" {
+		return false
+	}
+	token, err := h.parseJWT(tokenString)
+	if err != nil || !token.Valid {
+		return false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	tokenType, _ := claims["type"].(string)
+	job, _ := claims["job"].(string)
+	return tokenType == "log_share" && job == jobID
+}
+
 // --- GitHub API ---
 
+// githubEndpoints fills in GitHub's web and API base URLs. An Enterprise
+// Server web URL with no API URL gets the standard /api/v3 path.
+func githubEndpoints(baseURL, apiURL string) (string, string) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	if baseURL == "" {
+		baseURL = defaultGitHubBaseURL
+	}
+	if apiURL == "" {
+		if baseURL == defaultGitHubBaseURL {
+			apiURL = defaultGitHubAPIURL
+		} else {
+			apiURL = baseURL + "/api/v3"
+		}
+	}
+	return baseURL, apiURL
+}
+
 type githubUser struct {
 	Login string `json:"login"`
 	ID    int    `json:"id"`
@@ -568,7 +693,7 @@ func (h *AuthHandler) exchangeGitHubCode(code string) (string, error) {
 	data.Set("code", code)
 	data.Set("redirect_uri", h.config.BaseURL+"/auth/callback")
 
-	req, err := http.NewRequest("POST", githubTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequest("POST", h.config.GitHubBaseURL+"/login/oauth/access_token", strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -609,7 +734,7 @@ func (h *AuthHandler) exchangeGitHubCode(code string) (string, error) {
 }
 
 func (h *AuthHandler) getGitHubUser(accessToken string) (*githubUser, error) {
-	req, err := http.NewRequest("GET", githubUserURL, nil)
+	req, err := http.NewRequest("GET", h.config.GitHubAPIURL+"/user", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -642,7 +767,7 @@ func (h *AuthHandler) getGitHubUser(accessToken string) (*githubUser, error) {
 
 // getGitHubEmails fetches all verified emails from GitHub, with primary first.
 func (h *AuthHandler) getGitHubEmails(accessToken string) ([]string, error) {
-	req, err := http.NewRequest("GET", githubEmailsURL, nil)
+	req, err := http.NewRequest("GET", h.config.GitHubAPIURL+"/user/emails", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -891,18 +1016,12 @@ func (h *AuthHandler) createEmailSelectionToken(emails []string, username, retur
 		"exp":       time.Now().Add(10 * time.Minute).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(h.getJWTSigningKey())
+	return h.signJWT(claims)
 }
 
 // parseEmailSelectionToken parses a signed JWT containing email options.
 func (h *AuthHandler) parseEmailSelectionToken(tokenString string) (emails []string, username, returnTo string, err error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return h.getJWTSigningKey(), nil
-	})
+	token, err := h.parseJWT(tokenString)
 	if err != nil || !token.Valid {
 		return nil, "", "", fmt.Errorf("invalid token: %w", err)
 	}
@@ -961,15 +1080,23 @@ func (h *AuthHandler) handleDevice(w http.ResponseWriter, r *http.Request) {
 	userCode := string(userCodeChars[:4]) + "-" + string(userCodeChars[4:])
 
 	// Store the device code
-	h.deviceCodesMu.Lock()
-	h.deviceCodes[deviceCodeStr] = &deviceCode{
-		UserCode:  userCode,
-		ExpiresAt: time.Now().Add(deviceCodeExpiry),
+	ctx := r.Context()
+	now := time.Now()
+	if err := h.storage.CreateDeviceCode(ctx, &storage.DeviceCode{
+		DeviceCode: deviceCodeStr,
+		UserCode:   userCode,
+		ExpiresAt:  now.Add(deviceCodeExpiry),
+		CreatedAt:  now,
+	}); err != nil {
+		h.log.Error("failed to store device code", "error", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
 	}
-	h.deviceCodesMu.Unlock()
 
-	// Clean up expired codes periodically
-	go h.cleanupExpiredDeviceCodes()
+	// Sweep expired codes each time a login starts
+	if err := h.storage.DeleteExpiredDeviceCodes(ctx, now); err != nil {
+		h.log.Warn("failed to delete expired device codes", "error", err)
+	}
 
 	// Build verification URL
 	verificationURI := "/auth/device/verify"
@@ -1006,15 +1133,15 @@ func (h *AuthHandler) handleDeviceVerify(w http.ResponseWriter, r *http.Request)
 		}
 
 		// Find the device code by user code
-		h.deviceCodesMu.Lock()
 		var foundDeviceCode string
-		for dc, info := range h.deviceCodes {
-			if info.UserCode == userCode && time.Now().Before(info.ExpiresAt) {
-				foundDeviceCode = dc
-				break
-			}
+		dc, err := h.storage.GetDeviceCodeByUserCode(r.Context(), userCode)
+		if err == nil {
+			foundDeviceCode = dc.DeviceCode
+		} else if err != storage.ErrNotFound {
+			h.log.Error("failed to look up device code", "error", err)
+			h.renderDeviceVerifyPage(w, userCode, "Something went wrong. Please try again.")
+			return
 		}
-		h.deviceCodesMu.Unlock()
 
 		// Record the attempt (success or failure)
 		h.recordDeviceVerifyAttempt(ip, foundDeviceCode != "")
@@ -1034,12 +1161,11 @@ func (h *AuthHandler) handleDeviceVerify(w http.ResponseWriter, r *http.Request)
 		}
 
 		// Authorize the device
-		h.deviceCodesMu.Lock()
-		if dc, ok := h.deviceCodes[foundDeviceCode]; ok {
-			dc.Authorized = true
-			dc.Email = email
+		if err := h.storage.AuthorizeDeviceCode(r.Context(), foundDeviceCode, email); err != nil {
+			h.log.Error("failed to authorize device code", "error", err)
+			h.renderDeviceVerifyPage(w, userCode, "Invalid or expired code")
+			return
 		}
-		h.deviceCodesMu.Unlock()
 
 		h.log.Info("device authorized", "email", email, "code", userCode)
 		h.renderDeviceVerifyPage(w, "", "Device authorized! You can close this window.")
@@ -1247,22 +1373,25 @@ func (h *AuthHandler) handleDeviceToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.deviceCodesMu.RLock()
-	dc, exists := h.deviceCodes[req.DeviceCode]
-	h.deviceCodesMu.RUnlock()
-
-	if !exists {
+	ctx := r.Context()
+	dc, err := h.storage.GetDeviceCode(ctx, req.DeviceCode)
+	if err == storage.ErrNotFound {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_device_code"})
 		return
 	}
+	if err != nil {
+		h.log.Error("failed to get device code", "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "server_error"})
+		return
+	}
 
 	if time.Now().After(dc.ExpiresAt) {
 		// Clean up expired code
-		h.deviceCodesMu.Lock()
-		delete(h.deviceCodes, req.DeviceCode)
-		h.deviceCodesMu.Unlock()
+		_ = h.storage.DeleteDeviceCode(ctx, req.DeviceCode)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -1288,9 +1417,9 @@ func (h *AuthHandler) handleDeviceToken(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Clean up the device code
-	h.deviceCodesMu.Lock()
-	delete(h.deviceCodes, req.DeviceCode)
-	h.deviceCodesMu.Unlock()
+	if err := h.storage.DeleteDeviceCode(ctx, req.DeviceCode); err != nil {
+		h.log.Warn("failed to delete device code", "error", err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -1301,17 +1430,95 @@ func (h *AuthHandler) handleDeviceToken(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// maxUserSessionAge caps how long refreshing can keep a CLI login alive.
+// Past it, the CLI has to go through 'cinch login' again.
+const maxUserSessionAge = 180 * 24 * time.Hour
+
+// handleTokenRefresh exchanges a valid CLI token for a fresh one, so the CLI
+// can renew a token that is about to expire without a new device login.
+// The new token keeps the original login time, and refresh is refused once
+// the session is older than maxUserSessionAge.
+func (h *AuthHandler) handleTokenRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	email := h.ValidateUserToken(tokenString)
+	if email == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	loginAt, ok := h.userTokenLoginTime(tokenString)
+	if !ok || time.Since(loginAt) > maxUserSessionAge {
+		http.Error(w, "session expired, run 'cinch login'", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := h.signUserToken(email, loginAt)
+	if err != nil {
+		h.log.Error("failed to create user token", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"email":        email,
+	})
+}
+
 // createUserToken creates a long-lived JWT for CLI use.
 func (h *AuthHandler) createUserToken(email string) (string, error) {
+	return h.signUserToken(email, time.Now())
+}
+
+// signUserToken signs a CLI token for a login that happened at loginAt.
+// The token expires after 90 days, or when the session reaches
+// maxUserSessionAge if that comes first.
+func (h *AuthHandler) signUserToken(email string, loginAt time.Time) (string, error) {
+	now := time.Now()
+	exp := now.Add(90 * 24 * time.Hour) // 90 days
+	if limit := loginAt.Add(maxUserSessionAge); limit.Before(exp) {
+		exp = limit
+	}
 	claims := jwt.MapClaims{
-		"sub":  email,
-		"type": "user",
-		"iat":  time.Now().Unix(),
-		"exp":  time.Now().Add(90 * 24 * time.Hour).Unix(), // 90 days
+		"sub":       email,
+		"type":      "user",
+		"iat":       now.Unix(),
+		"exp":       exp.Unix(),
+		"auth_time": loginAt.Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(h.getJWTSigningKey())
+	return h.signJWT(claims)
+}
+
+// userTokenLoginTime returns when the login behind a CLI token happened.
+// Tokens issued before auth_time was recorded fall back to their iat.
+func (h *AuthHandler) userTokenLoginTime(tokenString string) (time.Time, bool) {
+	token, err := h.parseJWT(tokenString)
+	if err != nil || !token.Valid {
+		return time.Time{}, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, name := range []string{"auth_time", "iat"} {
+		if v, ok := claims[name].(float64); ok && v > 0 {
+			return time.Unix(int64(v), 0), true
+		}
+	}
+	return time.Time{}, false
 }
 
 // getWsURL returns the WebSocket URL for workers to connect to.
@@ -1334,12 +1541,7 @@ func (h *AuthHandler) getWsURL() string {
 // ValidateUserToken validates a Bearer token from the CLI.
 // Returns the user's email if valid, empty string if not.
 func (h *AuthHandler) ValidateUserToken(tokenString string) string {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return h.getJWTSigningKey(), nil
-	})
+	token, err := h.parseJWT(tokenString)
 	if err != nil || !token.Valid {
 		return ""
 	}
@@ -1363,18 +1565,6 @@ func (h *AuthHandler) ValidateUserToken(tokenString string) string {
 	return sub
 }
 
-func (h *AuthHandler) cleanupExpiredDeviceCodes() {
-	h.deviceCodesMu.Lock()
-	defer h.deviceCodesMu.Unlock()
-
-	now := time.Now()
-	for code, info := range h.deviceCodes {
-		if now.After(info.ExpiresAt) {
-			delete(h.deviceCodes, code)
-		}
-	}
-}
-
 // --- Helpers ---
 
 // sanitizeReturnTo validates and sanitizes a return_to URL parameter.
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldAuth := NewAuthHandler(AuthConfig{JWTSecret: "old-secret"}, nil, nil)
+	rotating := NewAuthHandler(AuthConfig{JWTSecret: "old-secret", JWTSecondarySecret: "new-secret"}, nil, nil)
+	rotated := NewAuthHandler(AuthConfig{JWTSecret: "new-secret"}, nil, nil)
+
+	oldToken, err := oldAuth.createUserToken("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mid-rotation: tokens from the old key still work, new ones use the new key
+	if got := rotating.ValidateUserToken(oldToken); got != "alice@example.com" {
+		t.Errorf("old token rejected during rotation, got %q", got)
+	}
+	newToken, err := rotating.createUserToken("bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if parsed.Header["kid"] != JWTKeyID([]byte("new-secret")) {
+		t.Errorf("kid = %v, want new key's ID", parsed.Header["kid"])
+	}
+
+	// After rotation: new tokens survive, old key is gone
+	if got := rotated.ValidateUserToken(newToken); got != "bob@example.com" {
+		t.Errorf("new token rejected after rotation, got %q", got)
+	}
+	if got := rotated.ValidateUserToken(oldToken); got != "" {
+		t.Errorf("old token accepted after old key removed")
+	}
+
+	// Legacy tokens without a kid verify against any configured key
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  "carol@example.com",
+		"type": "user",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+	legacyToken, _ := legacy.SignedString([]byte("old-secret"))
+	if got := rotating.ValidateUserToken(legacyToken); got != "carol@example.com" {
+		t.Errorf("legacy token rejected, got %q", got)
+	}
+
+	// Cookies are signed the same way
+	w := httptest.NewRecorder()
+	if err := rotating.SetAuthCookie(w, "dave@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(w.Result().Cookies()[0])
+	if user, ok := rotated.getAuthFromCookie(req); !ok || user != "dave@example.com" {
+		t.Errorf("cookie from rotating server rejected after rotation")
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	auth := NewAuthHandler(AuthConfig{JWTSecret: "secret"}, nil, nil)
+	token, err := auth.createUserToken("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/auth/token/refresh", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	auth.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := auth.ValidateUserToken(resp.AccessToken); got != "alice@example.com" {
+		t.Errorf("refreshed token validates as %q", got)
+	}
+
+	// Refreshing keeps the original login time
+	loginAt, _ := auth.userTokenLoginTime(token)
+	if got, ok := auth.userTokenLoginTime(resp.AccessToken); !ok || !got.Equal(loginAt) {
+		t.Errorf("refreshed login time = %v, want %v", got, loginAt)
+	}
+
+	// Invalid tokens can't be refreshed
+	req = httptest.NewRequest("POST", "/auth/token/refresh", nil)
+	req.Header.Set("Authorization", "Bearer garbage")
+	w = httptest.NewRecorder()
+	auth.ServeHTTP(w, req)
+	if w.Code != 401 {
+		t.Errorf("status = %d for invalid token, want 401", w.Code)
+	}
+
+	// Sessions past the maximum age need a new login, even with an unexpired token
+	stale, err := auth.signJWT(jwt.MapClaims{
+		"sub":       "alice@example.com",
+		"type":      "user",
+		"iat":       time.Now().Add(-time.Hour).Unix(),
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"auth_time": time.Now().Add(-maxUserSessionAge - time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest("POST", "/auth/token/refresh", nil)
+	req.Header.Set("Authorization", "Bearer "+stale)
+	w = httptest.NewRecorder()
+	auth.ServeHTTP(w, req)
+	if w.Code != 401 || !strings.Contains(w.Body.String(), "cinch login") {
+		t.Errorf("status = %d (%s) for expired session, want 401", w.Code, strings.TrimSpace(w.Body.String()))
+	}
+}
+
+func TestDeviceLoginSurvivesRestart(t *testing.T) {
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	defer store.Close()
+	cfg := AuthConfig{JWTSecret: "secret"}
+
+	// Start a login
+	before := NewAuthHandler(cfg, store, nil)
+	w := httptest.NewRecorder()
+	before.ServeHTTP(w, httptest.NewRequest("POST", "/auth/device", nil))
+	var start struct {
+		DeviceCode string `json:"device_code"`
+		UserCode   string `json:"user_code"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&start); err != nil || start.DeviceCode == "" {
+		t.Fatalf("device start failed: %v %s", err, w.Body.String())
+	}
+
+	// The server restarts; the user authorizes in the browser and the CLI keeps polling
+	after := NewAuthHandler(cfg, store, nil)
+	poll := func() map[string]any {
+		t.Helper()
+		w := httptest.NewRecorder()
+		after.ServeHTTP(w, httptest.NewRequest("POST", "/auth/device/token",
+			strings.NewReader(`{"device_code":"`+start.DeviceCode+`"}`)))
+		var resp map[string]any
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+	if resp := poll(); resp["error"] != "authorization_pending" {
+		t.Fatalf("poll before verify = %v, want authorization_pending", resp)
+	}
+
+	cookie := httptest.NewRecorder()
+	if err := after.SetAuthCookie(cookie, "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/auth/device/verify", strings.NewReader(url.Values{"code": {start.UserCode}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie.Result().Cookies()[0])
+	after.ServeHTTP(httptest.NewRecorder(), req)
+
+	resp := poll()
+	if resp["email"] != "alice@example.com" || resp["access_token"] == nil {
+		t.Fatalf("poll after verify = %v, want a token for alice@example.com", resp)
+	}
+
+	// The code is single-use
+	if resp := poll(); resp["error"] != "invalid_device_code" {
+		t.Errorf("second poll = %v, want invalid_device_code", resp)
+	}
+}
+
+func TestGitHubEnterpriseURLs(t *testing.T) {
+	tests := []struct {
+		base, api         string
+		wantBase, wantAPI string
+	}{
+		{"", "", "https://github.com", "https://api.github.com"},
+		{"https://github.example.com/", "", "https://github.example.com", "https://github.example.com/api/v3"},
+		{"https://github.example.com", "https://api.github.example.com/", "https://github.example.com", "https://api.github.example.com"},
+	}
+	for _, tt := range tests {
+		base, api := githubEndpoints(tt.base, tt.api)
+		if base != tt.wantBase || api != tt.wantAPI {
+			t.Errorf("githubEndpoints(%q, %q) = %q, %q; want %q, %q", tt.base, tt.api, base, api, tt.wantBase, tt.wantAPI)
+		}
+	}
+
+	// The login redirect goes to the configured instance
+	auth := NewAuthHandler(AuthConfig{
+		GitHubClientID: "client",
+		GitHubBaseURL:  "https://github.example.com",
+		JWTSecret:      "secret",
+		BaseURL:        "https://ci.example.com",
+	}, nil, nil)
+	w := httptest.NewRecorder()
+	auth.ServeHTTP(w, httptest.NewRequest("GET", "/auth/github", nil))
+	if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, "https://github.example.com/login/oauth/authorize?client_id=client&") {
+		t.Errorf("authorize redirect = %q", loc)
+	}
+
+	// Token and user requests hit the configured instance's web and API paths
+	var paths []string
+	ghes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			_, _ = w.Write([]byte(`{"access_token":"gho_x"}`))
+		case "/api/v3/user":
+			_, _ = w.Write([]byte(`{"login":"octocat","id":1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ghes.Close()
+
+	auth = NewAuthHandler(AuthConfig{GitHubClientID: "client", GitHubBaseURL: ghes.URL, JWTSecret: "secret"}, nil, nil)
+	token, err := auth.exchangeGitHubCode("code")
+	if err != nil || token != "gho_x" {
+		t.Fatalf("exchangeGitHubCode = %q, %v", token, err)
+	}
+	user, err := auth.getGitHubUser(token)
+	if err != nil || user.Login != "octocat" {
+		t.Fatalf("getGitHubUser = %+v, %v", user, err)
+	}
+	if strings.Join(paths, ",") != "/login/oauth/access_token,/api/v3/user" {
+		t.Errorf("requested paths = %v", paths)
+	}
+}
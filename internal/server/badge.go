@@ -195,8 +195,10 @@ func (h *BadgeHandler) getRepoStatus(ctx context.Context, forge, owner, repo, br
 	switch jobs[0].Status {
 	case storage.JobStatusSuccess:
 		return "passing"
-	case storage.JobStatusFailed, storage.JobStatusError:
+	case storage.JobStatusFailed:
 		return "failing"
+	case storage.JobStatusError:
+		return "error"
 	case storage.JobStatusRunning, storage.JobStatusQueued, storage.JobStatusPending:
 		return "running"
 	default:
@@ -210,6 +212,8 @@ func statusToColor(status string) string {
 		return "brightgreen"
 	case "failing":
 		return "red"
+	case "error":
+		return "orange"
 	case "running":
 		return "yellow"
 	default:
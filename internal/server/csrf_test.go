@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	h := CSRFMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Issue a token the way a page load does
+	w := httptest.NewRecorder()
+	token := EnsureCSRFCookie(w, httptest.NewRequest("GET", "/", nil))
+	if token == "" {
+		t.Fatal("no CSRF token issued")
+	}
+	csrfCookie := w.Result().Cookies()[0]
+	sessionCookie := &http.Cookie{Name: authCookieName, Value: "session"}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		cookies []*http.Cookie
+		header  string
+		bearer  bool
+		want    int
+	}{
+		{"matching token", "POST", "/api/repos", []*http.Cookie{sessionCookie, csrfCookie}, token, false, http.StatusOK},
+		{"matching token delete", "DELETE", "/api/user", []*http.Cookie{sessionCookie, csrfCookie}, token, false, http.StatusOK},
+		{"missing header", "POST", "/api/repos", []*http.Cookie{sessionCookie, csrfCookie}, "", false, http.StatusForbidden},
+		{"mismatched header", "DELETE", "/api/user", []*http.Cookie{sessionCookie, csrfCookie}, "forged", false, http.StatusForbidden},
+		{"missing cookie", "POST", "/api/repos", []*http.Cookie{sessionCookie}, token, false, http.StatusForbidden},
+		{"forge setup route", "POST", "/api/gitlab/setup", []*http.Cookie{sessionCookie}, "", false, http.StatusForbidden},
+		{"bearer exempt", "POST", "/api/repos", []*http.Cookie{sessionCookie}, "", true, http.StatusOK},
+		{"no session", "POST", "/api/repos", nil, "", false, http.StatusOK},
+		{"safe method", "GET", "/api/repos", []*http.Cookie{sessionCookie}, "", false, http.StatusOK},
+		{"outside api", "POST", "/webhooks", []*http.Cookie{sessionCookie}, "", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for _, c := range tt.cookies {
+				req.AddCookie(c)
+			}
+			if tt.header != "" {
+				req.Header.Set(csrfHeaderName, tt.header)
+			}
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureCSRFCookie(t *testing.T) {
+	// An existing token is reused, not rotated on every page load
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: strings.Repeat("a", 43)})
+	w := httptest.NewRecorder()
+	if got := EnsureCSRFCookie(w, req); got != strings.Repeat("a", 43) {
+		t.Errorf("token = %q, want existing cookie value", got)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("existing token was reissued")
+	}
+
+	page := InjectCSRFMeta([]byte("<html><head><title>Cinch</title></head></html>"), `tok"en`)
+	if !strings.Contains(string(page), `<meta name="csrf-token" content="tok&#34;en" />`) ||
+		!strings.HasSuffix(string(page), "</head></html>") {
+		t.Errorf("page = %s", page)
+	}
+}
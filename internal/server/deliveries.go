@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// Delivery sources (which handler received the webhook)
+const (
+	deliverySourceWebhook   = "webhook"
+	deliverySourceGitHubApp = "github_app"
+)
+
+// deliveryRetention is how long webhook deliveries are kept for replay.
+const deliveryRetention = 7 * 24 * time.Hour
+
+// deliveryIDHeaders are forge headers carrying a unique delivery ID, in lookup order.
+var deliveryIDHeaders = []string{
+	"X-GitHub-Delivery",
+	"X-Gitlab-Event-UUID",
+	"X-Forgejo-Delivery",
+	"X-Gitea-Delivery",
+}
+
+// redactedHeaders carry credentials and are never stored.
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"X-Gitlab-Token":      true,
+	"X-Hub-Signature":     true,
+	"X-Hub-Signature-256": true,
+	"X-Gitea-Signature":   true,
+	"X-Forgejo-Signature": true,
+	"X-Gogs-Signature":    true,
+}
+
+// maxDeliveryResultLen caps the stored summary of a handler response.
+const maxDeliveryResultLen = 500
+
+type replayKey struct{}
+
+type deliveryOutcomeKey struct{}
+
+// deliveryOutcome links a request to the delivery recorded while handling it.
+type deliveryOutcome struct {
+	id string
+}
+
+// deliveryResponseWriter captures the status and (start of the) body a
+// webhook handler writes, so the outcome can be saved with the delivery.
+type deliveryResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *deliveryResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *deliveryResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := maxDeliveryResultLen - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(room, len(b))])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// trackDelivery prepares a webhook request so that, once handled, the
+// handler's response is saved on the delivery recorded for it (if any).
+// Call the returned finish func after the handler returns.
+func trackDelivery(store storage.Storage, log *slog.Logger, w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	outcome := &deliveryOutcome{}
+	rw := &deliveryResponseWriter{ResponseWriter: w}
+	r = r.WithContext(context.WithValue(r.Context(), deliveryOutcomeKey{}, outcome))
+	finish := func() {
+		if outcome.id == "" {
+			return
+		}
+		saveDeliveryResult(context.Background(), store, log, outcome.id, rw.status, rw.body.Bytes())
+	}
+	return rw, r, finish
+}
+
+// saveDeliveryResult stores the handler's response as the delivery's outcome.
+func saveDeliveryResult(ctx context.Context, store storage.Storage, log *slog.Logger, id string, status int, body []byte) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	result, jobID := summarizeDeliveryResponse(body)
+	if err := store.UpdateWebhookDeliveryResult(ctx, id, status, result, jobID); err != nil {
+		log.Warn("failed to save webhook delivery result", "delivery_id", id, "error", err)
+	}
+}
+
+// summarizeDeliveryResponse extracts a short result and the job ID from a
+// webhook handler response. Handlers reply with {"job_id": ...} on success
+// and plain text or {"error": ...} otherwise.
+func summarizeDeliveryResponse(body []byte) (result, jobID string) {
+	var resp struct {
+		JobID string `json:"job_id"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil {
+		if resp.Error != "" {
+			return resp.Error, resp.JobID
+		}
+		if resp.JobID != "" {
+			return "job created", resp.JobID
+		}
+	}
+	result = strings.TrimSpace(string(body))
+	if len(result) > maxDeliveryResultLen {
+		result = result[:maxDeliveryResultLen]
+	}
+	return result, ""
+}
+
+// withReplay marks a context as an admin-initiated replay of a stored delivery.
+// Replays skip signature verification (the stored copy has no credentials) and
+// are not recorded again.
+func withReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, true)
+}
+
+// isReplay reports whether the request is a replay of a stored delivery.
+func isReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
+
+// recordDelivery stores a redacted copy of a verified webhook so it can be replayed later.
+// Failures are logged and never affect webhook processing.
+func recordDelivery(ctx context.Context, store storage.Storage, log *slog.Logger, source string, r *http.Request, body []byte, repoID string) {
+	if isReplay(ctx) {
+		return
+	}
+
+	d := &storage.WebhookDelivery{
+		ID:        deliveryID(r),
+		RepoID:    repoID,
+		Source:    source,
+		Headers:   redactHeaders(r.Header),
+		Payload:   redactPayload(body),
+		CreatedAt: time.Now(),
+	}
+	err := store.CreateWebhookDelivery(ctx, d)
+	if errors.Is(err, storage.ErrDeliveryExists) {
+		// Another repo's delivery has this ID; keep both
+		d.ID = generatedDeliveryID()
+		err = store.CreateWebhookDelivery(ctx, d)
+	}
+	if err != nil {
+		log.Warn("failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+		return
+	}
+	log.Debug("recorded webhook delivery", "delivery_id", d.ID, "repo_id", repoID)
+	if outcome, ok := ctx.Value(deliveryOutcomeKey{}).(*deliveryOutcome); ok {
+		outcome.id = d.ID
+	}
+}
+
+// deliveryID returns the forge's delivery ID, or generates one if the forge doesn't send it.
+func deliveryID(r *http.Request) string {
+	for _, h := range deliveryIDHeaders {
+		if id := r.Header.Get(h); id != "" {
+			return id
+		}
+	}
+	return generatedDeliveryID()
+}
+
+func generatedDeliveryID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "dlv_" + hex.EncodeToString(b)
+}
+
+// redactHeaders flattens headers, dropping any that carry credentials.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if redactedHeaders[http.CanonicalHeaderKey(k)] || len(v) == 0 {
+			continue
+		}
+		out[k] = v[0]
+	}
+	return out
+}
+
+// redactPayload replaces the values of secret-looking JSON fields.
+// Non-JSON bodies are stored as-is.
+func redactPayload(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if isSecretField(k) {
+				if s, ok := child.(string); ok && s != "" {
+					v[k] = "[REDACTED]"
+				}
+				continue
+			}
+			v[k] = redactValue(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child)
+		}
+	}
+	return v
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"token", "secret", "password"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
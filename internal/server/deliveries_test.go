@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ehrlich-b/cinch/internal/forge"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-GitHub-Event", "push")
+	h.Set("X-GitHub-Delivery", "abc-123")
+	h.Set("X-Hub-Signature-256", "sha256=deadbeef")
+	h.Set("X-Gitlab-Token", "secret")
+	h.Set("Authorization", "Basic Zm9vOmJhcg==")
+
+	got := redactHeaders(h)
+	if got["X-Github-Event"] != "push" || got["X-Github-Delivery"] != "abc-123" {
+		t.Errorf("event headers not kept: %v", got)
+	}
+	for _, k := range []string{"X-Hub-Signature-256", "X-Gitlab-Token", "Authorization"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("header %s should be redacted", k)
+		}
+	}
+}
+
+func TestRedactPayload(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main","id":12345678901234567890,"hook":{"secret":"s3cret","config":{"token":"t0ken"}},"commits":[{"password":"hunter2"}]}`)
+
+	var got map[string]any
+	if err := json.Unmarshal(redactPayload(body), &got); err != nil {
+		t.Fatalf("redacted payload is not JSON: %v", err)
+	}
+
+	if got["ref"] != "refs/heads/main" {
+		t.Errorf("ref = %v, want refs/heads/main", got["ref"])
+	}
+	hook := got["hook"].(map[string]any)
+	if hook["secret"] != "[REDACTED]" {
+		t.Errorf("hook.secret = %v, want redacted", hook["secret"])
+	}
+	if hook["config"].(map[string]any)["token"] != "[REDACTED]" {
+		t.Errorf("hook.config.token not redacted")
+	}
+	if got["commits"].([]any)[0].(map[string]any)["password"] != "[REDACTED]" {
+		t.Errorf("commits[0].password not redacted")
+	}
+
+	// Large numbers survive without float rounding
+	if !strings.Contains(string(redactPayload(body)), "12345678901234567890") {
+		t.Errorf("numeric id was altered: %s", redactPayload(body))
+	}
+
+	// Non-JSON bodies (e.g. form-encoded) are stored unchanged
+	form := []byte("payload=%7B%7D")
+	if string(redactPayload(form)) != string(form) {
+		t.Errorf("non-JSON body was modified")
+	}
+}
+
+func TestWebhookDeliveryResultRecorded(t *testing.T) {
+	repo := &forge.Repo{ForgeType: "github", Owner: "test", Name: "repo", CloneURL: "https://github.com/test/repo.git"}
+	h, store := newDedupTestHandler(t, &fakeForge{push: &forge.PushEvent{Repo: repo, Commit: "abc123def456", Ref: "refs/heads/main", Branch: "main"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Delivery", "dlv-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+
+	d, err := store.GetWebhookDelivery(t.Context(), "dlv-1")
+	if err != nil {
+		t.Fatalf("GetWebhookDelivery failed: %v", err)
+	}
+	if d.Status != http.StatusAccepted || d.JobID == "" {
+		t.Errorf("delivery status = %d, job = %q; want 202 and a job ID", d.Status, d.JobID)
+	}
+}
+
+func TestRecordDeliveryKeepsOtherReposDelivery(t *testing.T) {
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	record := func(repoID string) {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"repo":"`+repoID+`"}`))
+		req.Header.Set("X-GitHub-Delivery", "dlv-1")
+		recordDelivery(t.Context(), store, slog.Default(), deliverySourceWebhook, req, []byte(`{"repo":"`+repoID+`"}`), repoID)
+	}
+	record("r_1")
+	record("r_2")
+
+	d, err := store.GetWebhookDelivery(t.Context(), "dlv-1")
+	if err != nil {
+		t.Fatalf("GetWebhookDelivery failed: %v", err)
+	}
+	if d.RepoID != "r_1" || string(d.Payload) != `{"repo":"r_1"}` {
+		t.Errorf("delivery = %s %s, want r_1's to be kept", d.RepoID, d.Payload)
+	}
+
+	// A redelivery for the same repo still replaces it
+	record("r_1")
+	if _, err := store.GetWebhookDelivery(t.Context(), "dlv-1"); err != nil {
+		t.Errorf("GetWebhookDelivery after redelivery failed: %v", err)
+	}
+}
+
+func TestSummarizeDeliveryResponse(t *testing.T) {
+	tests := []struct {
+		body, result, jobID string
+	}{
+		{`{"job_id": "j_1"}`, "job created", "j_1"},
+		{`{"job_id": "j_1", "error": "billing_required"}`, "billing_required", "j_1"},
+		{"repo not found\n", "repo not found", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		result, jobID := summarizeDeliveryResponse([]byte(tt.body))
+		if result != tt.result || jobID != tt.jobID {
+			t.Errorf("summarize(%q) = %q, %q; want %q, %q", tt.body, result, jobID, tt.result, tt.jobID)
+		}
+	}
+}
@@ -2,10 +2,15 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ehrlich-b/cinch/internal/mailer"
+	"github.com/ehrlich-b/cinch/internal/notify"
 	"github.com/ehrlich-b/cinch/internal/protocol"
 	"github.com/ehrlich-b/cinch/internal/storage"
 )
@@ -24,17 +29,60 @@ type Dispatcher struct {
 	inflight map[string]*QueuedJob // jobs dispatched but not completed
 	queueCh  chan struct{}         // signals new jobs in queue
 
+	// Tier concurrency limits (hosted service)
+	tierLimits     bool
+	pendingReasons map[string]string // queued job ID -> why it isn't dispatched yet
+
+	metrics *Metrics
+
+	// Job result notifications (optional)
+	notifier *notify.Notifier
+	mailer   *mailer.Mailer
+	baseURL  string // for job links in notifications
+
 	// Control
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
+// noWorkerThreshold is how long a job waits for a worker before the
+// dispatcher explains why it's stuck.
+const noWorkerThreshold = 30 * time.Second
+
+// Backoff for automatic retries after infrastructure errors: the first
+// retry waits infraRetryBaseDelay, each later one twice as long as the last.
+const (
+	infraRetryBaseDelay = 30 * time.Second
+	infraRetryMaxDelay  = 10 * time.Minute
+)
+
 // SetGitHubApp sets the GitHub App handler for token regeneration on recovery.
 func (d *Dispatcher) SetGitHubApp(app *GitHubAppHandler) {
 	d.githubApp = app
 }
 
+// SetNotifier enables posting finished jobs to each repo's notification
+// URLs. baseURL is used to link to the job.
+func (d *Dispatcher) SetNotifier(n *notify.Notifier, baseURL string) {
+	d.notifier = n
+	d.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetMailer enables emailing commit authors when their jobs fail, for repos
+// that opt in. Job links use the base URL given to SetNotifier.
+func (d *Dispatcher) SetMailer(m *mailer.Mailer) {
+	d.mailer = m
+}
+
+// SetTierLimits enables per-user concurrent job limits based on the repo
+// owner's tier. Jobs beyond the limit stay queued until one finishes.
+func (d *Dispatcher) SetTierLimits(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tierLimits = enabled
+}
+
 // QueuedJob represents a job waiting for a worker.
 type QueuedJob struct {
 	Job            *storage.Job
@@ -51,6 +99,11 @@ type QueuedJob struct {
 	QueuedAt       time.Time
 	Attempts       int
 	MaxRetries     int
+	InfraRetries   int       // Automatic retries used after infrastructure errors
+	RetryAt        time.Time // Not dispatched before this (infrastructure retry backoff)
+	OwnerLimit     int       // Owner's concurrent job limit, looked up when queued (0 = unlimited)
+
+	heldAt time.Time // Last dispatch pass that held the job for a repo or owner slot
 }
 
 // NewDispatcher creates a new job dispatcher.
@@ -67,8 +120,11 @@ func NewDispatcher(hub *Hub, store storage.Storage, ws *WSHandler, log *slog.Log
 		queue:    make([]*QueuedJob, 0),
 		inflight: make(map[string]*QueuedJob),
 		queueCh:  make(chan struct{}, 1),
-		ctx:      ctx,
-		cancel:   cancel,
+
+		pendingReasons: make(map[string]string),
+		metrics:        NewMetrics(),
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 }
 
@@ -101,84 +157,86 @@ func (d *Dispatcher) recoverOrphanedJobs() {
 			continue
 		}
 
-		// Get repo for this job
-		repo, err := d.storage.GetRepo(ctx, job.RepoID)
-		if err != nil {
-			d.log.Error("failed to get repo for orphaned job", "job_id", job.ID, "error", err)
-			d.markJobError(ctx, job.ID)
+		if err := d.requeueStored(ctx, job); err != nil {
+			d.log.Error("failed to recover orphaned job", "job_id", job.ID, "error", err)
 			failed++
 			continue
 		}
+		recovered++
+		d.log.Info("re-queued orphaned job", "job_id", job.ID, "prev_status", job.Status)
+	}
 
-		// Determine clone token
-		var cloneToken string
-		var installationID int64
-
-		if job.InstallationID != nil && d.githubApp != nil && d.githubApp.IsConfigured() {
-			// GitHub App job - regenerate token
-			installationID = *job.InstallationID
-			token, err := d.githubApp.GetInstallationToken(installationID)
-			if err != nil {
-				d.log.Error("failed to get installation token for recovery", "job_id", job.ID, "error", err)
-				d.markJobError(ctx, job.ID)
-				failed++
-				continue
-			}
-			cloneToken = token
-		} else {
-			// Non-GitHub App - use stored forge token
-			cloneToken = repo.ForgeToken
-		}
+	if recovered > 0 || failed > 0 {
+		d.log.Info("orphan job recovery complete", "recovered", recovered, "failed", failed)
+	}
+}
 
-		// Reconstruct ref from branch or tag
-		var ref string
-		if job.Branch != "" {
-			ref = "refs/heads/" + job.Branch
-		} else if job.Tag != "" {
-			ref = "refs/tags/" + job.Tag
-		} else {
-			// No branch or tag - can't reconstruct ref
-			d.log.Warn("cannot recover job without branch or tag", "job_id", job.ID)
-			d.markJobError(ctx, job.ID)
-			failed++
-			continue
-		}
+// requeueStored rebuilds a queued job from its stored record and enqueues it.
+// For GitHub App jobs, a fresh token is generated; other forges use
+// repo.ForgeToken. Jobs that can't be rebuilt are marked as error.
+func (d *Dispatcher) requeueStored(ctx context.Context, job *storage.Job) error {
+	repo, err := d.storage.GetRepo(ctx, job.RepoID)
+	if err != nil {
+		d.markJobError(ctx, job.ID)
+		return fmt.Errorf("get repo: %w", err)
+	}
 
-		// Reset job status to pending (we're re-queuing it)
-		if err := d.storage.UpdateJobStatus(ctx, job.ID, storage.JobStatusPending, nil); err != nil {
-			d.log.Error("failed to reset job status", "job_id", job.ID, "error", err)
-			failed++
-			continue
-		}
+	// Determine clone token
+	var cloneToken string
+	var installationID int64
 
-		// Re-queue the job
-		queuedJob := &QueuedJob{
-			Job:            job,
-			Repo:           repo,
-			CloneURL:       repo.CloneURL,
-			Ref:            ref,
-			Branch:         job.Branch,
-			Tag:            job.Tag,
-			CloneToken:     cloneToken,
-			InstallationID: installationID,
-			// Note: Config is empty - worker reads .cinch.yaml after clone
+	if job.InstallationID != nil && d.githubApp != nil && d.githubApp.IsConfigured() {
+		// GitHub App job - regenerate token
+		installationID = *job.InstallationID
+		token, err := d.githubApp.GetInstallationToken(installationID)
+		if err != nil {
+			d.markJobError(ctx, job.ID)
+			return fmt.Errorf("get installation token: %w", err)
 		}
+		cloneToken = token
+	} else {
+		// Non-GitHub App - use stored forge token
+		cloneToken = repo.ForgeToken
+	}
 
-		d.Enqueue(queuedJob)
-		recovered++
-		d.log.Info("re-queued orphaned job", "job_id", job.ID, "prev_status", job.Status)
+	// Reconstruct ref from branch or tag
+	var ref string
+	if job.Branch != "" {
+		ref = "refs/heads/" + job.Branch
+	} else if job.Tag != "" {
+		ref = "refs/tags/" + job.Tag
+	} else {
+		// No branch or tag - can't reconstruct ref
+		d.markJobError(ctx, job.ID)
+		return fmt.Errorf("job has no branch or tag")
 	}
 
-	if recovered > 0 || failed > 0 {
-		d.log.Info("orphan job recovery complete", "recovered", recovered, "failed", failed)
+	// Reset job status to pending (we're re-queuing it)
+	if err := d.storage.UpdateJobStatus(ctx, job.ID, storage.JobStatusPending, nil); err != nil {
+		return fmt.Errorf("reset job status: %w", err)
 	}
+
+	d.Enqueue(&QueuedJob{
+		Job:            job,
+		Repo:           repo,
+		CloneURL:       repo.CloneURL,
+		Ref:            ref,
+		Branch:         job.Branch,
+		Tag:            job.Tag,
+		CloneToken:     cloneToken,
+		InstallationID: installationID,
+		// Note: Config is empty - worker reads .cinch.yaml after clone
+	})
+	return nil
 }
 
 // markJobError marks a job as error status.
 func (d *Dispatcher) markJobError(ctx context.Context, jobID string) {
 	if err := d.storage.UpdateJobStatus(ctx, jobID, storage.JobStatusError, nil); err != nil {
 		d.log.Error("failed to mark job as error", "job_id", jobID, "error", err)
+		return
 	}
+	d.metrics.JobFinished(storage.JobStatusError)
 }
 
 // Stop stops the dispatcher and waits for goroutines.
@@ -195,6 +253,12 @@ func (d *Dispatcher) Enqueue(job *QueuedJob) {
 		return
 	}
 
+	// Look up the owner's limit before taking d.mu so the DB round-trip
+	// doesn't block dispatch
+	if owner := jobOwner(job); owner != "" && d.tierLimitsEnabled() {
+		job.OwnerLimit = d.concurrencyLimit(owner)
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -240,16 +304,212 @@ func (d *Dispatcher) tryDispatch() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	var running map[string]int
+	if d.tierLimits {
+		running = d.runningByOwner()
+	}
+	repoRunning := d.runningByRepo()
+	reasons := make(map[string]string)
+
 	// Process queue from front
+	now := time.Now()
 	remaining := make([]*QueuedJob, 0, len(d.queue))
 	for _, qj := range d.queue {
+		if now.Before(qj.RetryAt) {
+			reasons[qj.Job.ID] = fmt.Sprintf("retrying after an infrastructure error in %s", qj.RetryAt.Sub(now).Round(time.Second))
+			remaining = append(remaining, qj)
+			continue
+		}
+
+		repoID := qj.Job.RepoID
+		if limit := maxParallel(qj); limit > 0 && repoRunning[repoID] >= limit {
+			reasons[qj.Job.ID] = fmt.Sprintf("waiting for a repo parallel slot (%d/%d jobs running)", repoRunning[repoID], limit)
+			qj.heldAt = now
+			remaining = append(remaining, qj)
+			continue
+		}
+
+		owner := jobOwner(qj)
+		if d.tierLimits && owner != "" {
+			if limit := qj.OwnerLimit; limit > 0 && running[owner] >= limit {
+				reasons[qj.Job.ID] = fmt.Sprintf("waiting for a concurrency slot (%d/%d jobs running)", running[owner], limit)
+				qj.heldAt = now
+				remaining = append(remaining, qj)
+				continue
+			}
+		}
+
 		if d.tryAssign(qj) {
 			d.log.Info("job dispatched", "job_id", qj.Job.ID)
+			if owner != "" && running != nil {
+				running[owner]++
+			}
+			repoRunning[repoID]++
 		} else {
+			if time.Since(qj.QueuedAt) >= noWorkerThreshold && qj.Job.Status != storage.JobStatusPendingContributor {
+				reason := d.noWorkerReason(qj.Labels, qj.Job)
+				reasons[qj.Job.ID] = reason
+				if d.pendingReasons[qj.Job.ID] != reason {
+					d.log.Info("job waiting for worker", "job_id", qj.Job.ID, "reason", reason)
+					d.postPendingReason(qj, reason)
+				}
+			}
 			remaining = append(remaining, qj)
 		}
 	}
 	d.queue = remaining
+	d.pendingReasons = reasons
+}
+
+// noWorkerReason explains why no worker picked up a job with these labels.
+// Only workers allowed to run the job are considered, and the text leaves
+// out worker counts: it's posted to the forge, and shouldn't change (and be
+// re-posted) or reveal other users' workers as they come and go.
+func (d *Dispatcher) noWorkerReason(labels []string, job *storage.Job) string {
+	for _, label := range labels {
+		if _, err := parseLabelExpr(label); err != nil {
+			return fmt.Sprintf("no matching worker: invalid label expression %q (%v)", label, err)
+		}
+	}
+	eligible, matching := d.hub.CountEligible(labels, job)
+	switch {
+	case eligible == 0:
+		return "no workers online"
+	case matching == 0:
+		return fmt.Sprintf("no matching worker for labels [%s]", strings.Join(labels, ", "))
+	default:
+		return "waiting for a free worker"
+	}
+}
+
+// postPendingReason updates the forge's pending status with why a job is stuck.
+// Check runs are left alone: they only take a conclusion once the job finishes.
+func (d *Dispatcher) postPendingReason(qj *QueuedJob, reason string) {
+	if d.ws == nil || d.ws.statusPoster == nil || qj.Job.CheckRunID != nil {
+		return
+	}
+	poster := d.ws.statusPoster
+	jobID := qj.Job.ID
+	go func() {
+		if err := poster.PostJobStatus(context.Background(), jobID, "pending", "Waiting: "+reason); err != nil {
+			d.log.Warn("failed to post pending reason", "job_id", jobID, "error", err)
+		}
+	}()
+}
+
+// jobOwner returns the user whose tier limits apply to a job.
+func jobOwner(qj *QueuedJob) string {
+	if qj.Repo == nil {
+		return ""
+	}
+	return qj.Repo.OwnerUserID
+}
+
+// runningByOwner counts in-flight jobs per owner. Caller must hold d.mu.
+func (d *Dispatcher) runningByOwner() map[string]int {
+	running := make(map[string]int)
+	for _, qj := range d.inflight {
+		if owner := jobOwner(qj); owner != "" {
+			running[owner]++
+		}
+	}
+	return running
+}
+
+// runningByRepo counts in-flight jobs per repo. Caller must hold d.mu.
+func (d *Dispatcher) runningByRepo() map[string]int {
+	running := make(map[string]int)
+	for _, qj := range d.inflight {
+		running[qj.Job.RepoID]++
+	}
+	return running
+}
+
+// maxParallel returns the max concurrent jobs for a queued job's repo (0 = unlimited).
+func maxParallel(qj *QueuedJob) int {
+	if qj.Repo == nil {
+		return 0
+	}
+	return qj.Repo.MaxParallel
+}
+
+// SetRepoMaxParallel applies a changed parallel limit to the repo's jobs
+// that are already queued or running.
+func (d *Dispatcher) SetRepoMaxParallel(repoID string, limit int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	update := func(qj *QueuedJob) {
+		if qj.Job.RepoID != repoID || qj.Repo == nil {
+			return
+		}
+		// Copy: the repo may be shared with handlers outside d.mu
+		repo := *qj.Repo
+		repo.MaxParallel = limit
+		qj.Repo = &repo
+	}
+	for _, qj := range d.queue {
+		update(qj)
+	}
+	for _, qj := range d.inflight {
+		update(qj)
+	}
+
+	select {
+	case d.queueCh <- struct{}{}:
+	default:
+	}
+}
+
+// tierLimitsEnabled reports whether per-user concurrency limits are enforced.
+func (d *Dispatcher) tierLimitsEnabled() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.tierLimits
+}
+
+// concurrencyLimit returns the max concurrent jobs for a user (0 = unlimited).
+// It queries storage, so don't call it while holding d.mu.
+func (d *Dispatcher) concurrencyLimit(userID string) int {
+	user, err := d.storage.GetUserByID(context.Background(), userID)
+	if err != nil {
+		// Don't hold jobs hostage to a lookup failure
+		d.log.Warn("failed to get user for concurrency limit", "user_id", userID, "error", err)
+		return 0
+	}
+	return user.MaxConcurrentJobs()
+}
+
+// PendingReason returns why a queued job hasn't been dispatched, if known.
+func (d *Dispatcher) PendingReason(jobID string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pendingReasons[jobID]
+}
+
+// QueuePosition returns a queued job's 1-based place in line, or 0 if the
+// job isn't in the queue.
+func (d *Dispatcher) QueuePosition(jobID string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, qj := range d.queue {
+		if qj.Job.ID == jobID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Concurrency returns a user's running job count and concurrency limit.
+// The limit is 0 when tier limits are disabled.
+func (d *Dispatcher) Concurrency(user *storage.User) (running, limit int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	running = d.runningByOwner()[user.ID]
+	if d.tierLimits {
+		limit = user.MaxConcurrentJobs()
+	}
+	return running, limit
 }
 
 // tryAssign attempts to assign a job to an available worker.
@@ -287,6 +547,13 @@ func (d *Dispatcher) tryAssign(qj *QueuedJob) bool {
 		},
 		Config: qj.Config,
 	}
+	if qj.Repo.JobTimeout != "" {
+		assign.Config.Timeout = qj.Repo.JobTimeout
+	}
+	if qj.Job.PRNumber != nil {
+		assign.Repo.IsPR = true
+		assign.Repo.PRNumber = *qj.Job.PRNumber
+	}
 
 	// Mark worker as busy BEFORE sending (prevents over-dispatch)
 	d.hub.AddActiveJob(worker.ID, qj.Job.ID)
@@ -341,8 +608,13 @@ func (d *Dispatcher) checkStaleWorkers() {
 			}
 			// Only mark as error if job is still in an active state
 			if job.Status == storage.JobStatusPending || job.Status == storage.JobStatusQueued || job.Status == storage.JobStatusRunning {
+				if _, ok := d.RetryInfraError(jobID); ok {
+					continue
+				}
 				if err := d.storage.UpdateJobStatus(ctx, jobID, storage.JobStatusError, nil); err != nil {
 					d.log.Error("failed to update job status", "job_id", jobID, "error", err)
+				} else {
+					d.metrics.JobFinished(storage.JobStatusError)
 				}
 			}
 		}
@@ -357,7 +629,9 @@ func (d *Dispatcher) checkStaleWorkers() {
 	}
 }
 
-// checkJobTimeouts marks jobs that have been queued too long.
+// checkJobTimeouts marks jobs that have been queued too long. Time spent
+// held for a repo parallel or owner concurrency slot doesn't count: those
+// jobs are waiting their turn, not stuck.
 func (d *Dispatcher) checkJobTimeouts() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -367,11 +641,13 @@ func (d *Dispatcher) checkJobTimeouts() {
 
 	remaining := make([]*QueuedJob, 0, len(d.queue))
 	for _, qj := range d.queue {
-		if now.Sub(qj.QueuedAt) > timeout {
+		if now.Sub(queueClockStart(qj)) > timeout {
 			d.log.Warn("job timed out in queue", "job_id", qj.Job.ID, "queued_at", qj.QueuedAt)
 			ctx := context.Background()
 			if err := d.storage.UpdateJobStatus(ctx, qj.Job.ID, storage.JobStatusError, nil); err != nil {
 				d.log.Error("failed to update job status", "job_id", qj.Job.ID, "error", err)
+			} else {
+				d.metrics.JobFinished(storage.JobStatusError)
 			}
 		} else {
 			remaining = append(remaining, qj)
@@ -380,6 +656,15 @@ func (d *Dispatcher) checkJobTimeouts() {
 	d.queue = remaining
 }
 
+// queueClockStart is when a job's queue timeout starts counting: when it
+// was queued, or when it was last held for a slot.
+func queueClockStart(qj *QueuedJob) time.Time {
+	if qj.heldAt.After(qj.QueuedAt) {
+		return qj.heldAt
+	}
+	return qj.QueuedAt
+}
+
 // QueueLength returns the current queue size.
 func (d *Dispatcher) QueueLength() int {
 	d.mu.Lock()
@@ -425,6 +710,8 @@ func (d *Dispatcher) Requeue(jobID string) {
 		ctx := context.Background()
 		if err := d.storage.UpdateJobStatus(ctx, jobID, storage.JobStatusError, nil); err != nil {
 			d.log.Error("failed to update job status", "job_id", jobID, "error", err)
+		} else {
+			d.metrics.JobFinished(storage.JobStatusError)
 		}
 		return
 	}
@@ -447,11 +734,289 @@ func (d *Dispatcher) Requeue(jobID string) {
 	}
 }
 
-// CompleteJob removes a job from inflight tracking.
-func (d *Dispatcher) CompleteJob(jobID string) {
+// InfraRetry describes an automatic retry scheduled after an
+// infrastructure error.
+type InfraRetry struct {
+	Attempt int           // 1-based retry number
+	Max     int           // The repo's retry limit
+	Delay   time.Duration // Backoff before the job can be dispatched again
+}
+
+// RetryInfraError re-queues an in-flight job that hit an infrastructure
+// error (clone failure, lost worker, image pull) if its repo allows more
+// automatic retries. The job keeps its ID and logs, and waits out a backoff
+// in the queue. Returns false if the job should be marked as error instead.
+func (d *Dispatcher) RetryInfraError(jobID string) (InfraRetry, bool) {
+	d.mu.Lock()
+	qj, ok := d.inflight[jobID]
+	d.mu.Unlock()
+	if !ok {
+		return InfraRetry{}, false
+	}
+
+	// Read the limit from storage so a changed setting applies right away
+	repo, err := d.storage.GetRepo(context.Background(), qj.Job.RepoID)
+	if err != nil {
+		d.log.Warn("failed to get repo for infra retries", "job_id", jobID, "error", err)
+		return InfraRetry{}, false
+	}
+	if qj.InfraRetries >= repo.InfraRetries {
+		return InfraRetry{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.inflight[jobID]; !ok {
+		return InfraRetry{}, false // Cancelled or completed meanwhile
+	}
+	delete(d.inflight, jobID)
+
+	qj.InfraRetries++
+	retry := InfraRetry{Attempt: qj.InfraRetries, Max: repo.InfraRetries, Delay: infraRetryDelay(qj.InfraRetries)}
+	qj.QueuedAt = time.Now()
+	qj.RetryAt = qj.QueuedAt.Add(retry.Delay)
+	d.queue = append(d.queue, qj)
+
+	if err := d.storage.UpdateJobStatus(context.Background(), jobID, storage.JobStatusQueued, nil); err != nil {
+		d.log.Error("failed to update job status to queued", "job_id", jobID, "error", err)
+	}
+	d.log.Info("job requeued after infrastructure error", "job_id", jobID, "attempt", retry.Attempt, "max", retry.Max, "delay", retry.Delay)
+	return retry, true
+}
+
+// infraRetryDelay returns the backoff before the given 1-based retry.
+func infraRetryDelay(attempt int) time.Duration {
+	delay := infraRetryBaseDelay
+	for i := 1; i < attempt && delay < infraRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, infraRetryMaxDelay)
+}
+
+// Remove drops a job from the queue before it is dispatched.
+// Returns true if the job was found in the queue.
+func (d *Dispatcher) Remove(jobID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, qj := range d.queue {
+		if qj.Job.ID == jobID {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// CompleteJob removes a job from inflight tracking and records its final status.
+// Jobs that are no longer inflight (e.g. already cancelled) aren't counted again.
+func (d *Dispatcher) CompleteJob(jobID string, status storage.JobStatus) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if _, ok := d.inflight[jobID]; !ok {
+		return
+	}
 	delete(d.inflight, jobID)
+	d.metrics.JobFinished(status)
+	go d.notifyFinished(jobID, status)
+}
+
+// notifyFinished posts a finished job to its repo's notification URLs and
+// emails a failure to the commit author, if the repo wants to hear about
+// this status.
+func (d *Dispatcher) notifyFinished(jobID string, status storage.JobStatus) {
+	if d.notifier == nil && d.mailer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(d.ctx, time.Minute)
+	defer cancel()
+
+	job, err := d.storage.GetJob(ctx, jobID)
+	if err != nil {
+		d.log.Warn("failed to get job for notification", "job_id", jobID, "error", err)
+		return
+	}
+	n, err := d.storage.GetRepoNotifications(ctx, job.RepoID)
+	if err != nil {
+		d.log.Warn("failed to get repo notifications", "repo_id", job.RepoID, "error", err)
+		return
+	}
+	wantsChat := d.notifier != nil && n.Wants(status)
+	wantsEmail := d.mailer != nil && n.WantsEmail(status) && job.Author != ""
+	if !wantsChat && !wantsEmail {
+		return
+	}
+	repo, err := d.storage.GetRepo(ctx, job.RepoID)
+	if err != nil {
+		d.log.Warn("failed to get repo for notification", "repo_id", job.RepoID, "error", err)
+		return
+	}
+
+	ev := notify.Event{
+		JobID:  job.ID,
+		Repo:   repo.Owner + "/" + repo.Name,
+		Commit: job.Commit,
+		Branch: job.Branch,
+		Tag:    job.Tag,
+		Status: string(status),
+	}
+	if job.StartedAt != nil && job.FinishedAt != nil {
+		ev.DurationMs = job.FinishedAt.Sub(*job.StartedAt).Milliseconds()
+	}
+	if d.baseURL != "" {
+		ev.JobURL = d.baseURL + "/jobs/" + job.ID
+	}
+
+	if wantsEmail {
+		d.emailAuthor(ctx, job, repo, ev)
+	}
+	if !wantsChat {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range n.URLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.notifier.Send(ctx, target, ev); err != nil {
+				d.log.Warn("failed to send job notification", "job_id", job.ID, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// emailAuthor mails a failed job to the cinch user who authored its commit.
+// Authors without a cinch account or known email are skipped, as are users
+// who haven't linked the repo's forge: job.Author is a forge username, and
+// the same name on another forge may belong to someone else.
+func (d *Dispatcher) emailAuthor(ctx context.Context, job *storage.Job, repo *storage.Repo, ev notify.Event) {
+	user, err := d.storage.GetUserByName(ctx, job.Author)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			d.log.Warn("failed to get job author for email", "job_id", job.ID, "author", job.Author, "error", err)
+		}
+		return
+	}
+	if user.Email == "" || !linkedToForge(user, repo.ForgeType) {
+		return
+	}
+
+	subject, body := mailer.JobFailed(ev)
+	if err := d.mailer.Send(user.Email, subject, body); err != nil {
+		d.log.Warn("failed to email job failure", "job_id", job.ID, "author", job.Author, "error", err)
+		return
+	}
+	d.log.Info("emailed job failure", "job_id", job.ID, "author", job.Author)
+}
+
+// linkedToForge reports whether the user has connected an account on the
+// given forge.
+func linkedToForge(user *storage.User, forgeType storage.ForgeType) bool {
+	switch forgeType {
+	case storage.ForgeTypeGitHub:
+		return !user.GitHubConnectedAt.IsZero()
+	case storage.ForgeTypeGitLab:
+		return user.GitLabCredentials != ""
+	case storage.ForgeTypeForgejo, storage.ForgeTypeGitea:
+		return user.ForgejoCredentials != ""
+	}
+	return false
+}
+
+// Cancel stops a pending, queued, or running job: it drops the job from the
+// queue, tells the assigned worker (if any) to stop, and marks it cancelled.
+func (d *Dispatcher) Cancel(ctx context.Context, job *storage.Job, reason string) error {
+	d.mu.Lock()
+	for i, qj := range d.queue {
+		if qj.Job.ID == job.ID {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			break
+		}
+	}
+	delete(d.inflight, job.ID)
+	delete(d.pendingReasons, job.ID)
+	d.mu.Unlock()
+
+	if job.WorkerID != nil && d.ws != nil {
+		if err := d.ws.CancelJob(*job.WorkerID, protocol.JobCancel{JobID: job.ID, Reason: reason}); err != nil && !errors.Is(err, ErrWorkerNotFound) {
+			d.log.Warn("failed to send cancel to worker", "job_id", job.ID, "worker_id", *job.WorkerID, "error", err)
+		}
+	}
+
+	if err := d.storage.UpdateJobStatus(ctx, job.ID, storage.JobStatusCancelled, nil); err != nil {
+		return fmt.Errorf("update job status: %w", err)
+	}
+	d.metrics.JobFinished(storage.JobStatusCancelled)
+	go d.notifyFinished(job.ID, storage.JobStatusCancelled)
+
+	if d.ws != nil && d.ws.statusPoster != nil {
+		if err := d.ws.statusPoster.PostJobStatus(ctx, job.ID, "cancelled", "Build cancelled: "+reason); err != nil {
+			d.log.Warn("failed to post cancelled status", "job_id", job.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// CancelSuperseded cancels the repo's pending and running jobs for the same
+// branch or PR as job, if the repo has cancel_in_progress enabled. Tag builds
+// are never cancelled. Returns the number of jobs cancelled.
+func (d *Dispatcher) CancelSuperseded(ctx context.Context, repo *storage.Repo, job *storage.Job) int {
+	if !repo.CancelInProgress || job.Tag != "" || job.Branch == "" {
+		return 0
+	}
+
+	jobs, err := d.storage.ListJobs(ctx, storage.JobFilter{RepoID: repo.ID, Branch: job.Branch, Limit: 100})
+	if err != nil {
+		d.log.Error("failed to list jobs to supersede", "repo_id", repo.ID, "branch", job.Branch, "error", err)
+		return 0
+	}
+
+	cancelled := 0
+	for _, old := range jobs {
+		if old.ID == job.ID || old.Tag != "" || !sameRef(old, job) {
+			continue
+		}
+		switch old.Status {
+		case storage.JobStatusPending, storage.JobStatusQueued, storage.JobStatusRunning, storage.JobStatusPendingContributor:
+		default:
+			continue
+		}
+		if err := d.Cancel(ctx, old, "superseded by "+job.ID); err != nil {
+			d.log.Error("failed to cancel superseded job", "job_id", old.ID, "error", err)
+			continue
+		}
+		d.log.Info("cancelled superseded job", "job_id", old.ID, "superseded_by", job.ID, "branch", job.Branch)
+		cancelled++
+	}
+	return cancelled
+}
+
+// sameRef reports whether two jobs build the same branch push or the same PR.
+func sameRef(a, b *storage.Job) bool {
+	if (a.PRNumber == nil) != (b.PRNumber == nil) {
+		return false
+	}
+	if a.PRNumber != nil {
+		return *a.PRNumber == *b.PRNumber
+	}
+	return a.Branch == b.Branch
+}
+
+// InflightLength returns the number of jobs assigned to workers and not yet complete.
+func (d *Dispatcher) InflightLength() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.inflight)
+}
+
+// Metrics returns the dispatcher's job and webhook counters.
+func (d *Dispatcher) Metrics() *Metrics {
+	if d == nil {
+		return nil
+	}
+	return d.metrics
 }
 
 // RequeueWorkerJobs re-queues all jobs that were assigned to a disconnected worker.
@@ -487,3 +1052,48 @@ func (d *Dispatcher) RequeueWorkerJobs(jobIDs []string) {
 		}
 	}
 }
+
+// RequeueJobsForWorker re-queues the running and queued jobs that storage
+// says are assigned to workerID, for a worker that went away without a clean
+// disconnect. Jobs still tracked in flight go back to the front of the queue;
+// others are rebuilt from storage. Returns the number of jobs re-queued.
+func (d *Dispatcher) RequeueJobsForWorker(ctx context.Context, workerID string) int {
+	jobs, err := d.storage.ListJobsByWorker(ctx, workerID, 100)
+	if err != nil {
+		d.log.Error("failed to list jobs for worker", "worker_id", workerID, "error", err)
+		return 0
+	}
+
+	requeued := 0
+	for _, job := range jobs {
+		if job.Status != storage.JobStatusRunning && job.Status != storage.JobStatusQueued {
+			continue
+		}
+
+		d.mu.Lock()
+		_, inflight := d.inflight[job.ID]
+		queued := false
+		for _, qj := range d.queue {
+			if qj.Job.ID == job.ID {
+				queued = true
+				break
+			}
+		}
+		d.mu.Unlock()
+
+		switch {
+		case queued:
+			continue // Already waiting for another worker
+		case inflight:
+			d.RequeueWorkerJobs([]string{job.ID})
+		default:
+			if err := d.requeueStored(ctx, job); err != nil {
+				d.log.Error("failed to requeue job", "job_id", job.ID, "worker_id", workerID, "error", err)
+				continue
+			}
+		}
+		requeued++
+		d.log.Info("job requeued (worker offline)", "job_id", job.ID, "worker_id", workerID)
+	}
+	return requeued
+}
@@ -1,9 +1,14 @@
 package server
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ehrlich-b/cinch/internal/notify"
 	"github.com/ehrlich-b/cinch/internal/protocol"
 	"github.com/ehrlich-b/cinch/internal/storage"
 )
@@ -110,11 +115,13 @@ func TestDispatcherAssignment(t *testing.T) {
 	defer dispatcher.Stop()
 
 	// Create and enqueue job
+	pr := 5
 	job := &storage.Job{
 		ID:        "j_1",
 		RepoID:    "r_1",
 		Commit:    "abc123",
 		Branch:    "main",
+		PRNumber:  &pr,
 		Status:    storage.JobStatusPending,
 		CreatedAt: time.Now(),
 	}
@@ -123,9 +130,10 @@ func TestDispatcherAssignment(t *testing.T) {
 	}
 
 	repo := &storage.Repo{
-		ID:        "r_1",
-		ForgeType: storage.ForgeTypeGitHub,
-		CloneURL:  "https://github.com/test/repo.git",
+		ID:         "r_1",
+		ForgeType:  storage.ForgeTypeGitHub,
+		CloneURL:   "https://github.com/test/repo.git",
+		JobTimeout: "45m0s",
 	}
 	queued := &QueuedJob{
 		Job:      job,
@@ -142,13 +150,23 @@ func TestDispatcherAssignment(t *testing.T) {
 	// Wait for dispatch
 	select {
 	case msg := <-workerSend:
-		msgType, _, err := protocol.Decode(msg)
+		msgType, payload, err := protocol.Decode(msg)
 		if err != nil {
 			t.Fatalf("decode failed: %v", err)
 		}
 		if msgType != protocol.TypeJobAssign {
 			t.Errorf("message type = %s, want %s", msgType, protocol.TypeJobAssign)
 		}
+		assign, err := protocol.DecodePayload[protocol.JobAssign](payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !assign.Repo.IsPR || assign.Repo.PRNumber != 5 {
+			t.Errorf("assignment repo = %+v, want PR 5", assign.Repo)
+		}
+		if assign.Config.Timeout != "45m0s" {
+			t.Errorf("assignment timeout = %q, want repo's 45m0s", assign.Config.Timeout)
+		}
 	case <-time.After(2 * time.Second):
 		t.Fatal("timeout waiting for job assignment")
 	}
@@ -242,3 +260,534 @@ func TestDispatcherPendingJobs(t *testing.T) {
 		t.Errorf("len(PendingJobs) = %d, want 3", len(pending))
 	}
 }
+
+func TestDispatcherQueuePosition(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	dispatcher := NewDispatcher(hub, store, nil, nil)
+	for _, id := range []string{"j_a", "j_b", "j_c"} {
+		dispatcher.Enqueue(&QueuedJob{Job: &storage.Job{ID: id}})
+	}
+
+	for id, want := range map[string]int{"j_a": 1, "j_b": 2, "j_c": 3, "j_missing": 0} {
+		if got := dispatcher.QueuePosition(id); got != want {
+			t.Errorf("QueuePosition(%s) = %d, want %d", id, got, want)
+		}
+	}
+}
+
+func TestDispatcherTierConcurrencyLimit(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	user, err := store.GetOrCreateUser(t.Context(), "alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser failed: %v", err)
+	}
+	repo := &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/test/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	}
+	if err := store.CreateRepo(t.Context(), repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	// Two idle workers, so only the tier limit can hold a job back
+	for _, id := range []string{"w_1", "w_2"} {
+		if err := store.CreateWorker(t.Context(), &storage.Worker{
+			ID:        id,
+			Name:      id,
+			Labels:    []string{"linux"},
+			Status:    storage.WorkerStatusOnline,
+			LastSeen:  time.Now(),
+			CreatedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("CreateWorker failed: %v", err)
+		}
+		hub.Register(&WorkerConn{ID: id, Labels: []string{"linux"}, Send: make(chan []byte, 10)})
+	}
+
+	ws := &WSHandler{hub: hub, storage: store}
+	dispatcher := NewDispatcher(hub, store, ws, nil)
+	dispatcher.SetTierLimits(true)
+
+	for _, id := range []string{"j_1", "j_2"} {
+		job := &storage.Job{
+			ID:        id,
+			RepoID:    "r_1",
+			Commit:    "abc123",
+			Branch:    "main",
+			Status:    storage.JobStatusPending,
+			CreatedAt: time.Now(),
+		}
+		if err := store.CreateJob(t.Context(), job); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+		dispatcher.Enqueue(&QueuedJob{
+			Job:      job,
+			Repo:     repo,
+			Labels:   []string{"linux"},
+			Config:   protocol.JobConfig{Command: "make test"},
+			CloneURL: repo.CloneURL,
+			Ref:      "refs/heads/main",
+			Branch:   "main",
+		})
+	}
+
+	dispatcher.tryDispatch()
+
+	// Free tier: one job runs, the other waits
+	if dispatcher.QueueLength() != 1 {
+		t.Fatalf("QueueLength = %d, want 1", dispatcher.QueueLength())
+	}
+	if reason := dispatcher.PendingReason("j_2"); reason == "" {
+		t.Error("expected pending reason for held job")
+	}
+	if running, limit := dispatcher.Concurrency(user); running != 1 || limit != storage.ConcurrencyFree {
+		t.Errorf("Concurrency = %d/%d, want 1/%d", running, limit, storage.ConcurrencyFree)
+	}
+
+	// Waiting for a slot doesn't count toward the queue timeout
+	dispatcher.mu.Lock()
+	dispatcher.queue[0].QueuedAt = time.Now().Add(-31 * time.Minute)
+	dispatcher.mu.Unlock()
+	dispatcher.checkJobTimeouts()
+	if dispatcher.QueueLength() != 1 {
+		t.Fatalf("QueueLength = %d after timeout check, want held job still queued", dispatcher.QueueLength())
+	}
+
+	// Finishing the running job frees the slot
+	dispatcher.CompleteJob("j_1", storage.JobStatusSuccess)
+	dispatcher.tryDispatch()
+	if dispatcher.QueueLength() != 0 {
+		t.Errorf("QueueLength = %d, want 0 after slot freed", dispatcher.QueueLength())
+	}
+	if reason := dispatcher.PendingReason("j_2"); reason != "" {
+		t.Errorf("pending reason = %q after dispatch, want empty", reason)
+	}
+}
+
+func TestDispatcherRepoMaxParallel(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	repo := &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/test/repo.git",
+		MaxParallel: 2,
+		CreatedAt:   time.Now(),
+	}
+	if err := store.CreateRepo(t.Context(), repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	// Three idle workers, so only the repo limit can hold a job back
+	for _, id := range []string{"w_1", "w_2", "w_3"} {
+		if err := store.CreateWorker(t.Context(), &storage.Worker{
+			ID:        id,
+			Name:      id,
+			Labels:    []string{"linux"},
+			Status:    storage.WorkerStatusOnline,
+			LastSeen:  time.Now(),
+			CreatedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("CreateWorker failed: %v", err)
+		}
+		hub.Register(&WorkerConn{ID: id, Labels: []string{"linux"}, Send: make(chan []byte, 10)})
+	}
+
+	ws := &WSHandler{hub: hub, storage: store}
+	dispatcher := NewDispatcher(hub, store, ws, nil)
+
+	for _, id := range []string{"j_1", "j_2", "j_3"} {
+		job := &storage.Job{
+			ID:        id,
+			RepoID:    "r_1",
+			Commit:    "abc123",
+			Branch:    "main",
+			Status:    storage.JobStatusPending,
+			CreatedAt: time.Now(),
+		}
+		if err := store.CreateJob(t.Context(), job); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+		dispatcher.Enqueue(&QueuedJob{
+			Job:      job,
+			Repo:     repo,
+			Labels:   []string{"linux"},
+			Config:   protocol.JobConfig{Command: "make test"},
+			CloneURL: repo.CloneURL,
+			Ref:      "refs/heads/main",
+			Branch:   "main",
+		})
+	}
+
+	dispatcher.tryDispatch()
+	if dispatcher.QueueLength() != 1 {
+		t.Fatalf("QueueLength = %d, want 1 with max_parallel 2", dispatcher.QueueLength())
+	}
+	if reason := dispatcher.PendingReason("j_3"); !strings.Contains(reason, "2/2") {
+		t.Errorf("pending reason = %q, want repo slot reason", reason)
+	}
+
+	// Waiting for a repo slot doesn't count toward the queue timeout
+	dispatcher.mu.Lock()
+	dispatcher.queue[0].QueuedAt = time.Now().Add(-31 * time.Minute)
+	dispatcher.mu.Unlock()
+	dispatcher.checkJobTimeouts()
+	if dispatcher.QueueLength() != 1 {
+		t.Fatalf("QueueLength = %d after timeout check, want held job still queued", dispatcher.QueueLength())
+	}
+
+	// Lifting the limit applies to the job already queued
+	dispatcher.SetRepoMaxParallel(repo.ID, 0)
+	dispatcher.tryDispatch()
+	if dispatcher.QueueLength() != 0 {
+		t.Errorf("QueueLength = %d, want 0 once unlimited", dispatcher.QueueLength())
+	}
+}
+
+func TestDispatcherRetryInfraError(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	repo := &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateRepo(t.Context(), repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	job := &storage.Job{ID: "j_1", RepoID: repo.ID, Commit: "abc123", Branch: "main", Status: storage.JobStatusRunning, CreatedAt: time.Now()}
+	if err := store.CreateJob(t.Context(), job); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	dispatcher := NewDispatcher(hub, store, nil, nil)
+	qj := &QueuedJob{Job: job, Repo: repo}
+	dispatcher.inflight[job.ID] = qj
+
+	// Retries are off by default
+	if _, ok := dispatcher.RetryInfraError(job.ID); ok {
+		t.Fatal("retried with infra_retries 0")
+	}
+
+	if err := store.UpdateRepoInfraRetries(t.Context(), repo.ID, 2); err != nil {
+		t.Fatalf("UpdateRepoInfraRetries failed: %v", err)
+	}
+	for attempt := 1; attempt <= 2; attempt++ {
+		retry, ok := dispatcher.RetryInfraError(job.ID)
+		if !ok {
+			t.Fatalf("attempt %d: not retried", attempt)
+		}
+		if retry.Attempt != attempt || retry.Max != 2 || retry.Delay != infraRetryDelay(attempt) {
+			t.Errorf("attempt %d: retry = %+v", attempt, retry)
+		}
+		if dispatcher.QueuePosition(job.ID) != 1 || dispatcher.InflightLength() != 0 {
+			t.Fatalf("attempt %d: job not back in the queue", attempt)
+		}
+		got, _ := store.GetJob(t.Context(), job.ID)
+		if got.Status != storage.JobStatusQueued {
+			t.Errorf("attempt %d: status = %s, want queued", attempt, got.Status)
+		}
+
+		// Held for the backoff even with a worker free
+		hub.Register(&WorkerConn{ID: "w_1", Send: make(chan []byte, 10)})
+		dispatcher.tryDispatch()
+		if reason := dispatcher.PendingReason(job.ID); !strings.Contains(reason, "infrastructure error") {
+			t.Errorf("attempt %d: pending reason = %q", attempt, reason)
+		}
+		if dispatcher.QueueLength() != 1 {
+			t.Fatalf("attempt %d: dispatched during backoff", attempt)
+		}
+
+		// Backoff over: the next worker takes it again
+		dispatcher.mu.Lock()
+		dispatcher.queue = nil
+		dispatcher.inflight[job.ID] = qj
+		dispatcher.mu.Unlock()
+		hub.Unregister("w_1")
+	}
+
+	// The cap is reached: the job should be marked as error
+	if _, ok := dispatcher.RetryInfraError(job.ID); ok {
+		t.Error("retried past infra_retries")
+	}
+
+	// Jobs that aren't in flight (e.g. cancelled) aren't retried
+	dispatcher.CompleteJob(job.ID, storage.JobStatusCancelled)
+	if _, ok := dispatcher.RetryInfraError(job.ID); ok {
+		t.Error("retried a job that isn't in flight")
+	}
+}
+
+func TestInfraRetryDelay(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, w := range want {
+		if got := infraRetryDelay(i + 1); got != w {
+			t.Errorf("infraRetryDelay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestDispatcherNoWorkerReason(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	repo := &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateRepo(t.Context(), repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	job := &storage.Job{
+		ID:         "j_1",
+		RepoID:     "r_1",
+		Commit:     "abc123",
+		Branch:     "main",
+		Author:     "alice",
+		TrustLevel: storage.TrustOwner,
+		Status:     storage.JobStatusPending,
+		CreatedAt:  time.Now(),
+	}
+	if err := store.CreateJob(t.Context(), job); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	ws := &WSHandler{hub: hub, storage: store}
+	dispatcher := NewDispatcher(hub, store, ws, nil)
+	dispatcher.Enqueue(&QueuedJob{
+		Job:      job,
+		Repo:     repo,
+		Labels:   []string{"gpu"},
+		Config:   protocol.JobConfig{Command: "make test"},
+		CloneURL: repo.CloneURL,
+		Ref:      "refs/heads/main",
+		Branch:   "main",
+	})
+
+	// Not annotated until the job has waited past the threshold
+	dispatcher.tryDispatch()
+	if reason := dispatcher.PendingReason("j_1"); reason != "" {
+		t.Errorf("pending reason = %q before threshold, want empty", reason)
+	}
+
+	dispatcher.mu.Lock()
+	dispatcher.queue[0].QueuedAt = time.Now().Add(-noWorkerThreshold)
+	dispatcher.mu.Unlock()
+
+	dispatcher.tryDispatch()
+	if reason := dispatcher.PendingReason("j_1"); reason != "no workers online" {
+		t.Errorf("pending reason = %q, want no workers online", reason)
+	}
+
+	// Another user's personal worker can't run the job, so it isn't counted
+	registerWorker := func(id string, mode protocol.WorkerMode, owner string, labels []string) {
+		t.Helper()
+		if err := store.CreateWorker(t.Context(), &storage.Worker{
+			ID:        id,
+			Name:      id,
+			Labels:    labels,
+			Status:    storage.WorkerStatusOnline,
+			LastSeen:  time.Now(),
+			CreatedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("CreateWorker failed: %v", err)
+		}
+		hub.Register(&WorkerConn{ID: id, Labels: labels, Mode: mode, OwnerName: owner, Send: make(chan []byte, 10)})
+	}
+	registerWorker("w_bob", protocol.ModePersonal, "bob", []string{"gpu"})
+	dispatcher.tryDispatch()
+	if reason := dispatcher.PendingReason("j_1"); reason != "no workers online" {
+		t.Errorf("pending reason = %q, want no workers online", reason)
+	}
+
+	// Eligible worker online but without the label
+	registerWorker("w_1", protocol.ModeShared, "carol", []string{"linux"})
+	dispatcher.tryDispatch()
+	want := "no matching worker for labels [gpu]"
+	if reason := dispatcher.PendingReason("j_1"); reason != want {
+		t.Errorf("pending reason = %q, want %q", reason, want)
+	}
+
+	// Matching worker arrives: job dispatches and the reason clears
+	registerWorker("w_2", protocol.ModeShared, "carol", []string{"gpu"})
+	dispatcher.tryDispatch()
+	if dispatcher.QueueLength() != 0 {
+		t.Errorf("QueueLength = %d, want 0", dispatcher.QueueLength())
+	}
+	if reason := dispatcher.PendingReason("j_1"); reason != "" {
+		t.Errorf("pending reason = %q after dispatch, want empty", reason)
+	}
+}
+
+func TestDispatcherCancelSuperseded(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	repo := &storage.Repo{
+		ID:               "r_1",
+		ForgeType:        storage.ForgeTypeGitHub,
+		CloneURL:         "https://github.com/test/repo.git",
+		CancelInProgress: true,
+		CreatedAt:        time.Now(),
+	}
+	if err := store.CreateRepo(t.Context(), repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	dispatcher := NewDispatcher(hub, store, nil, nil)
+
+	jobs := []*storage.Job{
+		{ID: "j_old", Commit: "aaa", Branch: "main", Status: storage.JobStatusPending},
+		{ID: "j_tag", Commit: "aaa", Branch: "main", Tag: "v1.0.0", Status: storage.JobStatusPending},
+		{ID: "j_other", Commit: "bbb", Branch: "feature", Status: storage.JobStatusPending},
+		{ID: "j_done", Commit: "ccc", Branch: "main", Status: storage.JobStatusSuccess},
+		{ID: "j_new", Commit: "ddd", Branch: "main", Status: storage.JobStatusPending},
+	}
+	for _, job := range jobs {
+		job.RepoID = "r_1"
+		job.CreatedAt = time.Now()
+		if err := store.CreateJob(t.Context(), job); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+		if job.Status == storage.JobStatusPending && job.ID != "j_new" {
+			dispatcher.Enqueue(&QueuedJob{Job: job, Repo: repo, Branch: job.Branch})
+		}
+	}
+
+	if n := dispatcher.CancelSuperseded(t.Context(), repo, jobs[4]); n != 1 {
+		t.Errorf("CancelSuperseded = %d, want 1", n)
+	}
+
+	want := map[string]storage.JobStatus{
+		"j_old":   storage.JobStatusCancelled,
+		"j_tag":   storage.JobStatusQueued,
+		"j_other": storage.JobStatusQueued,
+		"j_done":  storage.JobStatusSuccess,
+		"j_new":   storage.JobStatusPending,
+	}
+	for id, status := range want {
+		job, err := store.GetJob(t.Context(), id)
+		if err != nil {
+			t.Fatalf("GetJob(%s) failed: %v", id, err)
+		}
+		if job.Status != status {
+			t.Errorf("job %s status = %s, want %s", id, job.Status, status)
+		}
+	}
+	if dispatcher.QueueLength() != 2 {
+		t.Errorf("QueueLength = %d, want 2", dispatcher.QueueLength())
+	}
+
+	// Disabled setting leaves jobs alone
+	repo.CancelInProgress = false
+	if n := dispatcher.CancelSuperseded(t.Context(), repo, jobs[4]); n != 0 {
+		t.Errorf("CancelSuperseded with setting off = %d, want 0", n)
+	}
+}
+
+func TestDispatcherNotifiesOnCompletion(t *testing.T) {
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	got := make(chan notify.Event, 2)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev notify.Event
+		_ = json.NewDecoder(r.Body).Decode(&ev)
+		got <- ev
+	}))
+	defer sink.Close()
+
+	repo := &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		Owner:     "test",
+		Name:      "repo",
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateRepo(t.Context(), repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	if err := store.UpdateRepoNotifications(t.Context(), repo.ID, &storage.RepoNotifications{URLs: []string{sink.URL}}); err != nil {
+		t.Fatalf("UpdateRepoNotifications failed: %v", err)
+	}
+	for _, id := range []string{"j_ok", "j_bad"} {
+		job := &storage.Job{ID: id, RepoID: repo.ID, Commit: "abc1234", Branch: "main", Status: storage.JobStatusRunning, CreatedAt: time.Now()}
+		if err := store.CreateJob(t.Context(), job); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+	}
+
+	dispatcher := NewDispatcher(hub, store, nil, nil)
+	notifier := notify.New()
+	notifier.Client = sink.Client() // the sink is on loopback, which New's client refuses
+	dispatcher.SetNotifier(notifier, "https://cinch.example.com/")
+	for _, id := range []string{"j_ok", "j_bad"} {
+		dispatcher.inflight[id] = &QueuedJob{Job: &storage.Job{ID: id}}
+	}
+
+	// Success isn't in the default notify-on set, so only j_bad is posted
+	dispatcher.CompleteJob("j_ok", storage.JobStatusSuccess)
+	dispatcher.CompleteJob("j_bad", storage.JobStatusFailed)
+
+	select {
+	case ev := <-got:
+		if ev.JobID != "j_bad" || ev.Status != "failed" || ev.Repo != "test/repo" {
+			t.Errorf("event = %+v", ev)
+		}
+		if ev.JobURL != "https://cinch.example.com/jobs/j_bad" {
+			t.Errorf("JobURL = %q", ev.JobURL)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+	}
+	select {
+	case ev := <-got:
+		t.Errorf("unexpected notification for %s", ev.JobID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestLinkedToForge(t *testing.T) {
+	// A GitLab-only user named "alice" mustn't receive mail for GitHub jobs
+	// pushed by a different "alice"
+	gitlabUser := &storage.User{Name: "alice", GitLabCredentials: `{"type":"oauth"}`}
+	githubUser := &storage.User{Name: "alice", GitHubConnectedAt: time.Now()}
+
+	tests := []struct {
+		user  *storage.User
+		forge storage.ForgeType
+		want  bool
+	}{
+		{gitlabUser, storage.ForgeTypeGitLab, true},
+		{gitlabUser, storage.ForgeTypeGitHub, false},
+		{gitlabUser, storage.ForgeTypeForgejo, false},
+		{githubUser, storage.ForgeTypeGitHub, true},
+		{githubUser, storage.ForgeTypeBitbucket, false},
+		{&storage.User{ForgejoCredentials: "{}"}, storage.ForgeTypeGitea, true},
+	}
+	for _, tt := range tests {
+		if got := linkedToForge(tt.user, tt.forge); got != tt.want {
+			t.Errorf("linkedToForge(%+v, %s) = %v, want %v", tt.user, tt.forge, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/forge"
+)
+
+// forgeAPIClient is shared by every handler that calls forge APIs (status
+// posting, check runs, installation tokens, webhook setup) so rate-limit
+// budgets are tracked in one place rather than per call site.
+var (
+	forgeRateLimiter = forge.NewRateLimiter()
+	forgeAPIClient   = forgeRateLimiter.Client(10 * time.Second)
+)
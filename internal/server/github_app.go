@@ -13,10 +13,12 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ehrlich-b/cinch/internal/forge"
 	"github.com/ehrlich-b/cinch/internal/storage"
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -26,6 +28,8 @@ type GitHubAppConfig struct {
 	AppID         int64
 	PrivateKey    string // PEM-encoded private key
 	WebhookSecret string
+	BaseURL       string // Defaults to https://github.com; set for GitHub Enterprise Server
+	APIURL        string // Defaults to https://api.github.com, or BaseURL + "/api/v3"
 }
 
 // GitHubAppHandler handles GitHub App webhooks and token generation.
@@ -40,6 +44,8 @@ type GitHubAppHandler struct {
 	// Installation token cache
 	tokenCache   map[int64]*cachedToken
 	tokenCacheMu sync.RWMutex
+	mintLocksMu  sync.Mutex
+	mintLocks    map[int64]*sync.Mutex // per installation, so concurrent misses share one token
 }
 
 type cachedToken struct {
@@ -47,11 +53,21 @@ type cachedToken struct {
 	ExpiresAt time.Time
 }
 
+// githubEndpoints returns the GitHub web and API URLs the server is
+// configured for. A nil handler means github.com.
+func (h *GitHubAppHandler) githubEndpoints() (baseURL, apiURL string) {
+	if h == nil {
+		return defaultGitHubBaseURL, defaultGitHubAPIURL
+	}
+	return h.config.BaseURL, h.config.APIURL
+}
+
 // NewGitHubAppHandler creates a new GitHub App handler.
 func NewGitHubAppHandler(cfg GitHubAppConfig, store storage.Storage, dispatcher *Dispatcher, baseURL string, log *slog.Logger) (*GitHubAppHandler, error) {
 	if log == nil {
 		log = slog.Default()
 	}
+	cfg.BaseURL, cfg.APIURL = githubEndpoints(cfg.BaseURL, cfg.APIURL)
 
 	h := &GitHubAppHandler{
 		config:     cfg,
@@ -96,6 +112,9 @@ func (h *GitHubAppHandler) IsConfigured() bool {
 
 // ServeHTTP handles GitHub App webhook requests.
 func (h *GitHubAppHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w, r, finish := trackDelivery(h.storage, h.log, w, r)
+	defer finish()
+
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -112,13 +131,15 @@ func (h *GitHubAppHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify signature
+	// Verify signature (replays are admin-initiated from a stored, already-verified delivery)
 	signature := r.Header.Get("X-Hub-Signature-256")
-	if !h.verifySignature(body, signature) {
+	if !isReplay(r.Context()) && !h.verifySignature(body, signature) {
 		h.log.Warn("invalid webhook signature")
 		http.Error(w, "invalid signature", http.StatusUnauthorized)
 		return
 	}
+	h.recordDelivery(r, body)
+	h.dispatcher.Metrics().WebhookReceived("github")
 
 	// Route by event type
 	eventType := r.Header.Get("X-GitHub-Event")
@@ -129,6 +150,8 @@ func (h *GitHubAppHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handlePush(w, r, body)
 	case "pull_request":
 		h.handlePullRequest(w, r, body)
+	case "issue_comment":
+		h.handleIssueComment(w, r, body)
 	case "installation":
 		h.handleInstallation(w, r, body)
 	case "installation_repositories":
@@ -142,6 +165,23 @@ func (h *GitHubAppHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// recordDelivery stores the delivery for replay if it belongs to a configured repo.
+func (h *GitHubAppHandler) recordDelivery(r *http.Request, body []byte) {
+	var event struct {
+		Repository struct {
+			CloneURL string `json:"clone_url"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.Repository.CloneURL == "" {
+		return
+	}
+	repo, err := h.storage.GetRepoByCloneURL(r.Context(), event.Repository.CloneURL)
+	if err != nil {
+		return
+	}
+	recordDelivery(r.Context(), h.storage, h.log, deliverySourceGitHubApp, r, body, repo.ID)
+}
+
 func (h *GitHubAppHandler) verifySignature(payload []byte, signature string) bool {
 	if h.config.WebhookSecret == "" {
 		h.log.Error("webhook secret not configured - rejecting request")
@@ -167,8 +207,9 @@ func (h *GitHubAppHandler) verifySignature(payload []byte, signature string) boo
 // Push event handler
 func (h *GitHubAppHandler) handlePush(w http.ResponseWriter, r *http.Request, body []byte) {
 	var event struct {
-		Ref        string `json:"ref"`
-		After      string `json:"after"`
+		Ref        string             `json:"ref"`
+		After      string             `json:"after"`
+		Commits    []forge.PushCommit `json:"commits"`
 		Repository struct {
 			FullName string `json:"full_name"`
 			CloneURL string `json:"clone_url"`
@@ -269,6 +310,21 @@ func (h *GitHubAppHandler) handlePush(w http.ResponseWriter, r *http.Request, bo
 		}
 	}
 
+	if files := forge.ChangedFiles(event.Commits, 0); skipForPaths(repo, tag, files) {
+		h.log.Info("push skipped, no matching paths", "repo", event.Repository.FullName, "ref", event.Ref, "files", len(files))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, skippedNoPaths)
+		return
+	}
+
+	// GitHub redelivers on timeout; reuse the job from the earlier delivery
+	if existing := findDuplicateJob(ctx, h.storage, repo.ID, commit, event.Ref); existing != nil {
+		h.log.Info("duplicate webhook delivery, reusing job", "job_id", existing.ID, "repo", event.Repository.FullName, "ref", event.Ref)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]string{"job_id": existing.ID})
+		return
+	}
+
 	// Create job
 	installationID := event.Installation.ID
 	job := &storage.Job{
@@ -296,7 +352,7 @@ func (h *GitHubAppHandler) handlePush(w http.ResponseWriter, r *http.Request, bo
 	)
 
 	// Create GitHub Check Run
-	checkRunID, err := h.CreateCheckRun(repo, commit, job.ID, installationID)
+	checkRunID, err := h.CreateCheckRun(repo, job, installationID)
 	if err != nil {
 		h.log.Warn("failed to create check run", "error", err)
 	} else {
@@ -327,6 +383,7 @@ func (h *GitHubAppHandler) handlePush(w http.ResponseWriter, r *http.Request, bo
 		CloneToken:     cloneToken,
 		InstallationID: installationID,
 	}
+	h.dispatcher.CancelSuperseded(ctx, repo, job)
 	h.dispatcher.Enqueue(queuedJob)
 
 	w.WriteHeader(http.StatusOK)
@@ -476,6 +533,14 @@ func (h *GitHubAppHandler) handlePullRequest(w http.ResponseWriter, r *http.Requ
 		status = storage.JobStatusPendingContributor
 	}
 
+	// GitHub redelivers on timeout; reuse the job from the earlier delivery
+	if existing := findDuplicateJob(ctx, h.storage, repo.ID, commit, fmt.Sprintf("refs/pull/%d/head", prNum)); existing != nil {
+		h.log.Info("duplicate webhook delivery, reusing job", "job_id", existing.ID, "repo", event.Repository.FullName, "pr", prNum)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]string{"job_id": existing.ID})
+		return
+	}
+
 	// Create job
 	installationID := event.Installation.ID
 	job := &storage.Job{
@@ -510,7 +575,7 @@ func (h *GitHubAppHandler) handlePullRequest(w http.ResponseWriter, r *http.Requ
 	)
 
 	// Create GitHub Check Run
-	checkRunID, err := h.CreateCheckRun(repo, commit, job.ID, installationID)
+	checkRunID, err := h.CreateCheckRun(repo, job, installationID)
 	if err != nil {
 		h.log.Warn("failed to create check run", "error", err)
 	} else {
@@ -539,12 +604,255 @@ func (h *GitHubAppHandler) handlePullRequest(w http.ResponseWriter, r *http.Requ
 		CloneToken:     cloneToken,
 		InstallationID: installationID,
 	}
+	h.dispatcher.CancelSuperseded(ctx, repo, job)
 	h.dispatcher.Enqueue(queuedJob)
 
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(map[string]string{"job_id": job.ID})
 }
 
+// Comment commands recognized on pull requests.
+const (
+	commentCommandRun    = "run"
+	commentCommandCancel = "cancel"
+)
+
+// parseCommentCommand extracts a "/cinch <command>" from a PR comment.
+// Only the first non-empty line is considered. Returns "" if no command.
+func parseCommentCommand(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 || fields[0] != "/cinch" {
+			return ""
+		}
+		switch cmd := strings.ToLower(fields[1]); cmd {
+		case commentCommandRun, commentCommandCancel:
+			return cmd
+		}
+		return ""
+	}
+	return ""
+}
+
+// handleIssueComment handles "/cinch run" and "/cinch cancel" comments on PRs.
+// Lets collaborators approve fork PR jobs without leaving the PR conversation.
+func (h *GitHubAppHandler) handleIssueComment(w http.ResponseWriter, r *http.Request, body []byte) {
+	var event struct {
+		Action string `json:"action"`
+		Issue  struct {
+			Number      int       `json:"number"`
+			PullRequest *struct{} `json:"pull_request"`
+		} `json:"issue"`
+		Comment struct {
+			Body string `json:"body"`
+			User struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		} `json:"comment"`
+		Repository struct {
+			FullName string `json:"full_name"`
+			CloneURL string `json:"clone_url"`
+		} `json:"repository"`
+		Installation struct {
+			ID int64 `json:"id"`
+		} `json:"installation"`
+	}
+
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.log.Error("failed to parse issue_comment event", "error", err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Only new comments on pull requests
+	if event.Action != "created" || event.Issue.PullRequest == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	command := parseCommentCommand(event.Comment.Body)
+	if command == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ctx := r.Context()
+	commenter := event.Comment.User.Login
+	prNum := event.Issue.Number
+	installationID := event.Installation.ID
+
+	repo, err := h.storage.GetRepoByCloneURL(ctx, event.Repository.CloneURL)
+	if err != nil {
+		h.log.Debug("comment command for unknown repo", "repo", event.Repository.FullName)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// SECURITY: only collaborators with write access may approve or cancel
+	permission, err := h.GetCollaboratorPermission(repo, commenter, installationID)
+	if err != nil {
+		h.log.Warn("failed to check commenter permission", "repo", event.Repository.FullName, "user", commenter, "error", err)
+		http.Error(w, "failed to check permission", http.StatusBadGateway)
+		return
+	}
+	if permission != "admin" && permission != "write" {
+		h.log.Info("ignoring comment command from non-collaborator",
+			"repo", event.Repository.FullName, "pr", prNum, "user", commenter, "command", command)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	jobs, err := h.storage.ListJobs(ctx, storage.JobFilter{RepoID: repo.ID, Limit: 100})
+	if err != nil {
+		h.log.Error("failed to list jobs", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	var affected []string
+	switch command {
+	case commentCommandRun:
+		// Approve the most recent job awaiting approval for this PR
+		for _, job := range jobs {
+			if job.PRNumber == nil || *job.PRNumber != prNum || job.Status != storage.JobStatusPendingContributor {
+				continue
+			}
+			if err := h.approveAndEnqueue(ctx, repo, job, commenter); err != nil {
+				h.log.Error("failed to approve job", "job_id", job.ID, "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			affected = append(affected, job.ID)
+			break
+		}
+
+	case commentCommandCancel:
+		for _, job := range jobs {
+			if job.PRNumber == nil || *job.PRNumber != prNum {
+				continue
+			}
+			switch job.Status {
+			case storage.JobStatusPendingContributor, storage.JobStatusPending, storage.JobStatusQueued, storage.JobStatusRunning:
+			default:
+				continue
+			}
+			if err := h.dispatcher.Cancel(ctx, job, "cancelled by @"+commenter); err != nil {
+				h.log.Error("failed to cancel job", "job_id", job.ID, "error", err)
+				continue
+			}
+			if job.CheckRunID != nil && job.InstallationID != nil {
+				if err := h.UpdateCheckRun(repo, *job.CheckRunID, *job.InstallationID, "cancelled", "Build cancelled", "Cancelled by @"+commenter, ""); err != nil {
+					h.log.Warn("failed to update check run", "job_id", job.ID, "error", err)
+				}
+			}
+			affected = append(affected, job.ID)
+		}
+	}
+
+	h.log.Info("comment command handled",
+		"repo", event.Repository.FullName,
+		"pr", prNum,
+		"user", commenter,
+		"command", command,
+		"jobs", affected,
+	)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"command": command, "job_ids": affected})
+}
+
+// approveAndEnqueue approves a pending_contributor job and queues it for dispatch.
+func (h *GitHubAppHandler) approveAndEnqueue(ctx context.Context, repo *storage.Repo, job *storage.Job, approvedBy string) error {
+	var approved *storage.Job
+	err := h.storage.WithTx(ctx, func(tx storage.Storage) error {
+		if err := tx.ApproveJob(ctx, job.ID, approvedBy); err != nil {
+			return fmt.Errorf("approve job: %w", err)
+		}
+		reloaded, err := tx.GetJob(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("reload job: %w", err)
+		}
+		approved = reloaded
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var cloneToken string
+	var installationID int64
+	if approved.InstallationID != nil {
+		installationID = *approved.InstallationID
+		token, err := h.GetInstallationToken(installationID)
+		if err != nil {
+			h.log.Warn("failed to get installation token", "error", err)
+		} else {
+			cloneToken = token
+		}
+	}
+
+	h.dispatcher.Enqueue(&QueuedJob{
+		Job:            approved,
+		Repo:           repo,
+		CloneURL:       repo.CloneURL,
+		Ref:            fmt.Sprintf("refs/pull/%d/head", *approved.PRNumber),
+		Branch:         approved.Branch,
+		CloneToken:     cloneToken,
+		InstallationID: installationID,
+	})
+
+	h.log.Info("job approved", "job_id", job.ID, "approved_by", approvedBy)
+	return nil
+}
+
+// GetCollaboratorPermission returns a user's permission level on a repo
+// ("admin", "write", "read", or "none").
+func (h *GitHubAppHandler) GetCollaboratorPermission(repo *storage.Repo, username string, installationID int64) (string, error) {
+	if installationID == 0 {
+		return "", fmt.Errorf("no installation ID available")
+	}
+
+	token, err := h.GetInstallationToken(installationID)
+	if err != nil {
+		return "", fmt.Errorf("get installation token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/collaborators/%s/permission", h.config.APIURL, repo.Owner, repo.Name, username)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := forgeAPIClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Non-collaborators get a 404
+	if resp.StatusCode == http.StatusNotFound {
+		return "none", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("github api error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Permission string `json:"permission"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+
+	return result.Permission, nil
+}
+
 // handleInstallation handles GitHub App installation events.
 // When action is "created", we auto-create repos for all selected repositories.
 func (h *GitHubAppHandler) handleInstallation(w http.ResponseWriter, r *http.Request, body []byte) {
@@ -647,8 +955,8 @@ func (h *GitHubAppHandler) createRepoFromInstallation(ctx context.Context, fullN
 	}
 
 	owner, name := parts[0], parts[1]
-	cloneURL := fmt.Sprintf("https://github.com/%s.git", fullName)
-	htmlURL := fmt.Sprintf("https://github.com/%s", fullName)
+	cloneURL := fmt.Sprintf("%s/%s.git", h.config.BaseURL, fullName)
+	htmlURL := fmt.Sprintf("%s/%s", h.config.BaseURL, fullName)
 
 	// Check if repo already exists
 	_, err := h.storage.GetRepoByCloneURL(ctx, cloneURL)
@@ -682,12 +990,17 @@ func (h *GitHubAppHandler) createRepoFromInstallation(ctx context.Context, fullN
 // GetInstallationToken gets or refreshes an installation token.
 func (h *GitHubAppHandler) GetInstallationToken(installationID int64) (string, error) {
 	// Check cache
-	h.tokenCacheMu.RLock()
-	cached, ok := h.tokenCache[installationID]
-	h.tokenCacheMu.RUnlock()
+	if token, ok := h.cachedInstallationToken(installationID); ok {
+		return token, nil
+	}
 
-	if ok && time.Now().Add(5*time.Minute).Before(cached.ExpiresAt) {
-		return cached.Token, nil
+	// One mint per installation at a time; re-check in case another caller
+	// just minted
+	mintMu := h.mintLock(installationID)
+	mintMu.Lock()
+	defer mintMu.Unlock()
+	if token, ok := h.cachedInstallationToken(installationID); ok {
+		return token, nil
 	}
 
 	// Generate new token
@@ -704,9 +1017,38 @@ func (h *GitHubAppHandler) GetInstallationToken(installationID int64) (string, e
 	}
 	h.tokenCacheMu.Unlock()
 
+	// Installation tokens rotate but share the installation's rate limit
+	forgeRateLimiter.ShareBudget(token, "installation:"+strconv.FormatInt(installationID, 10), expiresAt)
+
 	return token, nil
 }
 
+func (h *GitHubAppHandler) mintLock(installationID int64) *sync.Mutex {
+	h.mintLocksMu.Lock()
+	defer h.mintLocksMu.Unlock()
+	mu, ok := h.mintLocks[installationID]
+	if !ok {
+		if h.mintLocks == nil {
+			h.mintLocks = make(map[int64]*sync.Mutex)
+		}
+		mu = &sync.Mutex{}
+		h.mintLocks[installationID] = mu
+	}
+	return mu
+}
+
+// cachedInstallationToken returns a cached token that isn't about to expire.
+func (h *GitHubAppHandler) cachedInstallationToken(installationID int64) (string, bool) {
+	h.tokenCacheMu.RLock()
+	cached, ok := h.tokenCache[installationID]
+	h.tokenCacheMu.RUnlock()
+
+	if ok && time.Now().Add(5*time.Minute).Before(cached.ExpiresAt) {
+		return cached.Token, true
+	}
+	return "", false
+}
+
 func (h *GitHubAppHandler) requestInstallationToken(installationID int64) (string, time.Time, error) {
 	// Create app JWT
 	appJWT, err := h.createAppJWT()
@@ -715,7 +1057,7 @@ func (h *GitHubAppHandler) requestInstallationToken(installationID int64) (strin
 	}
 
 	// Request installation token
-	url := fmt.Sprintf("https://api.github.com/app/installations/%d/access_tokens", installationID)
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", h.config.APIURL, installationID)
 	req, err := http.NewRequest("POST", url, nil)
 	if err != nil {
 		return "", time.Time{}, err
@@ -723,8 +1065,7 @@ func (h *GitHubAppHandler) requestInstallationToken(installationID int64) (strin
 	req.Header.Set("Authorization", "Bearer "+appJWT)
 	req.Header.Set("Accept", "application/vnd.github+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := forgeAPIClient.Do(req)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("request failed: %w", err)
 	}
@@ -762,8 +1103,9 @@ func (h *GitHubAppHandler) createAppJWT() (string, error) {
 	return token.SignedString(h.privateKey)
 }
 
-// CreateCheckRun creates a GitHub Check Run and returns its ID.
-func (h *GitHubAppHandler) CreateCheckRun(repo *storage.Repo, commit, jobID string, installationID int64) (int64, error) {
+// CreateCheckRun creates a GitHub Check Run for a job and returns its ID.
+// The check is named like the job's commit status (see statusContext).
+func (h *GitHubAppHandler) CreateCheckRun(repo *storage.Repo, job *storage.Job, installationID int64) (int64, error) {
 	if installationID == 0 {
 		return 0, fmt.Errorf("no installation ID available")
 	}
@@ -773,15 +1115,15 @@ func (h *GitHubAppHandler) CreateCheckRun(repo *storage.Repo, commit, jobID stri
 		return 0, fmt.Errorf("get installation token: %w", err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/check-runs", repo.Owner, repo.Name)
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs", h.config.APIURL, repo.Owner, repo.Name)
 
 	payload := map[string]any{
-		"name":     "cinch",
-		"head_sha": commit,
+		"name":     statusContext(repo, job),
+		"head_sha": job.Commit,
 		"status":   "queued",
 	}
 	if h.baseURL != "" {
-		payload["details_url"] = fmt.Sprintf("%s/jobs/%s", h.baseURL, jobID)
+		payload["details_url"] = fmt.Sprintf("%s/jobs/%s", h.baseURL, job.ID)
 	}
 
 	payloadBytes, _ := json.Marshal(payload)
@@ -794,8 +1136,7 @@ func (h *GitHubAppHandler) CreateCheckRun(repo *storage.Repo, commit, jobID stri
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := forgeAPIClient.Do(req)
 	if err != nil {
 		return 0, fmt.Errorf("request failed: %w", err)
 	}
@@ -830,7 +1171,7 @@ func (h *GitHubAppHandler) UpdateCheckRun(repo *storage.Repo, checkRunID int64,
 		return fmt.Errorf("get installation token: %w", err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/check-runs/%d", repo.Owner, repo.Name, checkRunID)
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", h.config.APIURL, repo.Owner, repo.Name, checkRunID)
 
 	output := map[string]string{
 		"title":   title,
@@ -861,8 +1202,7 @@ func (h *GitHubAppHandler) UpdateCheckRun(repo *storage.Repo, checkRunID int64,
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := forgeAPIClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -887,7 +1227,7 @@ func (h *GitHubAppHandler) UpdateCheckRunInProgress(repo *storage.Repo, checkRun
 		return fmt.Errorf("get installation token: %w", err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/check-runs/%d", repo.Owner, repo.Name, checkRunID)
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", h.config.APIURL, repo.Owner, repo.Name, checkRunID)
 
 	payload := map[string]any{
 		"status":     "in_progress",
@@ -904,8 +1244,7 @@ func (h *GitHubAppHandler) UpdateCheckRunInProgress(repo *storage.Repo, checkRun
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := forgeAPIClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -0,0 +1,45 @@
+package server
+
+import "testing"
+
+func TestParseCommentCommand(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"/cinch run", "run"},
+		{"/cinch cancel", "cancel"},
+		{"  /cinch RUN  ", "run"},
+		{"\n\n/cinch run\nlooks good to me", "run"},
+		{"/cinch", ""},
+		{"/cinch deploy", ""},
+		{"lgtm\n/cinch run", ""},
+		{"please /cinch run", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseCommentCommand(tt.body); got != tt.want {
+			t.Errorf("parseCommentCommand(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubAppVerifySignature(t *testing.T) {
+	// Test vector from GitHub's "Validating webhook deliveries" docs
+	h := &GitHubAppHandler{config: GitHubAppConfig{WebhookSecret: "It's a Secret to Everybody"}}
+	body := []byte("Hello, World!")
+
+	if !h.verifySignature(body, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17") {
+		t.Error("valid sha256 signature rejected")
+	}
+	for _, sig := range []string{
+		"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e18",
+		"sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59",
+		"",
+	} {
+		if h.verifySignature(body, sig) {
+			t.Errorf("signature %q accepted", sig)
+		}
+	}
+}
@@ -26,6 +26,7 @@ type WorkerConn struct {
 	// Connection state
 	ActiveJobs []string
 	LastPing   time.Time
+	Load       *protocol.WorkerLoad // Latest heartbeat snapshot; nil until reported
 
 	// Send is used to send messages to this worker.
 	// The actual WebSocket connection is managed separately.
@@ -156,6 +157,7 @@ func (h *Hub) List() []WorkerConn {
 			OwnerName:    w.OwnerName,
 			ActiveJobs:   append([]string(nil), w.ActiveJobs...),
 			LastPing:     w.LastPing,
+			Load:         w.Load,
 			// Note: Send channel is intentionally not copied
 		})
 	}
@@ -199,6 +201,40 @@ func (h *Hub) FindAvailable(labels []string) []*WorkerConn {
 	return available
 }
 
+// CountEligible returns how many connected workers the trust model lets
+// run job and how many of those have the given labels, regardless of free
+// slots. Other users' personal workers aren't counted.
+func (h *Hub) CountEligible(labels []string, job *storage.Job) (eligible, matching int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, w := range h.workers {
+		if !eligibleForJob(w, job) {
+			continue
+		}
+		eligible++
+		if h.matchesLabels(w, labels) {
+			matching++
+		}
+	}
+	return eligible, matching
+}
+
+// eligibleForJob reports whether SelectWorkerForJob could ever pick w for
+// job, given free slots: the author's (or a legacy ownerless) personal
+// worker, or a shared worker unless the job is an unapproved external PR.
+func eligibleForJob(w *WorkerConn, job *storage.Job) bool {
+	if job.Author == "" {
+		return true
+	}
+	switch w.Mode {
+	case "", protocol.ModePersonal:
+		return w.OwnerName == job.Author || w.OwnerName == ""
+	case protocol.ModeShared:
+		return !job.IsFork || job.TrustLevel != storage.TrustExternal || job.ApprovedBy != nil
+	}
+	return false
+}
+
 // SelectWorker returns the best available worker for the given labels.
 // Returns nil if no worker is available.
 // Deprecated: Use SelectWorkerForJob for trust-aware dispatch.
@@ -302,10 +338,12 @@ func (h *Hub) CanWorkerRunJob(w *WorkerConn, job *storage.Job) bool {
 	return false
 }
 
-// matchesLabels returns true if the worker has all the required labels.
+// matchesLabels returns true if the worker satisfies every required label
+// expression (see parseLabelExpr). An invalid expression matches no worker.
 func (h *Hub) matchesLabels(w *WorkerConn, required []string) bool {
 	for _, label := range required {
-		if !w.HasLabel(label) {
+		expr, err := parseLabelExpr(label)
+		if err != nil || !expr.match(w.HasLabel) {
 			return false
 		}
 	}
@@ -359,13 +397,17 @@ func (h *Hub) RemoveActiveJob(workerID, jobID string) {
 	}
 }
 
-// UpdateLastPing updates the last ping time for a worker.
-func (h *Hub) UpdateLastPing(workerID string, activeJobs []string) {
+// UpdateLastPing updates the last ping time for a worker, along with its
+// load snapshot if it sent one.
+func (h *Hub) UpdateLastPing(workerID string, activeJobs []string, load *protocol.WorkerLoad) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if w, ok := h.workers[workerID]; ok {
 		w.LastPing = time.Now()
 		w.ActiveJobs = activeJobs
+		if load != nil {
+			w.Load = load
+		}
 	}
 }
 
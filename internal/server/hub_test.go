@@ -1,8 +1,12 @@
 package server
 
 import (
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/ehrlich-b/cinch/internal/protocol"
 )
 
 func TestHubRegisterUnregister(t *testing.T) {
@@ -111,6 +115,34 @@ func TestHubFindAvailable(t *testing.T) {
 	}
 }
 
+func TestHubFindAvailableLabelExpr(t *testing.T) {
+	hub := NewHub()
+	hub.Register(&WorkerConn{ID: "w_1", Labels: []string{"linux", "amd64"}, Send: make(chan []byte, 1)})
+	hub.Register(&WorkerConn{ID: "w_2", Labels: []string{"linux", "arm64"}, Send: make(chan []byte, 1)})
+	hub.Register(&WorkerConn{ID: "w_3", Labels: []string{"macos", "arm64", "gpu"}, Send: make(chan []byte, 1)})
+
+	tests := []struct {
+		labels []string
+		want   []string
+	}{
+		{[]string{"linux AND arm64"}, []string{"w_2"}},
+		{[]string{"gpu OR amd64"}, []string{"w_1", "w_3"}},
+		{[]string{"arm64", "NOT linux"}, []string{"w_3"}},
+		{[]string{"(linux OR macos) AND NOT amd64"}, []string{"w_2", "w_3"}},
+		{[]string{"linux AND"}, nil}, // invalid expressions match nothing
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, w := range hub.FindAvailable(tt.labels) {
+			got = append(got, w.ID)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FindAvailable(%q) = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
+
 func TestHubSelectWorker(t *testing.T) {
 	hub := NewHub()
 
@@ -185,7 +217,7 @@ func TestHubUpdateLastPing(t *testing.T) {
 	})
 
 	time.Sleep(10 * time.Millisecond)
-	hub.UpdateLastPing("w_1", []string{"j_new"})
+	hub.UpdateLastPing("w_1", []string{"j_new"}, &protocol.WorkerLoad{CPUs: 4, SlotsUsed: 1, SlotsTotal: 1})
 
 	worker := hub.Get("w_1")
 	if time.Since(worker.LastPing) > time.Second {
@@ -194,6 +226,18 @@ func TestHubUpdateLastPing(t *testing.T) {
 	if len(worker.ActiveJobs) != 1 || worker.ActiveJobs[0] != "j_new" {
 		t.Errorf("ActiveJobs = %v, want [j_new]", worker.ActiveJobs)
 	}
+	if worker.Load == nil || worker.Load.CPUs != 4 {
+		t.Errorf("Load = %+v, want CPUs 4", worker.Load)
+	}
+
+	// A ping without load (older worker) keeps the last snapshot
+	hub.UpdateLastPing("w_1", nil, nil)
+	if worker.Load == nil {
+		t.Error("Load cleared by a ping without load")
+	}
+	if list := hub.List(); list[0].Load == nil || list[0].Load.CPUs != 4 {
+		t.Errorf("List() Load = %+v", list[0].Load)
+	}
 }
 
 func TestHubFindStale(t *testing.T) {
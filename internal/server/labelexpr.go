@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// labelExpr is a parsed worker label expression such as
+// "linux AND (arm64 OR amd64)" or "NOT windows". A plain label is the
+// simplest expression and matches workers that have it.
+//
+// Grammar (keywords are case-insensitive; AND binds tighter than OR):
+//
+//	expr   = term { "OR" term }
+//	term   = factor { "AND" factor }
+//	factor = "NOT" factor | "(" expr ")" | label
+type labelExpr interface {
+	match(hasLabel func(string) bool) bool
+}
+
+type labelMatch string
+
+func (l labelMatch) match(hasLabel func(string) bool) bool { return hasLabel(string(l)) }
+
+type labelNot struct{ x labelExpr }
+
+func (n labelNot) match(hasLabel func(string) bool) bool { return !n.x.match(hasLabel) }
+
+type labelAnd []labelExpr
+
+func (a labelAnd) match(hasLabel func(string) bool) bool {
+	for _, x := range a {
+		if !x.match(hasLabel) {
+			return false
+		}
+	}
+	return true
+}
+
+type labelOr []labelExpr
+
+func (o labelOr) match(hasLabel func(string) bool) bool {
+	for _, x := range o {
+		if x.match(hasLabel) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLabelExpr parses a worker label expression.
+func parseLabelExpr(s string) (labelExpr, error) {
+	p := &labelParser{tokens: tokenizeLabelExpr(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty label expression")
+	}
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	return x, nil
+}
+
+// tokenizeLabelExpr splits on whitespace, keeping parentheses as tokens.
+func tokenizeLabelExpr(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type labelParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *labelParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the given keyword or symbol.
+func (p *labelParser) accept(want string) bool {
+	tok, ok := p.peek()
+	if ok && strings.EqualFold(tok, want) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *labelParser) parseOr() (labelExpr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := labelOr{x}
+	for p.accept("OR") {
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, y)
+	}
+	if len(or) == 1 {
+		return x, nil
+	}
+	return or, nil
+}
+
+func (p *labelParser) parseAnd() (labelExpr, error) {
+	x, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	and := labelAnd{x}
+	for p.accept("AND") {
+		y, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, y)
+	}
+	if len(and) == 1 {
+		return x, nil
+	}
+	return and, nil
+}
+
+func (p *labelParser) parseFactor() (labelExpr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	switch {
+	case p.accept("NOT"):
+		x, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return labelNot{x}, nil
+	case p.accept("("):
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return x, nil
+	case tok == ")" || strings.EqualFold(tok, "AND") || strings.EqualFold(tok, "OR"):
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	p.pos++
+	return labelMatch(tok), nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import "testing"
+
+func TestParseLabelExpr(t *testing.T) {
+	tests := []struct {
+		expr   string
+		labels []string
+		want   bool
+	}{
+		{"linux", []string{"linux"}, true},
+		{"linux", []string{"windows"}, false},
+		{"linux AND arm64", []string{"linux", "arm64"}, true},
+		{"linux AND arm64", []string{"linux", "amd64"}, false},
+		{"gpu OR bigmem", []string{"bigmem"}, true},
+		{"gpu OR bigmem", []string{"linux"}, false},
+		{"NOT windows", []string{"linux"}, true},
+		{"NOT windows", []string{"windows"}, false},
+		{"linux and not arm64", []string{"linux", "amd64"}, true},
+		// AND binds tighter than OR
+		{"gpu OR linux AND arm64", []string{"gpu"}, true},
+		{"gpu OR linux AND arm64", []string{"linux"}, false},
+		{"(gpu OR linux) AND arm64", []string{"linux", "arm64"}, true},
+		{"(gpu OR linux) AND arm64", []string{"gpu"}, false},
+		{"NOT (gpu OR bigmem)", []string{"linux"}, true},
+		{"linux AND (arm64 OR amd64)", []string{"linux", "amd64"}, true},
+		{"x86_64-linux.v2", []string{"x86_64-linux.v2"}, true},
+	}
+
+	for _, tt := range tests {
+		expr, err := parseLabelExpr(tt.expr)
+		if err != nil {
+			t.Errorf("parseLabelExpr(%q) error: %v", tt.expr, err)
+			continue
+		}
+		w := &WorkerConn{Labels: tt.labels}
+		if got := expr.match(w.HasLabel); got != tt.want {
+			t.Errorf("%q with labels %v = %v, want %v", tt.expr, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelExprInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"   ",
+		"linux AND",
+		"OR gpu",
+		"(linux",
+		"linux)",
+		"linux arm64",
+		"NOT",
+		"()",
+	} {
+		if _, err := parseLabelExpr(expr); err == nil {
+			t.Errorf("parseLabelExpr(%q) = nil error, want error", expr)
+		}
+	}
+}
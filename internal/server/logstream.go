@@ -78,7 +78,9 @@ func (h *LogStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if repo.Private {
+	// A share link's token stands in for a session
+	shared := h.auth != nil && h.auth.ValidLogShareToken(r.URL.Query().Get("token"), jobID)
+	if repo.Private && !shared {
 		var email string
 		if h.auth != nil {
 			email = h.auth.GetUser(r)
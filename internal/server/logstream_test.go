@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/storage"
+	"github.com/gorilla/websocket"
+)
+
+// setupLogStream creates a log stream server backed by an in-memory log store.
+func setupLogStream(t *testing.T, status storage.JobStatus) (*LogStreamHandler, *logstore.MemoryLogStore, string) {
+	t.Helper()
+
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	t.Cleanup(func() { store.Close() })
+
+	_ = store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	_ = store.CreateJob(t.Context(), &storage.Job{
+		ID:        "j_1",
+		RepoID:    "r_1",
+		Status:    status,
+		CreatedAt: time.Now(),
+	})
+
+	logs := logstore.NewMemoryLogStore()
+	_ = logs.AppendChunk(t.Context(), "j_1", "stdout", []byte("step 1\n"))
+
+	handler := NewLogStreamHandler(store, nil, nil)
+	handler.SetLogStore(logs)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return handler, logs, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/logs/j_1"
+}
+
+func readLogStreamMessage(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg map[string]any
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return msg
+}
+
+func TestLogStreamFinishedJob(t *testing.T) {
+	_, _, wsURL := setupLogStream(t, storage.JobStatusSuccess)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	msg := readLogStreamMessage(t, conn)
+	if msg["type"] != "log" || msg["data"] != "step 1\n" {
+		t.Errorf("first message = %v, want stored log", msg)
+	}
+
+	msg = readLogStreamMessage(t, conn)
+	if msg["type"] != "status" || msg["status"] != "success" {
+		t.Errorf("second message = %v, want success status", msg)
+	}
+}
+
+func TestLogStreamRunningJob(t *testing.T) {
+	handler, _, wsURL := setupLogStream(t, storage.JobStatusRunning)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	msg := readLogStreamMessage(t, conn)
+	if msg["type"] != "log" || msg["data"] != "step 1\n" {
+		t.Errorf("first message = %v, want stored log", msg)
+	}
+
+	// Wait for the connection to subscribe before broadcasting
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		handler.mu.RLock()
+		subscribed := len(handler.subscribers["j_1"]) > 0
+		handler.mu.RUnlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	handler.BroadcastLog("j_1", "stdout", "step 2\n")
+	msg = readLogStreamMessage(t, conn)
+	if msg["type"] != "log" || msg["data"] != "step 2\n" {
+		t.Errorf("live message = %v, want broadcast log", msg)
+	}
+
+	exitCode := 1
+	handler.BroadcastJobComplete("j_1", "failed", &exitCode)
+	msg = readLogStreamMessage(t, conn)
+	if msg["type"] != "status" || msg["status"] != "failed" {
+		t.Errorf("final message = %v, want failed status", msg)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// Metrics holds the server's cumulative counters. Gauges (queue depth,
+// running jobs, connected workers) are read live from the Hub and Dispatcher
+// at scrape time. A nil *Metrics is valid and records nothing.
+type Metrics struct {
+	mu       sync.Mutex
+	jobs     map[storage.JobStatus]uint64 // finished jobs by terminal status
+	webhooks map[string]uint64            // received webhooks by forge
+}
+
+// NewMetrics creates an empty metrics registry.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		jobs:     make(map[storage.JobStatus]uint64),
+		webhooks: make(map[string]uint64),
+	}
+}
+
+// JobFinished counts a job reaching a terminal status.
+func (m *Metrics) JobFinished(status storage.JobStatus) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.jobs[status]++
+	m.mu.Unlock()
+}
+
+// WebhookReceived counts a webhook delivery from the given forge.
+func (m *Metrics) WebhookReceived(forge string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.webhooks[forge]++
+	m.mu.Unlock()
+}
+
+// MetricsHandler serves server metrics in the Prometheus text format.
+type MetricsHandler struct {
+	hub        *Hub
+	dispatcher *Dispatcher
+	token      string
+}
+
+// NewMetricsHandler creates a metrics handler. Counters come from the
+// dispatcher's Metrics; gauges are read from the hub and dispatcher.
+// Scrapers must send token as a bearer token; an empty token refuses every
+// request.
+func NewMetricsHandler(hub *Hub, dispatcher *Dispatcher, token string) *MetricsHandler {
+	return &MetricsHandler{hub: hub, dispatcher: dispatcher, token: token}
+}
+
+// ServeHTTP writes the current metrics.
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.token == "" || !ok || !TokensEqual(bearer, h.token) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var b strings.Builder
+	m := h.dispatcher.Metrics()
+
+	m.mu.Lock()
+	jobs := make(map[string]uint64, len(m.jobs))
+	for status, n := range m.jobs {
+		jobs[string(status)] = n
+	}
+	// Always expose the terminal statuses so rate() works from the first scrape
+	for _, status := range []storage.JobStatus{
+		storage.JobStatusSuccess,
+		storage.JobStatusFailed,
+		storage.JobStatusError,
+		storage.JobStatusCancelled,
+	} {
+		if _, ok := jobs[string(status)]; !ok {
+			jobs[string(status)] = 0
+		}
+	}
+	webhooks := make(map[string]uint64, len(m.webhooks))
+	for forge, n := range m.webhooks {
+		webhooks[forge] = n
+	}
+	m.mu.Unlock()
+
+	writeCounter(&b, "cinch_jobs_total", "Jobs finished, by terminal status.", "status", jobs)
+	writeGauge(&b, "cinch_jobs_running", "Jobs assigned to a worker and not yet finished.", h.dispatcher.InflightLength())
+	writeGauge(&b, "cinch_queue_depth", "Jobs waiting in the dispatch queue.", h.dispatcher.QueueLength())
+	writeGauge(&b, "cinch_workers_connected", "Workers currently connected.", h.hub.Count())
+	writeCounter(&b, "cinch_webhook_events_total", "Webhook deliveries received, by forge.", "forge", webhooks)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = io.WriteString(w, b.String())
+}
+
+// writeCounter writes a labelled counter family, sorted by label value.
+func writeCounter(b *strings.Builder, name, help, label string, values map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", name, label, k, values[k])
+	}
+}
+
+// writeGauge writes an unlabelled gauge.
+func writeGauge(b *strings.Builder, name, help string, value int) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+}
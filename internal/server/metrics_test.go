@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	hub := NewHub()
+	hub.Register(&WorkerConn{ID: "w_1", Send: make(chan []byte, 1)})
+
+	dispatcher := NewDispatcher(hub, nil, nil, nil)
+	for _, id := range []string{"j_1", "j_2", "j_3"} {
+		dispatcher.inflight[id] = &QueuedJob{Job: &storage.Job{ID: id}}
+	}
+	dispatcher.CompleteJob("j_1", storage.JobStatusSuccess)
+	dispatcher.CompleteJob("j_2", storage.JobStatusSuccess)
+	dispatcher.CompleteJob("j_3", storage.JobStatusFailed)
+	dispatcher.Metrics().WebhookReceived("gitlab")
+
+	handler := NewMetricsHandler(hub, dispatcher, "scrape-token")
+	for _, auth := range []string{"", "Bearer wrong", "scrape-token"} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", auth, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE cinch_jobs_total counter",
+		`cinch_jobs_total{status="success"} 2`,
+		`cinch_jobs_total{status="failed"} 1`,
+		`cinch_jobs_total{status="cancelled"} 0`,
+		"cinch_jobs_running 0",
+		"cinch_queue_depth 0",
+		"cinch_workers_connected 1",
+		`cinch_webhook_events_total{forge="gitlab"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// OIDCConfig holds OpenID Connect login configuration (Google, Okta,
+// Keycloak, or any other provider with discovery).
+type OIDCConfig struct {
+	Issuer       string // e.g. "https://accounts.google.com"
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // Defaults to the app base URL + /auth/oidc/callback
+}
+
+// OIDCStorage is the storage the OIDC handler needs.
+type OIDCStorage interface {
+	GetOrCreateUserByEmail(ctx context.Context, email, name string) (*storage.User, error)
+}
+
+// OIDCHandler logs users in with an OpenID Connect provider. The verified
+// email claim maps onto a cinch user exactly like a GitHub login does, and
+// the session is the same auth cookie.
+type OIDCHandler struct {
+	config     OIDCConfig
+	appBaseURL string
+	jwtSecret  []byte // Signs the state parameter
+	storage    OIDCStorage
+	log        *slog.Logger
+	client     *http.Client
+
+	// Discovery document and signing keys, fetched on first use
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]any // kid -> *rsa.PublicKey or *ecdsa.PublicKey
+	keysAt    time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcKeysMinRefresh limits how often an unknown kid triggers a JWKS
+// refetch, so forged tokens can't make us hammer the provider.
+const oidcKeysMinRefresh = time.Minute
+
+// NewOIDCHandler creates a new OIDC login handler.
+func NewOIDCHandler(cfg OIDCConfig, appBaseURL string, jwtSecret []byte, store OIDCStorage, log *slog.Logger) *OIDCHandler {
+	if log == nil {
+		log = slog.Default()
+	}
+	appBaseURL = strings.TrimSuffix(appBaseURL, "/")
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = appBaseURL + "/auth/oidc/callback"
+	}
+	return &OIDCHandler{
+		config:     cfg,
+		appBaseURL: appBaseURL,
+		jwtSecret:  jwtSecret,
+		storage:    store,
+		log:        log,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// IsConfigured returns true if OIDC login is configured.
+func (h *OIDCHandler) IsConfigured() bool {
+	return h.config.Issuer != "" && h.config.ClientID != "" && h.config.ClientSecret != ""
+}
+
+// HandleLogin redirects to the provider's authorization endpoint.
+// GET /auth/oidc
+func (h *OIDCHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.IsConfigured() {
+		http.Error(w, "OIDC login not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	disc, err := h.getDiscovery(r.Context())
+	if err != nil {
+		h.log.Error("OIDC discovery failed", "issuer", h.config.Issuer, "error", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	returnTo := sanitizeReturnTo(r.URL.Query().Get("return_to"), h.appBaseURL)
+	nonce, err := randomToken()
+	if err != nil {
+		h.log.Error("failed to create OIDC nonce", "error", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	state, err := h.createState(returnTo, nonce)
+	if err != nil {
+		h.log.Error("failed to create OAuth state", "error", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	q := url.Values{}
+	q.Set("client_id", h.config.ClientID)
+	q.Set("redirect_uri", h.config.RedirectURL)
+	q.Set("response_type", "code")
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	h.log.Info("redirecting to OIDC provider", "issuer", h.config.Issuer)
+	http.Redirect(w, r, disc.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+}
+
+// HandleCallback exchanges the code for an ID token, verifies it and logs
+// the user in by its email.
+// GET /auth/oidc/callback
+func (h *OIDCHandler) HandleCallback(w http.ResponseWriter, r *http.Request, authHelper ForgeAuthHelper) {
+	returnTo, nonce, err := h.parseState(r.URL.Query().Get("state"))
+	if err != nil {
+		h.log.Error("invalid OAuth state", "error", err)
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+		return
+	}
+
+	if errParam := r.URL.Query().Get("error"); errParam != "" {
+		h.log.Error("OIDC provider returned error", "error", errParam, "description", r.URL.Query().Get("error_description"))
+		http.Error(w, "Login failed: "+errParam, http.StatusBadRequest)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	rawIDToken, err := h.exchangeCode(r.Context(), code)
+	if err != nil {
+		h.log.Error("failed to exchange code", "error", err)
+		http.Error(w, "Failed to exchange code", http.StatusBadGateway)
+		return
+	}
+
+	claims, err := h.verifyIDToken(r.Context(), rawIDToken, nonce)
+	if err != nil {
+		h.log.Error("invalid ID token", "error", err)
+		http.Error(w, "Invalid ID token", http.StatusUnauthorized)
+		return
+	}
+
+	email, _ := claims["email"].(string)
+	if email == "" {
+		http.Error(w, "Identity provider did not return an email", http.StatusBadRequest)
+		return
+	}
+	if !emailVerified(claims["email_verified"]) {
+		h.log.Warn("OIDC login with unverified email", "email", email)
+		http.Error(w, "Email address is not verified", http.StatusForbidden)
+		return
+	}
+
+	name, _ := claims["preferred_username"].(string)
+	if name == "" {
+		name, _ = claims["name"].(string)
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+
+	if h.storage != nil {
+		if _, err := h.storage.GetOrCreateUserByEmail(r.Context(), email, name); err != nil {
+			h.log.Error("failed to get/create user", "error", err)
+			http.Error(w, "Failed to create user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := authHelper.SetAuthCookie(w, email); err != nil {
+		h.log.Error("failed to set auth cookie", "error", err)
+		http.Error(w, "Failed to complete login", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Info("user authenticated via OIDC", "email", email, "issuer", h.config.Issuer)
+
+	if returnTo == "" || returnTo == "/" {
+		returnTo = "/dashboard"
+	}
+	http.Redirect(w, r, returnTo, http.StatusFound)
+}
+
+// emailVerified reads the email_verified claim. Some providers send it as
+// the string "true" rather than a boolean.
+func emailVerified(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// --- State ---
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (h *OIDCHandler) createState(returnTo, nonce string) (string, error) {
+	claims := jwt.MapClaims{
+		"return_to": returnTo,
+		"nonce":     nonce,
+		"exp":       time.Now().Add(10 * time.Minute).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(h.jwtSecret)
+}
+
+func (h *OIDCHandler) parseState(stateToken string) (returnTo, nonce string, err error) {
+	token, err := jwt.Parse(stateToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return h.jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", fmt.Errorf("invalid state token: %w", err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", fmt.Errorf("invalid claims")
+	}
+	returnTo, _ = claims["return_to"].(string)
+	nonce, _ = claims["nonce"].(string)
+	if nonce == "" {
+		return "", "", fmt.Errorf("state has no nonce")
+	}
+	return returnTo, nonce, nil
+}
+
+// --- Provider ---
+
+// getDiscovery fetches and caches the issuer's discovery document.
+func (h *OIDCHandler) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	h.mu.Lock()
+	disc := h.discovery
+	h.mu.Unlock()
+	if disc != nil {
+		return disc, nil
+	}
+
+	disc = &oidcDiscovery{}
+	if err := h.getJSON(ctx, h.config.Issuer+"/.well-known/openid-configuration", disc); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(disc.Issuer, "/") != h.config.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", disc.Issuer, h.config.Issuer)
+	}
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing endpoints")
+	}
+
+	h.mu.Lock()
+	h.discovery = disc
+	h.mu.Unlock()
+	return disc, nil
+}
+
+func (h *OIDCHandler) exchangeCode(ctx context.Context, code string) (string, error) {
+	disc, err := h.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", h.config.RedirectURL)
+	data.Set("client_id", h.config.ClientID)
+	data.Set("client_secret", h.config.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", disc.TokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("token request returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return "", fmt.Errorf("no id_token in response")
+	}
+	return tokenResp.IDToken, nil
+}
+
+// verifyIDToken checks the ID token's signature against the issuer's JWKS
+// and its iss, aud, exp and nonce claims.
+func (h *OIDCHandler) verifyIDToken(ctx context.Context, raw, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return h.signingKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != h.config.Issuer {
+		return nil, fmt.Errorf("issuer %q does not match", iss)
+	}
+	if !claims.VerifyAudience(h.config.ClientID, true) {
+		return nil, fmt.Errorf("token is not for this client")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("token has no expiry or is expired")
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("nonce mismatch")
+	}
+	return claims, nil
+}
+
+// signingKey returns the issuer's public key for kid, refetching the JWKS
+// when the kid is unknown (providers rotate keys).
+func (h *OIDCHandler) signingKey(ctx context.Context, kid string) (any, error) {
+	h.mu.Lock()
+	key, ok := h.lookupKey(kid)
+	stale := time.Since(h.keysAt) >= oidcKeysMinRefresh
+	h.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	disc, err := h.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := h.getJSON(ctx, disc.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			h.log.Warn("skipping JWKS key", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keys, h.keysAt = keys, time.Now()
+	if key, ok := h.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds kid in the cached keys. A token without a kid matches
+// when the issuer publishes a single key. Caller holds h.mu.
+func (h *OIDCHandler) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(h.keys) == 1 {
+		for _, k := range h.keys {
+			return k, true
+		}
+	}
+	key, ok := h.keys[kid]
+	return key, ok
+}
+
+func (h *OIDCHandler) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// jsonWebKey is a public key from a JWKS document (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y: %w", err)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("point is not on curve")
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
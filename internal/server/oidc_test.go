@@ -0,0 +1,197 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockIssuer is an OIDC provider serving discovery, JWKS and a token
+// endpoint that returns whatever ID token the test sets.
+type mockIssuer struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("client_secret") != "secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockIssuer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newMockIssuer(t)
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+	auth := NewAuthHandler(AuthConfig{JWTSecret: "test-secret", BaseURL: "https://cinch.example.com"}, store, nil)
+	h := NewOIDCHandler(OIDCConfig{
+		Issuer:       issuer.URL,
+		ClientID:     "cinch",
+		ClientSecret: "secret",
+	}, "https://cinch.example.com", []byte("test-secret"), store, nil)
+
+	// login starts a flow and returns the state and nonce it sent
+	login := func(t *testing.T) (state, nonce string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.HandleLogin(w, httptest.NewRequest("GET", "/auth/oidc?return_to=/jobs", nil))
+		if w.Code != http.StatusFound {
+			t.Fatalf("login status = %d: %s", w.Code, w.Body.String())
+		}
+		loc, err := url.Parse(w.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := loc.Query()
+		if loc.Path != "/authorize" || q.Get("client_id") != "cinch" || q.Get("redirect_uri") != "https://cinch.example.com/auth/oidc/callback" {
+			t.Fatalf("unexpected authorization URL %s", loc)
+		}
+		return q.Get("state"), q.Get("nonce")
+	}
+	callback := func(state string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/auth/oidc/callback?code=good-code&state="+url.QueryEscape(state), nil)
+		h.HandleCallback(w, r, auth)
+		return w
+	}
+	claims := func(nonce string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            issuer.URL,
+			"aud":            "cinch",
+			"sub":            "12345",
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"nonce":          nonce,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		state, nonce := login(t)
+		issuer.idToken = issuer.sign(t, "test-key", claims(nonce))
+
+		w := callback(state)
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/jobs" {
+			t.Fatalf("callback = %d %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != authCookieName {
+			t.Fatalf("cookies = %v", cookies)
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(cookies[0])
+		if got := auth.GetUser(req); got != "alice@example.com" {
+			t.Errorf("GetUser = %q", got)
+		}
+		if u, err := store.GetUserByEmail(req.Context(), "alice@example.com"); err != nil || u.Name != "alice" {
+			t.Errorf("user = %+v, %v", u, err)
+		}
+	})
+
+	rejected := []struct {
+		name   string
+		mutate func(c jwt.MapClaims)
+		kid    string
+		want   int
+	}{
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }, "test-key", http.StatusUnauthorized},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, "test-key", http.StatusUnauthorized},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, "test-key", http.StatusUnauthorized},
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "replayed" }, "test-key", http.StatusUnauthorized},
+		{"unknown key", func(c jwt.MapClaims) {}, "other-key", http.StatusUnauthorized},
+		{"unverified email", func(c jwt.MapClaims) { c["email_verified"] = false }, "test-key", http.StatusForbidden},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			state, nonce := login(t)
+			c := claims(nonce)
+			tt.mutate(c)
+			issuer.idToken = issuer.sign(t, tt.kid, c)
+
+			w := callback(state)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if len(w.Result().Cookies()) != 0 {
+				t.Error("auth cookie set for rejected token")
+			}
+		})
+	}
+
+	t.Run("forged state", func(t *testing.T) {
+		w := callback("not-a-state")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	})
+}
+
+func TestJSONWebKeyRejectsBadKeys(t *testing.T) {
+	for _, k := range []jsonWebKey{
+		{Kty: "oct"},
+		{Kty: "RSA", N: "", E: "AQAB"},
+		{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"},
+	} {
+		if _, err := k.publicKey(); err == nil {
+			t.Errorf("publicKey(%+v) succeeded", k)
+		}
+	}
+}
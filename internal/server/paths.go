@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// skippedNoPaths is the webhook response (and delivery result) for a push
+// that didn't touch any of the repo's paths.
+const skippedNoPaths = "skipped (no matching paths)"
+
+// skipForPaths reports whether a push should be skipped because none of its
+// changed files match the repo's path filters. Tag pushes always build, and
+// so do pushes whose file list is unknown (nil).
+func skipForPaths(repo *storage.Repo, tag string, files []string) bool {
+	if len(repo.Paths) == 0 || tag != "" || files == nil {
+		return false
+	}
+	return !pathsMatch(repo.Paths, files)
+}
+
+// pathsMatch reports whether any file is selected by patterns. A file is
+// selected if it matches an include pattern (or there are none) and no
+// "!" exclude pattern; excludes win over includes.
+func pathsMatch(patterns, files []string) bool {
+	var includes, excludes []string
+	for _, p := range patterns {
+		if rest, ok := strings.CutPrefix(p, "!"); ok {
+			excludes = append(excludes, rest)
+		} else {
+			includes = append(includes, p)
+		}
+	}
+
+	for _, f := range files {
+		if len(includes) > 0 && !matchAny(includes, f) {
+			continue
+		}
+		if !matchAny(excludes, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob where each
+// segment uses path.Match syntax and "**" matches any number of segments,
+// e.g. "services/api/**" or "**/*.md".
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validatePathPattern checks a repo path filter before it's stored.
+func validatePathPattern(p string) error {
+	glob := strings.TrimPrefix(p, "!")
+	if glob == "" {
+		return fmt.Errorf("empty path pattern")
+	}
+	if strings.Contains(glob, ",") {
+		return fmt.Errorf("path pattern %q: commas are not allowed", p)
+	}
+	if strings.HasPrefix(glob, "/") {
+		return fmt.Errorf("path pattern %q: must be relative to the repo root", p)
+	}
+	for _, seg := range strings.Split(glob, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("path pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
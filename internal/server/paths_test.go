@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ehrlich-b/cinch/internal/forge"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"README.md", "README.md", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide.md", false},
+		{"**/*.md", "docs/guide.md", true},
+		{"**/*.md", "README.md", true},
+		{"services/api/**", "services/api/main.go", true},
+		{"services/api/**", "services/api/internal/db/db.go", true},
+		{"services/api/**", "services/web/main.go", false},
+		{"services/*/go.mod", "services/api/go.mod", true},
+		{"services/*/go.mod", "services/api/cmd/go.mod", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathsMatchPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		files    []string
+		want     bool
+	}{
+		{"include matches", []string{"api/**"}, []string{"api/main.go"}, true},
+		{"include misses", []string{"api/**"}, []string{"web/index.ts"}, false},
+		{"any file is enough", []string{"api/**"}, []string{"web/index.ts", "api/main.go"}, true},
+		{"exclude wins over include", []string{"api/**", "!**/*.md"}, []string{"api/README.md"}, false},
+		{"exclude leaves other files", []string{"api/**", "!**/*.md"}, []string{"api/README.md", "api/main.go"}, true},
+		{"exclude only", []string{"!docs/**"}, []string{"docs/index.md"}, false},
+		{"exclude only, other file", []string{"!docs/**"}, []string{"docs/index.md", "main.go"}, true},
+		{"no files", []string{"api/**"}, []string{}, false},
+	}
+	for _, tt := range tests {
+		if got := pathsMatch(tt.patterns, tt.files); got != tt.want {
+			t.Errorf("%s: pathsMatch(%v, %v) = %v, want %v", tt.name, tt.patterns, tt.files, got, tt.want)
+		}
+	}
+
+	repo := &storage.Repo{Paths: []string{"api/**"}}
+	if skipForPaths(repo, "v1.0.0", []string{"web/index.ts"}) {
+		t.Error("tag pushes should always build")
+	}
+	if skipForPaths(repo, "", nil) {
+		t.Error("pushes without a file list should always build")
+	}
+	if !skipForPaths(repo, "", []string{"web/index.ts"}) {
+		t.Error("expected push outside api/ to be skipped")
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	for _, p := range []string{"api/**", "!**/*.md", "go.mod", "services/*/go.mod"} {
+		if err := validatePathPattern(p); err != nil {
+			t.Errorf("validatePathPattern(%q): %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "!", "/abs/**", "a,b", "[bad"} {
+		if err := validatePathPattern(p); err == nil {
+			t.Errorf("validatePathPattern(%q): expected error", p)
+		}
+	}
+}
+
+func TestWebhookSkipsPushOutsidePaths(t *testing.T) {
+	f := &fakeForge{push: &forge.PushEvent{
+		Repo:         &forge.Repo{ForgeType: "github", Owner: "test", Name: "repo", CloneURL: "https://github.com/test/repo.git"},
+		Commit:       "abc123def456",
+		Ref:          "refs/heads/main",
+		Branch:       "main",
+		Sender:       "test",
+		ChangedFiles: []string{"docs/index.md"},
+	}}
+	h, store := newDedupTestHandler(t, f)
+	if err := store.UpdateRepoPaths(t.Context(), "r_1", []string{"api/**"}); err != nil {
+		t.Fatalf("UpdateRepoPaths failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != skippedNoPaths {
+		t.Fatalf("response = %d %q, want 200 %q", w.Code, w.Body.String(), skippedNoPaths)
+	}
+	jobs, _ := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if len(jobs) != 0 {
+		t.Fatalf("got %d jobs for a skipped push, want 0", len(jobs))
+	}
+
+	// A push touching a matching file builds
+	f.push.Commit = "def456abc123"
+	f.push.ChangedFiles = []string{"docs/index.md", "api/main.go"}
+	deliver(t, h)
+	jobs, _ = store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if len(jobs) != 1 {
+		t.Errorf("got %d jobs after matching push, want 1", len(jobs))
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(1, 3)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	h := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Burst up to the bucket size, then 429s
+	var ok, limited int
+	for range 10 {
+		w := request("10.0.0.1")
+		switch w.Code {
+		case http.StatusOK:
+			ok++
+		case http.StatusTooManyRequests:
+			limited++
+			if w.Header().Get("Retry-After") != "1" {
+				t.Errorf("Retry-After = %q, want 1", w.Header().Get("Retry-After"))
+			}
+		default:
+			t.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+	if ok != 3 || limited != 7 {
+		t.Errorf("got %d ok / %d limited, want 3 / 7", ok, limited)
+	}
+
+	// Other clients have their own bucket
+	if w := request("10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("other client status = %d, want 200", w.Code)
+	}
+
+	// Tokens refill at the sustained rate
+	now = now.Add(time.Second)
+	if w := request("10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("status after refill = %d, want 200", w.Code)
+	}
+	if w := request("10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429 once the refilled token is spent", w.Code)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	limiter := NewRateLimiter(10, 5)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("10.0.0.1")
+	now = now.Add(2 * rateLimitSweepInterval)
+	limiter.Allow("10.0.0.2")
+
+	if _, ok := limiter.clients["10.0.0.1"]; ok {
+		t.Error("idle client bucket was not swept")
+	}
+	if _, ok := limiter.clients["10.0.0.2"]; !ok {
+		t.Error("active client bucket was swept")
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// DefaultWorkerOfflineAfter is how long a worker may go unseen, while not
+// connected, before the reaper marks it offline.
+const DefaultWorkerOfflineAfter = 90 * time.Second
+
+// WorkerReaper marks workers offline when they died without a clean
+// disconnect (or the server restarted under them), so the UI stops showing
+// them as connected, and re-queues the jobs storage still has assigned to them.
+type WorkerReaper struct {
+	storage    storage.Storage
+	hub        *Hub
+	dispatcher *Dispatcher
+	threshold  time.Duration
+	interval   time.Duration
+	log        *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorkerReaper creates a reaper for workers unseen for longer than threshold.
+func NewWorkerReaper(store storage.Storage, hub *Hub, dispatcher *Dispatcher, threshold time.Duration, log *slog.Logger) *WorkerReaper {
+	if log == nil {
+		log = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WorkerReaper{
+		storage:    store,
+		hub:        hub,
+		dispatcher: dispatcher,
+		threshold:  threshold,
+		interval:   30 * time.Second,
+		log:        log,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start begins reaping in the background, once immediately and then every 30s.
+func (r *WorkerReaper) Start() {
+	r.wg.Add(1)
+	go r.loop()
+}
+
+// Stop stops the reaper and waits for the current pass to finish.
+func (r *WorkerReaper) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *WorkerReaper) loop() {
+	defer r.wg.Done()
+
+	r.Reap(r.ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reap(r.ctx)
+		}
+	}
+}
+
+// Reap marks offline every worker that storage shows as online, that hasn't
+// been seen within the threshold, and that isn't connected to this server.
+// Returns the number of workers marked offline.
+func (r *WorkerReaper) Reap(ctx context.Context) int {
+	workers, err := r.storage.ListWorkers(ctx)
+	if err != nil {
+		r.log.Error("failed to list workers", "error", err)
+		return 0
+	}
+
+	cutoff := time.Now().Add(-r.threshold)
+	reaped := 0
+	for _, w := range workers {
+		if w.Status == storage.WorkerStatusOffline || w.LastSeen.After(cutoff) || r.hub.Get(w.ID) != nil {
+			continue
+		}
+
+		if err := r.storage.UpdateWorkerStatus(ctx, w.ID, storage.WorkerStatusOffline); err != nil {
+			r.log.Error("failed to mark worker offline", "worker_id", w.ID, "error", err)
+			continue
+		}
+		reaped++
+
+		requeued := 0
+		if r.dispatcher != nil {
+			requeued = r.dispatcher.RequeueJobsForWorker(ctx, w.ID)
+		}
+		r.log.Info("marked dead worker offline", "worker_id", w.ID, "last_seen", w.LastSeen, "jobs_requeued", requeued)
+	}
+	return reaped
+}
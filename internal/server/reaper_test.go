@@ -0,0 +1,114 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+func TestWorkerReaperMarksDeadWorkersOffline(t *testing.T) {
+	ctx := t.Context()
+	hub := NewHub()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	stale := time.Now().Add(-5 * time.Minute)
+	for _, w := range []*storage.Worker{
+		{ID: "w_dead", Status: storage.WorkerStatusOnline, LastSeen: stale},
+		{ID: "w_connected", Status: storage.WorkerStatusOnline, LastSeen: stale},
+		{ID: "w_recent", Status: storage.WorkerStatusOnline, LastSeen: time.Now()},
+	} {
+		w.CreatedAt = time.Now()
+		if err := store.CreateWorker(ctx, w); err != nil {
+			t.Fatalf("CreateWorker failed: %v", err)
+		}
+	}
+	hub.Register(&WorkerConn{ID: "w_connected", Send: make(chan []byte, 10)})
+
+	if err := store.CreateRepo(ctx, &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	for _, id := range []string{"j_running", "j_done"} {
+		if err := store.CreateJob(ctx, &storage.Job{ID: id, RepoID: "r_1", Commit: "abc", Branch: "main", Status: storage.JobStatusPending, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+		if err := store.UpdateJobWorker(ctx, id, "w_dead"); err != nil {
+			t.Fatalf("UpdateJobWorker failed: %v", err)
+		}
+	}
+	_ = store.UpdateJobStatus(ctx, "j_running", storage.JobStatusRunning, nil)
+	exitCode := 0
+	_ = store.UpdateJobStatus(ctx, "j_done", storage.JobStatusSuccess, &exitCode)
+
+	dispatcher := NewDispatcher(hub, store, nil, nil)
+	reaper := NewWorkerReaper(store, hub, dispatcher, DefaultWorkerOfflineAfter, nil)
+
+	if n := reaper.Reap(ctx); n != 1 {
+		t.Errorf("Reap = %d, want 1", n)
+	}
+
+	want := map[string]storage.WorkerStatus{
+		"w_dead":      storage.WorkerStatusOffline,
+		"w_connected": storage.WorkerStatusOnline,
+		"w_recent":    storage.WorkerStatusOnline,
+	}
+	for id, status := range want {
+		w, err := store.GetWorker(ctx, id)
+		if err != nil {
+			t.Fatalf("GetWorker(%s) failed: %v", id, err)
+		}
+		if w.Status != status {
+			t.Errorf("worker %s status = %s, want %s", id, w.Status, status)
+		}
+	}
+
+	// The dead worker's running job goes back in the queue; finished jobs are left alone
+	if dispatcher.QueueLength() != 1 {
+		t.Errorf("QueueLength = %d, want 1", dispatcher.QueueLength())
+	}
+	job, _ := store.GetJob(ctx, "j_running")
+	if job.Status != storage.JobStatusQueued {
+		t.Errorf("j_running status = %s, want %s", job.Status, storage.JobStatusQueued)
+	}
+	job, _ = store.GetJob(ctx, "j_done")
+	if job.Status != storage.JobStatusSuccess {
+		t.Errorf("j_done status = %s, want %s", job.Status, storage.JobStatusSuccess)
+	}
+
+	// Offline workers aren't reaped again
+	if n := reaper.Reap(ctx); n != 0 {
+		t.Errorf("second Reap = %d, want 0", n)
+	}
+}
+
+func TestWorkerReaperSparesWorkerPingingAnotherServer(t *testing.T) {
+	ctx := t.Context()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	// Registered a while ago on server A, which shares the database
+	if err := store.CreateWorker(ctx, &storage.Worker{
+		ID:        "w_1",
+		Status:    storage.WorkerStatusOnline,
+		LastSeen:  time.Now().Add(-5 * time.Minute),
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateWorker failed: %v", err)
+	}
+	hubA := NewHub()
+	worker := &WorkerConn{ID: "w_1", Send: make(chan []byte, 10)}
+	hubA.Register(worker)
+	NewWSHandler(hubA, store, nil).handlePing(worker, []byte(`{"timestamp":1}`))
+
+	// Server B's reaper doesn't see the connection, only last_seen
+	reaper := NewWorkerReaper(store, NewHub(), nil, DefaultWorkerOfflineAfter, nil)
+	if n := reaper.Reap(ctx); n != 0 {
+		t.Errorf("Reap = %d, want 0 for a worker that just pinged", n)
+	}
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// logPruneBatchSize caps how many jobs are pruned per pass.
+const logPruneBatchSize = 500
+
+// LogPruner deletes logs of finished jobs older than the retention window.
+// Job records are kept so build history and stats survive; only the log
+// data is removed and the owner's storage usage is credited back.
+type LogPruner struct {
+	storage   storage.Storage
+	logStore  logstore.LogStore
+	retention time.Duration
+	interval  time.Duration
+	log       *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLogPruner creates a log pruner that removes logs older than retention.
+func NewLogPruner(store storage.Storage, logStore logstore.LogStore, retention time.Duration, log *slog.Logger) *LogPruner {
+	if log == nil {
+		log = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LogPruner{
+		storage:   store,
+		logStore:  logStore,
+		retention: retention,
+		interval:  time.Hour,
+		log:       log,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start begins pruning in the background, once immediately and then hourly.
+func (p *LogPruner) Start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+// Stop stops the pruner and waits for the current pass to finish.
+func (p *LogPruner) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *LogPruner) loop() {
+	defer p.wg.Done()
+
+	p.Prune(p.ctx)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.Prune(p.ctx)
+		}
+	}
+}
+
+// Prune deletes logs for all jobs that finished before the retention cutoff.
+// Returns the number of jobs whose logs were pruned.
+func (p *LogPruner) Prune(ctx context.Context) int {
+	pruned := p.pruneBefore(ctx, time.Now().Add(-p.retention))
+	if pruned > 0 {
+		p.log.Info("pruned expired job logs", "jobs", pruned, "retention", p.retention)
+	}
+	return pruned
+}
+
+// pruneBefore deletes logs for all jobs that finished before cutoff.
+func (p *LogPruner) pruneBefore(ctx context.Context, cutoff time.Time) int {
+	pruned := 0
+
+	for ctx.Err() == nil {
+		expired, err := p.storage.ListJobsWithExpiredLogs(ctx, cutoff, logPruneBatchSize)
+		if err != nil {
+			p.log.Error("failed to list expired logs", "error", err)
+			break
+		}
+		if len(expired) == 0 {
+			break
+		}
+
+		batchPruned := 0
+		for _, l := range expired {
+			if err := p.pruneJob(ctx, l); err != nil {
+				p.log.Error("failed to prune job logs", "job_id", l.JobID, "error", err)
+				continue
+			}
+			batchPruned++
+		}
+		pruned += batchPruned
+
+		// Every job in the batch failed; retry on the next pass instead of spinning
+		if batchPruned == 0 || len(expired) < logPruneBatchSize {
+			break
+		}
+	}
+
+	return pruned
+}
+
+func (p *LogPruner) pruneJob(ctx context.Context, l *storage.ExpiredLog) error {
+	if err := p.logStore.Delete(ctx, l.JobID); err != nil {
+		return err
+	}
+	if err := p.storage.MarkJobLogsPruned(ctx, l.JobID); err != nil {
+		return err
+	}
+	if l.LogSizeBytes > 0 {
+		repo := &storage.Repo{ForgeType: l.ForgeType, Owner: l.RepoOwner, OwnerUserID: l.OwnerUserID}
+		if err := chargeStorage(ctx, p.storage, repo, -l.LogSizeBytes); err != nil {
+			p.log.Warn("failed to credit storage usage", "job_id", l.JobID, "error", err)
+		}
+	}
+	return nil
+}
+
+// JobPruner deletes finished jobs older than the retention window, along
+// with their logs. Logs are removed from the log store first so R2 and
+// filesystem objects don't outlive their job records.
+type JobPruner struct {
+	logs      *LogPruner
+	storage   storage.Storage
+	retention time.Duration
+	interval  time.Duration
+	log       *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJobPruner creates a job pruner that removes jobs older than retention.
+func NewJobPruner(store storage.Storage, logStore logstore.LogStore, retention time.Duration, log *slog.Logger) *JobPruner {
+	if log == nil {
+		log = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobPruner{
+		logs:      NewLogPruner(store, logStore, retention, log),
+		storage:   store,
+		retention: retention,
+		interval:  time.Hour,
+		log:       log,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start begins pruning in the background, once immediately and then hourly.
+func (p *JobPruner) Start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+// Stop stops the pruner and waits for the current pass to finish.
+func (p *JobPruner) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *JobPruner) loop() {
+	defer p.wg.Done()
+
+	p.Prune(p.ctx)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.Prune(p.ctx)
+		}
+	}
+}
+
+// Prune deletes all jobs that finished before the retention cutoff.
+// Returns the number of jobs deleted.
+func (p *JobPruner) Prune(ctx context.Context) int {
+	cutoff := time.Now().Add(-p.retention)
+
+	// Drop log objects first; if any fail, keep the job rows so the
+	// logs can be found and retried on the next pass
+	p.logs.pruneBefore(ctx, cutoff)
+	remaining, err := p.storage.ListJobsWithExpiredLogs(ctx, cutoff, 1)
+	if err != nil {
+		p.log.Error("failed to list expired logs", "error", err)
+		return 0
+	}
+	if len(remaining) > 0 {
+		p.log.Warn("some job logs could not be deleted; keeping jobs until next pass")
+		return 0
+	}
+
+	deleted, err := p.storage.DeleteJobsOlderThan(ctx, cutoff)
+	if err != nil {
+		p.log.Error("failed to delete old jobs", "error", err)
+		return 0
+	}
+	if deleted > 0 {
+		p.log.Info("deleted expired jobs", "jobs", deleted, "retention", p.retention)
+	}
+	return deleted
+}
+
+// DeliveryPruner deletes stored webhook deliveries once they are older
+// than deliveryRetention and can no longer be replayed.
+type DeliveryPruner struct {
+	storage  storage.Storage
+	interval time.Duration
+	log      *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDeliveryPruner creates a pruner for expired webhook deliveries.
+func NewDeliveryPruner(store storage.Storage, log *slog.Logger) *DeliveryPruner {
+	if log == nil {
+		log = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DeliveryPruner{
+		storage:  store,
+		interval: time.Hour,
+		log:      log,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins pruning in the background, once immediately and then hourly.
+func (p *DeliveryPruner) Start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+// Stop stops the pruner and waits for the current pass to finish.
+func (p *DeliveryPruner) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *DeliveryPruner) loop() {
+	defer p.wg.Done()
+
+	p.Prune(p.ctx)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.Prune(p.ctx)
+		}
+	}
+}
+
+// Prune deletes webhook deliveries older than deliveryRetention.
+func (p *DeliveryPruner) Prune(ctx context.Context) {
+	if err := p.storage.DeleteWebhookDeliveriesBefore(ctx, time.Now().Add(-deliveryRetention)); err != nil {
+		p.log.Error("failed to prune webhook deliveries", "error", err)
+	}
+}
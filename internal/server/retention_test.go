@@ -0,0 +1,159 @@
+package server
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/logstore"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// hasLogs reports whether the log store still holds logs for a job.
+func hasLogs(t *testing.T, logs logstore.LogStore, jobID string) bool {
+	t.Helper()
+	r, err := logs.GetLogs(t.Context(), jobID)
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read logs: %v", err)
+	}
+	return len(data) > 0
+}
+
+func TestLogPrunerKeepsJobRecords(t *testing.T) {
+	ctx := t.Context()
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	defer store.Close()
+
+	user, err := store.GetOrCreateUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser failed: %v", err)
+	}
+	if err := store.UpdateUserStorageUsed(ctx, user.ID, 1500); err != nil {
+		t.Fatalf("UpdateUserStorageUsed failed: %v", err)
+	}
+
+	err = store.CreateRepo(ctx, &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		CloneURL:    "https://github.com/test/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	exitCode := 0
+	for _, id := range []string{"j_done", "j_running"} {
+		if err := store.CreateJob(ctx, &storage.Job{ID: id, RepoID: "r_1", Commit: "abc", Status: storage.JobStatusPending, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+	}
+	_ = store.UpdateJobStatus(ctx, "j_done", storage.JobStatusSuccess, &exitCode)
+	_ = store.UpdateJobLogSize(ctx, "j_done", 1000)
+	_ = store.UpdateJobArtifactSize(ctx, "j_done", 200)
+	_ = store.UpdateJobStatus(ctx, "j_running", storage.JobStatusRunning, nil)
+
+	logs := logstore.NewMemoryLogStore()
+	for _, id := range []string{"j_done", "j_running"} {
+		_ = logs.AppendChunk(ctx, id, "stdout", []byte("hello\n"))
+	}
+
+	// A day of retention keeps logs of a job that just finished
+	if n := NewLogPruner(store, logs, 24*time.Hour, nil).Prune(ctx); n != 0 {
+		t.Fatalf("pruned %d jobs within retention, want 0", n)
+	}
+
+	// Negative retention puts the cutoff in the future so every finished job expires
+	pruner := NewLogPruner(store, logs, -time.Hour, nil)
+	if n := pruner.Prune(ctx); n != 1 {
+		t.Fatalf("pruned %d jobs, want 1", n)
+	}
+	if hasLogs(t, logs, "j_done") || !hasLogs(t, logs, "j_running") {
+		t.Errorf("want only j_done's logs deleted")
+	}
+
+	// Job record survives with its log size zeroed
+	job, err := store.GetJob(ctx, "j_done")
+	if err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if job.Status != storage.JobStatusSuccess {
+		t.Errorf("status = %s, want success", job.Status)
+	}
+
+	user, _ = store.GetUserByID(ctx, user.ID)
+	// Log and artifact bytes are both credited back
+	if user.StorageUsedBytes != 300 {
+		t.Errorf("storage used = %d, want 300", user.StorageUsedBytes)
+	}
+
+	// Already-pruned jobs are skipped on later passes
+	if n := pruner.Prune(ctx); n != 0 {
+		t.Errorf("second pass pruned %d jobs, want 0", n)
+	}
+}
+
+func TestJobPrunerDeletesJobsAndLogs(t *testing.T) {
+	ctx := t.Context()
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	defer store.Close()
+
+	err = store.CreateRepo(ctx, &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	exitCode := 0
+	for _, id := range []string{"j_done", "j_pruned", "j_running"} {
+		if err := store.CreateJob(ctx, &storage.Job{ID: id, RepoID: "r_1", Commit: "abc", Status: storage.JobStatusPending, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+	}
+	_ = store.UpdateJobStatus(ctx, "j_done", storage.JobStatusSuccess, &exitCode)
+	_ = store.AppendLog(ctx, "j_done", "stdout", "hello\n")
+	_ = store.UpdateJobStatus(ctx, "j_pruned", storage.JobStatusFailed, &exitCode)
+	_ = store.MarkJobLogsPruned(ctx, "j_pruned")
+	_ = store.UpdateJobStatus(ctx, "j_running", storage.JobStatusRunning, nil)
+
+	logs := logstore.NewMemoryLogStore()
+	for _, id := range []string{"j_done", "j_running"} {
+		_ = logs.AppendChunk(ctx, id, "stdout", []byte("hello\n"))
+	}
+	if n := NewJobPruner(store, logs, 24*time.Hour, nil).Prune(ctx); n != 0 {
+		t.Fatalf("deleted %d jobs within retention, want 0", n)
+	}
+
+	// Negative retention puts the cutoff in the future so every finished job expires
+	if n := NewJobPruner(store, logs, -time.Hour, nil).Prune(ctx); n != 2 {
+		t.Fatalf("deleted %d jobs, want 2", n)
+	}
+	if hasLogs(t, logs, "j_done") || !hasLogs(t, logs, "j_running") {
+		t.Errorf("want only j_done's logs deleted")
+	}
+
+	for _, id := range []string{"j_done", "j_pruned"} {
+		if _, err := store.GetJob(ctx, id); err != storage.ErrNotFound {
+			t.Errorf("GetJob(%s) err = %v, want ErrNotFound", id, err)
+		}
+	}
+	if _, err := store.GetJob(ctx, "j_running"); err != nil {
+		t.Errorf("running job deleted: %v", err)
+	}
+}
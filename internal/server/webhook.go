@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -58,6 +59,9 @@ func (h *WebhookHandler) RegisterForge(f forge.Forge) {
 
 // ServeHTTP handles webhook requests.
 func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w, r, finish := trackDelivery(h.storage, h.log, w, r)
+	defer finish()
+
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -79,6 +83,9 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.log.Debug("webhook received", "forge", matchedForge.Name())
+	if h.dispatcher != nil {
+		h.dispatcher.Metrics().WebhookReceived(matchedForge.Name())
+	}
 
 	// Limit request body to 5MB to prevent DoS
 	r.Body = http.MaxBytesReader(w, r.Body, 5<<20)
@@ -131,7 +138,8 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// SECURITY: Verify signature BEFORE any state changes
-	if repo.WebhookSecret != "" {
+	// (replays are admin-initiated from a stored, already-verified delivery)
+	if repo.WebhookSecret != "" && !isReplay(ctx) {
 		r.Body = io.NopCloser(strings.NewReader(string(body)))
 		_, err = matchedForge.ParsePush(r, repo.WebhookSecret)
 		if err != nil {
@@ -140,6 +148,7 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	recordDelivery(ctx, h.storage, h.log, deliverySourceWebhook, r, body, repo.ID)
 
 	// Now safe to sync private flag (after signature verified)
 	if repo.Private != event.Repo.Private {
@@ -151,6 +160,21 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if skipForPaths(repo, event.Tag, event.ChangedFiles) {
+		h.log.Info("push skipped, no matching paths", "repo", event.Repo.FullName(), "ref", event.Ref, "files", len(event.ChangedFiles))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, skippedNoPaths)
+		return
+	}
+
+	// Forges redeliver on timeout; reuse the job from the earlier delivery
+	if existing := findDuplicateJob(ctx, h.storage, repo.ID, event.Commit, event.Ref); existing != nil {
+		h.log.Info("duplicate webhook delivery, reusing job", "job_id", existing.ID, "repo", event.Repo.FullName(), "ref", event.Ref)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"job_id": %q}`, existing.ID)
+		return
+	}
+
 	// Create job
 	job, err := h.createJob(ctx, repo, event)
 	if err != nil {
@@ -176,9 +200,11 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		exitCode := 1
 		if updateErr := h.storage.UpdateJobStatus(ctx, job.ID, storage.JobStatusFailed, &exitCode); updateErr != nil {
 			h.log.Error("failed to update job status", "error", updateErr)
+		} else if h.dispatcher != nil {
+			h.dispatcher.Metrics().JobFinished(storage.JobStatusFailed)
 		}
 		// Post failed status to GitHub/GitLab so user sees it
-		if statusErr := h.postStatus(ctx, matchedForge, repo, event.Commit, job.ID, forge.StatusError, errMsg); statusErr != nil {
+		if statusErr := h.postStatus(ctx, matchedForge, repo, job, forge.StatusError, errMsg); statusErr != nil {
 			h.log.Warn("failed to post billing error status", "error", statusErr)
 		}
 		w.WriteHeader(http.StatusAccepted)
@@ -187,7 +213,7 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Post pending status
-	if err := h.postStatus(ctx, matchedForge, repo, event.Commit, job.ID, forge.StatusPending, "Build queued"); err != nil {
+	if err := h.postStatus(ctx, matchedForge, repo, job, forge.StatusPending, "Build queued"); err != nil {
 		h.log.Warn("failed to post pending status", "error", err)
 		// Don't fail the webhook - job is already created
 	}
@@ -199,6 +225,7 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Queue job for dispatch
+	h.dispatcher.CancelSuperseded(ctx, repo, job)
 	h.dispatcher.Enqueue(&QueuedJob{
 		Job:      job,
 		Repo:     repo,
@@ -237,7 +264,8 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 	}
 
 	// SECURITY: Verify signature BEFORE any state changes
-	if repo.WebhookSecret != "" {
+	// (replays are admin-initiated from a stored, already-verified delivery)
+	if repo.WebhookSecret != "" && !isReplay(ctx) {
 		r.Body = io.NopCloser(strings.NewReader(string(body)))
 		_, err = matchedForge.ParsePullRequest(r, repo.WebhookSecret)
 		if err != nil {
@@ -246,6 +274,19 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 			return
 		}
 	}
+	recordDelivery(ctx, h.storage, h.log, deliverySourceWebhook, r, body, repo.ID)
+
+	// Bitbucket sends an abbreviated head hash; expand it before it's used
+	// for the job, statuses and duplicate detection
+	if resolver, ok := h.forgeClient(matchedForge, repo).(forge.PullRequestCommitResolver); ok && len(prEvent.Commit) < 40 {
+		commit, err := resolver.ResolvePullRequestCommit(ctx, prEvent.Repo, prEvent.Number, prEvent.Commit)
+		if err != nil {
+			h.log.Error("failed to resolve PR commit", "repo", prEvent.Repo.FullName(), "pr", prEvent.Number, "commit", prEvent.Commit, "error", err)
+			http.Error(w, "failed to resolve commit", http.StatusBadGateway)
+			return
+		}
+		prEvent.Commit = commit
+	}
 
 	// Now safe to sync private flag (after signature verified)
 	if repo.Private != prEvent.Repo.Private {
@@ -257,6 +298,15 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// Forges redeliver on timeout; reuse the job from the earlier delivery
+	prRef := fmt.Sprintf("refs/pull/%d/head", prEvent.Number)
+	if existing := findDuplicateJob(ctx, h.storage, repo.ID, prEvent.Commit, prRef); existing != nil {
+		h.log.Info("duplicate webhook delivery, reusing job", "job_id", existing.ID, "repo", prEvent.Repo.FullName(), "pr", prEvent.Number)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"job_id": %q}`, existing.ID)
+		return
+	}
+
 	// Create job for PR
 	job, err := h.createPRJob(ctx, repo, prEvent)
 	if err != nil {
@@ -284,9 +334,11 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 		exitCode := 1
 		if updateErr := h.storage.UpdateJobStatus(ctx, job.ID, storage.JobStatusFailed, &exitCode); updateErr != nil {
 			h.log.Error("failed to update job status", "error", updateErr)
+		} else if h.dispatcher != nil {
+			h.dispatcher.Metrics().JobFinished(storage.JobStatusFailed)
 		}
 		// Post failed status to GitHub/GitLab so user sees it
-		if statusErr := h.postStatus(ctx, matchedForge, repo, prEvent.Commit, job.ID, forge.StatusError, errMsg); statusErr != nil {
+		if statusErr := h.postStatus(ctx, matchedForge, repo, job, forge.StatusError, errMsg); statusErr != nil {
 			h.log.Warn("failed to post billing error status", "error", statusErr)
 		}
 		w.WriteHeader(http.StatusAccepted)
@@ -299,7 +351,7 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 	if job.Status == storage.JobStatusPendingContributor {
 		statusMsg = "Awaiting contributor CI - run `cinch worker` to provide results"
 	}
-	if err := h.postStatus(ctx, matchedForge, repo, prEvent.Commit, job.ID, forge.StatusPending, statusMsg); err != nil {
+	if err := h.postStatus(ctx, matchedForge, repo, job, forge.StatusPending, statusMsg); err != nil {
 		h.log.Warn("failed to post pending status", "error", err)
 	}
 
@@ -307,13 +359,14 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 	command := repo.Build
 
 	// Queue job for dispatch
+	h.dispatcher.CancelSuperseded(ctx, repo, job)
 	h.dispatcher.Enqueue(&QueuedJob{
 		Job:      job,
 		Repo:     repo,
 		Forge:    matchedForge,
 		Labels:   repo.Workers, // Worker labels for fan-out
 		CloneURL: prEvent.Repo.CloneURL,
-		Ref:      "refs/pull/" + fmt.Sprintf("%d", prEvent.Number) + "/head", // PR ref format
+		Ref:      prRef,
 		Branch:   prEvent.HeadBranch,
 		Config: protocol.JobConfig{
 			Command: command,
@@ -326,6 +379,24 @@ func (h *WebhookHandler) handlePullRequest(w http.ResponseWriter, r *http.Reques
 	fmt.Fprintf(w, `{"job_id": %q}`, job.ID)
 }
 
+// duplicateDeliveryWindow is how long a new job absorbs redeliveries of the
+// webhook that created it.
+const duplicateDeliveryWindow = 5 * time.Minute
+
+// findDuplicateJob returns a recently created, still active job for the same
+// repo, commit and git ref, or nil if the delivery should create a new job.
+func findDuplicateJob(ctx context.Context, store storage.Storage, repoID, commit, ref string) *storage.Job {
+	job, err := store.GetJobByCommitRef(ctx, repoID, commit, ref)
+	if err != nil || time.Since(job.CreatedAt) > duplicateDeliveryWindow {
+		return nil
+	}
+	switch job.Status {
+	case storage.JobStatusPending, storage.JobStatusQueued, storage.JobStatusRunning, storage.JobStatusPendingContributor:
+		return job
+	}
+	return nil
+}
+
 func (h *WebhookHandler) createPRJob(ctx context.Context, repo *storage.Repo, event *forge.PullRequestEvent) (*storage.Job, error) {
 	prNum := event.Number
 
@@ -411,26 +482,21 @@ func (h *WebhookHandler) determinePushTrustLevel(repo *storage.Repo, event *forg
 	return storage.TrustCollaborator
 }
 
-func (h *WebhookHandler) postStatus(ctx context.Context, f forge.Forge, repo *storage.Repo, commit, jobID string, state forge.StatusState, description string) error {
+func (h *WebhookHandler) postStatus(ctx context.Context, f forge.Forge, repo *storage.Repo, job *storage.Job, state forge.StatusState, description string) error {
 	// Build target URL for logs
 	targetURL := ""
 	if h.baseURL != "" {
-		targetURL = fmt.Sprintf("%s/jobs/%s", h.baseURL, jobID)
+		targetURL = fmt.Sprintf("%s/jobs/%s", h.baseURL, job.ID)
 	}
 
 	status := &forge.Status{
 		State:       state,
-		Context:     "cinch",
+		Context:     statusContext(repo, job),
 		Description: description,
 		TargetURL:   targetURL,
 	}
 
-	// Create forge instance with token
-	forgeInstance := forge.New(forge.ForgeConfig{
-		Type:    f.Name(),
-		Token:   repo.ForgeToken,
-		BaseURL: repo.HTMLURL, // Used by Forgejo to derive API URL
-	})
+	forgeInstance := h.forgeClient(f, repo)
 	if forgeInstance == nil {
 		return fmt.Errorf("unknown forge: %s", f.Name())
 	}
@@ -439,7 +505,79 @@ func (h *WebhookHandler) postStatus(ctx context.Context, f forge.Forge, repo *st
 		Owner:   repo.Owner,
 		Name:    repo.Name,
 		HTMLURL: repo.HTMLURL,
-	}, commit, status)
+	}, job.Commit, status)
+}
+
+// forgeClient returns an API client for the repo's forge, authenticated with
+// the repo's token. Returns nil if the forge type is unknown.
+func (h *WebhookHandler) forgeClient(f forge.Forge, repo *storage.Repo) forge.Forge {
+	_, githubAPI := h.githubApp.githubEndpoints()
+	return forge.New(forge.ForgeConfig{
+		Type:    f.Name(),
+		Token:   repo.ForgeToken,
+		BaseURL: repo.HTMLURL, // Used by self-hosted forges to derive the API URL
+		APIURL:  githubAPI,
+		Client:  forgeAPIClient,
+	})
+}
+
+// defaultStatusContext names commit statuses and check runs for repos
+// without a StatusContext template.
+const defaultStatusContext = "cinch"
+
+// statusContextPlaceholder matches {name} placeholders in a status context
+// template.
+var statusContextPlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// statusContext expands the repo's status context template for a job, so
+// jobs for the same commit (e.g. a push and a PR build) don't overwrite each
+// other's status. Placeholders: {event} (push, pr or tag), {branch} (branch
+// or tag name) and {step} (build, or release for tags when the repo has a
+// release command). The result is cut to the forge's length limit.
+func statusContext(repo *storage.Repo, job *storage.Job) string {
+	if repo.StatusContext == "" {
+		return defaultStatusContext
+	}
+
+	event, ref := "push", job.Branch
+	switch {
+	case job.Tag != "":
+		event, ref = "tag", job.Tag
+	case job.PRNumber != nil:
+		event = "pr"
+	}
+	step := "build"
+	if job.Tag != "" && repo.Release != "" {
+		step = "release"
+	}
+
+	name := strings.NewReplacer("{event}", event, "{branch}", ref, "{step}", step).Replace(repo.StatusContext)
+	if max := maxStatusContextLen(repo.ForgeType); len(name) > max {
+		name = strings.ToValidUTF8(name[:max], "")
+	}
+	return name
+}
+
+// unknownStatusPlaceholder returns the first placeholder in a status context
+// template that statusContext doesn't expand, or "".
+func unknownStatusPlaceholder(template string) string {
+	for _, p := range statusContextPlaceholder.FindAllString(template, -1) {
+		switch p {
+		case "{event}", "{branch}", "{step}":
+		default:
+			return p
+		}
+	}
+	return ""
+}
+
+// maxStatusContextLen is the longest status context a forge accepts.
+// Bitbucket uses it as the status key, which is capped at 40 characters.
+func maxStatusContextLen(forgeType storage.ForgeType) int {
+	if forgeType == storage.ForgeTypeBitbucket {
+		return 40
+	}
+	return 100
 }
 
 func generateJobID() string {
@@ -481,7 +619,9 @@ func (h *WebhookHandler) PostJobStatus(ctx context.Context, jobID string, state
 
 	// Use GitHub Check Run API if job has installation ID and check run ID
 	if job.InstallationID != nil && job.CheckRunID != nil && h.githubApp != nil && h.githubApp.IsConfigured() {
-		// Map state to GitHub conclusion
+		// Map state to GitHub conclusion. Check runs have no "error" conclusion;
+		// infra errors still block (neutral would let required checks pass),
+		// but get a distinct title so reviewers know it wasn't their code.
 		conclusion := state // success, failure already map directly
 		if state == "error" {
 			conclusion = "failure"
@@ -505,8 +645,12 @@ func (h *WebhookHandler) PostJobStatus(ctx context.Context, jobID string, state
 		title := "Build " + state
 		if state == "success" {
 			title = "Build passed"
-		} else if state == "failure" || state == "error" {
+		} else if state == "failure" {
 			title = "Build failed"
+		} else if state == "error" {
+			title = "Infrastructure error (not a build failure)"
+		} else if state == "cancelled" {
+			title = "Build cancelled"
 		}
 
 		return h.githubApp.UpdateCheckRun(repo, *job.CheckRunID, *job.InstallationID, conclusion, title, description, logText)
@@ -518,18 +662,26 @@ func (h *WebhookHandler) PostJobStatus(ctx context.Context, jobID string, state
 		targetURL = fmt.Sprintf("%s/jobs/%s", h.baseURL, jobID)
 	}
 
+	// Commit statuses have no cancelled state
+	if state == "cancelled" {
+		state = string(forge.StatusError)
+	}
+
 	status := &forge.Status{
 		State:       forge.StatusState(state),
-		Context:     "cinch",
+		Context:     statusContext(repo, job),
 		Description: description,
 		TargetURL:   targetURL,
 	}
 
 	// Post based on forge type
+	_, githubAPI := h.githubApp.githubEndpoints()
 	forgeInstance := forge.New(forge.ForgeConfig{
 		Type:    string(repo.ForgeType),
 		Token:   repo.ForgeToken,
 		BaseURL: repo.HTMLURL,
+		APIURL:  githubAPI,
+		Client:  forgeAPIClient,
 	})
 	if forgeInstance == nil {
 		return fmt.Errorf("unknown forge type: %s", repo.ForgeType)
@@ -0,0 +1,353 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/forge"
+	"github.com/ehrlich-b/cinch/internal/storage"
+)
+
+// fakeForge identifies every request and returns a fixed push or PR event.
+type fakeForge struct {
+	forge.Forge
+	push *forge.PushEvent
+	pr   *forge.PullRequestEvent
+}
+
+func (f *fakeForge) Name() string                  { return "fake" }
+func (f *fakeForge) Identify(r *http.Request) bool { return true }
+
+func (f *fakeForge) ParsePush(r *http.Request, secret string) (*forge.PushEvent, error) {
+	if f.push == nil {
+		return nil, errors.New("not a push")
+	}
+	return f.push, nil
+}
+
+func (f *fakeForge) ParsePullRequest(r *http.Request, secret string) (*forge.PullRequestEvent, error) {
+	if f.pr == nil {
+		return nil, errors.New("not a pull request")
+	}
+	return f.pr, nil
+}
+
+func newDedupTestHandler(t *testing.T, f *fakeForge) (*WebhookHandler, storage.Storage) {
+	t.Helper()
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.CreateRepo(t.Context(), &storage.Repo{
+		ID:        "r_1",
+		ForgeType: storage.ForgeTypeGitHub,
+		Owner:     "test",
+		Name:      "repo",
+		CloneURL:  "https://github.com/test/repo.git",
+		Build:     "make test",
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	h := NewWebhookHandler(store, NewDispatcher(NewHub(), store, nil, nil), "", nil)
+	h.RegisterForge(f)
+	return h, store
+}
+
+func deliver(t *testing.T, h *WebhookHandler) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+}
+
+func TestWebhookDuplicatePushDelivery(t *testing.T) {
+	repo := &forge.Repo{ForgeType: "github", Owner: "test", Name: "repo", CloneURL: "https://github.com/test/repo.git"}
+	f := &fakeForge{push: &forge.PushEvent{
+		Repo:   repo,
+		Commit: "abc123def456",
+		Ref:    "refs/heads/main",
+		Branch: "main",
+		Sender: "test",
+	}}
+	h, store := newDedupTestHandler(t, f)
+
+	deliver(t, h)
+	deliver(t, h)
+
+	jobs, err := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs after duplicate delivery, want 1", len(jobs))
+	}
+
+	// A push of the same commit as a tag is a different build
+	f.push = &forge.PushEvent{
+		Repo:   repo,
+		Commit: "abc123def456",
+		Ref:    "refs/tags/v1.0.0",
+		Tag:    "v1.0.0",
+		Sender: "test",
+	}
+	deliver(t, h)
+
+	jobs, _ = store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if len(jobs) != 2 {
+		t.Errorf("got %d jobs after tag push, want 2", len(jobs))
+	}
+}
+
+func TestWebhookDuplicatePRDelivery(t *testing.T) {
+	f := &fakeForge{pr: &forge.PullRequestEvent{
+		Repo:       &forge.Repo{ForgeType: "github", Owner: "test", Name: "repo", CloneURL: "https://github.com/test/repo.git"},
+		Number:     7,
+		Action:     "synchronize",
+		Commit:     "abc123def456",
+		HeadBranch: "feature",
+		BaseBranch: "main",
+		Sender:     "test",
+	}}
+	h, store := newDedupTestHandler(t, f)
+
+	deliver(t, h)
+	deliver(t, h)
+
+	jobs, err := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Errorf("got %d jobs after duplicate delivery, want 1", len(jobs))
+	}
+}
+
+func TestWebhookRedeliveryAfterFinish(t *testing.T) {
+	f := &fakeForge{push: &forge.PushEvent{
+		Repo:   &forge.Repo{ForgeType: "github", Owner: "test", Name: "repo", CloneURL: "https://github.com/test/repo.git"},
+		Commit: "abc123def456",
+		Ref:    "refs/heads/main",
+		Branch: "main",
+		Sender: "test",
+	}}
+	h, store := newDedupTestHandler(t, f)
+
+	deliver(t, h)
+	jobs, _ := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(jobs))
+	}
+	exitCode := 1
+	if err := store.UpdateJobStatus(t.Context(), jobs[0].ID, storage.JobStatusFailed, &exitCode); err != nil {
+		t.Fatalf("UpdateJobStatus failed: %v", err)
+	}
+
+	// Finished jobs don't absorb deliveries; this is a fresh build
+	deliver(t, h)
+	jobs, _ = store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_1"})
+	if len(jobs) != 2 {
+		t.Errorf("got %d jobs, want 2", len(jobs))
+	}
+}
+
+func TestWebhookGitLabMergeRequestJob(t *testing.T) {
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CreateRepo(t.Context(), &storage.Repo{
+		ID:            "r_gl",
+		ForgeType:     storage.ForgeTypeGitLab,
+		Owner:         "gitlabhq",
+		Name:          "gitlab-test",
+		CloneURL:      "http://example.com/gitlabhq/gitlab-test.git",
+		WebhookSecret: "secret",
+		Build:         "make test",
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	h := NewWebhookHandler(store, NewDispatcher(NewHub(), store, nil, nil), "", nil)
+	h.RegisterForge(&forge.GitLab{})
+
+	// Merge Request Hook body as sent by GitLab (trimmed)
+	body := `{
+		"object_kind": "merge_request",
+		"user": {"username": "root"},
+		"project": {
+			"id": 1, "name": "Gitlab Test", "namespace": "GitlabHQ",
+			"path_with_namespace": "gitlabhq/gitlab-test",
+			"web_url": "http://example.com/gitlabhq/gitlab-test",
+			"git_http_url": "http://example.com/gitlabhq/gitlab-test.git",
+			"visibility_level": 20
+		},
+		"object_attributes": {
+			"id": 99, "iid": 1, "action": "open", "title": "MS-Viewport",
+			"source_branch": "ms-viewport", "target_branch": "master",
+			"source_project_id": 14, "target_project_id": 14,
+			"merge_commit_sha": "f0e1d2c3b4a5968778695a4b3c2d1e0f9a8b7c6d",
+			"last_commit": {"id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7", "message": "fixed readme"}
+		}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", "secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+
+	jobs, err := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_gl"})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(jobs))
+	}
+	job := jobs[0]
+	if job.PRNumber == nil || *job.PRNumber != 1 {
+		t.Errorf("PRNumber = %v, want 1", job.PRNumber)
+	}
+	if job.PRBaseBranch != "master" || job.Branch != "ms-viewport" {
+		t.Errorf("PRBaseBranch/Branch = %q/%q, want master/ms-viewport", job.PRBaseBranch, job.Branch)
+	}
+	if job.Commit != "da1560886d4f094c3e6c9ef40349f7d38b5d27d7" {
+		t.Errorf("Commit = %q, want the MR source head", job.Commit)
+	}
+}
+
+func TestWebhookGiteaTagReleaseJob(t *testing.T) {
+	store, err := storage.NewSQLite(":memory:", "", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CreateRepo(t.Context(), &storage.Repo{
+		ID:            "r_gt",
+		ForgeType:     storage.ForgeTypeGitea,
+		Owner:         "user",
+		Name:          "repo",
+		CloneURL:      "https://gitea.example.com/user/repo.git",
+		WebhookSecret: "secret",
+		Build:         "make test",
+		Release:       "make release",
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	dispatcher := NewDispatcher(NewHub(), store, nil, nil)
+	h := NewWebhookHandler(store, dispatcher, "", nil)
+	h.RegisterForge(&forge.Forgejo{IsGitea: true})
+
+	// Tag push as sent by Gitea (trimmed)
+	body := `{
+		"ref": "refs/tags/v1.2.0",
+		"before": "0000000000000000000000000000000000000000",
+		"after": "5f2a9c0e1b7d4a3c6e8f0a1b2c3d4e5f60718293",
+		"repository": {
+			"name": "repo", "full_name": "user/repo",
+			"html_url": "https://gitea.example.com/user/repo",
+			"clone_url": "https://gitea.example.com/user/repo.git",
+			"owner": {"username": "user"}
+		},
+		"sender": {"username": "user"}
+	}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set("X-Gitea-Event", "push")
+	req.Header.Set("X-Gitea-Signature", hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+
+	jobs, err := store.ListJobs(t.Context(), storage.JobFilter{RepoID: "r_gt"})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(jobs))
+	}
+	if jobs[0].Tag != "v1.2.0" || jobs[0].Branch != "" {
+		t.Errorf("Tag/Branch = %q/%q, want v1.2.0/empty", jobs[0].Tag, jobs[0].Branch)
+	}
+
+	queued := dispatcher.PendingJobs()
+	if len(queued) != 1 {
+		t.Fatalf("got %d queued jobs, want 1", len(queued))
+	}
+	if queued[0].Config.Command != "make release" {
+		t.Errorf("Command = %q, want the release command", queued[0].Config.Command)
+	}
+	if queued[0].Tag != "v1.2.0" {
+		t.Errorf("queued Tag = %q, want v1.2.0", queued[0].Tag)
+	}
+}
+
+func TestStatusContext(t *testing.T) {
+	pr := 7
+	push := &storage.Job{Branch: "main"}
+	prJob := &storage.Job{Branch: "feature", PRNumber: &pr}
+	tag := &storage.Job{Tag: "v1.0.0"}
+	longBranch := &storage.Job{Branch: strings.Repeat("b", 60)}
+
+	tests := []struct {
+		template string
+		forge    storage.ForgeType
+		release  string
+		job      *storage.Job
+		want     string
+	}{
+		{"", storage.ForgeTypeGitHub, "", push, "cinch"},
+		{"", storage.ForgeTypeGitHub, "", prJob, "cinch"},
+		{"cinch/{event}", storage.ForgeTypeGitHub, "", push, "cinch/push"},
+		{"cinch/{event}", storage.ForgeTypeGitHub, "", prJob, "cinch/pr"},
+		{"cinch/{event}", storage.ForgeTypeGitHub, "", tag, "cinch/tag"},
+		{"cinch/{event}/{branch}", storage.ForgeTypeGitHub, "", tag, "cinch/tag/v1.0.0"},
+		{"cinch/{step}", storage.ForgeTypeGitHub, "make release", tag, "cinch/release"},
+		{"cinch/{step}", storage.ForgeTypeGitHub, "make release", push, "cinch/build"},
+		{"cinch/{step}", storage.ForgeTypeGitHub, "", tag, "cinch/build"},
+		{"cinch/{branch}", storage.ForgeTypeGitHub, "", longBranch, "cinch/" + strings.Repeat("b", 60)},
+		{"cinch/{branch}", storage.ForgeTypeBitbucket, "", longBranch, "cinch/" + strings.Repeat("b", 34)},
+		{"ci", storage.ForgeTypeGitHub, "", push, "ci"},
+	}
+	for _, tt := range tests {
+		repo := &storage.Repo{StatusContext: tt.template, ForgeType: tt.forge, Release: tt.release}
+		if got := statusContext(repo, tt.job); got != tt.want {
+			t.Errorf("statusContext(%q, %s, %+v) = %q, want %q", tt.template, tt.forge, tt.job, got, tt.want)
+		}
+	}
+
+	for template, want := range map[string]string{
+		"cinch/{event}/{step}": "",
+		"cinch/{label}":        "{label}",
+		"cinch/{branch}{os}":   "{os}",
+	} {
+		if got := unknownStatusPlaceholder(template); got != want {
+			t.Errorf("unknownStatusPlaceholder(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -116,8 +118,9 @@ type JWTValidator interface {
 type WorkerAvailableNotifier interface {
 	NotifyWorkerAvailable()
 	Requeue(jobID string)
-	CompleteJob(jobID string)
-	RequeueWorkerJobs(jobIDs []string) // re-queue all jobs when worker disconnects
+	CompleteJob(jobID string, status storage.JobStatus)
+	RequeueWorkerJobs(jobIDs []string)               // re-queue all jobs when worker disconnects
+	RetryInfraError(jobID string) (InfraRetry, bool) // re-queue after an infrastructure error, if the repo allows
 }
 
 // WSHandler handles WebSocket connections from workers.
@@ -131,6 +134,34 @@ type WSHandler struct {
 	jwtValidator   JWTValidator
 	githubApp      *GitHubAppHandler
 	workerNotifier WorkerAvailableNotifier
+
+	// In-progress artifact uploads, keyed by jobID/name
+	uploadsMu sync.Mutex
+	uploads   map[string]*artifactUpload
+
+	// Storage quota enforcement for logs (hosted service)
+	enforceQuotas bool
+	quotaMu       sync.Mutex
+	logQuotas     map[string]*logQuota // job ID -> bytes it may still store
+}
+
+// logQuota tracks how much more log output a running job may store before
+// its owner goes over their storage quota.
+type logQuota struct {
+	remaining int64 // bytes left; negative means unlimited
+	exceeded  bool
+}
+
+// logQuotaNote is appended to a job's stored log when it hits the quota.
+const logQuotaNote = "\n[cinch] log quota exceeded - further output was not stored\n"
+
+// artifactUpload spools an artifact's chunks to a temp file until the
+// final chunk arrives.
+type artifactUpload struct {
+	file   *os.File
+	jobID  string
+	size   int64
+	tooBig bool
 }
 
 // NewWSHandler creates a new WebSocket handler.
@@ -142,9 +173,19 @@ func NewWSHandler(hub *Hub, store storage.Storage, log *slog.Logger) *WSHandler
 		hub:     hub,
 		storage: store,
 		log:     log,
+		uploads: make(map[string]*artifactUpload),
+
+		logQuotas: make(map[string]*logQuota),
 	}
 }
 
+// SetStorageQuotas enables per-owner storage quotas: once a job's owner is
+// over quota its further log output is dropped (the build still finishes),
+// and finished logs are charged to the owner.
+func (h *WSHandler) SetStorageQuotas(enabled bool) {
+	h.enforceQuotas = enabled
+}
+
 // SetStatusPoster sets the status poster for reporting job status to forges.
 func (h *WSHandler) SetStatusPoster(sp StatusPoster) {
 	h.statusPoster = sp
@@ -300,6 +341,9 @@ func (h *WSHandler) readPump(conn *websocket.Conn, worker *WorkerConn) {
 		if h.workerNotifier != nil && len(worker.ActiveJobs) > 0 {
 			h.workerNotifier.RequeueWorkerJobs(worker.ActiveJobs)
 		}
+		for _, jobID := range worker.ActiveJobs {
+			h.discardArtifactUploads(jobID)
+		}
 		h.hub.Unregister(worker.ID)
 		conn.Close()
 		h.log.Info("worker disconnected", "worker_id", worker.ID)
@@ -381,6 +425,8 @@ func (h *WSHandler) handleMessage(worker *WorkerConn, data []byte) {
 		h.handleJobStarted(worker, payload)
 	case protocol.TypeLogChunk:
 		h.handleLogChunk(worker, payload)
+	case protocol.TypeArtifactChunk:
+		h.handleArtifactChunk(worker, payload)
 	case protocol.TypeJobComplete:
 		h.handleJobComplete(worker, payload)
 	case protocol.TypeJobError:
@@ -557,7 +603,13 @@ func (h *WSHandler) handlePing(worker *WorkerConn, payload []byte) {
 		return
 	}
 
-	h.hub.UpdateLastPing(worker.ID, ping.ActiveJobs)
+	h.hub.UpdateLastPing(worker.ID, ping.ActiveJobs, ping.Load)
+
+	// Other servers sharing the database only see liveness through
+	// last_seen; without this their reapers mark the worker offline
+	if err := h.storage.UpdateWorkerLastSeen(context.Background(), worker.ID); err != nil {
+		h.log.Warn("failed to update worker last seen", "worker_id", worker.ID, "error", err)
+	}
 
 	// Send PONG
 	msg, err := protocol.Encode(protocol.TypePong, protocol.Pong{
@@ -622,7 +674,7 @@ func (h *WSHandler) handleLogChunk(worker *WorkerConn, payload []byte) {
 	}
 
 	ctx := context.Background()
-	if h.logStore != nil {
+	if h.logStore != nil && h.acceptLogChunk(ctx, chunk.JobID, len(chunk.Data)) {
 		if err := h.logStore.AppendChunk(ctx, chunk.JobID, chunk.Stream, []byte(chunk.Data)); err != nil {
 			h.log.Error("failed to append log", "job_id", chunk.JobID, "error", err)
 		}
@@ -634,6 +686,230 @@ func (h *WSHandler) handleLogChunk(worker *WorkerConn, payload []byte) {
 	}
 }
 
+// acceptLogChunk reports whether n more bytes of the job's log may be stored.
+// The chunk that would take the owner over quota is dropped, a note is
+// stored in its place, and everything after it is dropped too.
+func (h *WSHandler) acceptLogChunk(ctx context.Context, jobID string, n int) bool {
+	if !h.enforceQuotas {
+		return true
+	}
+
+	h.quotaMu.Lock()
+	q, ok := h.logQuotas[jobID]
+	h.quotaMu.Unlock()
+	if !ok {
+		// Chunks for one job arrive in order from one worker, so the
+		// lookup can't race with itself
+		q = &logQuota{remaining: h.storageRemaining(ctx, jobID)}
+		h.quotaMu.Lock()
+		h.logQuotas[jobID] = q
+		h.quotaMu.Unlock()
+	}
+
+	h.quotaMu.Lock()
+	defer h.quotaMu.Unlock()
+	if q.exceeded {
+		return false
+	}
+	if q.remaining < 0 {
+		return true
+	}
+	if int64(n) > q.remaining {
+		q.exceeded = true
+		h.log.Warn("log quota exceeded, dropping further output", "job_id", jobID)
+		if err := h.logStore.AppendChunk(ctx, jobID, "stderr", []byte(logQuotaNote)); err != nil {
+			h.log.Error("failed to append log", "job_id", jobID, "error", err)
+		}
+		return false
+	}
+	q.remaining -= int64(n)
+	return true
+}
+
+// storageRemaining returns how many bytes the job's owner may still store,
+// or -1 if the owner has no quota. An org with team billing is checked
+// before the repo's owning user. Lookup failures never block logs.
+func (h *WSHandler) storageRemaining(ctx context.Context, jobID string) int64 {
+	repo, err := h.jobRepo(ctx, jobID)
+	if err != nil {
+		h.log.Warn("failed to look up job for storage quota", "job_id", jobID, "error", err)
+		return -1
+	}
+
+	if org, err := h.storage.GetOrgBilling(ctx, repo.ForgeType, repo.Owner); err == nil {
+		return max(org.StorageQuota()-org.StorageUsedBytes, 0)
+	}
+	if repo.OwnerUserID == "" {
+		return -1
+	}
+	user, err := h.storage.GetUserByID(ctx, repo.OwnerUserID)
+	if err != nil {
+		h.log.Warn("failed to look up owner for storage quota", "user_id", repo.OwnerUserID, "error", err)
+		return -1
+	}
+	return max(user.StorageQuota()-user.StorageUsedBytes, 0)
+}
+
+// jobRepo returns the repo a job belongs to.
+func (h *WSHandler) jobRepo(ctx context.Context, jobID string) (*storage.Repo, error) {
+	job, err := h.storage.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return h.storage.GetRepo(ctx, job.RepoID)
+}
+
+// finishLogQuota drops a finished job's quota tracking and charges its log
+// size to the account its quota is checked against. Usage is tracked even
+// when quotas aren't enforced, since log pruning always credits it back.
+func (h *WSHandler) finishLogQuota(ctx context.Context, jobID string, logSize int64) {
+	h.quotaMu.Lock()
+	delete(h.logQuotas, jobID)
+	h.quotaMu.Unlock()
+
+	if logSize <= 0 {
+		return
+	}
+	h.chargeJobStorage(ctx, jobID, logSize)
+}
+
+// chargeJobStorage charges deltaBytes of a job's logs or artifacts to the
+// account its repo's quota is checked against.
+func (h *WSHandler) chargeJobStorage(ctx context.Context, jobID string, deltaBytes int64) {
+	repo, err := h.jobRepo(ctx, jobID)
+	if err != nil {
+		return
+	}
+	if err := chargeStorage(ctx, h.storage, repo, deltaBytes); err != nil {
+		h.log.Warn("failed to update storage usage", "job_id", jobID, "error", err)
+	}
+}
+
+// chargeStorage adds deltaBytes (negative to credit) to the storage usage of
+// the account storageRemaining checks for a repo: its org if the org has
+// team billing, otherwise the repo's owning user.
+func chargeStorage(ctx context.Context, store storage.Storage, repo *storage.Repo, deltaBytes int64) error {
+	if org, err := store.GetOrgBilling(ctx, repo.ForgeType, repo.Owner); err == nil {
+		return store.UpdateOrgBillingStorageUsed(ctx, org.ID, deltaBytes)
+	}
+	if repo.OwnerUserID == "" {
+		return nil
+	}
+	return store.UpdateUserStorageUsed(ctx, repo.OwnerUserID, deltaBytes)
+}
+
+// handleArtifactChunk spools an artifact chunk and, on the final chunk,
+// stores the artifact and records the job's artifact size for quota tracking.
+func (h *WSHandler) handleArtifactChunk(worker *WorkerConn, payload []byte) {
+	chunk, err := protocol.DecodePayload[protocol.ArtifactChunk](payload)
+	if err != nil {
+		h.log.Warn("failed to decode ARTIFACT_CHUNK", "worker_id", worker.ID, "error", err)
+		return
+	}
+
+	if !h.hub.IsJobAssignedToWorker(worker.ID, chunk.JobID) {
+		h.log.Warn("worker tried to upload artifact for unassigned job",
+			"worker_id", worker.ID,
+			"job_id", chunk.JobID)
+		return
+	}
+	if h.logStore == nil || !logstore.ValidArtifactName(chunk.Name) {
+		h.log.Warn("rejecting artifact", "job_id", chunk.JobID, "name", chunk.Name)
+		return
+	}
+
+	key := chunk.JobID + "/" + chunk.Name
+	h.uploadsMu.Lock()
+	up, ok := h.uploads[key]
+	if !ok {
+		f, err := os.CreateTemp("", "cinch-artifact-*")
+		if err != nil {
+			h.uploadsMu.Unlock()
+			h.log.Error("failed to create artifact temp file", "job_id", chunk.JobID, "error", err)
+			return
+		}
+		up = &artifactUpload{file: f, jobID: chunk.JobID}
+		h.uploads[key] = up
+	}
+	if chunk.Final {
+		delete(h.uploads, key)
+	}
+	h.uploadsMu.Unlock()
+
+	// Keep draining an oversized upload until its final chunk so a later
+	// chunk doesn't start a fresh, truncated artifact
+	if !up.tooBig {
+		if up.size+int64(len(chunk.Data)) > protocol.MaxArtifactSize {
+			up.tooBig = true
+			h.log.Warn("artifact exceeds size limit", "job_id", chunk.JobID, "name", chunk.Name, "limit", protocol.MaxArtifactSize)
+		} else if _, err := up.file.Write(chunk.Data); err != nil {
+			up.tooBig = true
+			h.log.Error("failed to spool artifact", "job_id", chunk.JobID, "error", err)
+		} else {
+			up.size += int64(len(chunk.Data))
+		}
+	}
+	if !chunk.Final {
+		return
+	}
+	defer closeArtifactUpload(up)
+	if up.tooBig {
+		return
+	}
+
+	ctx := context.Background()
+	if _, err := up.file.Seek(0, io.SeekStart); err != nil {
+		h.log.Error("failed to rewind artifact", "job_id", chunk.JobID, "error", err)
+		return
+	}
+	// Artifacts are charged by how much they grow the job's total, so
+	// re-uploading a name doesn't count it twice
+	var before int64
+	if artifacts, err := h.logStore.ListArtifacts(ctx, chunk.JobID); err == nil {
+		for _, a := range artifacts {
+			before += a.SizeBytes
+		}
+	}
+	size, err := h.logStore.PutArtifact(ctx, chunk.JobID, chunk.Name, up.file)
+	if err != nil {
+		h.log.Error("failed to store artifact", "job_id", chunk.JobID, "name", chunk.Name, "error", err)
+		return
+	}
+
+	// Track storage usage across all of the job's artifacts
+	total := size
+	if artifacts, err := h.logStore.ListArtifacts(ctx, chunk.JobID); err == nil {
+		total = 0
+		for _, a := range artifacts {
+			total += a.SizeBytes
+		}
+	}
+	if err := h.storage.UpdateJobArtifactSize(ctx, chunk.JobID, total); err != nil {
+		h.log.Warn("failed to update job artifact size", "job_id", chunk.JobID, "error", err)
+	}
+	if total != before {
+		h.chargeJobStorage(ctx, chunk.JobID, total-before)
+	}
+	h.log.Info("stored artifact", "job_id", chunk.JobID, "name", chunk.Name, "size_bytes", size)
+}
+
+// discardArtifactUploads drops a job's unfinished artifact uploads.
+func (h *WSHandler) discardArtifactUploads(jobID string) {
+	h.uploadsMu.Lock()
+	defer h.uploadsMu.Unlock()
+	for key, up := range h.uploads {
+		if up.jobID == jobID {
+			closeArtifactUpload(up)
+			delete(h.uploads, key)
+		}
+	}
+}
+
+func closeArtifactUpload(up *artifactUpload) {
+	up.file.Close()
+	os.Remove(up.file.Name())
+}
+
 // handleJobComplete processes job completion.
 func (h *WSHandler) handleJobComplete(worker *WorkerConn, payload []byte) {
 	complete, err := protocol.DecodePayload[protocol.JobComplete](payload)
@@ -656,7 +932,11 @@ func (h *WSHandler) handleJobComplete(worker *WorkerConn, payload []byte) {
 	status := storage.JobStatusSuccess
 	forgeState := "success"
 	description := formatDuration(complete.DurationMs)
-	if complete.ExitCode != 0 {
+	if complete.TimedOut {
+		status = storage.JobStatusFailed
+		forgeState = "failure"
+		description = "Build timed out - " + description
+	} else if complete.ExitCode != 0 {
 		status = storage.JobStatusFailed
 		forgeState = "failure"
 		description = "Build failed - " + description
@@ -664,13 +944,18 @@ func (h *WSHandler) handleJobComplete(worker *WorkerConn, payload []byte) {
 		description = "Build passed - " + description
 	}
 
+	// A cancelled bare-metal job is killed and reports a failed run (exit
+	// 137); keep the cancelled status (already posted to the forge by the
+	// dispatcher)
 	exitCode := complete.ExitCode
-	if err := h.storage.UpdateJobStatus(ctx, complete.JobID, status, &exitCode); err != nil {
+	if job, err := h.storage.GetJob(ctx, complete.JobID); err == nil && job.Status == storage.JobStatusCancelled {
+		status = storage.JobStatusCancelled
+	} else if err := h.storage.UpdateJobStatus(ctx, complete.JobID, status, &exitCode); err != nil {
 		h.log.Error("failed to update job status", "job_id", complete.JobID, "error", err)
 	}
 
 	// Post status to forge
-	if h.statusPoster != nil {
+	if h.statusPoster != nil && status != storage.JobStatusCancelled {
 		if err := h.statusPoster.PostJobStatus(ctx, complete.JobID, forgeState, description); err != nil {
 			h.log.Warn("failed to post status to forge", "job_id", complete.JobID, "error", err)
 		}
@@ -686,8 +971,8 @@ func (h *WSHandler) handleJobComplete(worker *WorkerConn, payload []byte) {
 			if err := h.storage.UpdateJobLogSize(ctx, complete.JobID, logSize); err != nil {
 				h.log.Warn("failed to update job log size", "job_id", complete.JobID, "error", err)
 			}
-			// TODO: Update user's total storage when quota enforcement is enabled
 		}
+		h.finishLogQuota(ctx, complete.JobID, logSize)
 	}
 
 	// Broadcast to UI clients
@@ -696,14 +981,16 @@ func (h *WSHandler) handleJobComplete(worker *WorkerConn, payload []byte) {
 	}
 
 	h.hub.RemoveActiveJob(worker.ID, complete.JobID)
+	h.discardArtifactUploads(complete.JobID)
 	if h.workerNotifier != nil {
-		h.workerNotifier.CompleteJob(complete.JobID)
+		h.workerNotifier.CompleteJob(complete.JobID, status)
 	}
 	h.log.Info("job completed",
 		"worker_id", worker.ID,
 		"job_id", complete.JobID,
 		"exit_code", complete.ExitCode,
 		"duration_ms", complete.DurationMs,
+		"timed_out", complete.TimedOut,
 	)
 
 	// Send ACK
@@ -742,12 +1029,21 @@ func (h *WSHandler) handleJobError(worker *WorkerConn, payload []byte) {
 	}
 
 	ctx := context.Background()
-	if err := h.storage.UpdateJobStatus(ctx, jobErr.JobID, storage.JobStatusError, nil); err != nil {
+
+	// A cancelled job's worker reports the aborted run as an error; keep the
+	// cancelled status (already posted to the forge by the dispatcher)
+	status := storage.JobStatusError
+	if job, err := h.storage.GetJob(ctx, jobErr.JobID); err == nil && job.Status == storage.JobStatusCancelled {
+		status = storage.JobStatusCancelled
+	} else if h.retryInfraError(ctx, worker, jobErr) {
+		h.sendAck(worker, jobErr.JobID)
+		return
+	} else if err := h.storage.UpdateJobStatus(ctx, jobErr.JobID, storage.JobStatusError, nil); err != nil {
 		h.log.Error("failed to update job status", "job_id", jobErr.JobID, "error", err)
 	}
 
 	// Post error status to forge
-	if h.statusPoster != nil {
+	if h.statusPoster != nil && status == storage.JobStatusError {
 		description := "Build error: " + jobErr.Error
 		if jobErr.Phase != "" {
 			description = "Build error in " + jobErr.Phase + ": " + jobErr.Error
@@ -768,16 +1064,18 @@ func (h *WSHandler) handleJobError(worker *WorkerConn, payload []byte) {
 				h.log.Warn("failed to update job log size", "job_id", jobErr.JobID, "error", err)
 			}
 		}
+		h.finishLogQuota(ctx, jobErr.JobID, logSize)
 	}
 
 	// Broadcast to UI clients
 	if h.logBroadcaster != nil {
-		h.logBroadcaster.BroadcastJobComplete(jobErr.JobID, string(storage.JobStatusError), nil)
+		h.logBroadcaster.BroadcastJobComplete(jobErr.JobID, string(status), nil)
 	}
 
 	h.hub.RemoveActiveJob(worker.ID, jobErr.JobID)
+	h.discardArtifactUploads(jobErr.JobID)
 	if h.workerNotifier != nil {
-		h.workerNotifier.CompleteJob(jobErr.JobID)
+		h.workerNotifier.CompleteJob(jobErr.JobID, status)
 	}
 	h.log.Error("job error",
 		"worker_id", worker.ID,
@@ -786,9 +1084,57 @@ func (h *WSHandler) handleJobError(worker *WorkerConn, payload []byte) {
 		"error", jobErr.Error,
 	)
 
-	// Send ACK
+	h.sendAck(worker, jobErr.JobID)
+}
+
+// retryInfraError re-queues a job that hit a retryable infrastructure error
+// if its repo allows automatic retries, noting the retry in the job's log and
+// forge status. Returns false if the job should be marked as error.
+func (h *WSHandler) retryInfraError(ctx context.Context, worker *WorkerConn, jobErr protocol.JobError) bool {
+	if h.workerNotifier == nil || !jobErr.Retryable {
+		return false
+	}
+	retry, ok := h.workerNotifier.RetryInfraError(jobErr.JobID)
+	if !ok {
+		return false
+	}
+
+	h.hub.RemoveActiveJob(worker.ID, jobErr.JobID)
+	h.discardArtifactUploads(jobErr.JobID)
+
+	note := fmt.Sprintf("\n==> infrastructure error in %s: %s\n==> retrying in %s (attempt %d of %d)\n",
+		jobErr.Phase, jobErr.Error, retry.Delay, retry.Attempt, retry.Max)
+	if h.logStore != nil {
+		if err := h.logStore.AppendChunk(ctx, jobErr.JobID, protocol.StreamStderr, []byte(note)); err != nil {
+			h.log.Warn("failed to append retry note", "job_id", jobErr.JobID, "error", err)
+		}
+	}
+	if h.logBroadcaster != nil {
+		h.logBroadcaster.BroadcastLog(jobErr.JobID, protocol.StreamStderr, note)
+	}
+
+	if h.statusPoster != nil {
+		description := fmt.Sprintf("Retrying after infrastructure error (%d/%d)", retry.Attempt, retry.Max)
+		if err := h.statusPoster.PostJobStatus(ctx, jobErr.JobID, "pending", description); err != nil {
+			h.log.Warn("failed to post status to forge", "job_id", jobErr.JobID, "error", err)
+		}
+	}
+
+	h.log.Warn("job infrastructure error, retrying",
+		"worker_id", worker.ID,
+		"job_id", jobErr.JobID,
+		"phase", jobErr.Phase,
+		"error", jobErr.Error,
+		"attempt", retry.Attempt,
+		"delay", retry.Delay,
+	)
+	return true
+}
+
+// sendAck acknowledges a worker's message about ref.
+func (h *WSHandler) sendAck(worker *WorkerConn, ref string) {
 	msg, err := protocol.Encode(protocol.TypeAck, protocol.Ack{
-		Ref: jobErr.JobID,
+		Ref: ref,
 	})
 	if err != nil {
 		h.log.Error("failed to encode ACK", "error", err)
@@ -2,12 +2,15 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ehrlich-b/cinch/internal/logstore"
 	"github.com/ehrlich-b/cinch/internal/protocol"
 	"github.com/ehrlich-b/cinch/internal/storage"
 	"github.com/gorilla/websocket"
@@ -245,3 +248,283 @@ func TestWSHandlePing(t *testing.T) {
 		t.Errorf("message type = %s, want %s", msgType, protocol.TypePong)
 	}
 }
+
+func TestWSLogQuotaExceededMidStream(t *testing.T) {
+	ctx := context.Background()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	user, err := store.GetOrCreateUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser failed: %v", err)
+	}
+	// 100 bytes of headroom left
+	if err := store.UpdateUserStorageUsed(ctx, user.ID, storage.StorageQuotaFree-100); err != nil {
+		t.Fatalf("UpdateUserStorageUsed failed: %v", err)
+	}
+	if err := store.CreateRepo(ctx, &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		Owner:       "alice",
+		Name:        "repo",
+		CloneURL:    "https://github.com/alice/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	if err := store.CreateJob(ctx, &storage.Job{ID: "j_1", RepoID: "r_1", Status: storage.JobStatusRunning, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	logs := logstore.NewMemoryLogStore()
+	h := NewWSHandler(NewHub(), store, nil)
+	h.SetLogStore(logs)
+	h.SetStorageQuotas(true)
+
+	worker := &WorkerConn{ID: "w_1"}
+	send := func(data string) {
+		payload, _ := json.Marshal(protocol.LogChunk{JobID: "j_1", Stream: "stdout", Data: data})
+		h.handleLogChunk(worker, payload)
+	}
+	send(strings.Repeat("a", 60)) // fits: 40 bytes left
+	send(strings.Repeat("b", 60)) // crosses the limit: dropped
+	send("c")                     // everything after is dropped too
+
+	r, err := logs.GetLogs(ctx, "j_1")
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	stored, _ := io.ReadAll(r)
+	r.Close()
+	if !strings.Contains(string(stored), strings.Repeat("a", 60)) {
+		t.Error("chunk within quota was not stored")
+	}
+	if strings.Contains(string(stored), "bbb") || strings.Contains(string(stored), `"d":"c"`) {
+		t.Errorf("chunks past the quota were stored:\n%s", stored)
+	}
+	if !strings.Contains(string(stored), "log quota exceeded") {
+		t.Errorf("missing quota note:\n%s", stored)
+	}
+
+	// Finishing the job charges its log to the owner
+	logSize, _ := logs.Finalize(ctx, "j_1")
+	h.finishLogQuota(ctx, "j_1", logSize)
+	user, _ = store.GetUserByID(ctx, user.ID)
+	if want := storage.StorageQuotaFree - 100 + logSize; user.StorageUsedBytes != want {
+		t.Errorf("StorageUsedBytes = %d, want %d", user.StorageUsedBytes, want)
+	}
+}
+
+func TestStorageChargedAndCreditedToOrg(t *testing.T) {
+	ctx := context.Background()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	user, _ := store.GetOrCreateUser(ctx, "alice")
+	org := &storage.OrgBilling{ID: "ob_1", ForgeType: storage.ForgeTypeGitHub, ForgeOrg: "acme", OwnerUserID: user.ID, SeatLimit: 1, Status: "active", CreatedAt: time.Now()}
+	if err := store.CreateOrgBilling(ctx, org); err != nil {
+		t.Fatalf("CreateOrgBilling failed: %v", err)
+	}
+	if err := store.CreateRepo(ctx, &storage.Repo{
+		ID:          "r_1",
+		ForgeType:   storage.ForgeTypeGitHub,
+		Owner:       "acme",
+		Name:        "repo",
+		CloneURL:    "https://github.com/acme/repo.git",
+		OwnerUserID: user.ID,
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	if err := store.CreateJob(ctx, &storage.Job{ID: "j_1", RepoID: "r_1", Status: storage.JobStatusRunning, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	hub := NewHub()
+	logs := logstore.NewMemoryLogStore()
+	h := NewWSHandler(hub, store, nil)
+	h.SetLogStore(logs)
+	h.SetStorageQuotas(true)
+	worker := &WorkerConn{ID: "w_1", Send: make(chan []byte, 1)}
+	hub.Register(worker)
+	hub.AddActiveJob(worker.ID, "j_1")
+
+	payload, _ := json.Marshal(protocol.ArtifactChunk{JobID: "j_1", Name: "out.tar.gz", Data: make([]byte, 300), Final: true})
+	h.handleArtifactChunk(worker, payload)
+	payload, _ = json.Marshal(protocol.LogChunk{JobID: "j_1", Stream: "stdout", Data: strings.Repeat("a", 100)})
+	h.handleLogChunk(worker, payload)
+	logSize, _ := logs.Finalize(ctx, "j_1")
+	_ = store.UpdateJobLogSize(ctx, "j_1", logSize)
+	h.finishLogQuota(ctx, "j_1", logSize)
+
+	// The org's quota is the one checked, so it's the one charged
+	got, _ := store.GetOrgBilling(ctx, storage.ForgeTypeGitHub, "acme")
+	if want := logSize + 300; got.StorageUsedBytes != want {
+		t.Errorf("org storage used = %d, want %d", got.StorageUsedBytes, want)
+	}
+	if u, _ := store.GetUserByID(ctx, user.ID); u.StorageUsedBytes != 0 {
+		t.Errorf("user storage used = %d, want 0", u.StorageUsedBytes)
+	}
+
+	// Pruning credits logs and artifacts back to the same org
+	exitCode := 0
+	_ = store.UpdateJobStatus(ctx, "j_1", storage.JobStatusSuccess, &exitCode)
+	if n := NewLogPruner(store, logs, -time.Hour, nil).Prune(ctx); n != 1 {
+		t.Fatalf("pruned %d jobs, want 1", n)
+	}
+	got, _ = store.GetOrgBilling(ctx, storage.ForgeTypeGitHub, "acme")
+	if got.StorageUsedBytes != 0 {
+		t.Errorf("org storage used after prune = %d, want 0", got.StorageUsedBytes)
+	}
+}
+
+func TestWSJobErrorRetriesInfraFailure(t *testing.T) {
+	ctx := context.Background()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	repo := &storage.Repo{
+		ID:           "r_1",
+		ForgeType:    storage.ForgeTypeGitHub,
+		CloneURL:     "https://github.com/alice/repo.git",
+		InfraRetries: 1,
+		CreatedAt:    time.Now(),
+	}
+	if err := store.CreateRepo(ctx, repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	job := &storage.Job{ID: "j_1", RepoID: "r_1", Branch: "main", Status: storage.JobStatusRunning, CreatedAt: time.Now()}
+	if err := store.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	hub := NewHub()
+	logs := logstore.NewMemoryLogStore()
+	h := NewWSHandler(hub, store, nil)
+	h.SetLogStore(logs)
+	dispatcher := NewDispatcher(hub, store, h, nil)
+	h.SetWorkerNotifier(dispatcher)
+
+	worker := &WorkerConn{ID: "w_1", Send: make(chan []byte, 10)}
+	hub.Register(worker)
+	qj := &QueuedJob{Job: job, Repo: repo}
+	payload, _ := json.Marshal(protocol.JobError{JobID: job.ID, Phase: protocol.PhaseClone, Error: "connection reset", Retryable: true})
+	reportError := func() {
+		// As if dispatched to the worker
+		dispatcher.mu.Lock()
+		dispatcher.queue = nil
+		dispatcher.inflight[job.ID] = qj
+		dispatcher.mu.Unlock()
+		hub.AddActiveJob(worker.ID, job.ID)
+		h.handleJobError(worker, payload)
+	}
+
+	// First infra error: back in the queue, not marked as error
+	reportError()
+	got, _ := store.GetJob(ctx, job.ID)
+	if got.Status != storage.JobStatusQueued {
+		t.Errorf("status after first error = %s, want queued", got.Status)
+	}
+	if dispatcher.QueueLength() != 1 {
+		t.Errorf("QueueLength = %d, want 1", dispatcher.QueueLength())
+	}
+	if worker.AvailableSlots() != 1 {
+		t.Error("worker still has the retried job")
+	}
+	r, _ := logs.GetLogs(ctx, job.ID)
+	stored, _ := io.ReadAll(r)
+	r.Close()
+	if !strings.Contains(string(stored), "retrying in 30s (attempt 1 of 1)") {
+		t.Errorf("missing retry note:\n%s", stored)
+	}
+
+	// Out of retries: marked as error
+	reportError()
+	got, _ = store.GetJob(ctx, job.ID)
+	if got.Status != storage.JobStatusError {
+		t.Errorf("status after retries ran out = %s, want error", got.Status)
+	}
+}
+
+func TestWSJobErrorDoesNotRetryRepoErrors(t *testing.T) {
+	ctx := context.Background()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	repo := &storage.Repo{ID: "r_1", ForgeType: storage.ForgeTypeGitHub, InfraRetries: 3, CreatedAt: time.Now()}
+	if err := store.CreateRepo(ctx, repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	job := &storage.Job{ID: "j_1", RepoID: "r_1", Branch: "main", Status: storage.JobStatusRunning, CreatedAt: time.Now()}
+	if err := store.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	hub := NewHub()
+	h := NewWSHandler(hub, store, nil)
+	dispatcher := NewDispatcher(hub, store, h, nil)
+	h.SetWorkerNotifier(dispatcher)
+	worker := &WorkerConn{ID: "w_1", Send: make(chan []byte, 10)}
+	hub.Register(worker)
+	dispatcher.inflight[job.ID] = &QueuedJob{Job: job, Repo: repo}
+	hub.AddActiveJob(worker.ID, job.ID)
+
+	// A bad workdir fails the same way every time, so retries are wasted
+	payload, _ := json.Marshal(protocol.JobError{JobID: job.ID, Phase: protocol.PhaseSetup, Error: "workdir not found"})
+	h.handleJobError(worker, payload)
+	got, _ := store.GetJob(ctx, job.ID)
+	if got.Status != storage.JobStatusError {
+		t.Errorf("status = %s, want error", got.Status)
+	}
+	if dispatcher.QueueLength() != 0 {
+		t.Errorf("QueueLength = %d, want 0", dispatcher.QueueLength())
+	}
+}
+
+// recordingStatusPoster records the forge states posted for jobs.
+type recordingStatusPoster struct {
+	states []string
+}
+
+func (p *recordingStatusPoster) PostJobStatus(ctx context.Context, jobID, state, description string) error {
+	p.states = append(p.states, state)
+	return nil
+}
+
+func TestWSJobCompleteKeepsCancelledStatus(t *testing.T) {
+	ctx := context.Background()
+	store, _ := storage.NewSQLite(":memory:", "", "")
+	defer store.Close()
+
+	if err := store.CreateRepo(ctx, &storage.Repo{ID: "r_1", ForgeType: storage.ForgeTypeGitHub, CloneURL: "https://github.com/alice/repo.git", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	if err := store.CreateJob(ctx, &storage.Job{ID: "j_1", RepoID: "r_1", Status: storage.JobStatusRunning, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if err := store.UpdateJobStatus(ctx, "j_1", storage.JobStatusCancelled, nil); err != nil {
+		t.Fatalf("UpdateJobStatus failed: %v", err)
+	}
+
+	hub := NewHub()
+	h := NewWSHandler(hub, store, nil)
+	poster := &recordingStatusPoster{}
+	h.SetStatusPoster(poster)
+
+	worker := &WorkerConn{ID: "w_1", Send: make(chan []byte, 10)}
+	hub.Register(worker)
+	hub.AddActiveJob(worker.ID, "j_1")
+
+	// The killed bare-metal process exits 137
+	payload, _ := json.Marshal(protocol.JobComplete{JobID: "j_1", ExitCode: 137})
+	h.handleJobComplete(worker, payload)
+
+	got, _ := store.GetJob(ctx, "j_1")
+	if got.Status != storage.JobStatusCancelled {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+	if len(poster.states) != 0 {
+		t.Errorf("posted %v to the forge for a cancelled job", poster.states)
+	}
+}
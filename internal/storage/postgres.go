@@ -16,7 +16,9 @@ import (
 
 // PostgresStorage implements Storage using PostgreSQL.
 type PostgresStorage struct {
-	db              *sql.DB
+	db              dbtx           // conn, or the active transaction
+	conn            *sql.DB        // underlying connection pool
+	inTx            bool           // true when db is a transaction
 	cipher          *crypto.Cipher // nil = no encryption (tests)
 	secondaryCipher *crypto.Cipher // non-nil during key rotation
 	log             *slog.Logger
@@ -56,7 +58,7 @@ func NewPostgres(dsn string, encryptionSecret, secondarySecret string) (*Postgre
 		}
 	}
 
-	s := &PostgresStorage{db: db, cipher: cipher, secondaryCipher: secondaryCipher, log: slog.Default()}
+	s := &PostgresStorage{db: db, conn: db, cipher: cipher, secondaryCipher: secondaryCipher, log: slog.Default()}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
@@ -198,15 +200,57 @@ func (s *PostgresStorage) migrate() error {
 	)`)
 	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_relays_user_id ON relays(user_id)")
 
+	// Webhook deliveries for replay (redacted, pruned after a few days)
+	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		repo_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		headers TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at)")
+
+	// Pending device authorizations for 'cinch login' (short-lived)
+	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS device_codes (
+		device_code TEXT PRIMARY KEY,
+		user_code TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		authorized BOOLEAN NOT NULL DEFAULT FALSE,
+		email TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_device_codes_user_code ON device_codes(user_code)")
+
 	// Add columns that may not exist (for migrations)
 	alterStatements := []string{
 		// Storage quota columns
 		`ALTER TABLE users ADD COLUMN IF NOT EXISTS tier TEXT NOT NULL DEFAULT 'free'`,
 		`ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_used_bytes BIGINT NOT NULL DEFAULT 0`,
 		`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS log_size_bytes BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS artifact_size_bytes BIGINT NOT NULL DEFAULT 0`,
+		// Webhook delivery outcome columns
+		`ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS status INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS result TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS job_id TEXT NOT NULL DEFAULT ''`,
+		// Log retention columns
+		`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS logs_pruned_at TIMESTAMPTZ`,
 		// Authorization columns
 		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS owner_user_id TEXT NOT NULL DEFAULT ''`,
 		`ALTER TABLE tokens ADD COLUMN IF NOT EXISTS owner_user_id TEXT NOT NULL DEFAULT ''`,
+		// Supersede in-flight jobs on new pushes
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS cancel_in_progress BOOLEAN NOT NULL DEFAULT FALSE`,
+		// Cap on the repo's concurrently running jobs
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS max_parallel INTEGER NOT NULL DEFAULT 0`,
+		// Automatic retries after infrastructure errors
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS infra_retries INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS paths TEXT NOT NULL DEFAULT ''`,
+		// Commit status / check run name template
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS status_context TEXT NOT NULL DEFAULT ''`,
+		// Per-repo job timeout sent to workers
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS job_timeout TEXT NOT NULL DEFAULT ''`,
+		// Job notification webhooks (encrypted JSON)
+		`ALTER TABLE repos ADD COLUMN IF NOT EXISTS notifications TEXT NOT NULL DEFAULT ''`,
 	}
 	for _, stmt := range alterStatements {
 		_, _ = s.db.Exec(stmt)
@@ -584,7 +628,43 @@ func (s *PostgresStorage) decryptUserCredentials(user *User) error {
 }
 
 func (s *PostgresStorage) Close() error {
-	return s.db.Close()
+	return s.conn.Close()
+}
+
+// WithTx runs fn inside a database transaction.
+func (s *PostgresStorage) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	return s.withTx(ctx, func(tx *PostgresStorage) error {
+		return fn(tx)
+	})
+}
+
+func (s *PostgresStorage) withTx(ctx context.Context, fn func(tx *PostgresStorage) error) (err error) {
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	txs := *s
+	txs.db = tx
+	txs.inTx = true
+	if err = fn(&txs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // --- Jobs ---
@@ -598,6 +678,27 @@ func (s *PostgresStorage) CreateJob(ctx context.Context, job *Job) error {
 	return err
 }
 
+// ImportJob inserts a job from a backup, keeping its status, exit code, and
+// timestamps. The worker is not kept (workers aren't part of a backup).
+// Returns false without error if a job with the same ID already exists.
+func (s *PostgresStorage) ImportJob(ctx context.Context, job *Job) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO jobs (id, repo_id, commit_sha, branch, tag, pr_number, pr_base_branch, status, exit_code, installation_id, check_run_id,
+		                   started_at, finished_at, created_at, author, trust_level, is_fork, approved_by, approved_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		 ON CONFLICT(id) DO NOTHING`,
+		job.ID, job.RepoID, job.Commit, job.Branch, job.Tag, job.PRNumber, job.PRBaseBranch, job.Status, job.ExitCode, job.InstallationID, job.CheckRunID,
+		job.StartedAt, job.FinishedAt, job.CreatedAt, job.Author, job.TrustLevel, job.IsFork, job.ApprovedBy, job.ApprovedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 func (s *PostgresStorage) GetJob(ctx context.Context, id string) (*Job, error) {
 	job := &Job{}
 	err := s.db.QueryRowContext(ctx,
@@ -640,6 +741,21 @@ func (s *PostgresStorage) GetJobSiblings(ctx context.Context, repoID, commit, ex
 	return jobs, rows.Err()
 }
 
+// GetJobByCommitRef returns the newest job for the repo, commit and git ref,
+// or ErrNotFound.
+func (s *PostgresStorage) GetJobByCommitRef(ctx context.Context, repoID, commit, ref string) (*Job, error) {
+	jobs, err := s.GetJobSiblings(ctx, repoID, commit, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if jobMatchesRef(job, ref) {
+			return job, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
 func (s *PostgresStorage) UpdateJobCheckRunID(ctx context.Context, id string, checkRunID int64) error {
 	_, err := s.db.ExecContext(ctx,
 		`UPDATE jobs SET check_run_id = $1 WHERE id = $2`,
@@ -650,27 +766,10 @@ func (s *PostgresStorage) UpdateJobCheckRunID(ctx context.Context, id string, ch
 func (s *PostgresStorage) ListJobs(ctx context.Context, filter JobFilter) ([]*Job, error) {
 	query := `SELECT id, repo_id, commit_sha, branch, tag, pr_number, pr_base_branch, status, exit_code, worker_id,
 	                 installation_id, check_run_id, started_at, finished_at, created_at,
-	                 author, trust_level, is_fork, approved_by, approved_at FROM jobs WHERE 1=1`
-	args := []any{}
-	argNum := 1
-
-	if filter.RepoID != "" {
-		query += fmt.Sprintf(" AND repo_id = $%d", argNum)
-		args = append(args, filter.RepoID)
-		argNum++
-	}
-	if filter.Status != "" {
-		query += fmt.Sprintf(" AND status = $%d", argNum)
-		args = append(args, filter.Status)
-		argNum++
-	}
-	if filter.Branch != "" {
-		query += fmt.Sprintf(" AND branch = $%d", argNum)
-		args = append(args, filter.Branch)
-		argNum++
-	}
-
-	query += " ORDER BY created_at DESC"
+	                 author, trust_level, is_fork, approved_by, approved_at FROM jobs`
+	where, args := pgJobFilterWhere(filter)
+	argNum := len(args) + 1
+	query += where + " ORDER BY created_at DESC"
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argNum)
@@ -702,6 +801,45 @@ func (s *PostgresStorage) ListJobs(ctx context.Context, filter JobFilter) ([]*Jo
 	return jobs, rows.Err()
 }
 
+// CountJobs counts the jobs matching filter, ignoring its Limit and Offset.
+func (s *PostgresStorage) CountJobs(ctx context.Context, filter JobFilter) (int, error) {
+	where, args := pgJobFilterWhere(filter)
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs"+where, args...).Scan(&n)
+	return n, err
+}
+
+// pgJobFilterWhere builds the WHERE clause shared by ListJobs and CountJobs.
+// Placeholders are numbered from $1.
+func pgJobFilterWhere(filter JobFilter) (string, []any) {
+	where := " WHERE 1=1"
+	args := []any{}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where += fmt.Sprintf(" AND "+cond, len(args))
+	}
+	if filter.RepoID != "" {
+		add("repo_id = $%d", filter.RepoID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.Branch != "" {
+		add("branch = $%d", filter.Branch)
+	}
+	if filter.Commit != "" {
+		add("commit_sha LIKE $%d", filter.Commit+"%")
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add("created_at <= $%d", filter.CreatedBefore)
+	}
+	return where, args
+}
+
 func (s *PostgresStorage) ListJobsByWorker(ctx context.Context, workerID string, limit int) ([]*Job, error) {
 	if limit <= 0 {
 		limit = 10
@@ -878,6 +1016,7 @@ func (s *PostgresStorage) CreateRepo(ctx context.Context, repo *Repo) error {
 
 	// Convert workers slice to comma-separated string
 	workers := strings.Join(repo.Workers, ",")
+	paths := strings.Join(repo.Paths, ",")
 
 	// Convert and encrypt secrets map to JSON
 	var secretsJSON string
@@ -894,8 +1033,8 @@ func (s *PostgresStorage) CreateRepo(ctx context.Context, repo *Repo) error {
 
 	// Use upsert to handle re-onboarding: if repo exists, update token and webhook secret
 	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO repos (id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		`INSERT INTO repos (id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 		 ON CONFLICT (clone_url) DO UPDATE SET
 		 	webhook_secret = EXCLUDED.webhook_secret,
 		 	forge_token = EXCLUDED.forge_token,
@@ -903,18 +1042,18 @@ func (s *PostgresStorage) CreateRepo(ctx context.Context, repo *Repo) error {
 		 	private = EXCLUDED.private,
 		 	owner_user_id = CASE WHEN repos.owner_user_id = '' THEN EXCLUDED.owner_user_id ELSE repos.owner_user_id END`,
 		repo.ID, repo.ForgeType, repo.Owner, repo.Name, repo.CloneURL, repo.HTMLURL,
-		webhookSecret, forgeToken, repo.Build, repo.Release, workers, secretsJSON, repo.Private, repo.OwnerUserID, repo.CreatedAt)
+		webhookSecret, forgeToken, repo.Build, repo.Release, workers, secretsJSON, repo.Private, repo.CancelInProgress, repo.MaxParallel, repo.InfraRetries, paths, repo.StatusContext, repo.JobTimeout, repo.OwnerUserID, repo.CreatedAt)
 	return err
 }
 
 func (s *PostgresStorage) GetRepo(ctx context.Context, id string) (*Repo, error) {
 	repo := &Repo{}
-	var workers, secretsJSON string
+	var workers, paths, secretsJSON string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE id = $1`, id).Scan(
 		&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL, &repo.HTMLURL,
-		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt)
+		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -925,6 +1064,9 @@ func (s *PostgresStorage) GetRepo(ctx context.Context, id string) (*Repo, error)
 	if workers != "" {
 		repo.Workers = strings.Split(workers, ",")
 	}
+	if paths != "" {
+		repo.Paths = strings.Split(paths, ",")
+	}
 	// Decrypt secrets
 	if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
 		return nil, fmt.Errorf("decrypt webhook_secret: %w", err)
@@ -949,12 +1091,12 @@ func (s *PostgresStorage) GetRepo(ctx context.Context, id string) (*Repo, error)
 
 func (s *PostgresStorage) GetRepoByCloneURL(ctx context.Context, cloneURL string) (*Repo, error) {
 	repo := &Repo{}
-	var workers, secretsJSON string
+	var workers, paths, secretsJSON string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE clone_url = $1`, cloneURL).Scan(
 		&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL, &repo.HTMLURL,
-		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt)
+		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -965,6 +1107,9 @@ func (s *PostgresStorage) GetRepoByCloneURL(ctx context.Context, cloneURL string
 	if workers != "" {
 		repo.Workers = strings.Split(workers, ",")
 	}
+	if paths != "" {
+		repo.Paths = strings.Split(paths, ",")
+	}
 	// Decrypt secrets
 	if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
 		return nil, fmt.Errorf("decrypt webhook_secret: %w", err)
@@ -989,7 +1134,7 @@ func (s *PostgresStorage) GetRepoByCloneURL(ctx context.Context, cloneURL string
 
 func (s *PostgresStorage) ListRepos(ctx context.Context) ([]*Repo, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
@@ -1001,7 +1146,7 @@ func (s *PostgresStorage) ListRepos(ctx context.Context) ([]*Repo, error) {
 
 func (s *PostgresStorage) ListReposByOwner(ctx context.Context, ownerUserID string) ([]*Repo, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE owner_user_id = $1 ORDER BY created_at DESC`, ownerUserID)
 	if err != nil {
 		return nil, err
@@ -1015,15 +1160,18 @@ func (s *PostgresStorage) scanRepos(rows *sql.Rows) ([]*Repo, error) {
 	var repos []*Repo
 	for rows.Next() {
 		repo := &Repo{}
-		var workers, secretsJSON string
+		var workers, paths, secretsJSON string
 		if err := rows.Scan(&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL,
-			&repo.HTMLURL, &repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt); err != nil {
+			&repo.HTMLURL, &repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt); err != nil {
 			return nil, err
 		}
 		// Parse workers from comma-separated string
 		if workers != "" {
 			repo.Workers = strings.Split(workers, ",")
 		}
+		if paths != "" {
+			repo.Paths = strings.Split(paths, ",")
+		}
 		// Decrypt secrets
 		var err error
 		if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
@@ -1056,12 +1204,12 @@ func (s *PostgresStorage) DeleteRepo(ctx context.Context, id string) error {
 
 func (s *PostgresStorage) GetRepoByOwnerName(ctx context.Context, forge, owner, name string) (*Repo, error) {
 	repo := &Repo{}
-	var workers, secretsJSON string
+	var workers, paths, secretsJSON string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE forge_type = $1 AND owner = $2 AND name = $3`, forge, owner, name).Scan(
 		&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL, &repo.HTMLURL,
-		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt)
+		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -1072,6 +1220,9 @@ func (s *PostgresStorage) GetRepoByOwnerName(ctx context.Context, forge, owner,
 	if workers != "" {
 		repo.Workers = strings.Split(workers, ",")
 	}
+	if paths != "" {
+		repo.Paths = strings.Split(paths, ",")
+	}
 	// Decrypt secrets
 	if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
 		return nil, fmt.Errorf("decrypt webhook_secret: %w", err)
@@ -1101,6 +1252,97 @@ func (s *PostgresStorage) UpdateRepoPrivate(ctx context.Context, id string, priv
 	return err
 }
 
+func (s *PostgresStorage) UpdateRepoBuild(ctx context.Context, id, build, release string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET build = $1, release = $2 WHERE id = $3`,
+		build, release, id)
+	return err
+}
+
+func (s *PostgresStorage) UpdateRepoCancelInProgress(ctx context.Context, id string, enabled bool) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET cancel_in_progress = $1 WHERE id = $2`,
+		enabled, id)
+	return err
+}
+
+func (s *PostgresStorage) UpdateRepoMaxParallel(ctx context.Context, id string, limit int) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET max_parallel = $1 WHERE id = $2`,
+		limit, id)
+	return err
+}
+
+func (s *PostgresStorage) UpdateRepoInfraRetries(ctx context.Context, id string, retries int) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET infra_retries = $1 WHERE id = $2`,
+		retries, id)
+	return err
+}
+
+func (s *PostgresStorage) UpdateRepoPaths(ctx context.Context, id string, paths []string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET paths = $1 WHERE id = $2`,
+		strings.Join(paths, ","), id)
+	return err
+}
+
+func (s *PostgresStorage) UpdateRepoStatusContext(ctx context.Context, id string, template string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET status_context = $1 WHERE id = $2`,
+		template, id)
+	return err
+}
+
+func (s *PostgresStorage) UpdateRepoJobTimeout(ctx context.Context, id string, timeout string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET job_timeout = $1 WHERE id = $2`,
+		timeout, id)
+	return err
+}
+
+// GetRepoNotifications returns the repo's job notification settings (empty
+// if none are configured).
+func (s *PostgresStorage) GetRepoNotifications(ctx context.Context, id string) (*RepoNotifications, error) {
+	var enc string
+	err := s.db.QueryRowContext(ctx, `SELECT notifications FROM repos WHERE id = $1`, id).Scan(&enc)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := &RepoNotifications{}
+	if enc == "" {
+		return n, nil
+	}
+	decrypted, err := s.decrypt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt notifications: %w", err)
+	}
+	if err := json.Unmarshal([]byte(decrypted), n); err != nil {
+		return nil, fmt.Errorf("unmarshal notifications: %w", err)
+	}
+	return n, nil
+}
+
+// UpdateRepoNotifications replaces the repo's job notification settings.
+func (s *PostgresStorage) UpdateRepoNotifications(ctx context.Context, id string, n *RepoNotifications) error {
+	var enc string
+	if n != nil && !n.IsZero() {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return fmt.Errorf("marshal notifications: %w", err)
+		}
+		if enc, err = s.encrypt(string(data)); err != nil {
+			return fmt.Errorf("encrypt notifications: %w", err)
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE repos SET notifications = $1 WHERE id = $2`, enc, id)
+	return err
+}
+
 func (s *PostgresStorage) UpdateRepoHTMLURL(ctx context.Context, id string, htmlURL string) error {
 	_, err := s.db.ExecContext(ctx,
 		`UPDATE repos SET html_url = $1 WHERE id = $2`,
@@ -1524,31 +1766,27 @@ func (s *PostgresStorage) ClearUserGitHubConnected(ctx context.Context, userID s
 
 func (s *PostgresStorage) DeleteUser(ctx context.Context, id string) error {
 	// Delete user and all associated data in a transaction
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback() }()
-
-	// Delete tokens owned by this user
-	_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE owner_user_id = $1`, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user tokens: %w", err)
-	}
+	return s.withTx(ctx, func(tx *PostgresStorage) error {
+		// Delete tokens owned by this user
+		_, err := tx.db.ExecContext(ctx, `DELETE FROM tokens WHERE owner_user_id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user tokens: %w", err)
+		}
 
-	// Delete repos owned by this user
-	_, err = tx.ExecContext(ctx, `DELETE FROM repos WHERE owner_user_id = $1`, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user repos: %w", err)
-	}
+		// Delete repos owned by this user
+		_, err = tx.db.ExecContext(ctx, `DELETE FROM repos WHERE owner_user_id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user repos: %w", err)
+		}
 
-	// Delete the user record
-	_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
-	}
+		// Delete the user record
+		_, err = tx.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
 
-	return tx.Commit()
+		return nil
+	})
 }
 
 // UpdateJobLogSize updates the log size for a job.
@@ -1559,6 +1797,14 @@ func (s *PostgresStorage) UpdateJobLogSize(ctx context.Context, jobID string, si
 	return err
 }
 
+// UpdateJobArtifactSize updates the total artifact size for a job.
+func (s *PostgresStorage) UpdateJobArtifactSize(ctx context.Context, jobID string, sizeBytes int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE jobs SET artifact_size_bytes = $1 WHERE id = $2`,
+		sizeBytes, jobID)
+	return err
+}
+
 // UpdateUserStorageUsed adds deltaBytes to the user's storage usage.
 func (s *PostgresStorage) UpdateUserStorageUsed(ctx context.Context, userID string, deltaBytes int64) error {
 	_, err := s.db.ExecContext(ctx,
@@ -1567,6 +1813,167 @@ func (s *PostgresStorage) UpdateUserStorageUsed(ctx context.Context, userID stri
 	return err
 }
 
+// --- Log retention ---
+
+// ListJobsWithExpiredLogs returns finished jobs whose logs haven't been pruned
+// and that finished before the given time, oldest first.
+func (s *PostgresStorage) ListJobsWithExpiredLogs(ctx context.Context, finishedBefore time.Time, limit int) ([]*ExpiredLog, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.id, j.log_size_bytes + j.artifact_size_bytes, COALESCE(r.owner_user_id, ''),
+		       COALESCE(r.forge_type, ''), COALESCE(r.owner, '')
+		FROM jobs j
+		LEFT JOIN repos r ON r.id = j.repo_id
+		WHERE j.finished_at IS NOT NULL AND j.finished_at < $1 AND j.logs_pruned_at IS NULL
+		ORDER BY j.finished_at
+		LIMIT $2`,
+		finishedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*ExpiredLog
+	for rows.Next() {
+		l := &ExpiredLog{}
+		var forgeType string
+		if err := rows.Scan(&l.JobID, &l.LogSizeBytes, &l.OwnerUserID, &forgeType, &l.RepoOwner); err != nil {
+			return nil, err
+		}
+		l.ForgeType = ForgeType(forgeType)
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// MarkJobLogsPruned zeroes a job's log and artifact sizes and records that its logs were pruned.
+// Any logs stored in the job_logs table are deleted as well.
+func (s *PostgresStorage) MarkJobLogsPruned(ctx context.Context, jobID string) error {
+	return s.withTx(ctx, func(tx *PostgresStorage) error {
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM job_logs WHERE job_id = $1`, jobID); err != nil {
+			return fmt.Errorf("delete job logs: %w", err)
+		}
+		_, err := tx.db.ExecContext(ctx,
+			`UPDATE jobs SET log_size_bytes = 0, artifact_size_bytes = 0, logs_pruned_at = $1 WHERE id = $2`,
+			time.Now(), jobID)
+		return err
+	})
+}
+
+// DeleteJobsOlderThan deletes finished jobs (and any logs in job_logs) that
+// finished before cutoff. Jobs still pending or running are kept.
+// Log store objects are not touched; callers delete those first.
+func (s *PostgresStorage) DeleteJobsOlderThan(ctx context.Context, cutoff time.Time) (int, error) {
+	var deleted int64
+	err := s.withTx(ctx, func(tx *PostgresStorage) error {
+		if _, err := tx.db.ExecContext(ctx, `
+			DELETE FROM job_logs WHERE job_id IN (
+				SELECT id FROM jobs WHERE finished_at IS NOT NULL AND finished_at < $1
+			)`, cutoff); err != nil {
+			return fmt.Errorf("delete job logs: %w", err)
+		}
+		res, err := tx.db.ExecContext(ctx,
+			`DELETE FROM jobs WHERE finished_at IS NOT NULL AND finished_at < $1`, cutoff)
+		if err != nil {
+			return fmt.Errorf("delete jobs: %w", err)
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
+
+// --- Usage ---
+
+// GetUserUsage counts a user's repos, and the jobs and build time of those
+// repos since the given time.
+func (s *PostgresStorage) GetUserUsage(ctx context.Context, userID string, since time.Time) (*UserUsage, error) {
+	u := &UserUsage{}
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM repos WHERE owner_user_id = $1`, userID).Scan(&u.Repos); err != nil {
+		return nil, fmt.Errorf("count repos: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.started_at, j.finished_at
+		FROM jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE r.owner_user_id = $1 AND j.created_at >= $2`,
+		userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var startedAt, finishedAt sql.NullTime
+		if err := rows.Scan(&startedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		u.Jobs++
+		if startedAt.Valid && finishedAt.Valid && finishedAt.Time.After(startedAt.Time) {
+			u.BuildSeconds += int64(finishedAt.Time.Sub(startedAt.Time).Seconds())
+		}
+	}
+	return u, rows.Err()
+}
+
+// JobStats counts repos and recent jobs visible to viewerUserID ("" for
+// anonymous) and averages the run time of recently finished jobs.
+func (s *PostgresStorage) JobStats(ctx context.Context, viewerUserID string, now time.Time) (*JobStats, error) {
+	// Anonymous viewers ("") match no owner, so see only public repos
+	const visible = "(NOT r.private OR ($1 <> '' AND r.owner_user_id = $1))"
+	stats := &JobStats{Last24h: make(map[JobStatus]int), Last7d: make(map[JobStatus]int)}
+
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM repos r WHERE `+visible, viewerUserID).Scan(&stats.Repos); err != nil {
+		return nil, fmt.Errorf("count repos: %w", err)
+	}
+
+	dayAgo, weekAgo := now.Add(-24*time.Hour), now.Add(-7*24*time.Hour)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.status, COUNT(*), SUM(CASE WHEN j.created_at >= $2 THEN 1 ELSE 0 END)
+		FROM jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE j.created_at >= $3 AND `+visible+`
+		GROUP BY j.status`,
+		viewerUserID, dayAgo, weekAgo)
+	if err != nil {
+		return nil, fmt.Errorf("count jobs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status JobStatus
+		var week, day int
+		if err := rows.Scan(&status, &week, &day); err != nil {
+			return nil, err
+		}
+		stats.Last7d[status] = week
+		if day > 0 {
+			stats.Last24h[status] = day
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var avgMs sql.NullFloat64
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT AVG(EXTRACT(EPOCH FROM (j.finished_at - j.started_at)) * 1000)
+		FROM jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE j.finished_at >= $2 AND j.started_at IS NOT NULL AND `+visible,
+		viewerUserID, weekAgo).Scan(&avgMs); err != nil {
+		return nil, fmt.Errorf("average duration: %w", err)
+	}
+	if avgMs.Valid {
+		stats.AvgDuration = time.Duration(avgMs.Float64) * time.Millisecond
+	}
+	return stats, nil
+}
+
 // --- Billing ---
 
 // UpdateUserTier updates a user's subscription tier.
@@ -1633,6 +2040,14 @@ func (s *PostgresStorage) UpdateOrgBillingSeatsUsed(ctx context.Context, id stri
 	return err
 }
 
+// UpdateOrgBillingStorageUsed adjusts an org's storage usage by deltaBytes.
+func (s *PostgresStorage) UpdateOrgBillingStorageUsed(ctx context.Context, id string, deltaBytes int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE org_billing SET storage_used_bytes = storage_used_bytes + $1 WHERE id = $2`,
+		deltaBytes, id)
+	return err
+}
+
 // IsOrgSeat checks if a user has consumed a seat in the current billing period.
 func (s *PostgresStorage) IsOrgSeat(ctx context.Context, orgBillingID, userID string) (bool, error) {
 	var count int
@@ -1647,37 +2062,206 @@ func (s *PostgresStorage) IsOrgSeat(ctx context.Context, orgBillingID, userID st
 
 // AddOrgSeat records that a user has consumed a seat this billing period.
 func (s *PostgresStorage) AddOrgSeat(ctx context.Context, orgBillingID, userID, forgeUsername string) error {
-	// Use ON CONFLICT DO NOTHING to handle race conditions
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO org_seats (org_billing_id, user_id, forge_username, consumed_at)
-		 VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
-		orgBillingID, userID, forgeUsername, time.Now())
+	return s.withTx(ctx, func(tx *PostgresStorage) error {
+		// Use ON CONFLICT DO NOTHING to handle race conditions
+		_, err := tx.db.ExecContext(ctx,
+			`INSERT INTO org_seats (org_billing_id, user_id, forge_username, consumed_at)
+			 VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
+			orgBillingID, userID, forgeUsername, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// Update seats_used in org_billing (count actual seats)
+		_, err = tx.db.ExecContext(ctx,
+			`UPDATE org_billing SET seats_used = (SELECT COUNT(*) FROM org_seats WHERE org_billing_id = $1)
+			 WHERE id = $1`,
+			orgBillingID)
+		return err
+	})
+}
+
+// ResetOrgSeats clears all seat consumption at the start of a new billing period.
+func (s *PostgresStorage) ResetOrgSeats(ctx context.Context, orgBillingID string) error {
+	return s.withTx(ctx, func(tx *PostgresStorage) error {
+		// Delete all seat records for this org
+		_, err := tx.db.ExecContext(ctx,
+			`DELETE FROM org_seats WHERE org_billing_id = $1`,
+			orgBillingID)
+		if err != nil {
+			return err
+		}
+
+		// Reset seats_used counter and update period_start
+		_, err = tx.db.ExecContext(ctx,
+			`UPDATE org_billing SET seats_used = 0, period_start = $1 WHERE id = $2`,
+			time.Now(), orgBillingID)
+		return err
+	})
+}
+
+// --- Webhook Deliveries ---
+
+// CreateWebhookDelivery stores a delivery, replacing any earlier one with the same ID
+// for the same repo (forges reuse the delivery ID when redelivering). Returns
+// ErrDeliveryExists if the ID is already recorded for another repo.
+func (s *PostgresStorage) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	headersJSON, err := json.Marshal(d.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+	headers, err := s.encrypt(string(headersJSON))
+	if err != nil {
+		return fmt.Errorf("encrypt headers: %w", err)
+	}
+	payload, err := s.encrypt(string(d.Payload))
+	if err != nil {
+		return fmt.Errorf("encrypt payload: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (id, repo_id, source, headers, payload, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (id) DO UPDATE SET source = EXCLUDED.source,
+		 headers = EXCLUDED.headers, payload = EXCLUDED.payload, created_at = EXCLUDED.created_at,
+		 status = 0, result = '', job_id = ''
+		 WHERE webhook_deliveries.repo_id = EXCLUDED.repo_id`,
+		d.ID, d.RepoID, d.Source, headers, payload, d.CreatedAt)
 	if err != nil {
 		return err
 	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrDeliveryExists
+	}
+	return nil
+}
 
-	// Update seats_used in org_billing (count actual seats)
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE org_billing SET seats_used = (SELECT COUNT(*) FROM org_seats WHERE org_billing_id = $1)
-		 WHERE id = $1`,
-		orgBillingID)
+func (s *PostgresStorage) GetWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error) {
+	d := &WebhookDelivery{}
+	var headers, payload string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, repo_id, source, headers, payload, status, result, job_id, created_at
+		 FROM webhook_deliveries WHERE id = $1`, id).Scan(
+		&d.ID, &d.RepoID, &d.Source, &headers, &payload, &d.Status, &d.Result, &d.JobID, &d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if headers, err = s.decrypt(headers); err != nil {
+		return nil, fmt.Errorf("decrypt headers: %w", err)
+	}
+	if payload, err = s.decrypt(payload); err != nil {
+		return nil, fmt.Errorf("decrypt payload: %w", err)
+	}
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &d.Headers); err != nil {
+			return nil, fmt.Errorf("unmarshal headers: %w", err)
+		}
+	}
+	d.Payload = []byte(payload)
+	return d, nil
+}
+
+// UpdateWebhookDeliveryResult records how the handler responded to a delivery.
+func (s *PostgresStorage) UpdateWebhookDeliveryResult(ctx context.Context, id string, status int, result, jobID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = $1, result = $2, job_id = $3 WHERE id = $4`,
+		status, result, jobID, id)
 	return err
 }
 
-// ResetOrgSeats clears all seat consumption at the start of a new billing period.
-func (s *PostgresStorage) ResetOrgSeats(ctx context.Context, orgBillingID string) error {
-	// Delete all seat records for this org
+// ListWebhookDeliveriesByOwner returns the most recent deliveries for repos
+// owned by the user, newest first. Headers and payload are not loaded.
+func (s *PostgresStorage) ListWebhookDeliveriesByOwner(ctx context.Context, ownerUserID string, limit int) ([]*WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.id, d.repo_id, d.source, d.status, d.result, d.job_id, d.created_at
+		FROM webhook_deliveries d
+		JOIN repos r ON r.id = d.repo_id
+		WHERE r.owner_user_id = $1
+		ORDER BY d.created_at DESC
+		LIMIT $2`,
+		ownerUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		d := &WebhookDelivery{}
+		if err := rows.Scan(&d.ID, &d.RepoID, &d.Source, &d.Status, &d.Result, &d.JobID, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *PostgresStorage) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error {
 	_, err := s.db.ExecContext(ctx,
-		`DELETE FROM org_seats WHERE org_billing_id = $1`,
-		orgBillingID)
+		`DELETE FROM webhook_deliveries WHERE created_at < $1`, before)
+	return err
+}
+
+// --- Device Codes ---
+
+func (s *PostgresStorage) CreateDeviceCode(ctx context.Context, dc *DeviceCode) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO device_codes (device_code, user_code, expires_at, authorized, email, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		dc.DeviceCode, dc.UserCode, dc.ExpiresAt, dc.Authorized, dc.Email, dc.CreatedAt)
+	return err
+}
+
+func (s *PostgresStorage) GetDeviceCode(ctx context.Context, deviceCode string) (*DeviceCode, error) {
+	return s.scanDeviceCode(s.db.QueryRowContext(ctx,
+		`SELECT device_code, user_code, expires_at, authorized, email, created_at
+		 FROM device_codes WHERE device_code = $1`, deviceCode))
+}
+
+// GetDeviceCodeByUserCode returns the unexpired device code with the given user code.
+func (s *PostgresStorage) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*DeviceCode, error) {
+	return s.scanDeviceCode(s.db.QueryRowContext(ctx,
+		`SELECT device_code, user_code, expires_at, authorized, email, created_at
+		 FROM device_codes WHERE user_code = $1 AND expires_at > $2
+		 ORDER BY created_at DESC LIMIT 1`, userCode, time.Now()))
+}
+
+func (s *PostgresStorage) scanDeviceCode(row *sql.Row) (*DeviceCode, error) {
+	dc := &DeviceCode{}
+	err := row.Scan(&dc.DeviceCode, &dc.UserCode, &dc.ExpiresAt, &dc.Authorized, &dc.Email, &dc.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+func (s *PostgresStorage) AuthorizeDeviceCode(ctx context.Context, deviceCode, email string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE device_codes SET authorized = $1, email = $2 WHERE device_code = $3`,
+		true, email, deviceCode)
 	if err != nil {
 		return err
 	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	// Reset seats_used counter and update period_start
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE org_billing SET seats_used = 0, period_start = $1 WHERE id = $2`,
-		time.Now(), orgBillingID)
+func (s *PostgresStorage) DeleteDeviceCode(ctx context.Context, deviceCode string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM device_codes WHERE device_code = $1`, deviceCode)
+	return err
+}
+
+// DeleteExpiredDeviceCodes removes device codes that expired before now.
+func (s *PostgresStorage) DeleteExpiredDeviceCodes(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM device_codes WHERE expires_at < $1`, now)
 	return err
 }
 
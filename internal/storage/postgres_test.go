@@ -45,6 +45,10 @@ func TestPostgresStorage(t *testing.T) {
 	t.Run("Logs", func(t *testing.T) {
 		testPostgresLogs(t, store)
 	})
+
+	t.Run("ImportJob", func(t *testing.T) {
+		testImportJob(t, store)
+	})
 }
 
 func cleanupPostgres(t *testing.T, store *PostgresStorage) {
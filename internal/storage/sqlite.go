@@ -17,7 +17,9 @@ import (
 
 // SQLiteStorage implements Storage using SQLite.
 type SQLiteStorage struct {
-	db              *sql.DB
+	db              dbtx           // conn, or the active transaction
+	conn            *sql.DB        // underlying connection pool
+	inTx            bool           // true when db is a transaction
 	cipher          *crypto.Cipher // nil = no encryption (tests)
 	secondaryCipher *crypto.Cipher // non-nil during key rotation
 	log             *slog.Logger
@@ -67,7 +69,7 @@ func NewSQLite(dsn string, encryptionSecret, secondarySecret string) (*SQLiteSto
 		}
 	}
 
-	s := &SQLiteStorage{db: db, cipher: cipher, secondaryCipher: secondaryCipher, log: slog.Default()}
+	s := &SQLiteStorage{db: db, conn: db, cipher: cipher, secondaryCipher: secondaryCipher, log: slog.Default()}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
@@ -206,6 +208,10 @@ func (s *SQLiteStorage) migrate() error {
 
 	// Storage tracking: add log size to jobs
 	_, _ = s.db.Exec("ALTER TABLE jobs ADD COLUMN log_size_bytes INTEGER NOT NULL DEFAULT 0")
+	_, _ = s.db.Exec("ALTER TABLE jobs ADD COLUMN artifact_size_bytes INTEGER NOT NULL DEFAULT 0")
+
+	// Log retention: track when logs were pruned (job row is kept)
+	_, _ = s.db.Exec("ALTER TABLE jobs ADD COLUMN logs_pruned_at DATETIME")
 
 	// Org billing tables for Team Pro
 	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS org_billing (
@@ -241,6 +247,31 @@ func (s *SQLiteStorage) migrate() error {
 	)`)
 	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_relays_user_id ON relays(user_id)")
 
+	// Webhook deliveries for replay (redacted, pruned after a few days)
+	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		repo_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		headers TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at)")
+
+	// Pending device authorizations for 'cinch login' (short-lived)
+	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS device_codes (
+		device_code TEXT PRIMARY KEY,
+		user_code TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		authorized INTEGER NOT NULL DEFAULT 0,
+		email TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_device_codes_user_code ON device_codes(user_code)")
+	_, _ = s.db.Exec("ALTER TABLE webhook_deliveries ADD COLUMN status INTEGER NOT NULL DEFAULT 0")
+	_, _ = s.db.Exec("ALTER TABLE webhook_deliveries ADD COLUMN result TEXT NOT NULL DEFAULT ''")
+	_, _ = s.db.Exec("ALTER TABLE webhook_deliveries ADD COLUMN job_id TEXT NOT NULL DEFAULT ''")
+
 	// Key canary for validating encryption key on startup
 	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS key_canary (
 		id INTEGER PRIMARY KEY CHECK (id = 1),
@@ -277,6 +308,27 @@ func (s *SQLiteStorage) migrate() error {
 	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN owner_user_id TEXT NOT NULL DEFAULT ''")
 	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_repos_owner_user_id ON repos(owner_user_id)")
 
+	// Add cancel_in_progress to repos (supersede in-flight jobs on new pushes)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN cancel_in_progress INTEGER NOT NULL DEFAULT 0")
+
+	// Add max_parallel to repos (cap on the repo's concurrently running jobs)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN max_parallel INTEGER NOT NULL DEFAULT 0")
+
+	// Add infra_retries to repos (automatic retries after infrastructure errors)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN infra_retries INTEGER NOT NULL DEFAULT 0")
+
+	// Add paths to repos (comma-separated globs a push must touch to build)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN paths TEXT NOT NULL DEFAULT ''")
+
+	// Add status_context to repos (commit status / check run name template)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN status_context TEXT NOT NULL DEFAULT ''")
+
+	// Add job_timeout to repos (per-repo job timeout sent to workers)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN job_timeout TEXT NOT NULL DEFAULT ''")
+
+	// Add notifications to repos (encrypted JSON RepoNotifications)
+	_, _ = s.db.Exec("ALTER TABLE repos ADD COLUMN notifications TEXT NOT NULL DEFAULT ''")
+
 	// Add owner_user_id to tokens for authorization
 	_, _ = s.db.Exec("ALTER TABLE tokens ADD COLUMN owner_user_id TEXT NOT NULL DEFAULT ''")
 	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_tokens_owner_user_id ON tokens(owner_user_id)")
@@ -638,7 +690,43 @@ func (s *SQLiteStorage) hasUniqueConstraintOnUsersName() bool {
 }
 
 func (s *SQLiteStorage) Close() error {
-	return s.db.Close()
+	return s.conn.Close()
+}
+
+// WithTx runs fn inside a database transaction.
+func (s *SQLiteStorage) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	return s.withTx(ctx, func(tx *SQLiteStorage) error {
+		return fn(tx)
+	})
+}
+
+func (s *SQLiteStorage) withTx(ctx context.Context, fn func(tx *SQLiteStorage) error) (err error) {
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	txs := *s
+	txs.db = tx
+	txs.inTx = true
+	if err = fn(&txs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // --- Jobs ---
@@ -652,6 +740,27 @@ func (s *SQLiteStorage) CreateJob(ctx context.Context, job *Job) error {
 	return err
 }
 
+// ImportJob inserts a job from a backup, keeping its status, exit code, and
+// timestamps. The worker is not kept (workers aren't part of a backup).
+// Returns false without error if a job with the same ID already exists.
+func (s *SQLiteStorage) ImportJob(ctx context.Context, job *Job) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO jobs (id, repo_id, commit_sha, branch, tag, pr_number, pr_base_branch, status, exit_code, installation_id, check_run_id,
+		                   started_at, finished_at, created_at, author, trust_level, is_fork, approved_by, approved_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO NOTHING`,
+		job.ID, job.RepoID, job.Commit, job.Branch, job.Tag, job.PRNumber, job.PRBaseBranch, job.Status, job.ExitCode, job.InstallationID, job.CheckRunID,
+		job.StartedAt, job.FinishedAt, job.CreatedAt, job.Author, job.TrustLevel, job.IsFork, job.ApprovedBy, job.ApprovedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 func (s *SQLiteStorage) GetJob(ctx context.Context, id string) (*Job, error) {
 	job := &Job{}
 	err := s.db.QueryRowContext(ctx,
@@ -694,6 +803,21 @@ func (s *SQLiteStorage) GetJobSiblings(ctx context.Context, repoID, commit, excl
 	return jobs, rows.Err()
 }
 
+// GetJobByCommitRef returns the newest job for the repo, commit and git ref,
+// or ErrNotFound.
+func (s *SQLiteStorage) GetJobByCommitRef(ctx context.Context, repoID, commit, ref string) (*Job, error) {
+	jobs, err := s.GetJobSiblings(ctx, repoID, commit, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if jobMatchesRef(job, ref) {
+			return job, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
 func (s *SQLiteStorage) UpdateJobCheckRunID(ctx context.Context, id string, checkRunID int64) error {
 	_, err := s.db.ExecContext(ctx,
 		`UPDATE jobs SET check_run_id = ? WHERE id = ?`,
@@ -704,23 +828,9 @@ func (s *SQLiteStorage) UpdateJobCheckRunID(ctx context.Context, id string, chec
 func (s *SQLiteStorage) ListJobs(ctx context.Context, filter JobFilter) ([]*Job, error) {
 	query := `SELECT id, repo_id, commit_sha, branch, tag, pr_number, pr_base_branch, status, exit_code, worker_id,
 	                 installation_id, check_run_id, started_at, finished_at, created_at,
-	                 author, trust_level, is_fork, approved_by, approved_at FROM jobs WHERE 1=1`
-	args := []any{}
-
-	if filter.RepoID != "" {
-		query += " AND repo_id = ?"
-		args = append(args, filter.RepoID)
-	}
-	if filter.Status != "" {
-		query += " AND status = ?"
-		args = append(args, filter.Status)
-	}
-	if filter.Branch != "" {
-		query += " AND branch = ?"
-		args = append(args, filter.Branch)
-	}
-
-	query += " ORDER BY created_at DESC"
+	                 author, trust_level, is_fork, approved_by, approved_at FROM jobs`
+	where, args := jobFilterWhere(filter)
+	query += where + " ORDER BY created_at DESC"
 
 	if filter.Limit > 0 {
 		query += " LIMIT ?"
@@ -751,6 +861,47 @@ func (s *SQLiteStorage) ListJobs(ctx context.Context, filter JobFilter) ([]*Job,
 	return jobs, rows.Err()
 }
 
+// CountJobs counts the jobs matching filter, ignoring its Limit and Offset.
+func (s *SQLiteStorage) CountJobs(ctx context.Context, filter JobFilter) (int, error) {
+	where, args := jobFilterWhere(filter)
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs"+where, args...).Scan(&n)
+	return n, err
+}
+
+// jobFilterWhere builds the WHERE clause shared by ListJobs and CountJobs.
+func jobFilterWhere(filter JobFilter) (string, []any) {
+	where := " WHERE 1=1"
+	args := []any{}
+
+	if filter.RepoID != "" {
+		where += " AND repo_id = ?"
+		args = append(args, filter.RepoID)
+	}
+	if filter.Status != "" {
+		where += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Branch != "" {
+		where += " AND branch = ?"
+		args = append(args, filter.Branch)
+	}
+	if filter.Commit != "" {
+		where += " AND commit_sha LIKE ?"
+		args = append(args, filter.Commit+"%")
+	}
+	// Timestamps are stored as text in the server's local zone; compare in the same zone
+	if !filter.CreatedAfter.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, filter.CreatedAfter.Local())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where += " AND created_at <= ?"
+		args = append(args, filter.CreatedBefore.Local())
+	}
+	return where, args
+}
+
 func (s *SQLiteStorage) ListJobsByWorker(ctx context.Context, workerID string, limit int) ([]*Job, error) {
 	if limit <= 0 {
 		limit = 10
@@ -927,6 +1078,7 @@ func (s *SQLiteStorage) CreateRepo(ctx context.Context, repo *Repo) error {
 
 	// Convert workers slice to comma-separated string
 	workers := strings.Join(repo.Workers, ",")
+	paths := strings.Join(repo.Paths, ",")
 
 	// Convert and encrypt secrets map to JSON
 	var secretsJSON string
@@ -943,8 +1095,8 @@ func (s *SQLiteStorage) CreateRepo(ctx context.Context, repo *Repo) error {
 
 	// Use upsert to handle re-onboarding: if repo exists, update token and webhook secret
 	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO repos (id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`INSERT INTO repos (id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		 ON CONFLICT(clone_url) DO UPDATE SET
 		 	webhook_secret = excluded.webhook_secret,
 		 	forge_token = excluded.forge_token,
@@ -952,18 +1104,18 @@ func (s *SQLiteStorage) CreateRepo(ctx context.Context, repo *Repo) error {
 		 	private = excluded.private,
 		 	owner_user_id = CASE WHEN repos.owner_user_id = '' THEN excluded.owner_user_id ELSE repos.owner_user_id END`,
 		repo.ID, repo.ForgeType, repo.Owner, repo.Name, repo.CloneURL, repo.HTMLURL,
-		webhookSecret, forgeToken, repo.Build, repo.Release, workers, secretsJSON, repo.Private, repo.OwnerUserID, repo.CreatedAt)
+		webhookSecret, forgeToken, repo.Build, repo.Release, workers, secretsJSON, repo.Private, repo.CancelInProgress, repo.MaxParallel, repo.InfraRetries, paths, repo.StatusContext, repo.JobTimeout, repo.OwnerUserID, repo.CreatedAt)
 	return err
 }
 
 func (s *SQLiteStorage) GetRepo(ctx context.Context, id string) (*Repo, error) {
 	repo := &Repo{}
-	var workers, secretsJSON string
+	var workers, paths, secretsJSON string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE id = ?`, id).Scan(
 		&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL, &repo.HTMLURL,
-		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt)
+		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -974,6 +1126,9 @@ func (s *SQLiteStorage) GetRepo(ctx context.Context, id string) (*Repo, error) {
 	if workers != "" {
 		repo.Workers = strings.Split(workers, ",")
 	}
+	if paths != "" {
+		repo.Paths = strings.Split(paths, ",")
+	}
 	// Decrypt secrets
 	if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
 		return nil, fmt.Errorf("decrypt webhook_secret: %w", err)
@@ -998,12 +1153,12 @@ func (s *SQLiteStorage) GetRepo(ctx context.Context, id string) (*Repo, error) {
 
 func (s *SQLiteStorage) GetRepoByCloneURL(ctx context.Context, cloneURL string) (*Repo, error) {
 	repo := &Repo{}
-	var workers, secretsJSON string
+	var workers, paths, secretsJSON string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE clone_url = ?`, cloneURL).Scan(
 		&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL, &repo.HTMLURL,
-		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt)
+		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -1014,6 +1169,9 @@ func (s *SQLiteStorage) GetRepoByCloneURL(ctx context.Context, cloneURL string)
 	if workers != "" {
 		repo.Workers = strings.Split(workers, ",")
 	}
+	if paths != "" {
+		repo.Paths = strings.Split(paths, ",")
+	}
 	// Decrypt secrets
 	if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
 		return nil, fmt.Errorf("decrypt webhook_secret: %w", err)
@@ -1038,7 +1196,7 @@ func (s *SQLiteStorage) GetRepoByCloneURL(ctx context.Context, cloneURL string)
 
 func (s *SQLiteStorage) ListRepos(ctx context.Context) ([]*Repo, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
@@ -1050,7 +1208,7 @@ func (s *SQLiteStorage) ListRepos(ctx context.Context) ([]*Repo, error) {
 
 func (s *SQLiteStorage) ListReposByOwner(ctx context.Context, ownerUserID string) ([]*Repo, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE owner_user_id = ? ORDER BY created_at DESC`, ownerUserID)
 	if err != nil {
 		return nil, err
@@ -1064,15 +1222,18 @@ func (s *SQLiteStorage) scanRepos(rows *sql.Rows) ([]*Repo, error) {
 	var repos []*Repo
 	for rows.Next() {
 		repo := &Repo{}
-		var workers, secretsJSON string
+		var workers, paths, secretsJSON string
 		if err := rows.Scan(&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL,
-			&repo.HTMLURL, &repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt); err != nil {
+			&repo.HTMLURL, &repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt); err != nil {
 			return nil, err
 		}
 		// Parse workers from comma-separated string
 		if workers != "" {
 			repo.Workers = strings.Split(workers, ",")
 		}
+		if paths != "" {
+			repo.Paths = strings.Split(paths, ",")
+		}
 		// Decrypt secrets
 		var err error
 		if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
@@ -1105,12 +1266,12 @@ func (s *SQLiteStorage) DeleteRepo(ctx context.Context, id string) error {
 
 func (s *SQLiteStorage) GetRepoByOwnerName(ctx context.Context, forge, owner, name string) (*Repo, error) {
 	repo := &Repo{}
-	var workers, secretsJSON string
+	var workers, paths, secretsJSON string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, owner_user_id, created_at
+		`SELECT id, forge_type, owner, name, clone_url, html_url, webhook_secret, forge_token, build, release, workers, secrets, private, cancel_in_progress, max_parallel, infra_retries, paths, status_context, job_timeout, owner_user_id, created_at
 		 FROM repos WHERE forge_type = ? AND owner = ? AND name = ?`, forge, owner, name).Scan(
 		&repo.ID, &repo.ForgeType, &repo.Owner, &repo.Name, &repo.CloneURL, &repo.HTMLURL,
-		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.OwnerUserID, &repo.CreatedAt)
+		&repo.WebhookSecret, &repo.ForgeToken, &repo.Build, &repo.Release, &workers, &secretsJSON, &repo.Private, &repo.CancelInProgress, &repo.MaxParallel, &repo.InfraRetries, &paths, &repo.StatusContext, &repo.JobTimeout, &repo.OwnerUserID, &repo.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -1121,6 +1282,9 @@ func (s *SQLiteStorage) GetRepoByOwnerName(ctx context.Context, forge, owner, na
 	if workers != "" {
 		repo.Workers = strings.Split(workers, ",")
 	}
+	if paths != "" {
+		repo.Paths = strings.Split(paths, ",")
+	}
 	// Decrypt secrets
 	if repo.WebhookSecret, err = s.decrypt(repo.WebhookSecret); err != nil {
 		return nil, fmt.Errorf("decrypt webhook_secret: %w", err)
@@ -1150,6 +1314,97 @@ func (s *SQLiteStorage) UpdateRepoPrivate(ctx context.Context, id string, privat
 	return err
 }
 
+func (s *SQLiteStorage) UpdateRepoBuild(ctx context.Context, id, build, release string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET build = ?, release = ? WHERE id = ?`,
+		build, release, id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateRepoCancelInProgress(ctx context.Context, id string, enabled bool) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET cancel_in_progress = ? WHERE id = ?`,
+		enabled, id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateRepoMaxParallel(ctx context.Context, id string, limit int) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET max_parallel = ? WHERE id = ?`,
+		limit, id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateRepoInfraRetries(ctx context.Context, id string, retries int) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET infra_retries = ? WHERE id = ?`,
+		retries, id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateRepoPaths(ctx context.Context, id string, paths []string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET paths = ? WHERE id = ?`,
+		strings.Join(paths, ","), id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateRepoStatusContext(ctx context.Context, id string, template string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET status_context = ? WHERE id = ?`,
+		template, id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateRepoJobTimeout(ctx context.Context, id string, timeout string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE repos SET job_timeout = ? WHERE id = ?`,
+		timeout, id)
+	return err
+}
+
+// GetRepoNotifications returns the repo's job notification settings (empty
+// if none are configured).
+func (s *SQLiteStorage) GetRepoNotifications(ctx context.Context, id string) (*RepoNotifications, error) {
+	var enc string
+	err := s.db.QueryRowContext(ctx, `SELECT notifications FROM repos WHERE id = ?`, id).Scan(&enc)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := &RepoNotifications{}
+	if enc == "" {
+		return n, nil
+	}
+	decrypted, err := s.decrypt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt notifications: %w", err)
+	}
+	if err := json.Unmarshal([]byte(decrypted), n); err != nil {
+		return nil, fmt.Errorf("unmarshal notifications: %w", err)
+	}
+	return n, nil
+}
+
+// UpdateRepoNotifications replaces the repo's job notification settings.
+func (s *SQLiteStorage) UpdateRepoNotifications(ctx context.Context, id string, n *RepoNotifications) error {
+	var enc string
+	if n != nil && !n.IsZero() {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return fmt.Errorf("marshal notifications: %w", err)
+		}
+		if enc, err = s.encrypt(string(data)); err != nil {
+			return fmt.Errorf("encrypt notifications: %w", err)
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE repos SET notifications = ? WHERE id = ?`, enc, id)
+	return err
+}
+
 func (s *SQLiteStorage) UpdateRepoHTMLURL(ctx context.Context, id string, htmlURL string) error {
 	_, err := s.db.ExecContext(ctx,
 		`UPDATE repos SET html_url = ? WHERE id = ?`,
@@ -1568,31 +1823,27 @@ func (s *SQLiteStorage) ClearUserGitHubConnected(ctx context.Context, userID str
 
 func (s *SQLiteStorage) DeleteUser(ctx context.Context, id string) error {
 	// Delete user and all associated data in a transaction
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback() }()
-
-	// Delete tokens owned by this user
-	_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE owner_user_id = ?`, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user tokens: %w", err)
-	}
+	return s.withTx(ctx, func(tx *SQLiteStorage) error {
+		// Delete tokens owned by this user
+		_, err := tx.db.ExecContext(ctx, `DELETE FROM tokens WHERE owner_user_id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user tokens: %w", err)
+		}
 
-	// Delete repos owned by this user
-	_, err = tx.ExecContext(ctx, `DELETE FROM repos WHERE owner_user_id = ?`, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user repos: %w", err)
-	}
+		// Delete repos owned by this user
+		_, err = tx.db.ExecContext(ctx, `DELETE FROM repos WHERE owner_user_id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user repos: %w", err)
+		}
 
-	// Delete the user record
-	_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
-	}
+		// Delete the user record
+		_, err = tx.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
 
-	return tx.Commit()
+		return nil
+	})
 }
 
 // UpdateJobLogSize updates the log size for a job.
@@ -1603,6 +1854,14 @@ func (s *SQLiteStorage) UpdateJobLogSize(ctx context.Context, jobID string, size
 	return err
 }
 
+// UpdateJobArtifactSize updates the total artifact size for a job.
+func (s *SQLiteStorage) UpdateJobArtifactSize(ctx context.Context, jobID string, sizeBytes int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE jobs SET artifact_size_bytes = ? WHERE id = ?`,
+		sizeBytes, jobID)
+	return err
+}
+
 // UpdateUserStorageUsed adds deltaBytes to the user's storage usage.
 func (s *SQLiteStorage) UpdateUserStorageUsed(ctx context.Context, userID string, deltaBytes int64) error {
 	_, err := s.db.ExecContext(ctx,
@@ -1611,6 +1870,170 @@ func (s *SQLiteStorage) UpdateUserStorageUsed(ctx context.Context, userID string
 	return err
 }
 
+// --- Log retention ---
+
+// ListJobsWithExpiredLogs returns finished jobs whose logs haven't been pruned
+// and that finished before the given time, oldest first.
+func (s *SQLiteStorage) ListJobsWithExpiredLogs(ctx context.Context, finishedBefore time.Time, limit int) ([]*ExpiredLog, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.id, j.log_size_bytes + j.artifact_size_bytes, COALESCE(r.owner_user_id, ''),
+		       COALESCE(r.forge_type, ''), COALESCE(r.owner, '')
+		FROM jobs j
+		LEFT JOIN repos r ON r.id = j.repo_id
+		WHERE j.finished_at IS NOT NULL AND j.finished_at < ? AND j.logs_pruned_at IS NULL
+		ORDER BY j.finished_at
+		LIMIT ?`,
+		finishedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*ExpiredLog
+	for rows.Next() {
+		l := &ExpiredLog{}
+		var forgeType string
+		if err := rows.Scan(&l.JobID, &l.LogSizeBytes, &l.OwnerUserID, &forgeType, &l.RepoOwner); err != nil {
+			return nil, err
+		}
+		l.ForgeType = ForgeType(forgeType)
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// MarkJobLogsPruned zeroes a job's log and artifact sizes and records that its logs were pruned.
+// Any logs stored in the job_logs table are deleted as well.
+func (s *SQLiteStorage) MarkJobLogsPruned(ctx context.Context, jobID string) error {
+	return s.withTx(ctx, func(tx *SQLiteStorage) error {
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM job_logs WHERE job_id = ?`, jobID); err != nil {
+			return fmt.Errorf("delete job logs: %w", err)
+		}
+		_, err := tx.db.ExecContext(ctx,
+			`UPDATE jobs SET log_size_bytes = 0, artifact_size_bytes = 0, logs_pruned_at = ? WHERE id = ?`,
+			time.Now(), jobID)
+		return err
+	})
+}
+
+// DeleteJobsOlderThan deletes finished jobs (and any logs in job_logs) that
+// finished before cutoff. Jobs still pending or running are kept.
+// Log store objects are not touched; callers delete those first.
+func (s *SQLiteStorage) DeleteJobsOlderThan(ctx context.Context, cutoff time.Time) (int, error) {
+	var deleted int64
+	err := s.withTx(ctx, func(tx *SQLiteStorage) error {
+		if _, err := tx.db.ExecContext(ctx, `
+			DELETE FROM job_logs WHERE job_id IN (
+				SELECT id FROM jobs WHERE finished_at IS NOT NULL AND finished_at < ?
+			)`, cutoff); err != nil {
+			return fmt.Errorf("delete job logs: %w", err)
+		}
+		res, err := tx.db.ExecContext(ctx,
+			`DELETE FROM jobs WHERE finished_at IS NOT NULL AND finished_at < ?`, cutoff)
+		if err != nil {
+			return fmt.Errorf("delete jobs: %w", err)
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
+
+// --- Usage ---
+
+// GetUserUsage counts a user's repos, and the jobs and build time of those
+// repos since the given time.
+func (s *SQLiteStorage) GetUserUsage(ctx context.Context, userID string, since time.Time) (*UserUsage, error) {
+	u := &UserUsage{}
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM repos WHERE owner_user_id = ?`, userID).Scan(&u.Repos); err != nil {
+		return nil, fmt.Errorf("count repos: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.started_at, j.finished_at
+		FROM jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE r.owner_user_id = ? AND j.created_at >= ?`,
+		userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var startedAt, finishedAt sql.NullTime
+		if err := rows.Scan(&startedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		u.Jobs++
+		if startedAt.Valid && finishedAt.Valid && finishedAt.Time.After(startedAt.Time) {
+			u.BuildSeconds += int64(finishedAt.Time.Sub(startedAt.Time).Seconds())
+		}
+	}
+	return u, rows.Err()
+}
+
+// JobStats counts repos and recent jobs visible to viewerUserID ("" for
+// anonymous) and averages the run time of recently finished jobs.
+func (s *SQLiteStorage) JobStats(ctx context.Context, viewerUserID string, now time.Time) (*JobStats, error) {
+	// Anonymous viewers ("") match no owner, so see only public repos
+	const visible = "(NOT r.private OR (? <> '' AND r.owner_user_id = ?))"
+	args := []any{viewerUserID, viewerUserID}
+	stats := &JobStats{Last24h: make(map[JobStatus]int), Last7d: make(map[JobStatus]int)}
+
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM repos r WHERE `+visible, args...).Scan(&stats.Repos); err != nil {
+		return nil, fmt.Errorf("count repos: %w", err)
+	}
+
+	dayAgo, weekAgo := now.Add(-24*time.Hour), now.Add(-7*24*time.Hour)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT j.status, COUNT(*), SUM(CASE WHEN j.created_at >= ? THEN 1 ELSE 0 END)
+		FROM jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE j.created_at >= ? AND `+visible+`
+		GROUP BY j.status`,
+		append([]any{dayAgo, weekAgo}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("count jobs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status JobStatus
+		var week, day int
+		if err := rows.Scan(&status, &week, &day); err != nil {
+			return nil, err
+		}
+		stats.Last7d[status] = week
+		if day > 0 {
+			stats.Last24h[status] = day
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Timestamps are stored as text; julianday reads the leading
+	// "YYYY-MM-DD HH:MM:SS.fff" and its fraction of a day is converted to ms
+	var avgMs sql.NullFloat64
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT AVG((julianday(substr(j.finished_at, 1, 23)) - julianday(substr(j.started_at, 1, 23))) * 86400000)
+		FROM jobs j
+		JOIN repos r ON r.id = j.repo_id
+		WHERE j.finished_at >= ? AND j.started_at IS NOT NULL AND `+visible,
+		append([]any{weekAgo}, args...)...).Scan(&avgMs); err != nil {
+		return nil, fmt.Errorf("average duration: %w", err)
+	}
+	if avgMs.Valid {
+		stats.AvgDuration = time.Duration(avgMs.Float64) * time.Millisecond
+	}
+	return stats, nil
+}
+
 // --- Billing ---
 
 // UpdateUserTier updates a user's subscription tier.
@@ -1677,6 +2100,14 @@ func (s *SQLiteStorage) UpdateOrgBillingSeatsUsed(ctx context.Context, id string
 	return err
 }
 
+// UpdateOrgBillingStorageUsed adjusts an org's storage usage by deltaBytes.
+func (s *SQLiteStorage) UpdateOrgBillingStorageUsed(ctx context.Context, id string, deltaBytes int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE org_billing SET storage_used_bytes = storage_used_bytes + ? WHERE id = ?`,
+		deltaBytes, id)
+	return err
+}
+
 // IsOrgSeat checks if a user has consumed a seat in the current billing period.
 func (s *SQLiteStorage) IsOrgSeat(ctx context.Context, orgBillingID, userID string) (bool, error) {
 	var count int
@@ -1691,38 +2122,42 @@ func (s *SQLiteStorage) IsOrgSeat(ctx context.Context, orgBillingID, userID stri
 
 // AddOrgSeat records that a user has consumed a seat this billing period.
 func (s *SQLiteStorage) AddOrgSeat(ctx context.Context, orgBillingID, userID, forgeUsername string) error {
-	// Use INSERT OR IGNORE to handle race conditions
-	_, err := s.db.ExecContext(ctx,
-		`INSERT OR IGNORE INTO org_seats (org_billing_id, user_id, forge_username, consumed_at)
-		 VALUES (?, ?, ?, ?)`,
-		orgBillingID, userID, forgeUsername, time.Now())
-	if err != nil {
-		return err
-	}
+	return s.withTx(ctx, func(tx *SQLiteStorage) error {
+		// Use INSERT OR IGNORE to handle race conditions
+		_, err := tx.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO org_seats (org_billing_id, user_id, forge_username, consumed_at)
+			 VALUES (?, ?, ?, ?)`,
+			orgBillingID, userID, forgeUsername, time.Now())
+		if err != nil {
+			return err
+		}
 
-	// Increment seats_used in org_billing (atomic with check)
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE org_billing SET seats_used = (SELECT COUNT(*) FROM org_seats WHERE org_billing_id = ?)
-		 WHERE id = ?`,
-		orgBillingID, orgBillingID)
-	return err
+		// Increment seats_used in org_billing (atomic with check)
+		_, err = tx.db.ExecContext(ctx,
+			`UPDATE org_billing SET seats_used = (SELECT COUNT(*) FROM org_seats WHERE org_billing_id = ?)
+			 WHERE id = ?`,
+			orgBillingID, orgBillingID)
+		return err
+	})
 }
 
 // ResetOrgSeats clears all seat consumption at the start of a new billing period.
 func (s *SQLiteStorage) ResetOrgSeats(ctx context.Context, orgBillingID string) error {
-	// Delete all seat records for this org
-	_, err := s.db.ExecContext(ctx,
-		`DELETE FROM org_seats WHERE org_billing_id = ?`,
-		orgBillingID)
-	if err != nil {
-		return err
-	}
+	return s.withTx(ctx, func(tx *SQLiteStorage) error {
+		// Delete all seat records for this org
+		_, err := tx.db.ExecContext(ctx,
+			`DELETE FROM org_seats WHERE org_billing_id = ?`,
+			orgBillingID)
+		if err != nil {
+			return err
+		}
 
-	// Reset seats_used counter and update period_start
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE org_billing SET seats_used = 0, period_start = ? WHERE id = ?`,
-		time.Now(), orgBillingID)
-	return err
+		// Reset seats_used counter and update period_start
+		_, err = tx.db.ExecContext(ctx,
+			`UPDATE org_billing SET seats_used = 0, period_start = ? WHERE id = ?`,
+			time.Now(), orgBillingID)
+		return err
+	})
 }
 
 // Helper functions for emails JSON
@@ -1799,6 +2234,171 @@ func (s *SQLiteStorage) GetRelayByID(ctx context.Context, relayID string) (*Rela
 	return relay, err
 }
 
+// --- Webhook Deliveries ---
+
+// CreateWebhookDelivery stores a delivery, replacing any earlier one with the same ID
+// for the same repo (forges reuse the delivery ID when redelivering). Returns
+// ErrDeliveryExists if the ID is already recorded for another repo.
+func (s *SQLiteStorage) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	headersJSON, err := json.Marshal(d.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+	headers, err := s.encrypt(string(headersJSON))
+	if err != nil {
+		return fmt.Errorf("encrypt headers: %w", err)
+	}
+	payload, err := s.encrypt(string(d.Payload))
+	if err != nil {
+		return fmt.Errorf("encrypt payload: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (id, repo_id, source, headers, payload, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET source = excluded.source, headers = excluded.headers,
+		 payload = excluded.payload, created_at = excluded.created_at,
+		 status = 0, result = '', job_id = ''
+		 WHERE webhook_deliveries.repo_id = excluded.repo_id`,
+		d.ID, d.RepoID, d.Source, headers, payload, d.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrDeliveryExists
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) GetWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error) {
+	d := &WebhookDelivery{}
+	var headers, payload string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, repo_id, source, headers, payload, status, result, job_id, created_at
+		 FROM webhook_deliveries WHERE id = ?`, id).Scan(
+		&d.ID, &d.RepoID, &d.Source, &headers, &payload, &d.Status, &d.Result, &d.JobID, &d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if headers, err = s.decrypt(headers); err != nil {
+		return nil, fmt.Errorf("decrypt headers: %w", err)
+	}
+	if payload, err = s.decrypt(payload); err != nil {
+		return nil, fmt.Errorf("decrypt payload: %w", err)
+	}
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &d.Headers); err != nil {
+			return nil, fmt.Errorf("unmarshal headers: %w", err)
+		}
+	}
+	d.Payload = []byte(payload)
+	return d, nil
+}
+
+// UpdateWebhookDeliveryResult records how the handler responded to a delivery.
+func (s *SQLiteStorage) UpdateWebhookDeliveryResult(ctx context.Context, id string, status int, result, jobID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = ?, result = ?, job_id = ? WHERE id = ?`,
+		status, result, jobID, id)
+	return err
+}
+
+// ListWebhookDeliveriesByOwner returns the most recent deliveries for repos
+// owned by the user, newest first. Headers and payload are not loaded.
+func (s *SQLiteStorage) ListWebhookDeliveriesByOwner(ctx context.Context, ownerUserID string, limit int) ([]*WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.id, d.repo_id, d.source, d.status, d.result, d.job_id, d.created_at
+		FROM webhook_deliveries d
+		JOIN repos r ON r.id = d.repo_id
+		WHERE r.owner_user_id = ?
+		ORDER BY d.created_at DESC
+		LIMIT ?`,
+		ownerUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		d := &WebhookDelivery{}
+		if err := rows.Scan(&d.ID, &d.RepoID, &d.Source, &d.Status, &d.Result, &d.JobID, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *SQLiteStorage) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE created_at < ?`, before)
+	return err
+}
+
+// --- Device Codes ---
+
+func (s *SQLiteStorage) CreateDeviceCode(ctx context.Context, dc *DeviceCode) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO device_codes (device_code, user_code, expires_at, authorized, email, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		dc.DeviceCode, dc.UserCode, dc.ExpiresAt, dc.Authorized, dc.Email, dc.CreatedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetDeviceCode(ctx context.Context, deviceCode string) (*DeviceCode, error) {
+	return s.scanDeviceCode(s.db.QueryRowContext(ctx,
+		`SELECT device_code, user_code, expires_at, authorized, email, created_at
+		 FROM device_codes WHERE device_code = ?`, deviceCode))
+}
+
+// GetDeviceCodeByUserCode returns the unexpired device code with the given user code.
+func (s *SQLiteStorage) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*DeviceCode, error) {
+	return s.scanDeviceCode(s.db.QueryRowContext(ctx,
+		`SELECT device_code, user_code, expires_at, authorized, email, created_at
+		 FROM device_codes WHERE user_code = ? AND expires_at > ?
+		 ORDER BY created_at DESC LIMIT 1`, userCode, time.Now()))
+}
+
+func (s *SQLiteStorage) scanDeviceCode(row *sql.Row) (*DeviceCode, error) {
+	dc := &DeviceCode{}
+	err := row.Scan(&dc.DeviceCode, &dc.UserCode, &dc.ExpiresAt, &dc.Authorized, &dc.Email, &dc.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+func (s *SQLiteStorage) AuthorizeDeviceCode(ctx context.Context, deviceCode, email string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE device_codes SET authorized = ?, email = ? WHERE device_code = ?`,
+		true, email, deviceCode)
+	if err != nil {
+		return err
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteDeviceCode(ctx context.Context, deviceCode string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM device_codes WHERE device_code = ?`, deviceCode)
+	return err
+}
+
+// DeleteExpiredDeviceCodes removes device codes that expired before now.
+func (s *SQLiteStorage) DeleteExpiredDeviceCodes(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM device_codes WHERE expires_at < ?`, now)
+	return err
+}
+
 // --- Logs ---
 
 func (s *SQLiteStorage) AppendLog(ctx context.Context, jobID, stream, data string) error {
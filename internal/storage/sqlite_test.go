@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -114,6 +115,88 @@ func TestRepoCRUD(t *testing.T) {
 	if got.ID != repo.ID {
 		t.Errorf("ID = %q, want %q", got.ID, repo.ID)
 	}
+	if got.CancelInProgress {
+		t.Error("CancelInProgress should default to false")
+	}
+
+	// Update settings
+	if err := s.UpdateRepoCancelInProgress(ctx, repo.ID, true); err != nil {
+		t.Fatalf("UpdateRepoCancelInProgress failed: %v", err)
+	}
+	got, _ = s.GetRepo(ctx, repo.ID)
+	if !got.CancelInProgress {
+		t.Error("CancelInProgress should be true after update")
+	}
+	if err := s.UpdateRepoMaxParallel(ctx, repo.ID, 3); err != nil {
+		t.Fatalf("UpdateRepoMaxParallel failed: %v", err)
+	}
+	got, _ = s.GetRepo(ctx, repo.ID)
+	if got.MaxParallel != 3 {
+		t.Errorf("MaxParallel = %d, want 3", got.MaxParallel)
+	}
+	if err := s.UpdateRepoInfraRetries(ctx, repo.ID, 2); err != nil {
+		t.Fatalf("UpdateRepoInfraRetries failed: %v", err)
+	}
+	got, _ = s.GetRepoByOwnerName(ctx, string(repo.ForgeType), repo.Owner, repo.Name)
+	if got.InfraRetries != 2 {
+		t.Errorf("InfraRetries = %d, want 2", got.InfraRetries)
+	}
+	if err := s.UpdateRepoPaths(ctx, repo.ID, []string{"api/**", "!**/*.md"}); err != nil {
+		t.Fatalf("UpdateRepoPaths failed: %v", err)
+	}
+	got, _ = s.GetRepo(ctx, repo.ID)
+	if strings.Join(got.Paths, " ") != "api/** !**/*.md" {
+		t.Errorf("Paths = %v, want [api/** !**/*.md]", got.Paths)
+	}
+	if err := s.UpdateRepoStatusContext(ctx, repo.ID, "cinch/{event}"); err != nil {
+		t.Fatalf("UpdateRepoStatusContext failed: %v", err)
+	}
+	got, _ = s.GetRepoByCloneURL(ctx, repo.CloneURL)
+	if got.StatusContext != "cinch/{event}" {
+		t.Errorf("StatusContext = %q, want %q", got.StatusContext, "cinch/{event}")
+	}
+
+	n, err := s.GetRepoNotifications(ctx, repo.ID)
+	if err != nil {
+		t.Fatalf("GetRepoNotifications failed: %v", err)
+	}
+	if n.Wants(JobStatusFailed) {
+		t.Error("repo without notification URLs should not want notifications")
+	}
+	n = &RepoNotifications{URLs: []string{"https://hooks.slack.com/services/T/B/X"}}
+	if err := s.UpdateRepoNotifications(ctx, repo.ID, n); err != nil {
+		t.Fatalf("UpdateRepoNotifications failed: %v", err)
+	}
+	n, _ = s.GetRepoNotifications(ctx, repo.ID)
+	if len(n.URLs) != 1 || n.URLs[0] != "https://hooks.slack.com/services/T/B/X" {
+		t.Errorf("URLs = %v", n.URLs)
+	}
+	if !n.Wants(JobStatusFailed) || !n.Wants(JobStatusError) || n.Wants(JobStatusSuccess) {
+		t.Error("default notifications should be failed and error only")
+	}
+	n.On = []JobStatus{JobStatusSuccess}
+	_ = s.UpdateRepoNotifications(ctx, repo.ID, n)
+	n, _ = s.GetRepoNotifications(ctx, repo.ID)
+	if !n.Wants(JobStatusSuccess) || n.Wants(JobStatusFailed) {
+		t.Errorf("On = %v, want only success", n.On)
+	}
+	n.Email = true
+	_ = s.UpdateRepoNotifications(ctx, repo.ID, n)
+	n, _ = s.GetRepoNotifications(ctx, repo.ID)
+	if !n.WantsEmail(JobStatusFailed) || n.WantsEmail(JobStatusSuccess) {
+		t.Errorf("Email = %t, want failure emails only", n.Email)
+	}
+	// Email-only settings are kept too
+	if err := s.UpdateRepoNotifications(ctx, repo.ID, &RepoNotifications{Email: true}); err != nil {
+		t.Fatalf("UpdateRepoNotifications failed: %v", err)
+	}
+	n, _ = s.GetRepoNotifications(ctx, repo.ID)
+	if !n.Email || len(n.URLs) != 0 || !n.WantsEmail(JobStatusFailed) {
+		t.Errorf("email-only notifications = %+v", n)
+	}
+	if _, err := s.GetRepoNotifications(ctx, "r_missing"); err != ErrNotFound {
+		t.Errorf("GetRepoNotifications(missing) error = %v, want ErrNotFound", err)
+	}
 
 	// List
 	repos, err := s.ListRepos(ctx)
@@ -223,6 +306,67 @@ func TestJobCRUD(t *testing.T) {
 	}
 }
 
+func TestWithTxRollback(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	repo := &Repo{
+		ID:        "r_tx",
+		ForgeType: ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/tx.git",
+		CreatedAt: time.Now(),
+	}
+	if err := s.CreateRepo(ctx, repo); err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+
+	// Fail after the first statement - nothing should be persisted
+	injected := errors.New("injected failure")
+	err := s.WithTx(ctx, func(tx Storage) error {
+		job := &Job{ID: "j_tx1", RepoID: repo.ID, Commit: "abc", Status: JobStatusPending, CreatedAt: time.Now()}
+		if err := tx.CreateJob(ctx, job); err != nil {
+			return err
+		}
+		if err := tx.UpdateJobStatus(ctx, job.ID, JobStatusQueued, nil); err != nil {
+			return err
+		}
+		return injected
+	})
+	if !errors.Is(err, injected) {
+		t.Fatalf("WithTx error = %v, want %v", err, injected)
+	}
+	if _, err := s.GetJob(ctx, "j_tx1"); err != ErrNotFound {
+		t.Errorf("GetJob after rollback: err = %v, want ErrNotFound", err)
+	}
+
+	// Nested WithTx joins the outer transaction, so an outer failure rolls back both
+	err = s.WithTx(ctx, func(tx Storage) error {
+		if err := tx.WithTx(ctx, func(inner Storage) error {
+			return inner.CreateJob(ctx, &Job{ID: "j_tx2", RepoID: repo.ID, Commit: "def", Status: JobStatusPending, CreatedAt: time.Now()})
+		}); err != nil {
+			return err
+		}
+		return injected
+	})
+	if !errors.Is(err, injected) {
+		t.Fatalf("nested WithTx error = %v, want %v", err, injected)
+	}
+	if _, err := s.GetJob(ctx, "j_tx2"); err != ErrNotFound {
+		t.Errorf("GetJob after nested rollback: err = %v, want ErrNotFound", err)
+	}
+
+	// Successful transaction commits
+	err = s.WithTx(ctx, func(tx Storage) error {
+		return tx.CreateJob(ctx, &Job{ID: "j_tx3", RepoID: repo.ID, Commit: "ghi", Status: JobStatusPending, CreatedAt: time.Now()})
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+	if _, err := s.GetJob(ctx, "j_tx3"); err != nil {
+		t.Errorf("GetJob after commit failed: %v", err)
+	}
+}
+
 func TestTokenCRUD(t *testing.T) {
 	s := newTestStorage(t)
 	ctx := context.Background()
@@ -380,6 +524,222 @@ func TestJobListPagination(t *testing.T) {
 	if len(jobs) != 2 {
 		t.Errorf("len(jobs) = %d, want 2", len(jobs))
 	}
+
+	// Count ignores limit and offset but applies the filter
+	total, err := s.CountJobs(ctx, JobFilter{RepoID: repo.ID, Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("CountJobs failed: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("CountJobs = %d, want 5", total)
+	}
+	if total, _ := s.CountJobs(ctx, JobFilter{Status: JobStatusSuccess}); total != 0 {
+		t.Errorf("CountJobs(success) = %d, want 0", total)
+	}
+}
+
+func TestJobListTimeWindow(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	repo := &Repo{
+		ID:        "r_test",
+		ForgeType: ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	}
+	if err := s.CreateRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	// One job per day, 0-4 days ago
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		job := &Job{
+			ID:        "j_" + string(rune('a'+i)),
+			RepoID:    repo.ID,
+			Commit:    "abc",
+			Branch:    "main",
+			Status:    JobStatusSuccess,
+			CreatedAt: now.Add(-time.Duration(i) * 24 * time.Hour),
+		}
+		if err := s.CreateJob(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jobs, err := s.ListJobs(ctx, JobFilter{CreatedAfter: now.Add(-36 * time.Hour)})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Errorf("since 36h: len(jobs) = %d, want 2", len(jobs))
+	}
+
+	// Bounds given in UTC still match jobs stored in local time
+	jobs, err = s.ListJobs(ctx, JobFilter{
+		CreatedAfter:  now.Add(-84 * time.Hour).UTC(),
+		CreatedBefore: now.Add(-12 * time.Hour).UTC(),
+	})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs) != 3 || jobs[0].ID != "j_b" || jobs[2].ID != "j_d" {
+		t.Errorf("window: got %d jobs, want j_b..j_d", len(jobs))
+	}
+}
+
+func TestGetJobByCommitRef(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	repo := &Repo{
+		ID:        "r_test",
+		ForgeType: ForgeTypeGitHub,
+		CloneURL:  "https://github.com/test/repo.git",
+		CreatedAt: time.Now(),
+	}
+	if err := s.CreateRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := 7
+	now := time.Now()
+	for _, job := range []*Job{
+		{ID: "j_old", Branch: "main", CreatedAt: now.Add(-time.Hour)},
+		{ID: "j_push", Branch: "main", CreatedAt: now},
+		{ID: "j_pr", Branch: "main", PRNumber: &pr, CreatedAt: now},
+		{ID: "j_tag", Tag: "v1.0.0", CreatedAt: now},
+	} {
+		job.RepoID = repo.ID
+		job.Commit = "abc"
+		job.Status = JobStatusPending
+		if err := s.CreateJob(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for ref, want := range map[string]string{
+		"refs/heads/main":   "j_push",
+		"refs/pull/7/head":  "j_pr",
+		"refs/tags/v1.0.0":  "j_tag",
+		"refs/heads/other":  "",
+		"refs/pull/8/head":  "",
+		"refs/tags/v2.0.0":  "",
+		"refs/unknown/main": "",
+	} {
+		job, err := s.GetJobByCommitRef(ctx, repo.ID, "abc", ref)
+		if want == "" {
+			if err != ErrNotFound {
+				t.Errorf("%s: expected ErrNotFound, got %v", ref, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: GetJobByCommitRef failed: %v", ref, err)
+			continue
+		}
+		if job.ID != want {
+			t.Errorf("%s: got %s, want %s", ref, job.ID, want)
+		}
+	}
+
+	if _, err := s.GetJobByCommitRef(ctx, repo.ID, "def", "refs/heads/main"); err != ErrNotFound {
+		t.Errorf("other commit: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetUserUsage(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, r := range []*Repo{
+		{ID: "r_mine", ForgeType: ForgeTypeGitHub, CloneURL: "https://github.com/me/a.git", OwnerUserID: "u_me", CreatedAt: time.Now()},
+		{ID: "r_other", ForgeType: ForgeTypeGitHub, CloneURL: "https://github.com/them/b.git", OwnerUserID: "u_them", CreatedAt: time.Now()},
+	} {
+		if err := s.CreateRepo(ctx, r); err != nil {
+			t.Fatalf("CreateRepo failed: %v", err)
+		}
+	}
+
+	now := time.Now()
+	for _, j := range []*Job{
+		{ID: "j_old", RepoID: "r_mine", Commit: "a", Status: JobStatusPending, CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "j_new1", RepoID: "r_mine", Commit: "b", Status: JobStatusPending, CreatedAt: now},
+		{ID: "j_new2", RepoID: "r_mine", Commit: "c", Status: JobStatusPending, CreatedAt: now},
+		{ID: "j_theirs", RepoID: "r_other", Commit: "d", Status: JobStatusPending, CreatedAt: now},
+	} {
+		if err := s.CreateJob(ctx, j); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+	}
+
+	usage, err := s.GetUserUsage(ctx, "u_me", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetUserUsage failed: %v", err)
+	}
+	if usage.Repos != 1 {
+		t.Errorf("Repos = %d, want 1", usage.Repos)
+	}
+	if usage.Jobs != 2 {
+		t.Errorf("Jobs = %d, want 2", usage.Jobs)
+	}
+}
+
+func TestJobStats(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, r := range []*Repo{
+		{ID: "r_public", ForgeType: ForgeTypeGitHub, CloneURL: "https://github.com/me/a.git", OwnerUserID: "u_me", CreatedAt: time.Now()},
+		{ID: "r_private", ForgeType: ForgeTypeGitHub, CloneURL: "https://github.com/me/b.git", OwnerUserID: "u_me", Private: true, CreatedAt: time.Now()},
+	} {
+		if err := s.CreateRepo(ctx, r); err != nil {
+			t.Fatalf("CreateRepo failed: %v", err)
+		}
+	}
+
+	now := time.Now()
+	for _, j := range []*Job{
+		{ID: "j_old", RepoID: "r_public", Commit: "a", Status: JobStatusFailed, CreatedAt: now.Add(-3 * 24 * time.Hour)},
+		{ID: "j_new", RepoID: "r_public", Commit: "b", Status: JobStatusPending, CreatedAt: now},
+		{ID: "j_secret", RepoID: "r_private", Commit: "c", Status: JobStatusPending, CreatedAt: now},
+	} {
+		if err := s.CreateJob(ctx, j); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+	}
+	// j_new ran for about 2s
+	started := now.Add(-2 * time.Second)
+	if _, err := s.db.ExecContext(ctx, `UPDATE jobs SET status = ?, started_at = ?, finished_at = ? WHERE id = ?`,
+		JobStatusSuccess, started, now, "j_new"); err != nil {
+		t.Fatal(err)
+	}
+
+	anon, err := s.JobStats(ctx, "", now)
+	if err != nil {
+		t.Fatalf("JobStats failed: %v", err)
+	}
+	if anon.Repos != 1 {
+		t.Errorf("anonymous Repos = %d, want 1", anon.Repos)
+	}
+	if anon.Last24h[JobStatusSuccess] != 1 || anon.Last24h[JobStatusPending] != 0 || anon.Last24h[JobStatusFailed] != 0 {
+		t.Errorf("anonymous Last24h = %v, want only 1 success", anon.Last24h)
+	}
+	if anon.Last7d[JobStatusFailed] != 1 || anon.Last7d[JobStatusSuccess] != 1 {
+		t.Errorf("anonymous Last7d = %v", anon.Last7d)
+	}
+	if d := anon.AvgDuration; d < 1900*time.Millisecond || d > 2100*time.Millisecond {
+		t.Errorf("AvgDuration = %v, want ~2s", d)
+	}
+
+	owner, err := s.JobStats(ctx, "u_me", now)
+	if err != nil {
+		t.Fatalf("JobStats failed: %v", err)
+	}
+	if owner.Repos != 2 || owner.Last24h[JobStatusPending] != 1 {
+		t.Errorf("owner stats = %+v, want private repo included", owner)
+	}
 }
 
 func TestWorkerEmptyLabels(t *testing.T) {
@@ -599,3 +959,122 @@ func TestCanaryValidation(t *testing.T) {
 
 	s1.Close()
 }
+
+func TestWebhookDeliveries(t *testing.T) {
+	s, err := NewSQLite(":memory:", "test-encryption-key", "")
+	if err != nil {
+		t.Fatalf("NewSQLite failed: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	for _, r := range []*Repo{
+		{ID: "r_mine", ForgeType: ForgeTypeGitHub, CloneURL: "https://github.com/me/a.git", OwnerUserID: "u_me", CreatedAt: time.Now()},
+		{ID: "r_other", ForgeType: ForgeTypeGitHub, CloneURL: "https://github.com/you/b.git", OwnerUserID: "u_you", CreatedAt: time.Now()},
+	} {
+		if err := s.CreateRepo(ctx, r); err != nil {
+			t.Fatalf("CreateRepo failed: %v", err)
+		}
+	}
+
+	now := time.Now()
+	for _, d := range []*WebhookDelivery{
+		{ID: "d_old", RepoID: "r_mine", Source: "webhook", Payload: []byte(`{"ref":"old"}`), CreatedAt: now.Add(-time.Hour)},
+		{ID: "d_new", RepoID: "r_mine", Source: "webhook", Headers: map[string]string{"X-Github-Event": "push"}, Payload: []byte(`{"ref":"new"}`), CreatedAt: now},
+		{ID: "d_other", RepoID: "r_other", Source: "webhook", Payload: []byte(`{}`), CreatedAt: now},
+	} {
+		if err := s.CreateWebhookDelivery(ctx, d); err != nil {
+			t.Fatalf("CreateWebhookDelivery failed: %v", err)
+		}
+	}
+
+	// Payload and headers are encrypted at rest
+	var rawPayload, rawHeaders string
+	if err := s.db.QueryRow("SELECT payload, headers FROM webhook_deliveries WHERE id = ?", "d_new").Scan(&rawPayload, &rawHeaders); err != nil {
+		t.Fatalf("raw query failed: %v", err)
+	}
+	if !strings.HasPrefix(rawPayload, "enc:") || !strings.HasPrefix(rawHeaders, "enc:") {
+		t.Errorf("delivery stored unencrypted: payload=%q headers=%q", rawPayload, rawHeaders)
+	}
+
+	if err := s.UpdateWebhookDeliveryResult(ctx, "d_new", 202, "job created", "j_1"); err != nil {
+		t.Fatalf("UpdateWebhookDeliveryResult failed: %v", err)
+	}
+	got, err := s.GetWebhookDelivery(ctx, "d_new")
+	if err != nil {
+		t.Fatalf("GetWebhookDelivery failed: %v", err)
+	}
+	if string(got.Payload) != `{"ref":"new"}` || got.Headers["X-Github-Event"] != "push" {
+		t.Errorf("delivery = %+v, want decrypted payload and headers", got)
+	}
+	if got.Status != 202 || got.Result != "job created" || got.JobID != "j_1" {
+		t.Errorf("result = %d %q %q, want 202 \"job created\" j_1", got.Status, got.Result, got.JobID)
+	}
+
+	list, err := s.ListWebhookDeliveriesByOwner(ctx, "u_me", 10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveriesByOwner failed: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != "d_new" || list[1].ID != "d_old" {
+		t.Fatalf("list = %v, want [d_new d_old]", list)
+	}
+	if list[0].JobID != "j_1" || list[0].Payload != nil {
+		t.Errorf("list entry = %+v, want job ID and no payload", list[0])
+	}
+	if list, _ := s.ListWebhookDeliveriesByOwner(ctx, "u_me", 1); len(list) != 1 {
+		t.Errorf("limit 1 returned %d deliveries", len(list))
+	}
+}
+
+func TestDeviceCodes(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, dc := range []*DeviceCode{
+		{DeviceCode: "dc_live", UserCode: "ABCD-2345", ExpiresAt: now.Add(15 * time.Minute), CreatedAt: now},
+		{DeviceCode: "dc_old", UserCode: "WXYZ-6789", ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-16 * time.Minute)},
+	} {
+		if err := s.CreateDeviceCode(ctx, dc); err != nil {
+			t.Fatalf("CreateDeviceCode failed: %v", err)
+		}
+	}
+
+	got, err := s.GetDeviceCodeByUserCode(ctx, "ABCD-2345")
+	if err != nil {
+		t.Fatalf("GetDeviceCodeByUserCode failed: %v", err)
+	}
+	if got.DeviceCode != "dc_live" || got.Authorized {
+		t.Errorf("got %+v, want unauthorized dc_live", got)
+	}
+	if _, err := s.GetDeviceCodeByUserCode(ctx, "WXYZ-6789"); err != ErrNotFound {
+		t.Errorf("expired user code lookup err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.AuthorizeDeviceCode(ctx, "dc_live", "a@example.com"); err != nil {
+		t.Fatalf("AuthorizeDeviceCode failed: %v", err)
+	}
+	if err := s.AuthorizeDeviceCode(ctx, "dc_missing", "a@example.com"); err != ErrNotFound {
+		t.Errorf("authorize missing code err = %v, want ErrNotFound", err)
+	}
+	got, err = s.GetDeviceCode(ctx, "dc_live")
+	if err != nil {
+		t.Fatalf("GetDeviceCode failed: %v", err)
+	}
+	if !got.Authorized || got.Email != "a@example.com" {
+		t.Errorf("got %+v, want authorized for a@example.com", got)
+	}
+
+	if err := s.DeleteExpiredDeviceCodes(ctx, now); err != nil {
+		t.Fatalf("DeleteExpiredDeviceCodes failed: %v", err)
+	}
+	if _, err := s.GetDeviceCode(ctx, "dc_old"); err != ErrNotFound {
+		t.Errorf("expired code not swept: err = %v", err)
+	}
+	if err := s.DeleteDeviceCode(ctx, "dc_live"); err != nil {
+		t.Fatalf("DeleteDeviceCode failed: %v", err)
+	}
+	if _, err := s.GetDeviceCode(ctx, "dc_live"); err != ErrNotFound {
+		t.Errorf("deleted code still present: err = %v", err)
+	}
+}
@@ -2,21 +2,31 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrDeliveryExists is returned when a webhook delivery ID is already
+	// recorded for a different repo.
+	ErrDeliveryExists = errors.New("delivery ID recorded for another repo")
 )
 
 // Storage defines the interface for all database operations.
 type Storage interface {
 	// Jobs
 	CreateJob(ctx context.Context, job *Job) error
+	ImportJob(ctx context.Context, job *Job) (bool, error) // Insert a job from a backup as-is; false if the ID already exists
 	GetJob(ctx context.Context, id string) (*Job, error)
 	GetJobSiblings(ctx context.Context, repoID, commit, excludeJobID string) ([]*Job, error) // Other jobs for same repo+commit
+	GetJobByCommitRef(ctx context.Context, repoID, commit, ref string) (*Job, error)         // Newest job for repo+commit+git ref
 	ListJobs(ctx context.Context, filter JobFilter) ([]*Job, error)
+	CountJobs(ctx context.Context, filter JobFilter) (int, error)
 	ListJobsByWorker(ctx context.Context, workerID string, limit int) ([]*Job, error)
 	UpdateJobStatus(ctx context.Context, id string, status JobStatus, exitCode *int) error
 	UpdateJobWorker(ctx context.Context, jobID, workerID string) error
@@ -42,7 +52,16 @@ type Storage interface {
 	ListReposByOwner(ctx context.Context, ownerUserID string) ([]*Repo, error)
 	UpdateRepoPrivate(ctx context.Context, id string, private bool) error
 	UpdateRepoHTMLURL(ctx context.Context, id string, htmlURL string) error
+	UpdateRepoBuild(ctx context.Context, id, build, release string) error
+	UpdateRepoCancelInProgress(ctx context.Context, id string, enabled bool) error
+	UpdateRepoMaxParallel(ctx context.Context, id string, limit int) error
+	UpdateRepoInfraRetries(ctx context.Context, id string, retries int) error
+	UpdateRepoPaths(ctx context.Context, id string, paths []string) error
+	UpdateRepoStatusContext(ctx context.Context, id string, template string) error
+	UpdateRepoJobTimeout(ctx context.Context, id string, timeout string) error
 	UpdateRepoSecrets(ctx context.Context, id string, secrets map[string]string) error
+	GetRepoNotifications(ctx context.Context, id string) (*RepoNotifications, error)
+	UpdateRepoNotifications(ctx context.Context, id string, n *RepoNotifications) error
 	DeleteRepo(ctx context.Context, id string) error
 
 	// Tokens
@@ -76,8 +95,18 @@ type Storage interface {
 
 	// Storage quota
 	UpdateJobLogSize(ctx context.Context, jobID string, sizeBytes int64) error
+	UpdateJobArtifactSize(ctx context.Context, jobID string, sizeBytes int64) error
 	UpdateUserStorageUsed(ctx context.Context, userID string, deltaBytes int64) error
 
+	// Log retention
+	ListJobsWithExpiredLogs(ctx context.Context, finishedBefore time.Time, limit int) ([]*ExpiredLog, error)
+	MarkJobLogsPruned(ctx context.Context, jobID string) error
+	DeleteJobsOlderThan(ctx context.Context, cutoff time.Time) (int, error)
+
+	// Usage
+	GetUserUsage(ctx context.Context, userID string, since time.Time) (*UserUsage, error)
+	JobStats(ctx context.Context, viewerUserID string, now time.Time) (*JobStats, error) // Aggregates over repos the viewer can see
+
 	// Billing
 	UpdateUserTier(ctx context.Context, userID string, tier UserTier) error
 
@@ -86,6 +115,7 @@ type Storage interface {
 	GetOrgBilling(ctx context.Context, forgeType ForgeType, forgeOrg string) (*OrgBilling, error)
 	UpdateOrgBillingSeatLimit(ctx context.Context, id string, seatLimit int) error
 	UpdateOrgBillingSeatsUsed(ctx context.Context, id string, seatsUsed int) error
+	UpdateOrgBillingStorageUsed(ctx context.Context, id string, deltaBytes int64) error
 	IsOrgSeat(ctx context.Context, orgBillingID, userID string) (bool, error)
 	AddOrgSeat(ctx context.Context, orgBillingID, userID, forgeUsername string) error
 	ResetOrgSeats(ctx context.Context, orgBillingID string) error // Called at billing period start
@@ -94,10 +124,42 @@ type Storage interface {
 	GetOrCreateRelayID(ctx context.Context, userID string) (string, error)
 	GetRelayByID(ctx context.Context, relayID string) (*Relay, error)
 
+	// Webhook deliveries (for replaying events when debugging)
+	CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	UpdateWebhookDeliveryResult(ctx context.Context, id string, status int, result, jobID string) error
+	ListWebhookDeliveriesByOwner(ctx context.Context, ownerUserID string, limit int) ([]*WebhookDelivery, error)
+	DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error
+
+	// Device codes (pending 'cinch login' device authorizations)
+	CreateDeviceCode(ctx context.Context, dc *DeviceCode) error
+	GetDeviceCode(ctx context.Context, deviceCode string) (*DeviceCode, error)
+	GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*DeviceCode, error)
+	AuthorizeDeviceCode(ctx context.Context, deviceCode, email string) error
+	DeleteDeviceCode(ctx context.Context, deviceCode string) error
+	DeleteExpiredDeviceCodes(ctx context.Context, now time.Time) error
+
+	// Transactions
+	// WithTx runs fn with a Storage bound to a single transaction.
+	// The transaction commits if fn returns nil and rolls back otherwise.
+	// Calling WithTx on a transactional Storage reuses the outer transaction.
+	WithTx(ctx context.Context, fn func(tx Storage) error) error
+
 	// Lifecycle
 	Close() error
 }
 
+// dbtx is the subset of *sql.DB and *sql.Tx used by the SQL backends,
+// so the same query methods run either directly or inside a transaction.
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // JobStatus represents the state of a job.
 type JobStatus string
 
@@ -152,13 +214,59 @@ type Job struct {
 	LogSizeBytes int64 // Size of compressed logs in bytes
 }
 
+// jobMatchesRef reports whether job was built for the git ref: refs/heads/X
+// (a branch push), refs/tags/X, or refs/pull/N/head (PR N).
+func jobMatchesRef(job *Job, ref string) bool {
+	if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+		return job.PRNumber == nil && job.Tag == "" && job.Branch == branch
+	}
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		return job.Tag == tag
+	}
+	if rest, ok := strings.CutPrefix(ref, "refs/pull/"); ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(rest, "/head"))
+		return err == nil && job.PRNumber != nil && *job.PRNumber == n
+	}
+	return false
+}
+
+// ExpiredLog identifies a finished job whose logs are past the retention window.
+type ExpiredLog struct {
+	JobID        string
+	LogSizeBytes int64     // Log and artifact bytes, which are pruned together
+	OwnerUserID  string    // Repo's owning user (empty if unowned)
+	ForgeType    ForgeType // Repo's forge and owner, to find org billing
+	RepoOwner    string
+}
+
+// UserUsage summarizes what a user's repos have consumed since a point in time.
+type UserUsage struct {
+	Repos        int   // Repos owned (not time-bounded)
+	Jobs         int   // Jobs created since the start time
+	BuildSeconds int64 // Run time (started to finished) of those jobs
+}
+
+// JobStats aggregates jobs across the repos a viewer can see: public repos,
+// plus their own private repos when the viewer is signed in.
+type JobStats struct {
+	Repos       int               // Visible repos
+	Last24h     map[JobStatus]int // Jobs created in the last 24h, by status
+	Last7d      map[JobStatus]int // Jobs created in the last 7d, by status
+	AvgDuration time.Duration     // Mean run time of jobs finished in the last 7d
+}
+
 // JobFilter for listing jobs.
 type JobFilter struct {
 	RepoID string
 	Status JobStatus
 	Branch string
+	Commit string // Lowercase hex SHA, or a prefix of one
 	Limit  int
 	Offset int
+
+	// Time window on created_at (zero = unbounded)
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
 }
 
 // WorkerStatus represents the connection state of a worker.
@@ -186,10 +294,12 @@ type Worker struct {
 type ForgeType string
 
 const (
-	ForgeTypeGitHub  ForgeType = "github"
-	ForgeTypeGitLab  ForgeType = "gitlab"
-	ForgeTypeForgejo ForgeType = "forgejo"
-	ForgeTypeGitea   ForgeType = "gitea"
+	ForgeTypeGitHub    ForgeType = "github"
+	ForgeTypeGitLab    ForgeType = "gitlab"
+	ForgeTypeForgejo   ForgeType = "forgejo"
+	ForgeTypeGitea     ForgeType = "gitea"
+	ForgeTypeAzure     ForgeType = "azure"
+	ForgeTypeBitbucket ForgeType = "bitbucket"
 )
 
 // UserTier represents the subscription tier.
@@ -209,6 +319,12 @@ const (
 	// Self-hosted: no quota (return math.MaxInt64 or skip enforcement)
 )
 
+// Concurrent job limits by tier (hosted service only, see Dispatcher.SetTierLimits)
+const (
+	ConcurrencyFree = 1
+	ConcurrencyPro  = 10
+)
+
 // User represents a Cinch user with connected forge credentials.
 type User struct {
 	ID                   string
@@ -235,6 +351,14 @@ func (u *User) StorageQuota() int64 {
 	return StorageQuotaFree
 }
 
+// MaxConcurrentJobs returns how many of this user's jobs may run at once.
+func (u *User) MaxConcurrentJobs() int {
+	if u.Tier == UserTierPro {
+		return ConcurrencyPro
+	}
+	return ConcurrencyFree
+}
+
 // HasPro returns true if user has Pro status (personal subscription or org seat).
 // For MVP, only checks personal tier. Org seat check will be added with team billing.
 func (u *User) HasPro() bool {
@@ -261,8 +385,60 @@ type Repo struct {
 	Workers       []string          // Worker labels for fan-out (e.g., ["linux-amd64", "linux-arm64"])
 	Secrets       map[string]string // Environment secrets injected into jobs (encrypted at rest)
 	Private       bool              // Whether the repo is private
-	OwnerUserID   string            // Cinch user who owns this repo (for authorization)
-	CreatedAt     time.Time
+	// CancelInProgress cancels pending/running jobs for the same branch or PR
+	// when a newer push arrives. Tag pushes are never cancelled.
+	CancelInProgress bool
+	// MaxParallel caps how many of the repo's jobs run at once; extra jobs
+	// stay queued. 0 means unlimited.
+	MaxParallel int
+	// InfraRetries is how many times a job that hits an infrastructure
+	// error (clone failure, lost worker, image pull) is re-queued before it
+	// is marked as error. Build failures are never retried. 0 means off.
+	InfraRetries int
+	// Paths are glob patterns a branch push must touch to build, e.g.
+	// "services/api/**". Patterns starting with "!" exclude files. Empty
+	// means every push builds.
+	Paths []string
+	// StatusContext names the commit status and check run each job posts,
+	// e.g. "cinch/{event}". Empty means "cinch".
+	StatusContext string
+	// JobTimeout is sent to workers as the job timeout (a Go duration such
+	// as "45m"), overriding .cinch.yaml's timeout. Empty leaves it to the
+	// config.
+	JobTimeout  string
+	OwnerUserID string // Cinch user who owns this repo (for authorization)
+	CreatedAt   time.Time
+}
+
+// RepoNotifications configures where a repo's finished jobs are reported.
+// Stored encrypted: chat webhook URLs are credentials.
+type RepoNotifications struct {
+	URLs []string    `json:"urls"`         // Slack, Discord, or generic JSON webhook URLs
+	On   []JobStatus `json:"on,omitempty"` // Statuses that notify; empty means failed and error
+
+	Email bool `json:"email,omitempty"` // Email the commit author when a job fails
+}
+
+// IsZero reports whether no notification setting is set.
+func (n *RepoNotifications) IsZero() bool {
+	return len(n.URLs) == 0 && len(n.On) == 0 && !n.Email
+}
+
+// Wants reports whether a job finishing with status should be reported.
+func (n *RepoNotifications) Wants(status JobStatus) bool {
+	if len(n.URLs) == 0 {
+		return false
+	}
+	if len(n.On) == 0 {
+		return status == JobStatusFailed || status == JobStatusError
+	}
+	return slices.Contains(n.On, status)
+}
+
+// WantsEmail reports whether a job finishing with status should be emailed
+// to its author. Only failures are emailed.
+func (n *RepoNotifications) WantsEmail(status JobStatus) bool {
+	return n.Email && (status == JobStatusFailed || status == JobStatusError)
 }
 
 // Token represents a worker authentication token.
@@ -328,3 +504,27 @@ type Relay struct {
 	UserID    string // Owner of this relay
 	CreatedAt time.Time
 }
+
+// WebhookDelivery is a redacted copy of a received webhook, kept so it can be replayed.
+// Headers and payload are encrypted at rest.
+type WebhookDelivery struct {
+	ID        string            // Forge delivery ID (e.g., X-GitHub-Delivery) or generated
+	RepoID    string            // Repo the delivery was for
+	Source    string            // Handler that received it: "webhook" or "github_app"
+	Headers   map[string]string // Request headers with credentials removed
+	Payload   []byte            // Request body with secret-looking fields redacted
+	Status    int               // HTTP status the handler returned (0 until handled)
+	Result    string            // Handler response summary, e.g. an error message
+	JobID     string            // Job created (or reused) for the delivery, if any
+	CreatedAt time.Time
+}
+
+// DeviceCode is a pending device authorization (the 'cinch login' flow).
+type DeviceCode struct {
+	DeviceCode string    // Secret code the CLI polls with
+	UserCode   string    // Human-readable code the user enters (e.g., "ABCD-2345")
+	ExpiresAt  time.Time // When the code expires
+	Authorized bool      // Whether the user has authorized the device
+	Email      string    // User's email, set when authorized
+	CreatedAt  time.Time
+}
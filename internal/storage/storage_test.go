@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests in this file run against any Storage; the Postgres suite calls them
+// too when TEST_DATABASE_URL is set.
+
+func TestImportJob(t *testing.T) {
+	testImportJob(t, newTestStorage(t))
+}
+
+func testImportJob(t *testing.T, store Storage) {
+	ctx := context.Background()
+
+	repo := &Repo{
+		ID:        "r_import",
+		ForgeType: ForgeTypeGitHub,
+		Owner:     "owner",
+		Name:      "import",
+		CloneURL:  "https://github.com/owner/import.git",
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateRepo(ctx, repo); err != nil {
+		t.Fatalf("CreateRepo: %v", err)
+	}
+
+	exitCode := 2
+	started := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	finished := started.Add(30 * time.Second)
+	job := &Job{
+		ID:         "j_import",
+		RepoID:     repo.ID,
+		Commit:     "abc123",
+		Branch:     "main",
+		Status:     JobStatusFailed,
+		ExitCode:   &exitCode,
+		StartedAt:  &started,
+		FinishedAt: &finished,
+		CreatedAt:  started,
+		Author:     "alice",
+		TrustLevel: TrustOwner,
+	}
+
+	inserted, err := store.ImportJob(ctx, job)
+	if err != nil || !inserted {
+		t.Fatalf("ImportJob = %v, %v; want inserted", inserted, err)
+	}
+	got, err := store.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Status != JobStatusFailed || got.ExitCode == nil || *got.ExitCode != 2 || got.Author != "alice" {
+		t.Errorf("imported job = %+v", got)
+	}
+
+	// Importing the same backup twice is a no-op
+	inserted, err = store.ImportJob(ctx, job)
+	if err != nil || inserted {
+		t.Errorf("second ImportJob = %v, %v; want skipped", inserted, err)
+	}
+}
+
+// TestPostgresInsertArity catches INSERTs whose column and VALUES lists
+// disagree, which Postgres only reports at runtime.
+func TestPostgresInsertArity(t *testing.T) {
+	src, err := os.ReadFile("postgres.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`INSERT INTO (\w+)\s*\(([^)]*)\)\s*VALUES\s*\(([^)]*)\)`)
+	matches := re.FindAllStringSubmatch(string(src), -1)
+	if len(matches) == 0 {
+		t.Fatal("no INSERT statements found")
+	}
+	for _, m := range matches {
+		cols := len(strings.Split(m[2], ","))
+		vals := len(strings.Split(m[3], ","))
+		if cols != vals {
+			t.Errorf("INSERT INTO %s: %d columns, %d values", m[1], cols, vals)
+		}
+	}
+}
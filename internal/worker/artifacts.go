@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ehrlich-b/cinch/internal/protocol"
+)
+
+const (
+	// artifactArchiveName is the name the server stores a job's artifacts under.
+	artifactArchiveName = "artifacts.tar.gz"
+
+	// artifactChunkSize keeps each ARTIFACT_CHUNK (base64-encoded) well under
+	// the server's 1MB message limit.
+	artifactChunkSize = 512 * 1024
+)
+
+// collectArtifacts returns the regular files in workDir matched by the
+// artifact glob patterns, as sorted slash-separated paths relative to
+// workDir. Matched directories are included recursively. Symlinks are
+// skipped so a build can't smuggle out files from outside the checkout.
+func collectArtifacts(workDir string, patterns []string) ([]string, error) {
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(workDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("bad artifact pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				// A symlinked parent directory can still lead outside
+				if resolved, err := filepath.EvalSymlinks(path); err != nil || !isWithin(root, resolved) {
+					return nil
+				}
+				rel, err := filepath.Rel(workDir, path)
+				if err != nil {
+					return err
+				}
+				seen[filepath.ToSlash(rel)] = true
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// writeArtifactArchive writes files (relative to workDir) to w as a tar.gz.
+func writeArtifactArchive(w io.Writer, workDir string, files []string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, name := range files {
+		if err := addArtifactFile(tw, workDir, name); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func addArtifactFile(tw *tar.Writer, workDir, name string) error {
+	f, err := os.Open(filepath.Join(workDir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// uploadArtifacts archives the files matched by patterns and streams the
+// archive to the server. Progress goes to out, which ends up in the job log.
+// Failures are reported but never fail the job.
+func (w *Worker) uploadArtifacts(jobID, workDir string, patterns []string, out io.Writer) {
+	files, err := collectArtifacts(workDir, patterns)
+	if err != nil {
+		fmt.Fprintf(out, "==> artifacts: %v\n", err)
+		return
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(out, "==> artifacts: no files matched\n")
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "cinch-artifacts-*.tar.gz")
+	if err != nil {
+		fmt.Fprintf(out, "==> artifacts: %v\n", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := writeArtifactArchive(tmp, workDir, files); err != nil {
+		fmt.Fprintf(out, "==> artifacts: archive failed: %v\n", err)
+		return
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		fmt.Fprintf(out, "==> artifacts: %v\n", err)
+		return
+	}
+	if size > protocol.MaxArtifactSize {
+		fmt.Fprintf(out, "==> artifacts: archive is %d bytes, over the %d byte limit; not uploading\n", size, protocol.MaxArtifactSize)
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		fmt.Fprintf(out, "==> artifacts: %v\n", err)
+		return
+	}
+
+	buf := make([]byte, artifactChunkSize)
+	for sent := int64(0); ; {
+		n, err := io.ReadFull(tmp, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			fmt.Fprintf(out, "==> artifacts: %v\n", err)
+			return
+		}
+		sent += int64(n)
+		chunk := protocol.ArtifactChunk{
+			JobID: jobID,
+			Name:  artifactArchiveName,
+			Data:  buf[:n],
+			Final: sent >= size,
+		}
+		if err := w.send(protocol.TypeArtifactChunk, chunk); err != nil {
+			fmt.Fprintf(out, "==> artifacts: upload failed: %v\n", err)
+			return
+		}
+		if chunk.Final {
+			break
+		}
+	}
+
+	fmt.Fprintf(out, "==> uploaded %d artifact file(s) (%d bytes)\n", len(files), size)
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"coverage.out", "dist/app", "dist/lib/app.so", "src/main.go"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "dist", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(dir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := collectArtifacts(dir, []string{"dist", "*.out", "coverage.out", "missing/*", "linkdir/*"})
+	if err != nil {
+		t.Fatalf("collectArtifacts failed: %v", err)
+	}
+	want := []string{"coverage.out", "dist/app", "dist/lib/app.so"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeArtifactArchive(&buf, dir, files); err != nil {
+		t.Fatalf("writeArtifactArchive failed: %v", err)
+	}
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var got []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if string(data) != hdr.Name {
+			t.Errorf("%s contents = %q", hdr.Name, data)
+		}
+		got = append(got, hdr.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("archive entries = %v, want %v", got, want)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/ehrlich-b/cinch/internal/protocol"
 )
@@ -16,6 +18,62 @@ type GitCloner struct {
 	// BaseDir is the base directory for clones.
 	// If empty, uses ~/.cinch/work (for Docker mount compatibility).
 	BaseDir string
+
+	// Proxy is passed to git as http.proxy (GIT_PROXY). If empty, git uses
+	// HTTPS_PROXY/HTTP_PROXY from the environment as usual.
+	Proxy string
+
+	// Rewrites map clone URLs to an internal mirror before cloning.
+	Rewrites []CloneURLRewrite
+}
+
+// CloneURLRewrite rewrites clone URLs matching Pattern to Replacement
+// (regexp.ReplaceAllString syntax, so $1 etc. refer to capture groups).
+type CloneURLRewrite struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// ParseCloneURLRewrites parses CINCH_CLONE_URL_REWRITE: semicolon-separated
+// rules of the form "<regex> <replacement>".
+//
+//	^https://github\.com/ https://git-mirror.internal/github/
+func ParseCloneURLRewrites(spec string) ([]CloneURLRewrite, error) {
+	var rewrites []CloneURLRewrite
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		fields := strings.Fields(rule)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid clone URL rewrite %q: want \"<regex> <replacement>\"", rule)
+		}
+		re, err := regexp.Compile(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid clone URL rewrite pattern %q: %w", fields[0], err)
+		}
+		rewrites = append(rewrites, CloneURLRewrite{Pattern: re, Replacement: fields[1]})
+	}
+	return rewrites, nil
+}
+
+// rewriteCloneURL applies the first matching rewrite rule.
+func (c *GitCloner) rewriteCloneURL(cloneURL string) string {
+	for _, rw := range c.Rewrites {
+		if rw.Pattern.MatchString(cloneURL) {
+			return rw.Pattern.ReplaceAllString(cloneURL, rw.Replacement)
+		}
+	}
+	return cloneURL
+}
+
+// gitArgs prefixes a git command with per-invocation config (proxy).
+func (c *GitCloner) gitArgs(args ...string) []string {
+	if c.Proxy == "" {
+		return args
+	}
+	return append([]string{"-c", "http.proxy=" + c.Proxy}, args...)
 }
 
 // Clone clones a repository and checks out the specified commit.
@@ -43,7 +101,7 @@ func (c *GitCloner) Clone(ctx context.Context, repo protocol.JobRepo) (string, e
 	}
 
 	// Build clone URL - use credential helper to avoid token in process list
-	cloneURL := repo.CloneURL
+	cloneURL := c.rewriteCloneURL(repo.CloneURL)
 	var askpassScript string
 	if repo.CloneToken != "" {
 		// Create a temporary askpass script that provides the token
@@ -56,7 +114,7 @@ func (c *GitCloner) Clone(ctx context.Context, repo protocol.JobRepo) (string, e
 		defer os.Remove(askpassScript)
 
 		// Add username to URL (password will come from askpass script)
-		cloneURL, err = injectUsername(repo.CloneURL)
+		cloneURL, err = injectUsername(cloneURL)
 		if err != nil {
 			os.RemoveAll(workDir)
 			return "", fmt.Errorf("inject username: %w", err)
@@ -69,7 +127,7 @@ func (c *GitCloner) Clone(ctx context.Context, repo protocol.JobRepo) (string, e
 	if repo.Tag != "" {
 		refToClone = repo.Tag
 	}
-	args := []string{"clone", "--depth=1", "--branch", refToClone, cloneURL, workDir}
+	args := c.gitArgs("clone", "--depth=1", "--branch", refToClone, cloneURL, workDir)
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Env = append(os.Environ(),
 		"GIT_TERMINAL_PROMPT=0", // Don't prompt for credentials
@@ -91,7 +149,7 @@ func (c *GitCloner) Clone(ctx context.Context, repo protocol.JobRepo) (string, e
 
 		if err := checkoutCmd.Run(); err != nil {
 			// Commit not in shallow clone, need to fetch it
-			fetchCmd := exec.CommandContext(ctx, "git", "fetch", "--depth=1", "origin", repo.Commit)
+			fetchCmd := exec.CommandContext(ctx, "git", c.gitArgs("fetch", "--depth=1", "origin", repo.Commit)...)
 			fetchCmd.Dir = workDir
 			if output, err := fetchCmd.CombinedOutput(); err != nil {
 				os.RemoveAll(workDir)
@@ -162,8 +220,13 @@ func injectUsername(cloneURL string) (string, error) {
 		return "", err
 	}
 
-	// Set just the username - password will be provided by GIT_ASKPASS
-	u.User = url.User("x-access-token")
+	// Set just the username - password will be provided by GIT_ASKPASS.
+	// Bitbucket access tokens only work with their own fixed username.
+	username := "x-access-token"
+	if u.Host == "bitbucket.org" {
+		username = "x-token-auth"
+	}
+	u.User = url.User(username)
 
 	return u.String(), nil
 }
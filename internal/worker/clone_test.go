@@ -26,6 +26,11 @@ func TestInjectUsername(t *testing.T) {
 			url:      "https://git.example.com/user/repo.git",
 			expected: "https://x-access-token@git.example.com/user/repo.git",
 		},
+		{
+			name:     "bitbucket",
+			url:      "https://bitbucket.org/workspace/repo.git",
+			expected: "https://x-token-auth@bitbucket.org/workspace/repo.git",
+		},
 	}
 
 	for _, tt := range tests {
@@ -189,3 +194,70 @@ func TestCloneInvalidURL(t *testing.T) {
 		t.Error("expected error for invalid repo")
 	}
 }
+
+func TestParseCloneURLRewrites(t *testing.T) {
+	rewrites, err := ParseCloneURLRewrites(`^https://github\.com/ https://mirror.internal/github/; ^https://gitlab\.com/(.*)$ https://mirror.internal/gitlab/$1`)
+	if err != nil {
+		t.Fatalf("ParseCloneURLRewrites failed: %v", err)
+	}
+	cloner := &GitCloner{Rewrites: rewrites}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/user/repo.git", "https://mirror.internal/github/user/repo.git"},
+		{"https://gitlab.com/group/repo.git", "https://mirror.internal/gitlab/group/repo.git"},
+		{"https://codeberg.org/user/repo.git", "https://codeberg.org/user/repo.git"},
+	}
+	for _, tt := range tests {
+		if got := cloner.rewriteCloneURL(tt.url); got != tt.want {
+			t.Errorf("rewriteCloneURL(%s) = %s, want %s", tt.url, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"no-replacement", "([ https://x/"} {
+		if _, err := ParseCloneURLRewrites(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCloneUsesRewrittenURL(t *testing.T) {
+	if err := EnsureGit(); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+
+	// Local repo standing in for the internal mirror
+	srcDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"checkout", "-b", "main"},
+		{"-c", "user.email=test@test.com", "-c", "user.name=Test", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = srcDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	rewrites, err := ParseCloneURLRewrites(`^https://github\.com/test/repo\.git$ ` + srcDir)
+	if err != nil {
+		t.Fatalf("ParseCloneURLRewrites failed: %v", err)
+	}
+	cloner := &GitCloner{BaseDir: t.TempDir(), Rewrites: rewrites}
+
+	workDir, err := cloner.Clone(context.Background(), protocol.JobRepo{
+		CloneURL: "https://github.com/test/repo.git",
+		Branch:   "main",
+	})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if _, err := os.Stat(filepath.Join(workDir, ".git")); err != nil {
+		t.Error("expected clone from rewritten URL")
+	}
+}
@@ -3,6 +3,7 @@ package container
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,10 @@ import (
 // DefaultImage is used when no devcontainer or Dockerfile is found.
 const DefaultImage = "ubuntu:22.04"
 
+// ErrPull is wrapped by PrepareImage errors from pulling an image, as
+// opposed to building one.
+var ErrPull = errors.New("pull image")
+
 // ImageSource describes where the container image comes from.
 type ImageSource struct {
 	// Type is "image", "dockerfile", "devcontainer", or "bare-metal"
@@ -27,6 +32,9 @@ type ImageSource struct {
 
 	// Context directory for docker build
 	Context string
+
+	// DevcontainerJSON is the devcontainer.json path (for Type="devcontainer")
+	DevcontainerJSON string
 }
 
 // ResolveContainer figures out what container image to use based on config.
@@ -149,8 +157,9 @@ func parseDevcontainer(jsonPath, repoDir string) (*ImageSource, error) {
 	// Direct image reference
 	if config.Image != "" {
 		return &ImageSource{
-			Type:  "devcontainer",
-			Image: config.Image,
+			Type:             "devcontainer",
+			Image:            config.Image,
+			DevcontainerJSON: jsonPath,
 		}, nil
 	}
 
@@ -161,18 +170,20 @@ func parseDevcontainer(jsonPath, repoDir string) (*ImageSource, error) {
 			context = filepath.Join(devcontainerDir, config.Build.Context)
 		}
 		return &ImageSource{
-			Type:       "devcontainer",
-			Dockerfile: filepath.Join(devcontainerDir, config.Build.Dockerfile),
-			Context:    context,
+			Type:             "devcontainer",
+			Dockerfile:       filepath.Join(devcontainerDir, config.Build.Dockerfile),
+			Context:          context,
+			DevcontainerJSON: jsonPath,
 		}, nil
 	}
 
 	// Legacy dockerFile field
 	if config.DockerFile != "" {
 		return &ImageSource{
-			Type:       "devcontainer",
-			Dockerfile: filepath.Join(devcontainerDir, config.DockerFile),
-			Context:    devcontainerDir,
+			Type:             "devcontainer",
+			Dockerfile:       filepath.Join(devcontainerDir, config.DockerFile),
+			Context:          devcontainerDir,
+			DevcontainerJSON: jsonPath,
 		}, nil
 	}
 
@@ -180,9 +191,10 @@ func parseDevcontainer(jsonPath, repoDir string) (*ImageSource, error) {
 	devcontainerDockerfile := filepath.Join(devcontainerDir, "Dockerfile")
 	if _, err := os.Stat(devcontainerDockerfile); err == nil {
 		return &ImageSource{
-			Type:       "devcontainer",
-			Dockerfile: devcontainerDockerfile,
-			Context:    devcontainerDir,
+			Type:             "devcontainer",
+			Dockerfile:       devcontainerDockerfile,
+			Context:          devcontainerDir,
+			DevcontainerJSON: jsonPath,
 		}, nil
 	}
 
@@ -200,7 +212,7 @@ func PrepareImage(ctx context.Context, source *ImageSource, jobID string, stdout
 		fmt.Fprintf(stdout, "$ %s\n", cmd)
 		d := &Docker{Image: source.Image, Stdout: stdout, Stderr: stderr}
 		if err := d.Pull(ctx); err != nil {
-			return "", fmt.Errorf("pull image: %w", err)
+			return "", fmt.Errorf("%w: %w", ErrPull, err)
 		}
 		return source.Image, nil
 
@@ -210,7 +222,7 @@ func PrepareImage(ctx context.Context, source *ImageSource, jobID string, stdout
 			fmt.Fprintf(stdout, "$ docker pull %s\n", source.Image)
 			d := &Docker{Image: source.Image, Stdout: stdout, Stderr: stderr}
 			if err := d.Pull(ctx); err != nil {
-				return "", fmt.Errorf("pull image: %w", err)
+				return "", fmt.Errorf("%w: %w", ErrPull, err)
 			}
 			return source.Image, nil
 		}
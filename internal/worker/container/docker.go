@@ -6,8 +6,10 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 )
 
@@ -17,6 +19,10 @@ type Docker struct {
 	// WorkDir on host to mount as /workspace
 	WorkDir string
 
+	// Dir is the directory inside WorkDir to run commands in, e.g. a
+	// monorepo subproject. Default: WorkDir itself.
+	Dir string
+
 	// Image to run (e.g., "ubuntu:22.04")
 	Image string
 
@@ -26,6 +32,15 @@ type Docker struct {
 	// Network to join (for service containers)
 	Network string
 
+	// Name for the container. When set, the container is force-removed if
+	// ctx is cancelled (killing the docker CLI alone leaves it running).
+	Name string
+
+	// KeepOnFailure leaves the container running when the command exits
+	// non-zero, so it can be inspected with docker exec. Requires Name; the
+	// caller must RemoveContainer it when done.
+	KeepOnFailure bool
+
 	// CacheVolumes maps volume names to container paths
 	// e.g., {"cinch-npm": "/root/.npm"}
 	CacheVolumes map[string]string
@@ -38,16 +53,71 @@ type Docker struct {
 // Run executes a command inside a container.
 // Returns the exit code.
 func (d *Docker) Run(ctx context.Context, command string) (int, error) {
-	args := []string{"run", "--rm", "--platform", "linux/" + runtime.GOARCH}
+	if d.KeepOnFailure {
+		return d.runKeepable(ctx, command)
+	}
+
+	args, err := d.runArgs("--rm")
+	if err != nil {
+		return 1, err
+	}
+	args = append(args, d.Image, "sh", "-c", command)
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = d.Stdout
+	cmd.Stderr = d.Stderr
+	return d.wait(ctx, cmd)
+}
+
+// runKeepable starts the container idle and runs command in it with docker
+// exec, so the container is still running if the command fails.
+func (d *Docker) runKeepable(ctx context.Context, command string) (int, error) {
+	if d.Name == "" {
+		return 1, fmt.Errorf("keep on failure requires a container name")
+	}
+
+	args, err := d.runArgs("-d")
+	if err != nil {
+		return 1, err
+	}
+	// tail is in every image that has sh, unlike sleep infinity
+	args = append(args, d.Image, "tail", "-f", "/dev/null")
+
+	if out, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput(); err != nil {
+		_ = RemoveContainer(context.Background(), d.Name)
+		if ctx.Err() != nil {
+			return 137, nil
+		}
+		return 1, fmt.Errorf("start container: %s", strings.TrimSpace(string(out)))
+	}
+
+	cmd := exec.Command("docker", "exec", d.Name, "sh", "-c", command)
+	cmd.Stdout = d.Stdout
+	cmd.Stderr = d.Stderr
+	code, err := d.wait(ctx, cmd)
+	if err != nil || code == 0 || ctx.Err() != nil {
+		_ = RemoveContainer(context.Background(), d.Name)
+	}
+	return code, err
+}
+
+// runArgs returns the docker run arguments up to the image, with mode
+// ("--rm" or "-d") after "run".
+func (d *Docker) runArgs(mode string) ([]string, error) {
+	args := []string{"run", mode, "--platform", "linux/" + runtime.GOARCH}
+
+	if d.Name != "" {
+		args = append(args, "--name", d.Name)
+	}
 
 	// Mount workspace
 	if d.WorkDir != "" {
 		absPath, err := filepath.Abs(d.WorkDir)
 		if err != nil {
-			return 1, fmt.Errorf("resolve workdir: %w", err)
+			return nil, fmt.Errorf("resolve workdir: %w", err)
 		}
 		args = append(args, "-v", absPath+":/workspace")
-		args = append(args, "-w", "/workspace")
+		args = append(args, "-w", path.Join("/workspace", filepath.ToSlash(d.Dir)))
 	}
 
 	// Inject cinch binary into container
@@ -71,16 +141,38 @@ func (d *Docker) Run(ctx context.Context, command string) (int, error) {
 	if d.Network != "" {
 		args = append(args, "--network", d.Network)
 	}
+	return args, nil
+}
 
-	// Image and command
-	args = append(args, d.Image, "sh", "-c", command)
+// wait runs cmd until it exits or ctx is cancelled, in which case the
+// container is removed and 137 returned.
+func (d *Docker) wait(ctx context.Context, cmd *exec.Cmd) (int, error) {
+	if err := cmd.Start(); err != nil {
+		return 1, err
+	}
 
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	cmd.Stdout = d.Stdout
-	cmd.Stderr = d.Stderr
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return exitCode(err), nil
+	case <-ctx.Done():
+		// Remove the container itself, then the CLI process
+		if d.Name != "" {
+			_ = RemoveContainer(context.Background(), d.Name)
+		}
+		_ = cmd.Process.Kill()
+		<-done
+		return 137, nil // 128 + 9 (SIGKILL)
+	}
+}
 
-	err := cmd.Run()
-	return exitCode(err), nil
+// AttachCommand returns the command to open a shell in a running container.
+func AttachCommand(name string) string {
+	return "docker exec -it " + name + " sh"
 }
 
 // Pull fetches an image if not present locally.
@@ -221,6 +313,17 @@ func StopService(ctx context.Context, containerID string) error {
 	return cmd.Run()
 }
 
+// RemoveContainer force-removes a container, killing it if still running.
+// A container that no longer exists is not an error.
+func RemoveContainer(ctx context.Context, nameOrID string) error {
+	cmd := exec.CommandContext(ctx, "docker", "rm", "-f", nameOrID)
+	out, err := cmd.CombinedOutput()
+	if err != nil && !strings.Contains(string(out), "No such container") {
+		return fmt.Errorf("docker rm failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 // ExecInContainer runs a command inside a running container.
 // Returns the exit code.
 func ExecInContainer(ctx context.Context, containerID, command string) (int, error) {
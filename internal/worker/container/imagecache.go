@@ -0,0 +1,290 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ImageCache remembers which locally built image was made from which build
+// inputs, so an unchanged Dockerfile/devcontainer reuses the image instead
+// of rebuilding it. Entries map a hash of the inputs (see BuildInputsHash)
+// to an image tag, stored as JSON.
+type ImageCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewImageCache creates an image cache backed by the file at path.
+func NewImageCache(path string) *ImageCache {
+	return &ImageCache{path: path}
+}
+
+// DefaultImageCachePath returns the default cache file path.
+func DefaultImageCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cinch", "image-cache.json")
+}
+
+// Lookup returns the image tag built from the inputs with the given hash.
+func (c *ImageCache) Lookup(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, _ := c.load()
+	tag, ok := entries[key]
+	return tag, ok
+}
+
+// Store records that tag was built from the inputs with the given hash.
+func (c *ImageCache) Store(key, tag string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		// A corrupt cache file only costs a rebuild; start over
+		entries = make(map[string]string)
+	}
+	entries[key] = tag
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write image cache: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func (c *ImageCache) load() (map[string]string, error) {
+	entries := make(map[string]string)
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return make(map[string]string), err
+	}
+	return entries, nil
+}
+
+// NeedsBuild reports whether the source is built from a Dockerfile.
+func (s *ImageSource) NeedsBuild() bool {
+	return (s.Type == "dockerfile" || s.Type == "devcontainer") && s.Dockerfile != ""
+}
+
+// BuildInputsHash hashes everything that determines a built image: the
+// Dockerfile, devcontainer.json, and the files the Dockerfile COPYs or ADDs
+// from the build context. Other changes to the context (e.g. source code
+// that isn't copied in) don't change the hash.
+func BuildInputsHash(source *ImageSource) (string, error) {
+	h := sha256.New()
+
+	dockerfile, err := os.ReadFile(source.Dockerfile)
+	if err != nil {
+		return "", fmt.Errorf("read dockerfile: %w", err)
+	}
+	fmt.Fprintf(h, "dockerfile\x00%d\x00", len(dockerfile))
+	h.Write(dockerfile)
+
+	if source.DevcontainerJSON != "" {
+		data, err := os.ReadFile(source.DevcontainerJSON)
+		if err != nil {
+			return "", fmt.Errorf("read devcontainer.json: %w", err)
+		}
+		fmt.Fprintf(h, "devcontainer\x00%d\x00", len(data))
+		h.Write(data)
+	}
+
+	files, err := copySources(source.Context, string(dockerfile))
+	if err != nil {
+		return "", err
+	}
+	for _, rel := range files {
+		if err := hashFile(h, source.Context, rel); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h io.Writer, dir, rel string) error {
+	f, err := os.Open(filepath.Join(dir, rel))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "file\x00%s\x00%o\x00%d\x00", filepath.ToSlash(rel), info.Mode().Perm(), info.Size())
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// copySources returns the context files (relative, sorted) that the
+// Dockerfile's COPY and ADD instructions read. Copies from other build
+// stages and remote ADD URLs are skipped.
+func copySources(contextDir, dockerfile string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, inst := range dockerInstructions(dockerfile) {
+		fields := strings.Fields(inst)
+		if len(fields) < 2 {
+			continue
+		}
+		op := strings.ToUpper(fields[0])
+		if op != "COPY" && op != "ADD" {
+			continue
+		}
+
+		args, fromStage := copyArgs(strings.TrimSpace(inst[len(fields[0]):]))
+		if fromStage || len(args) < 2 {
+			continue
+		}
+		for _, src := range args[:len(args)-1] {
+			if strings.Contains(src, "://") {
+				continue
+			}
+			matches, err := filepath.Glob(filepath.Join(contextDir, filepath.FromSlash(src)))
+			if err != nil {
+				return nil, fmt.Errorf("bad COPY source %q: %w", src, err)
+			}
+			for _, match := range matches {
+				err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+					if err != nil {
+						return err
+					}
+					if !d.Type().IsRegular() {
+						return nil
+					}
+					rel, err := filepath.Rel(contextDir, path)
+					if err != nil {
+						return err
+					}
+					seen[rel] = true
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// copyArgs splits the arguments of a COPY/ADD instruction (shell or JSON
+// form) and drops --flags. fromStage is true for --from copies, which read
+// from another image rather than the build context.
+func copyArgs(rest string) (args []string, fromStage bool) {
+	fields := strings.Fields(rest)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		if strings.HasPrefix(fields[0], "--from=") {
+			fromStage = true
+		}
+		fields = fields[1:]
+	}
+
+	joined := strings.Join(fields, " ")
+	if strings.HasPrefix(joined, "[") {
+		var jsonArgs []string
+		if err := json.Unmarshal([]byte(joined), &jsonArgs); err == nil {
+			return jsonArgs, fromStage
+		}
+	}
+	return fields, fromStage
+}
+
+// dockerInstructions splits a Dockerfile into instructions, joining
+// backslash-continued lines and dropping comments.
+func dockerInstructions(dockerfile string) []string {
+	var insts []string
+	var cur strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(dockerfile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			cur.WriteString(strings.TrimSuffix(line, "\\"))
+			cur.WriteString(" ")
+			continue
+		}
+		cur.WriteString(line)
+		if inst := strings.TrimSpace(cur.String()); inst != "" {
+			insts = append(insts, inst)
+		}
+		cur.Reset()
+	}
+	if inst := strings.TrimSpace(cur.String()); inst != "" {
+		insts = append(insts, inst)
+	}
+	return insts
+}
+
+// imageExists reports whether a local image with the given tag exists.
+func imageExists(ctx context.Context, tag string) bool {
+	return exec.CommandContext(ctx, "docker", "image", "inspect", tag).Run() == nil
+}
+
+// PrepareCachedImage is PrepareImage with an image cache: images built from
+// a Dockerfile get a tag derived from their build inputs, and a later call
+// with unchanged inputs reuses the image instead of rebuilding it. A nil
+// cache behaves exactly like PrepareImage.
+func PrepareCachedImage(ctx context.Context, source *ImageSource, jobID string, cache *ImageCache, stdout, stderr io.Writer) (string, error) {
+	if cache == nil || !source.NeedsBuild() {
+		return PrepareImage(ctx, source, jobID, stdout, stderr)
+	}
+
+	key, err := BuildInputsHash(source)
+	if err != nil {
+		return "", err
+	}
+	if tag, ok := cache.Lookup(key); ok && imageExists(ctx, tag) {
+		fmt.Fprintf(stdout, "Using cached image %s (build inputs unchanged)\n", tag)
+		return tag, nil
+	}
+
+	tag := "cinch-cache-" + key[:16]
+	fmt.Fprintf(stdout, "$ docker build -f %s -t %s %s\n", source.Dockerfile, tag, source.Context)
+	if err := Build(ctx, source.Dockerfile, source.Context, tag, stdout, stderr); err != nil {
+		return "", fmt.Errorf("build image: %w", err)
+	}
+	if err := cache.Store(key, tag); err != nil {
+		fmt.Fprintf(stderr, "Warning: failed to update image cache: %v\n", err)
+	}
+	return tag, nil
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestImageCacheLookupStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "image-cache.json")
+	cache := NewImageCache(path)
+
+	if _, ok := cache.Lookup("abc"); ok {
+		t.Fatal("empty cache should miss")
+	}
+	if err := cache.Store("abc", "cinch-cache-abc"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	// A fresh cache reads the same file
+	tag, ok := NewImageCache(path).Lookup("abc")
+	if !ok || tag != "cinch-cache-abc" {
+		t.Errorf("Lookup = %q, %v; want cinch-cache-abc, true", tag, ok)
+	}
+
+	// A corrupt file is a miss, and is replaced on the next store
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup("abc"); ok {
+		t.Error("corrupt cache should miss")
+	}
+	if err := cache.Store("def", "cinch-cache-def"); err != nil {
+		t.Fatalf("Store over corrupt file failed: %v", err)
+	}
+	if _, ok := cache.Lookup("def"); !ok {
+		t.Error("Lookup after recovering store should hit")
+	}
+}
+
+func TestBuildInputsHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Dockerfile", "FROM golang:1.24\nCOPY go.mod \\\n  go.sum /src/\nCOPY --from=builder /app /app\nRUN go mod download\n")
+	write("go.mod", "module x")
+	write("go.sum", "")
+	write("main.go", "package main")
+
+	source := &ImageSource{Type: "dockerfile", Dockerfile: filepath.Join(dir, "Dockerfile"), Context: dir}
+	hash := func() string {
+		t.Helper()
+		h, err := BuildInputsHash(source)
+		if err != nil {
+			t.Fatalf("BuildInputsHash failed: %v", err)
+		}
+		return h
+	}
+
+	base := hash()
+	if hash() != base {
+		t.Fatal("hash is not stable")
+	}
+
+	// Files the Dockerfile doesn't copy don't matter
+	write("main.go", "package main // changed")
+	if hash() != base {
+		t.Error("hash changed for a file outside the COPY sources")
+	}
+
+	// Copied files do
+	write("go.mod", "module y")
+	changed := hash()
+	if changed == base {
+		t.Error("hash unchanged after editing a copied file")
+	}
+
+	write("Dockerfile", "FROM golang:1.25\nCOPY go.mod go.sum /src/\n")
+	if hash() == changed {
+		t.Error("hash unchanged after editing the Dockerfile")
+	}
+}
+
+func TestCopySources(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "conf/app.yaml", "conf/db.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dockerfile := `# COPY ignored.txt /
+FROM alpine
+copy *.txt /data/
+ADD --chown=app ["conf", "/etc/app/"]
+ADD https://example.com/file.tgz /tmp/
+COPY --from=build /out /out
+`
+	files, err := copySources(dir, dockerfile)
+	if err != nil {
+		t.Fatalf("copySources failed: %v", err)
+	}
+	want := []string{"a.txt", "b.txt", filepath.Join("conf", "app.yaml"), filepath.Join("conf", "db.yaml")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -77,8 +79,13 @@ func (m *ServiceManager) startService(ctx context.Context, name string, svc conf
 		return fmt.Errorf("pull image: %w", err)
 	}
 
-	// Start container
+	// Track by name before starting, so a cancel mid-start still cleans up
 	containerName := fmt.Sprintf("cinch-%s-%s", m.JobID, name)
+	m.mu.Lock()
+	m.containers = append(m.containers, containerName)
+	m.mu.Unlock()
+
+	// Start container
 	containerID, err := StartService(ctx, ServiceConfig{
 		Name:        containerName,
 		Image:       svc.Image,
@@ -91,16 +98,11 @@ func (m *ServiceManager) startService(ctx context.Context, name string, svc conf
 		return fmt.Errorf("start container: %w", err)
 	}
 
-	// Track for cleanup
-	m.mu.Lock()
-	m.containers = append(m.containers, containerID)
-	m.mu.Unlock()
-
 	// Wait for healthy
 	if svc.Healthcheck != nil {
 		fmt.Fprintf(m.Stdout, "Waiting for %s to be healthy...\n", name)
 		if err := m.waitHealthy(ctx, containerID, svc.Healthcheck); err != nil {
-			return fmt.Errorf("healthcheck: %w", err)
+			return err
 		}
 		fmt.Fprintf(m.Stdout, "Service %s is ready\n", name)
 	} else {
@@ -112,12 +114,21 @@ func (m *ServiceManager) startService(ctx context.Context, name string, svc conf
 	return nil
 }
 
+// waitHealthy polls the service's health checks until they all pass.
 func (m *ServiceManager) waitHealthy(ctx context.Context, containerID string, hc *config.Healthcheck) error {
 	timeout := hc.Timeout.Duration()
 	if timeout == 0 {
 		timeout = 60 * time.Second
 	}
 
+	var checks []string
+	if hc.Cmd != "" {
+		checks = append(checks, hc.Cmd)
+	}
+	if hc.Port != 0 {
+		checks = append(checks, tcpListenCheck(hc.Port))
+	}
+
 	deadline := time.Now().Add(timeout)
 	interval := 2 * time.Second
 
@@ -129,11 +140,18 @@ func (m *ServiceManager) waitHealthy(ctx context.Context, containerID string, hc
 		}
 
 		if time.Now().After(deadline) {
-			return fmt.Errorf("timeout after %s", timeout)
+			return fmt.Errorf("not healthy after %s (%s)", timeout, describeHealthcheck(hc))
 		}
 
-		exitCode, err := ExecInContainer(ctx, containerID, hc.Cmd)
-		if err == nil && exitCode == 0 {
+		healthy := true
+		for _, check := range checks {
+			exitCode, err := ExecInContainer(ctx, containerID, check)
+			if err != nil || exitCode != 0 {
+				healthy = false
+				break
+			}
+		}
+		if healthy {
 			return nil
 		}
 
@@ -141,6 +159,48 @@ func (m *ServiceManager) waitHealthy(ctx context.Context, containerID string, hc
 	}
 }
 
+// tcpListenCheck returns a shell command that succeeds once something in the
+// container listens on port. It reads /proc/net/tcp{,6} (LISTEN is state 0A)
+// rather than connecting, so the image needn't ship nc or bash.
+func tcpListenCheck(port int) string {
+	return fmt.Sprintf("grep -qE ':%04X [0-9A-F]+:0000 0A' /proc/net/tcp /proc/net/tcp6 2>/dev/null", port)
+}
+
+func describeHealthcheck(hc *config.Healthcheck) string {
+	var parts []string
+	if hc.Cmd != "" {
+		parts = append(parts, fmt.Sprintf("cmd %q", hc.Cmd))
+	}
+	if hc.Port != 0 {
+		parts = append(parts, fmt.Sprintf("port %d", hc.Port))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ServiceEnv returns the env vars telling the build where its services are:
+// CINCH_SERVICE_<NAME>_HOST for each, plus CINCH_SERVICE_<NAME>_PORT for
+// services with a health check port. <NAME> is the service name upper-cased,
+// with anything but letters and digits turned into underscores.
+func ServiceEnv(services map[string]config.Service) map[string]string {
+	env := make(map[string]string, len(services))
+	for name, svc := range services {
+		key := strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			}
+			return '_'
+		}, name)
+		env["CINCH_SERVICE_"+key+"_HOST"] = name
+		if hc := svc.Healthcheck; hc != nil && hc.Port != 0 {
+			env["CINCH_SERVICE_"+key+"_PORT"] = strconv.Itoa(hc.Port)
+		}
+	}
+	return env
+}
+
 // Cleanup stops all service containers and removes the network.
 // Always attempts to clean up everything, even on errors.
 func (m *ServiceManager) Cleanup(ctx context.Context) {
@@ -150,11 +210,14 @@ func (m *ServiceManager) Cleanup(ctx context.Context) {
 
 	fmt.Fprintf(m.Stdout, "\nCleaning up services...\n")
 
-	// Stop containers (use background context to ensure cleanup happens)
+	// Remove containers (use background context to ensure cleanup happens)
 	cleanupCtx := context.Background()
-	for _, containerID := range m.containers {
-		if err := StopService(cleanupCtx, containerID); err != nil {
-			fmt.Fprintf(m.Stderr, "Warning: failed to stop container %s: %v\n", containerID[:12], err)
+	m.mu.Lock()
+	containers := m.containers
+	m.mu.Unlock()
+	for _, name := range containers {
+		if err := RemoveContainer(cleanupCtx, name); err != nil {
+			fmt.Fprintf(m.Stderr, "Warning: failed to remove container %s: %v\n", name, err)
 		}
 	}
 
@@ -0,0 +1,36 @@
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ehrlich-b/cinch/internal/config"
+)
+
+func TestServiceEnv(t *testing.T) {
+	env := ServiceEnv(map[string]config.Service{
+		"postgres": {Image: "postgres:16", Healthcheck: &config.Healthcheck{Port: 5432}},
+		"my-redis": {Image: "redis:7"},
+	})
+
+	want := map[string]string{
+		"CINCH_SERVICE_POSTGRES_HOST": "postgres",
+		"CINCH_SERVICE_POSTGRES_PORT": "5432",
+		"CINCH_SERVICE_MY_REDIS_HOST": "my-redis",
+	}
+	if len(env) != len(want) {
+		t.Errorf("got %d vars, want %d: %v", len(env), len(want), env)
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+}
+
+func TestTCPListenCheck(t *testing.T) {
+	// 5432 is 0x1538 in /proc/net/tcp's local_address column
+	if cmd := tcpListenCheck(5432); !strings.Contains(cmd, ":1538 ") {
+		t.Errorf("tcpListenCheck(5432) = %q, want port 1538 in hex", cmd)
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/config"
+	"github.com/ehrlich-b/cinch/internal/worker/container"
+)
+
+// debugHold is a failed build's container, services and workspace, kept
+// running for inspection (debug_on_failure in .cinch.yaml).
+type debugHold struct {
+	container string
+	services  *container.ServiceManager // nil if the build had none
+	workDir   string
+}
+
+// debugWindow returns how long to keep a failed build's container, capped
+// at config.MaxDebugOnFailure. Zero means don't keep it.
+func debugWindow(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.DebugOnFailure <= 0 {
+		return 0
+	}
+	return min(cfg.DebugOnFailure.Duration(), config.MaxDebugOnFailure)
+}
+
+// printDebugHint tells the job log how to attach to a kept container.
+func printDebugHint(w io.Writer, h *debugHold, hostname string, window time.Duration) {
+	fmt.Fprintf(w, "\n==> debug-on-failure: container %s kept on worker %s for %s\n", h.container, hostname, window)
+	fmt.Fprintf(w, "==> attach on the worker host with: %s\n", container.AttachCommand(h.container))
+}
+
+// holdForDebug keeps h until window passes or the worker stops, then removes
+// the container, services and workspace.
+func (w *Worker) holdForDebug(jobID string, h *debugHold, window time.Duration) {
+	w.log.Info("keeping failed job container for debugging", "job_id", jobID, "container", h.container, "window", window)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		timer := time.NewTimer(window)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-w.ctx.Done():
+		}
+
+		if err := container.RemoveContainer(context.Background(), h.container); err != nil {
+			w.log.Warn("failed to remove debug container", "job_id", jobID, "container", h.container, "error", err)
+		}
+		if h.services != nil {
+			// The job's log stream is closed; don't write cleanup output to it
+			h.services.Stdout = io.Discard
+			h.services.Stderr = io.Discard
+			h.services.Cleanup(context.Background())
+		}
+		_ = os.RemoveAll(h.workDir)
+		w.log.Info("removed debug container", "job_id", jobID, "container", h.container)
+	}()
+}
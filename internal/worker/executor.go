@@ -47,6 +47,8 @@ func (e *Executor) Run(ctx context.Context, command string) (int, error) {
 
 	select {
 	case err := <-done:
+		// Reap anything the command left running in the background
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 		return exitCode(err), nil
 	case <-ctx.Done():
 		// Kill the entire process group (negative PID)
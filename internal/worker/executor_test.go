@@ -140,6 +140,40 @@ func TestExecutorContextCancel(t *testing.T) {
 	}
 }
 
+func TestExecutorKillsBackgroundChildren(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exec := &Executor{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	// Background process detaches from stdout so the command can exit
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	exitCode, err := exec.Run(context.Background(), "sleep 30 >/dev/null 2>&1 & echo $! > "+pidFile)
+	if err != nil || exitCode != 0 {
+		t.Fatalf("Run = %d, %v", exitCode, err)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("read pid: %v", err)
+	}
+	pid := strings.TrimSpace(string(data))
+
+	// Gone, or a zombie waiting to be reaped
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stat, err := os.ReadFile("/proc/" + pid + "/stat")
+		if err != nil || strings.Contains(string(stat), ") Z ") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background process %s still running after job exit", pid)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 func TestExecutorMultilineCommand(t *testing.T) {
 	var stdout, stderr bytes.Buffer
 	exec := &Executor{
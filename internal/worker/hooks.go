@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/protocol"
+)
+
+// hookTimeout bounds how long a pre/post-job hook may run.
+const hookTimeout = 5 * time.Minute
+
+// Hook kinds, passed to the script as CINCH_HOOK.
+const (
+	hookPreJob  = "pre-job"
+	hookPostJob = "post-job"
+)
+
+// hookEnv returns the job metadata passed to hook scripts.
+// Forge tokens are deliberately not included.
+func hookEnv(kind string, assign protocol.JobAssign) map[string]string {
+	env := map[string]string{
+		"CINCH_HOOK":   kind,
+		"CINCH_JOB_ID": assign.JobID,
+		"CINCH_REPO":   assign.Repo.CloneURL,
+		"CINCH_REF":    assign.Repo.Ref,
+		"CINCH_BRANCH": assign.Repo.Branch,
+		"CINCH_TAG":    assign.Repo.Tag,
+		"CINCH_COMMIT": assign.Repo.Commit,
+		"CINCH_FORGE":  assign.Repo.ForgeType,
+	}
+	if assign.Repo.IsPR {
+		env["CINCH_PR_NUMBER"] = strconv.Itoa(assign.Repo.PRNumber)
+	}
+	return env
+}
+
+// runHook runs an operator hook script on the host. A non-zero exit is an error.
+// Output goes to the worker log, not the job log.
+func (w *Worker) runHook(ctx context.Context, kind, script string, env map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	executor := &Executor{
+		Env:    env,
+		Stdout: &out,
+		Stderr: &out,
+	}
+
+	start := time.Now()
+	code, err := executor.Run(ctx, script)
+	if output := strings.TrimSpace(out.String()); output != "" {
+		w.log.Info("hook output", "hook", kind, "job_id", env["CINCH_JOB_ID"], "output", output)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook: %w", kind, err)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %s", kind, hookTimeout)
+	}
+	if code != 0 {
+		return fmt.Errorf("%s hook exited with code %d", kind, code)
+	}
+	w.log.Debug("hook completed", "hook", kind, "job_id", env["CINCH_JOB_ID"], "duration", time.Since(start))
+	return nil
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ehrlich-b/cinch/internal/protocol"
+)
+
+func TestRunHook(t *testing.T) {
+	w := NewWorker(WorkerConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assign := protocol.JobAssign{
+		JobID: "j_hook",
+		Repo: protocol.JobRepo{
+			CloneURL:   "https://github.com/owner/repo.git",
+			CloneToken: "ghs_secret",
+			Commit:     "abc1234",
+			Branch:     "main",
+			ForgeType:  "github",
+		},
+	}
+
+	out := filepath.Join(t.TempDir(), "env")
+	env := hookEnv(hookPreJob, assign)
+	if err := w.runHook(context.Background(), hookPreJob, "env > "+out, env); err != nil {
+		t.Fatalf("runHook: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{"CINCH_HOOK=pre-job", "CINCH_JOB_ID=j_hook", "CINCH_COMMIT=abc1234", "CINCH_BRANCH=main"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("hook env missing %s", want)
+		}
+	}
+	if strings.Contains(got, "ghs_secret") {
+		t.Error("forge token leaked into hook env")
+	}
+
+	if err := w.runHook(context.Background(), hookPostJob, "exit 3", env); err == nil {
+		t.Error("expected error for failing hook")
+	} else if !strings.Contains(err.Error(), "code 3") {
+		t.Errorf("error = %v, want exit code 3", err)
+	}
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/ehrlich-b/cinch/internal/protocol"
+)
+
+// hostLoad snapshots the host's CPU count, load average and free memory.
+// Load average and memory come from /proc and are left zero where it
+// doesn't exist (macOS, Windows).
+func hostLoad() protocol.WorkerLoad {
+	load := protocol.WorkerLoad{CPUs: runtime.NumCPU()}
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		load.LoadAvg1 = parseLoadAvg(string(data))
+	}
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		load.MemFreeBytes = parseMemAvailable(string(data))
+	}
+	return load
+}
+
+// parseLoadAvg returns the 1-minute load average from /proc/loadavg.
+func parseLoadAvg(s string) float64 {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(fields[0], 64)
+	return v
+}
+
+// parseMemAvailable returns MemAvailable from /proc/meminfo in bytes. It's
+// a better measure of usable memory than MemFree, which ignores page cache.
+func parseMemAvailable(s string) uint64 {
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
@@ -0,0 +1,22 @@
+package worker
+
+import "testing"
+
+func TestParseLoadAvg(t *testing.T) {
+	if got := parseLoadAvg("0.52 0.58 0.59 1/467 12345\n"); got != 0.52 {
+		t.Errorf("parseLoadAvg = %v, want 0.52", got)
+	}
+	if got := parseLoadAvg(""); got != 0 {
+		t.Errorf("parseLoadAvg(empty) = %v, want 0", got)
+	}
+}
+
+func TestParseMemAvailable(t *testing.T) {
+	meminfo := "MemTotal:       16318480 kB\nMemFree:         1022480 kB\nMemAvailable:    8123456 kB\nBuffers:          123456 kB\n"
+	if got := parseMemAvailable(meminfo); got != 8123456*1024 {
+		t.Errorf("parseMemAvailable = %d, want %d", got, 8123456*1024)
+	}
+	if got := parseMemAvailable("MemTotal: 100 kB\n"); got != 0 {
+		t.Errorf("parseMemAvailable without MemAvailable = %d, want 0", got)
+	}
+}
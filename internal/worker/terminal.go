@@ -125,6 +125,16 @@ func (t *Terminal) PrintJobComplete(exitCode int, duration time.Duration) {
 	fmt.Fprintln(t.out)
 }
 
+// PrintStep prints when a step of a multi-step build starts.
+func (t *Terminal) PrintStep(index, total int, name string) {
+	if !t.isTTY {
+		fmt.Fprintf(t.out, "[job] step %d/%d: %s\n", index, total, name)
+		return
+	}
+
+	fmt.Fprintf(t.out, "%s%s▸ [%d/%d]%s %s\n", colorBold, colorCyan, index, total, colorReset, name)
+}
+
 // PrintJobError prints a job error banner.
 func (t *Terminal) PrintJobError(phase, errMsg string) {
 	if !t.isTTY {
@@ -256,6 +266,8 @@ func forgeDisplayName(cloneURL, forgeType string) string {
 		return "CODEBERG"
 	case "gitea.com":
 		return "GITEA"
+	case "bitbucket.org":
+		return "BITBUCKET"
 	}
 
 	// For self-hosted or unknown hosts, use the forge type
@@ -12,6 +12,8 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -43,6 +45,19 @@ type WorkerConfig struct {
 	SocketPath  string // Unix socket path for daemon mode
 	Shared      bool   // Shared mode: run collaborator code (default: personal mode)
 	OwnerName   string // Username of worker owner (for trust model)
+	MaxJobs     int    // Stop accepting jobs after this many (0 = unlimited, 1 = --once)
+	PreJobHook  string // Script run before cloning; failure fails the job as an infra error
+	PostJobHook string // Script run after results are reported; failure is only logged
+
+	// Egress control for locked-down networks
+	GitProxy         string            // http.proxy for git clone/fetch (GIT_PROXY)
+	CloneURLRewrites []CloneURLRewrite // Route clones through an internal mirror
+}
+
+// proxyEnvVars are forwarded from the worker's environment into jobs.
+var proxyEnvVars = []string{
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "no_proxy",
 }
 
 // JobInfo holds information about a running job.
@@ -53,6 +68,7 @@ type JobInfo struct {
 	Tag       string
 	Commit    string
 	Command   string
+	Step      string // name of the running step in a multi-step build
 	Mode      string
 	Forge     string
 	StartedAt time.Time
@@ -79,6 +95,13 @@ type Worker struct {
 	wg       sync.WaitGroup
 	draining bool // When true, reject new jobs but let existing ones finish
 
+	// Job cap (MaxJobs)
+	accepted     int           // jobs accepted so far
+	lastExitCode int           // exit code of the most recently finished job
+	done         chan struct{} // closed once MaxJobs jobs have finished
+	doneOnce     sync.Once
+	lastActive   time.Time // when the worker last had a job running
+
 	// Callbacks
 	OnJobStart    func(jobID string)
 	OnJobComplete func(jobID string, exitCode int, duration time.Duration)
@@ -90,7 +113,8 @@ type Worker struct {
 
 // EventBroadcaster receives job events for streaming to clients.
 type EventBroadcaster interface {
-	BroadcastJobStarted(jobID, repo, branch, tag, commit, command, mode, forge string)
+	BroadcastJobStarted(jobID, repo, branch, tag, commit, command, mode, forge string, steps []string)
+	BroadcastStepStarted(jobID string, index, total int, name string)
 	BroadcastLogChunk(jobID, stream string, data []byte)
 	BroadcastJobCompleted(jobID string, exitCode int, durationMs int64)
 }
@@ -112,11 +136,27 @@ func NewWorker(cfg WorkerConfig, log *slog.Logger) *Worker {
 		config:     cfg,
 		log:        log,
 		activeJobs: make(map[string]*JobInfo),
+		done:       make(chan struct{}),
+		lastActive: time.Now(),
 		ctx:        ctx,
 		cancel:     cancel,
 	}
 }
 
+// Done returns a channel that is closed once the worker has run MaxJobs jobs
+// and they have all finished. It never closes if MaxJobs is 0.
+func (w *Worker) Done() <-chan struct{} {
+	return w.done
+}
+
+// LastExitCode returns the exit code of the most recently finished job
+// (1 if it failed before or outside the build command).
+func (w *Worker) LastExitCode() int {
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+	return w.lastExitCode
+}
+
 // SetEventBroadcaster sets the event broadcaster for daemon mode.
 func (w *Worker) SetEventBroadcaster(eb EventBroadcaster) {
 	w.eventBroadcaster = eb
@@ -527,6 +567,11 @@ func (w *Worker) handleJobAssign(payload []byte) {
 		Cancel:    jobCancel,
 	}
 	w.activeJobs[assign.JobID] = jobInfo
+	w.accepted++
+	if w.config.MaxJobs > 0 && w.accepted >= w.config.MaxJobs {
+		// Job cap reached: finish this one, then stop
+		w.draining = true
+	}
 	w.jobsLock.Unlock()
 
 	// Acknowledge
@@ -552,6 +597,7 @@ func (w *Worker) handleJobCancel(payload []byte) {
 	if jobInfo, ok := w.activeJobs[cancel.JobID]; ok {
 		jobInfo.Cancel()
 		delete(w.activeJobs, cancel.JobID)
+		w.lastActive = time.Now()
 	}
 	w.jobsLock.Unlock()
 
@@ -614,12 +660,33 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 	start := time.Now()
 	term := NewTerminal(os.Stdout)
 
+	// Assume failure until the build command reports an exit code
+	exitCode := 1
 	defer func() {
 		w.jobsLock.Lock()
 		delete(w.activeJobs, jobID)
+		w.lastExitCode = exitCode
+		w.lastActive = time.Now()
+		capReached := w.config.MaxJobs > 0 && w.accepted >= w.config.MaxJobs && len(w.activeJobs) == 0
 		w.jobsLock.Unlock()
+		if capReached {
+			w.doneOnce.Do(func() { close(w.done) })
+		}
 	}()
 
+	// Post-job hook runs after results are reported, whatever the outcome
+	jobStatus := "error"
+	if w.config.PostJobHook != "" {
+		defer func() {
+			env := hookEnv(hookPostJob, assign)
+			env["CINCH_JOB_STATUS"] = jobStatus
+			env["CINCH_EXIT_CODE"] = strconv.Itoa(exitCode)
+			if err := w.runHook(context.Background(), hookPostJob, w.config.PostJobHook, env); err != nil {
+				w.log.Warn("post-job hook failed", "job_id", jobID, "error", err)
+			}
+		}()
+	}
+
 	// Print job claimed (non-TTY mode)
 	term.PrintJobClaimed(jobID)
 
@@ -631,6 +698,15 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 		w.OnJobStart(jobID)
 	}
 
+	// Pre-job hook runs before cloning
+	if w.config.PreJobHook != "" {
+		if err := w.runHook(ctx, hookPreJob, w.config.PreJobHook, hookEnv(hookPreJob, assign)); err != nil {
+			term.PrintJobError(protocol.PhaseSetup, err.Error())
+			w.reportError(jobID, protocol.PhaseSetup, err.Error())
+			return
+		}
+	}
+
 	// Print cloning (non-TTY mode)
 	ref := assign.Repo.Branch
 	if assign.Repo.Tag != "" {
@@ -642,26 +718,42 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 	workDir, err := w.cloneRepo(ctx, assign.Repo)
 	if err != nil {
 		term.PrintJobError(protocol.PhaseClone, err.Error())
-		w.reportError(jobID, protocol.PhaseClone, err.Error())
+		w.reportInfraError(jobID, protocol.PhaseClone, err.Error())
 		return
 	}
-	defer os.RemoveAll(workDir)
+	// A failed build's container may be kept for debugging, along with its
+	// workspace; the hold removes both when it expires
+	var hold *debugHold
+	var window time.Duration
+	defer func() {
+		if hold != nil {
+			w.holdForDebug(jobID, hold, window)
+			return
+		}
+		_ = os.RemoveAll(workDir)
+	}()
 
 	// Load config from repo (overrides server-provided config)
-	command := assign.Config.Command
+	steps := []config.Step{{Name: "build", Run: assign.Config.Command}}
 	cfg, _, err := config.Load(workDir)
 	if err == nil {
 		// Select build or release based on whether this is a tag push
 		isTag := assign.Repo.Tag != ""
-		configCommand := cfg.CommandForEvent(isTag)
-		if configCommand != "" {
-			command = configCommand
-			w.log.Debug("using command from .cinch.yaml", "command", command, "is_tag", isTag)
+		if configSteps := cfg.StepsForEvent(isTag); len(configSteps) > 0 {
+			steps = configSteps
+			w.log.Debug("using steps from .cinch.yaml", "steps", len(steps), "is_tag", isTag)
 		}
 	}
-	if command == "" {
-		command = "make check" // Default fallback
-		w.log.Debug("using default command", "command", command)
+	window = debugWindow(cfg)
+	if steps[0].Run == "" {
+		steps[0].Run = "make check" // Default fallback
+		w.log.Debug("using default command", "command", steps[0].Run)
+	}
+	command := describeSteps(steps)
+	timeout := jobTimeout(assign.Config.Timeout, cfg)
+	stepNames := make([]string, len(steps))
+	for i, step := range steps {
+		stepNames[i] = step.Name
 	}
 
 	// Create log streamer
@@ -687,6 +779,25 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 	env["CINCH_COMMIT"] = assign.Repo.Commit
 	env["CINCH_REPO"] = assign.Repo.CloneURL
 	env["CINCH_FORGE"] = assign.Repo.ForgeType
+	env["CINCH_EVENT"] = assign.Repo.Event()
+
+	// Plain env from .cinch.yaml fills in under secrets and CINCH_* vars
+	if cfg != nil {
+		for k, v := range cfg.Env {
+			if _, set := env[k]; !set {
+				env[k] = v
+			}
+		}
+	}
+
+	// Forward proxy settings so build steps in containers use the same egress
+	for _, k := range proxyEnvVars {
+		if v := os.Getenv(k); v != "" {
+			if _, set := env[k]; !set {
+				env[k] = v
+			}
+		}
+	}
 
 	// Set forge-specific token env var for API access (releases, comments, etc.)
 	if assign.Repo.CloneToken != "" {
@@ -698,6 +809,8 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 			env["CI_JOB_TOKEN"] = assign.Repo.CloneToken // GitLab compat
 		case "forgejo", "gitea":
 			env["GITEA_TOKEN"] = assign.Repo.CloneToken
+		case "azure":
+			env["AZURE_DEVOPS_EXT_PAT"] = assign.Repo.CloneToken // az CLI
 		}
 		env["CINCH_FORGE_TOKEN"] = assign.Repo.CloneToken
 	}
@@ -708,9 +821,17 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 		effectiveCfg = &config.Config{}
 	}
 
+	// Monorepo builds run from a subdirectory of the clone
+	runDir, err := buildDir(workDir, effectiveCfg.Workdir)
+	if err != nil {
+		term.PrintJobError(protocol.PhaseSetup, err.Error())
+		w.reportError(jobID, protocol.PhaseSetup, err.Error())
+		return
+	}
+
 	// Determine execution mode and prepare for running
-	var exitCode int
 	var runErr error
+	var timedOut bool
 	var execMode string
 
 	// In verbose mode, output goes to terminal AND server
@@ -751,11 +872,15 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 
 		// Broadcast job start to daemon clients
 		if w.eventBroadcaster != nil {
-			w.eventBroadcaster.BroadcastJobStarted(jobID, assign.Repo.CloneURL, assign.Repo.Branch, assign.Repo.Tag, assign.Repo.Commit, command, execMode, assign.Repo.ForgeType)
+			w.eventBroadcaster.BroadcastJobStarted(jobID, assign.Repo.CloneURL, assign.Repo.Branch, assign.Repo.Tag, assign.Repo.Commit, command, execMode, assign.Repo.ForgeType, stepNames)
 		}
 
 		if source.Type == "bare-metal" {
-			exitCode, runErr = w.runBareMetal(ctx, command, workDir, env, stdout, stderr)
+			exitCode, timedOut, runErr = runWithTimeout(ctx, timeout, stderr, func(ctx context.Context) (int, error) {
+				return w.runSteps(jobInfo, steps, stdout, func(command string) (int, error) {
+					return w.runBareMetal(ctx, command, runDir, env, stdout, stderr)
+				})
+			})
 		} else {
 			w.log.Info("executing job",
 				"job_id", jobID,
@@ -767,7 +892,11 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 				"container_type", source.Type,
 			)
 
-			exitCode, runErr = w.runInContainer(ctx, jobID, source, command, workDir, env, stdout, stderr)
+			exitCode, timedOut, runErr = runWithTimeout(ctx, timeout, stderr, func(ctx context.Context) (int, error) {
+				var code int
+				code, hold, err = w.runInContainer(ctx, jobInfo, source, effectiveCfg.Services, steps, workDir, effectiveCfg.Workdir, env, window, stdout, stderr)
+				return code, err
+			})
 		}
 	} else {
 		// Bare-metal mode
@@ -780,7 +909,7 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 
 		// Broadcast job start to daemon clients
 		if w.eventBroadcaster != nil {
-			w.eventBroadcaster.BroadcastJobStarted(jobID, assign.Repo.CloneURL, assign.Repo.Branch, assign.Repo.Tag, assign.Repo.Commit, command, execMode, assign.Repo.ForgeType)
+			w.eventBroadcaster.BroadcastJobStarted(jobID, assign.Repo.CloneURL, assign.Repo.Branch, assign.Repo.Tag, assign.Repo.Commit, command, execMode, assign.Repo.ForgeType, stepNames)
 		}
 
 		w.log.Info("executing job",
@@ -792,14 +921,42 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 			"mode", "bare-metal",
 		)
 
-		exitCode, runErr = w.runBareMetal(ctx, command, workDir, env, stdout, stderr)
+		exitCode, timedOut, runErr = runWithTimeout(ctx, timeout, stderr, func(ctx context.Context) (int, error) {
+			return w.runSteps(jobInfo, steps, stdout, func(command string) (int, error) {
+				return w.runBareMetal(ctx, command, runDir, env, stdout, stderr)
+			})
+		})
 	}
 	if runErr != nil && ctx.Err() != nil {
 		// Context cancelled
+		exitCode = 1
+		jobStatus = "cancelled"
 		term.PrintJobError(protocol.PhaseExecute, "job cancelled")
 		w.reportError(jobID, protocol.PhaseExecute, "job cancelled")
 		return
 	}
+	if runErr != nil {
+		// The build's commands never ran (image pull, service or process
+		// start failed): report an infrastructure error, not a build failure
+		streamer.Flush()
+		term.PrintJobError(protocol.PhaseSetup, runErr.Error())
+		if errors.Is(runErr, container.ErrPull) {
+			w.reportInfraError(jobID, protocol.PhaseSetup, runErr.Error())
+		} else {
+			w.reportError(jobID, protocol.PhaseSetup, runErr.Error())
+		}
+		return
+	}
+
+	if hold != nil {
+		printDebugHint(stdout, hold, w.config.Hostname, window)
+	}
+
+	// Keep build outputs whether or not the build passed (test reports
+	// matter most when it didn't)
+	if cfg != nil && len(cfg.Artifacts) > 0 {
+		w.uploadArtifacts(jobID, workDir, cfg.Artifacts, stdout)
+	}
 
 	// Flush any remaining logs
 	streamer.Flush()
@@ -812,8 +969,18 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 	// Print waiting message (non-TTY mode)
 	term.PrintJobWaiting()
 
+	jobStatus = "success"
+	if exitCode != 0 {
+		jobStatus = "failed"
+	}
+	if timedOut {
+		w.log.Warn("job exceeded timeout", "job_id", jobID, "timeout", timeout)
+	}
+
 	// Report completion
-	if err := w.send(protocol.TypeJobComplete, protocol.NewJobComplete(jobID, exitCode, duration)); err != nil {
+	complete := protocol.NewJobComplete(jobID, exitCode, duration)
+	complete.TimedOut = timedOut
+	if err := w.send(protocol.TypeJobComplete, complete); err != nil {
 		w.log.Warn("failed to send JOB_COMPLETE", "job_id", jobID, "error", err)
 	}
 
@@ -831,11 +998,18 @@ func (w *Worker) executeJob(ctx context.Context, assign protocol.JobAssign, jobI
 
 // reportError sends a job error message.
 func (w *Worker) reportError(jobID, phase, errMsg string) {
-	if err := w.send(protocol.TypeJobError, protocol.JobError{
-		JobID: jobID,
-		Error: errMsg,
-		Phase: phase,
-	}); err != nil {
+	w.sendJobError(protocol.JobError{JobID: jobID, Error: errMsg, Phase: phase})
+}
+
+// reportInfraError sends a job error the server may retry: a transient
+// failure outside the repo's control, like a clone or image pull.
+func (w *Worker) reportInfraError(jobID, phase, errMsg string) {
+	w.sendJobError(protocol.JobError{JobID: jobID, Error: errMsg, Phase: phase, Retryable: true})
+}
+
+func (w *Worker) sendJobError(jobErr protocol.JobError) {
+	jobID, phase, errMsg := jobErr.JobID, jobErr.Phase, jobErr.Error
+	if err := w.send(protocol.TypeJobError, jobErr); err != nil {
 		w.log.Warn("failed to send JOB_ERROR", "job_id", jobID, "error", err)
 	}
 
@@ -848,7 +1022,10 @@ func (w *Worker) reportError(jobID, phase, errMsg string) {
 
 // cloneRepo clones the repository and returns the working directory.
 func (w *Worker) cloneRepo(ctx context.Context, repo protocol.JobRepo) (string, error) {
-	cloner := &GitCloner{}
+	cloner := &GitCloner{
+		Proxy:    w.config.GitProxy,
+		Rewrites: w.config.CloneURLRewrites,
+	}
 	return cloner.Clone(ctx, repo)
 }
 
@@ -871,7 +1048,13 @@ func (w *Worker) pingLoop() {
 			}
 			w.jobsLock.Unlock()
 
-			if err := w.send(protocol.TypePing, protocol.NewPing(activeJobs)); err != nil {
+			ping := protocol.NewPing(activeJobs)
+			load := hostLoad()
+			load.SlotsUsed = len(activeJobs)
+			load.SlotsTotal = w.config.Concurrency
+			ping.Load = &load
+
+			if err := w.send(protocol.TypePing, ping); err != nil {
 				w.log.Warn("failed to send ping", "error", err)
 			}
 		}
@@ -885,6 +1068,17 @@ func (w *Worker) ActiveJobCount() int {
 	return len(w.activeJobs)
 }
 
+// IdleFor returns how long the worker has gone without a running job, or 0
+// while a job is running.
+func (w *Worker) IdleFor() time.Duration {
+	w.jobsLock.Lock()
+	defer w.jobsLock.Unlock()
+	if len(w.activeJobs) > 0 {
+		return 0
+	}
+	return time.Since(w.lastActive)
+}
+
 // WorkerID returns the assigned worker ID.
 func (w *Worker) WorkerID() string {
 	return w.workerID
@@ -901,25 +1095,163 @@ func (w *Worker) runBareMetal(ctx context.Context, command, workDir string, env
 	return executor.Run(ctx, command)
 }
 
-// runInContainer executes a command inside a container.
-func (w *Worker) runInContainer(ctx context.Context, jobID string, source *container.ImageSource, command, workDir string, env map[string]string, stdout, stderr io.Writer) (int, error) {
+// buildDir returns the directory under the clone at root that a build with
+// the given workdir runs in. The config already rejects paths that leave the
+// repo; this also catches symlinks that do.
+func buildDir(root, workdir string) (string, error) {
+	if workdir == "" {
+		return root, nil
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("resolve workspace: %w", err)
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Join(root, workdir))
+	if err != nil {
+		return "", fmt.Errorf("workdir %q: %w", workdir, err)
+	}
+	if rel, err := filepath.Rel(realRoot, dir); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("workdir %q is outside the repo", workdir)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("workdir %q is not a directory", workdir)
+	}
+	return filepath.Join(root, workdir), nil
+}
+
+// runInContainer executes the job's steps inside a container, with any services they need.
+// workDir is mounted as the workspace and the steps run in its subdir.
+// The image and services are set up once and shared by all steps.
+// Services and the job container are torn down when the job finishes or ctx is cancelled,
+// unless debugWindow is set and a step fails: then they're returned as a hold to keep.
+func (w *Worker) runInContainer(ctx context.Context, job *JobInfo, source *container.ImageSource, services map[string]config.Service, steps []config.Step, workDir, subdir string, env map[string]string, debugWindow time.Duration, stdout, stderr io.Writer) (int, *debugHold, error) {
+	jobID := job.ID
+
 	// Prepare image (pull or build)
 	image, err := container.PrepareImage(ctx, source, jobID, stdout, stderr)
 	if err != nil {
-		return 1, fmt.Errorf("prepare image: %w", err)
+		return 1, nil, fmt.Errorf("prepare image: %w", err)
 	}
 
 	// Run command in container
 	docker := &container.Docker{
-		WorkDir:      workDir,
-		Image:        image,
-		Env:          env,
-		CacheVolumes: container.DefaultCacheVolumes(),
-		Stdout:       stdout,
-		Stderr:       stderr,
+		WorkDir:       workDir,
+		Dir:           subdir,
+		Image:         image,
+		Env:           env,
+		Name:          "cinch-" + jobID,
+		KeepOnFailure: debugWindow > 0,
+		CacheVolumes:  container.DefaultCacheVolumes(),
+		Stdout:        stdout,
+		Stderr:        stderr,
+	}
+
+	// Start services
+	var svcManager *container.ServiceManager
+	kept := false
+	if len(services) > 0 {
+		svcManager = container.NewServiceManager(jobID, stdout, stderr)
+		defer func() {
+			if !kept {
+				svcManager.Cleanup(context.Background())
+			}
+		}()
+		if err := svcManager.Setup(ctx, services); err != nil {
+			if ctx.Err() != nil {
+				return 137, nil, nil
+			}
+			return 1, nil, fmt.Errorf("start services: %w", err)
+		}
+		docker.Network = svcManager.Network
+		for k, v := range container.ServiceEnv(services) {
+			if _, ok := docker.Env[k]; !ok {
+				docker.Env[k] = v
+			}
+		}
+	}
+
+	code, err := w.runSteps(job, steps, stdout, func(command string) (int, error) {
+		return docker.Run(ctx, command)
+	})
+	if !docker.KeepOnFailure || err != nil || code == 0 || ctx.Err() != nil {
+		return code, nil, err
 	}
+	kept = true
+	return code, &debugHold{container: docker.Name, services: svcManager, workDir: workDir}, nil
+}
+
+// defaultJobTimeout bounds a build when no timeout is configured.
+const defaultJobTimeout = 30 * time.Minute
+
+// timeoutExitCode is reported for builds killed by the job timeout, as timeout(1) does.
+const timeoutExitCode = 124
 
-	return docker.Run(ctx, command)
+// jobTimeout returns the build timeout: the one sent with the job, else the
+// repo's .cinch.yaml timeout, else defaultJobTimeout.
+func jobTimeout(assigned string, cfg *config.Config) time.Duration {
+	if d, err := time.ParseDuration(assigned); err == nil && d > 0 {
+		return d
+	}
+	if cfg != nil && cfg.Timeout > 0 {
+		return time.Duration(cfg.Timeout)
+	}
+	return defaultJobTimeout
+}
+
+// runWithTimeout runs the build with a context that expires after timeout.
+// run must stop once its context is done (the executor kills the process
+// group; containers are removed). On expiry the log gets a timeout line and
+// timeoutExitCode is returned with timedOut set.
+func runWithTimeout(ctx context.Context, timeout time.Duration, stderr io.Writer, run func(ctx context.Context) (int, error)) (exitCode int, timedOut bool, err error) {
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	exitCode, err = run(runCtx)
+	if ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		fmt.Fprintf(stderr, "\n==> job exceeded timeout of %s\n", timeout)
+		return timeoutExitCode, true, nil
+	}
+	return exitCode, false, err
+}
+
+// runSteps runs steps in order with run, stopping at the first step that
+// fails. Multi-step builds get a header line in the log before each step.
+func (w *Worker) runSteps(job *JobInfo, steps []config.Step, stdout io.Writer, run func(command string) (int, error)) (int, error) {
+	for i, step := range steps {
+		if len(steps) > 1 {
+			w.jobsLock.Lock()
+			job.Step = step.Name
+			w.jobsLock.Unlock()
+
+			if w.eventBroadcaster != nil {
+				w.eventBroadcaster.BroadcastStepStarted(job.ID, i+1, len(steps), step.Name)
+			}
+			fmt.Fprintf(stdout, "==> [%d/%d] %s\n", i+1, len(steps), step.Name)
+			w.log.Info("running step", "job_id", job.ID, "step", step.Name, "index", i+1, "total", len(steps))
+		}
+
+		exitCode, err := run(step.Run)
+		if err != nil || exitCode != 0 {
+			if len(steps) > 1 && err == nil {
+				fmt.Fprintf(stdout, "==> step %q failed with exit code %d\n", step.Name, exitCode)
+			}
+			return exitCode, err
+		}
+	}
+	return 0, nil
+}
+
+// describeSteps returns a one-line summary of the steps for banners: the
+// command itself for a single step, or the step names in order.
+func describeSteps(steps []config.Step) string {
+	if len(steps) == 1 {
+		return steps[0].Run
+	}
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+	}
+	return strings.Join(names, " → ")
 }
 
 // pendingResultsDir returns the directory for storing pending results.
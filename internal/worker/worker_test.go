@@ -0,0 +1,224 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehrlich-b/cinch/internal/config"
+	"github.com/ehrlich-b/cinch/internal/worker/container"
+)
+
+func TestRunInContainerCancelRemovesServices(t *testing.T) {
+	if os.Getenv("CINCH_TEST_DOCKER") == "" {
+		t.Skip("CINCH_TEST_DOCKER not set, skipping container test")
+	}
+
+	w := &Worker{}
+	jobID := "j_canceltest"
+	services := map[string]config.Service{
+		"cache": {Image: "alpine:3.19", Command: "sleep 300"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Cancel once the job container is up
+		for i := 0; i < 120; i++ {
+			out, _ := exec.Command("docker", "ps", "-q", "--filter", "name=^cinch-"+jobID+"$").Output()
+			if len(bytes.TrimSpace(out)) > 0 {
+				break
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+		cancel()
+	}()
+
+	var stdout, stderr bytes.Buffer
+	exitCode, hold, err := w.runInContainer(ctx, &JobInfo{ID: jobID}, &container.ImageSource{Type: "image", Image: "alpine:3.19"},
+		services, []config.Step{{Name: "build", Run: "sleep 300"}}, t.TempDir(), "", nil, time.Minute, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("runInContainer: %v\n%s", err, stderr.String())
+	}
+	if exitCode == 0 {
+		t.Error("expected non-zero exit code for cancelled job")
+	}
+	if hold != nil {
+		t.Error("cancelled job should not be kept for debugging")
+	}
+
+	out, err := exec.Command("docker", "ps", "-a", "--format", "{{.Names}}", "--filter", "name=cinch-"+jobID).Output()
+	if err != nil {
+		t.Fatalf("docker ps: %v", err)
+	}
+	if left := strings.TrimSpace(string(out)); left != "" {
+		t.Errorf("containers left after cancel: %s", left)
+	}
+}
+
+func TestDebugWindow(t *testing.T) {
+	tests := []struct {
+		cfg  *config.Config
+		want time.Duration
+	}{
+		{nil, 0},
+		{&config.Config{}, 0},
+		{&config.Config{DebugOnFailure: config.Duration(10 * time.Minute)}, 10 * time.Minute},
+		{&config.Config{DebugOnFailure: config.Duration(5 * time.Hour)}, config.MaxDebugOnFailure},
+	}
+	for _, tt := range tests {
+		if got := debugWindow(tt.cfg); got != tt.want {
+			t.Errorf("debugWindow(%+v) = %s, want %s", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestRunStepsFailsFast(t *testing.T) {
+	w := &Worker{log: slog.Default()}
+	steps := []config.Step{
+		{Name: "lint", Run: "make lint"},
+		{Name: "test", Run: "make test"},
+		{Name: "build", Run: "make build"},
+	}
+
+	var ran []string
+	var out bytes.Buffer
+	exitCode, err := w.runSteps(&JobInfo{ID: "j_steps"}, steps, &out, func(command string) (int, error) {
+		ran = append(ran, command)
+		if command == "make test" {
+			return 2, nil
+		}
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatalf("runSteps: %v", err)
+	}
+	if exitCode != 2 {
+		t.Errorf("exit code = %d, want 2", exitCode)
+	}
+	if len(ran) != 2 {
+		t.Errorf("ran %v, want to stop after the failing step", ran)
+	}
+	for _, want := range []string{"==> [1/3] lint", "==> [2/3] test", `step "test" failed with exit code 2`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "[3/3]") {
+		t.Errorf("output should not include the skipped step:\n%s", out.String())
+	}
+}
+
+func TestRunStepsSingleStepNoHeader(t *testing.T) {
+	w := &Worker{log: slog.Default()}
+	var out bytes.Buffer
+	exitCode, err := w.runSteps(&JobInfo{ID: "j_single"}, []config.Step{{Name: "build", Run: "make check"}}, &out, func(string) (int, error) {
+		return 0, nil
+	})
+	if err != nil || exitCode != 0 {
+		t.Fatalf("runSteps = %d, %v", exitCode, err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("single-step build should not print a header, got %q", out.String())
+	}
+}
+
+func TestRunWithTimeoutKillsCommand(t *testing.T) {
+	var stderr bytes.Buffer
+	executor := &Executor{WorkDir: t.TempDir(), Stdout: &stderr, Stderr: &stderr}
+
+	start := time.Now()
+	exitCode, timedOut, err := runWithTimeout(context.Background(), 200*time.Millisecond, &stderr, func(ctx context.Context) (int, error) {
+		return executor.Run(ctx, "sleep 10")
+	})
+	if err != nil {
+		t.Fatalf("runWithTimeout: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command ran for %s, want it killed at the timeout", elapsed)
+	}
+	if !timedOut {
+		t.Error("timedOut = false, want true")
+	}
+	if exitCode != timeoutExitCode {
+		t.Errorf("exit code = %d, want %d", exitCode, timeoutExitCode)
+	}
+	if !strings.Contains(stderr.String(), "job exceeded timeout of 200ms") {
+		t.Errorf("output missing timeout line:\n%s", stderr.String())
+	}
+}
+
+func TestRunWithTimeoutCancelIsNotTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var stderr bytes.Buffer
+	exitCode, timedOut, _ := runWithTimeout(ctx, time.Minute, &stderr, func(runCtx context.Context) (int, error) {
+		cancel()
+		<-runCtx.Done()
+		return 137, nil
+	})
+	if timedOut || exitCode != 137 {
+		t.Errorf("runWithTimeout = %d, timedOut %v; want 137, false", exitCode, timedOut)
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	cfg := &config.Config{Timeout: config.Duration(15 * time.Minute)}
+	tests := []struct {
+		assigned string
+		cfg      *config.Config
+		want     time.Duration
+	}{
+		{"", nil, defaultJobTimeout},
+		{"", cfg, 15 * time.Minute},
+		{"1h", cfg, time.Hour},
+		{"bogus", cfg, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := jobTimeout(tt.assigned, tt.cfg); got != tt.want {
+			t.Errorf("jobTimeout(%q) = %s, want %s", tt.assigned, got, tt.want)
+		}
+	}
+}
+
+func TestBuildDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "services", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "README"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := buildDir(root, ""); err != nil || got != root {
+		t.Errorf("buildDir(\"\") = %q, %v; want the repo root", got, err)
+	}
+	if got, err := buildDir(root, "services/api"); err != nil || got != filepath.Join(root, "services", "api") {
+		t.Errorf("buildDir(services/api) = %q, %v", got, err)
+	}
+	for _, workdir := range []string{"missing", "README", "escape"} {
+		if _, err := buildDir(root, workdir); err == nil {
+			t.Errorf("buildDir(%q): expected error", workdir)
+		}
+	}
+}
+
+func TestIdleFor(t *testing.T) {
+	w := NewWorker(WorkerConfig{}, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	w.lastActive = time.Now().Add(-time.Minute)
+	if got := w.IdleFor(); got < time.Minute {
+		t.Errorf("IdleFor() = %s, want at least 1m", got)
+	}
+
+	w.activeJobs["j_1"] = &JobInfo{ID: "j_1"}
+	if got := w.IdleFor(); got != 0 {
+		t.Errorf("IdleFor() with a running job = %s, want 0", got)
+	}
+}
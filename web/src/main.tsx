@@ -1,8 +1,11 @@
 import { StrictMode } from 'react'
 import { createRoot } from 'react-dom/client'
 import { App } from './App'
+import { installCSRFFetch } from './utils/csrf'
 import './index.css'
 
+installCSRFFetch()
+
 createRoot(document.getElementById('root')!).render(
   <StrictMode>
     <App />
@@ -95,6 +95,15 @@ export interface Worker {
   active_jobs: string[]
   currentJob?: string
   last_seen: string
+  load?: WorkerLoad
+}
+
+export interface WorkerLoad {
+  cpus?: number
+  load_avg_1?: number
+  mem_free_bytes?: number
+  slots_used: number
+  slots_total: number
 }
 
 export interface WorkerEvent {
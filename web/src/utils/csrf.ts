@@ -0,0 +1,20 @@
+const SAFE_METHODS = ['GET', 'HEAD', 'OPTIONS']
+
+// installCSRFFetch makes same-origin mutations send the CSRF token the
+// server put in index.html, which cookie-authenticated /api/ calls require.
+export function installCSRFFetch() {
+  const token = document.querySelector<HTMLMetaElement>('meta[name="csrf-token"]')?.content
+  if (!token) return
+
+  const originalFetch = window.fetch.bind(window)
+  window.fetch = (input: RequestInfo | URL, init?: RequestInit) => {
+    const request = new Request(input, init)
+    const sameOrigin = new URL(request.url).origin === window.location.origin
+    if (!sameOrigin || SAFE_METHODS.includes(request.method)) {
+      return originalFetch(input, init)
+    }
+    const headers = new Headers(request.headers)
+    headers.set('X-CSRF-Token', token)
+    return originalFetch(new Request(request, { headers }))
+  }
+}